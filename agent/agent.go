@@ -18,6 +18,10 @@ import (
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// workers holds a token for each running gather when the number of
+	// concurrent gathers is limited.
+	workers chan struct{}
 }
 
 // NewAgent returns an Agent for the given Config.
@@ -25,6 +29,9 @@ func NewAgent(config *config.Config) (*Agent, error) {
 	a := &Agent{
 		Config: config,
 	}
+	if config.Agent.MaxConcurrentGathers > 0 {
+		a.workers = make(chan struct{}, config.Agent.MaxConcurrentGathers)
+	}
 	return a, nil
 }
 
@@ -205,11 +212,15 @@ func (a *Agent) runInputs(
 		interval := a.Config.Agent.Interval.Duration
 		precision := a.Config.Agent.Precision.Duration
 		jitter := a.Config.Agent.CollectionJitter.Duration
+		timeout := a.Config.Agent.GatherTimeout.Duration
 
-		// Overwrite agent interval if this plugin has its own.
+		// Overwrite agent interval and timeout if this plugin has its own.
 		if input.Config.Interval != 0 {
 			interval = input.Config.Interval
 		}
+		if input.Config.Timeout != 0 {
+			timeout = input.Config.Timeout
+		}
 
 		acc := NewAccumulator(input, dst)
 		acc.SetPrecision(precision, interval)
@@ -226,7 +237,7 @@ func (a *Agent) runInputs(
 				}
 			}
 
-			a.gatherOnInterval(ctx, acc, input, interval, jitter)
+			a.gatherOnInterval(ctx, acc, input, interval, jitter, timeout)
 		}(input)
 	}
 	wg.Wait()
@@ -236,27 +247,48 @@ func (a *Agent) runInputs(
 
 // gather runs an input's gather function periodically until the context is
 // done.
+//
+// A gather abandoned after its timeout may still be running at the next
+// interval, in which case that gather is skipped so an input never gathers
+// concurrently.
 func (a *Agent) gatherOnInterval(
 	ctx context.Context,
 	acc telegraf.Accumulator,
 	input *models.RunningInput,
 	interval time.Duration,
 	jitter time.Duration,
+	timeout time.Duration,
 ) {
 	defer panicRecover(input)
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	var running chan struct{}
+	defer func() {
+		// Wait for an abandoned gather, as the accumulator must not be used
+		// once the inputs are stopped.
+		if running != nil {
+			<-running
+		}
+	}()
+
 	for {
 		err := internal.SleepContext(ctx, internal.RandomDuration(jitter))
 		if err != nil {
 			return
 		}
 
-		err = a.gatherOnce(acc, input, interval)
-		if err != nil {
-			acc.AddError(err)
+		if running != nil && !isClosed(running) {
+			input.GathersSkipped.Incr(1)
+			log.Printf("W! [agent] input %q skipped a gather, the previous one is still running",
+				input.Name())
+		} else {
+			running = make(chan struct{})
+			err = a.gatherOnce(ctx, acc, input, interval, timeout, running)
+			if err != nil {
+				acc.AddError(err)
+			}
 		}
 
 		select {
@@ -269,18 +301,44 @@ func (a *Agent) gatherOnInterval(
 }
 
 // gatherOnce runs the input's Gather function once, logging a warning each
-// interval it fails to complete before.
+// interval it fails to complete before.  If a timeout is set the gather is
+// cancelled and abandoned once it expires.  The running channel is closed
+// when the gather returns.
 func (a *Agent) gatherOnce(
+	ctx context.Context,
 	acc telegraf.Accumulator,
 	input *models.RunningInput,
+	interval time.Duration,
 	timeout time.Duration,
+	running chan struct{},
 ) error {
-	ticker := time.NewTicker(timeout)
+	if !a.acquireWorker(ctx, interval) {
+		close(running)
+		if ctx.Err() != nil {
+			return nil
+		}
+		input.GathersSkipped.Incr(1)
+		return fmt.Errorf("skipped gather, no worker available within %s", interval)
+	}
+
+	gatherCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	done := make(chan error)
+	done := make(chan error, 1)
 	go func() {
-		done <- input.Gather(acc)
+		defer close(running)
+		defer a.releaseWorker()
+		done <- input.GatherContext(gatherCtx, acc)
 	}()
 
 	for {
@@ -290,10 +348,50 @@ func (a *Agent) gatherOnce(
 		case <-ticker.C:
 			log.Printf("W! [agent] input %q did not complete within its interval",
 				input.Name())
+		case <-timeoutC:
+			input.GatherTimeouts.Incr(1)
+			return fmt.Errorf("gather timed out after %s", timeout)
 		}
 	}
 }
 
+// acquireWorker waits for a free worker if the number of concurrent gathers
+// is limited.  It returns false if none became available within the wait
+// duration or the context is done.
+func (a *Agent) acquireWorker(ctx context.Context, wait time.Duration) bool {
+	if a.workers == nil {
+		return true
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case a.workers <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// releaseWorker frees the worker of a completed gather.
+func (a *Agent) releaseWorker() {
+	if a.workers != nil {
+		<-a.workers
+	}
+}
+
+func isClosed(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
 // runProcessors applies processors to metrics.
 func (a *Agent) runProcessors(
 	src <-chan telegraf.Metric,
@@ -484,6 +582,15 @@ func (a *Agent) flush(
 		}
 	}
 
+	// Writes in progress are cancelled when the context is done, the final
+	// flush is not so that cached metrics can still be delivered.
+	write := func() error {
+		return output.WriteContext(ctx)
+	}
+	writeBatch := func() error {
+		return output.WriteBatchContext(ctx)
+	}
+
 	for {
 		// Favor shutdown over other methods.
 		select {
@@ -495,14 +602,14 @@ func (a *Agent) flush(
 
 		select {
 		case <-ticker.C:
-			logError(a.flushOnce(output, interval, output.Write))
+			logError(a.flushOnce(output, interval, write))
 		case <-output.BatchReady:
 			// Favor the ticker over batch ready
 			select {
 			case <-ticker.C:
-				logError(a.flushOnce(output, interval, output.Write))
+				logError(a.flushOnce(output, interval, write))
 			default:
-				logError(a.flushOnce(output, interval, output.WriteBatch))
+				logError(a.flushOnce(output, interval, writeBatch))
 			}
 		case <-ctx.Done():
 			logError(a.flushOnce(output, interval, output.Write))
@@ -589,7 +696,12 @@ func (a *Agent) startServiceInputs(
 			acc := NewAccumulator(input, dst)
 			acc.SetPrecision(time.Nanosecond, 0)
 
-			err := si.Start(acc)
+			var err error
+			if csi, ok := si.(telegraf.ContextServiceInput); ok {
+				err = csi.StartContext(ctx, acc)
+			} else {
+				err = si.Start(acc)
+			}
 			if err != nil {
 				log.Printf("E! [agent] Service for input %s failed to start: %v",
 					input.Name(), err)
@@ -1,9 +1,15 @@
 package agent
 
 import (
+	"context"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/testutil"
 
 	// needing to load the plugins
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
@@ -11,6 +17,7 @@ import (
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAgent_OmitHostname(t *testing.T) {
@@ -109,3 +116,110 @@ func TestAgent_LoadOutput(t *testing.T) {
 	a, _ = NewAgent(c)
 	assert.Equal(t, 3, len(a.Config.Outputs))
 }
+
+type blockingInput struct {
+	release chan struct{}
+}
+
+func (i *blockingInput) SampleConfig() string { return "" }
+func (i *blockingInput) Description() string  { return "" }
+func (i *blockingInput) Gather(acc telegraf.Accumulator) error {
+	<-i.release
+	return nil
+}
+
+type contextInput struct {
+	blockingInput
+	err error
+}
+
+func (i *contextInput) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
+	<-ctx.Done()
+	i.err = ctx.Err()
+	return nil
+}
+
+func TestAgent_GatherTimeout(t *testing.T) {
+	a, err := NewAgent(config.NewConfig())
+	require.NoError(t, err)
+
+	input := &blockingInput{release: make(chan struct{})}
+	ri := models.NewRunningInput(input, &models.InputConfig{Name: "TestAgent_GatherTimeout"})
+
+	running := make(chan struct{})
+	err = a.gatherOnce(context.Background(), &testutil.Accumulator{}, ri, time.Minute, 10*time.Millisecond, running)
+	require.Error(t, err)
+	assert.Equal(t, int64(1), ri.GatherTimeouts.Get())
+	assert.False(t, isClosed(running))
+
+	close(input.release)
+	<-running
+}
+
+func TestAgent_GatherTimeoutCancelsContext(t *testing.T) {
+	a, err := NewAgent(config.NewConfig())
+	require.NoError(t, err)
+
+	input := &contextInput{}
+	ri := models.NewRunningInput(input, &models.InputConfig{Name: "TestAgent_GatherTimeoutCancelsContext"})
+
+	running := make(chan struct{})
+	err = a.gatherOnce(context.Background(), &testutil.Accumulator{}, ri, time.Minute, 10*time.Millisecond, running)
+	require.Error(t, err)
+	<-running
+	assert.Equal(t, context.Canceled, input.err)
+}
+
+func TestAgent_GatherSkippedWhileRunning(t *testing.T) {
+	a, err := NewAgent(config.NewConfig())
+	require.NoError(t, err)
+
+	input := &blockingInput{release: make(chan struct{})}
+	ri := models.NewRunningInput(input, &models.InputConfig{Name: "TestAgent_GatherSkippedWhileRunning"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.gatherOnInterval(ctx, &testutil.Accumulator{}, ri, 10*time.Millisecond, 0, 5*time.Millisecond)
+	}()
+
+	for ri.GathersSkipped.Get() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	close(input.release)
+	wg.Wait()
+	assert.Equal(t, int64(1), ri.GatherTimeouts.Get())
+}
+
+func TestAgent_MaxConcurrentGathers(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.MaxConcurrentGathers = 1
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	input := &blockingInput{release: make(chan struct{})}
+	first := models.NewRunningInput(input, &models.InputConfig{Name: "TestAgent_MaxConcurrentGathers_first"})
+	second := models.NewRunningInput(input, &models.InputConfig{Name: "TestAgent_MaxConcurrentGathers_second"})
+
+	running := make(chan struct{})
+	err = a.gatherOnce(context.Background(), &testutil.Accumulator{}, first, time.Minute, 10*time.Millisecond, running)
+	require.Error(t, err)
+
+	// The abandoned gather still holds the only worker.
+	skipped := make(chan struct{})
+	err = a.gatherOnce(context.Background(), &testutil.Accumulator{}, second, 10*time.Millisecond, 0, skipped)
+	require.Error(t, err)
+	assert.True(t, isClosed(skipped))
+	assert.Equal(t, int64(1), second.GathersSkipped.Get())
+
+	close(input.release)
+	<-running
+
+	done := make(chan struct{})
+	err = a.gatherOnce(context.Background(), &testutil.Accumulator{}, second, time.Minute, 0, done)
+	require.NoError(t, err)
+	<-done
+}
@@ -1,5 +1,7 @@
 package telegraf
 
+import "context"
+
 type Input interface {
 	// SampleConfig returns the default configuration of the Input
 	SampleConfig() string
@@ -12,6 +14,16 @@ type Input interface {
 	Gather(Accumulator) error
 }
 
+// ContextInput is an Input whose gather can be cancelled.
+type ContextInput interface {
+	Input
+
+	// GatherContext is called instead of Gather.  The context is done when
+	// the gather timeout expires or the agent is shutting down, the Input
+	// should then abort its work and return.
+	GatherContext(context.Context, Accumulator) error
+}
+
 type ServiceInput interface {
 	Input
 
@@ -22,3 +34,13 @@ type ServiceInput interface {
 	// Stop stops the services and closes any necessary channels and connections
 	Stop()
 }
+
+// ContextServiceInput is a ServiceInput that is told when the agent is
+// shutting down.
+type ContextServiceInput interface {
+	ServiceInput
+
+	// StartContext is called instead of Start.  The context is done when the
+	// agent begins shutting down, Stop is still called afterwards.
+	StartContext(context.Context, Accumulator) error
+}
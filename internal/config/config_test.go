@@ -1,10 +1,12 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/models"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/exec"
@@ -177,3 +179,35 @@ func TestConfig_LoadDirectory(t *testing.T) {
 	assert.Equal(t, pConfig, c.Inputs[3].Config,
 		"Merged Testdata did not produce correct procstat metadata.")
 }
+
+type initializerInput struct {
+	Valid bool
+
+	initialized bool
+}
+
+func (i *initializerInput) SampleConfig() string                  { return "" }
+func (i *initializerInput) Description() string                   { return "" }
+func (i *initializerInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+func (i *initializerInput) Init() error {
+	if !i.Valid {
+		return errors.New("invalid")
+	}
+	i.initialized = true
+	return nil
+}
+
+func TestConfig_InitPlugins(t *testing.T) {
+	inputs.Add("initializer", func() telegraf.Input {
+		return &initializerInput{}
+	})
+	defer delete(inputs.Inputs, "initializer")
+
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/plugin_init.toml")
+	assert.EqualError(t, err,
+		"Error parsing ./testdata/plugin_init.toml, Could not initialize inputs.initializer: invalid")
+	assert.Len(t, c.Inputs, 1)
+	assert.True(t, c.Inputs[0].Input.(*initializerInput).initialized)
+}
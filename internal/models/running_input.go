@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -17,6 +18,8 @@ type RunningInput struct {
 
 	MetricsGathered selfstat.Stat
 	GatherTime      selfstat.Stat
+	GatherTimeouts  selfstat.Stat
+	GathersSkipped  selfstat.Stat
 }
 
 func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
@@ -33,6 +36,16 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 			"gather_time_ns",
 			map[string]string{"input": config.Name},
 		),
+		GatherTimeouts: selfstat.Register(
+			"gather",
+			"gather_timeouts",
+			map[string]string{"input": config.Name},
+		),
+		GathersSkipped: selfstat.Register(
+			"gather",
+			"gathers_skipped",
+			map[string]string{"input": config.Name},
+		),
 	}
 }
 
@@ -40,6 +53,7 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 type InputConfig struct {
 	Name     string
 	Interval time.Duration
+	Timeout  time.Duration
 
 	NameOverride      string
 	MeasurementPrefix string
@@ -82,8 +96,19 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 }
 
 func (r *RunningInput) Gather(acc telegraf.Accumulator) error {
+	return r.GatherContext(context.Background(), acc)
+}
+
+// GatherContext gathers the input, passing the context to inputs
+// implementing telegraf.ContextInput.
+func (r *RunningInput) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
 	start := time.Now()
-	err := r.Input.Gather(acc)
+	var err error
+	if ci, ok := r.Input.(telegraf.ContextInput); ok {
+		err = ci.GatherContext(ctx, acc)
+	} else {
+		err = r.Input.Gather(acc)
+	}
 	elapsed := time.Since(start)
 	r.GatherTime.Incr(elapsed.Nanoseconds())
 	return err
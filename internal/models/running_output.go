@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"log"
 	"sync"
 	"time"
@@ -156,6 +157,12 @@ func (ro *RunningOutput) addBatchToBuffer() {
 // Write writes all metrics to the output, stopping when all have been sent on
 // or error.
 func (ro *RunningOutput) Write() error {
+	return ro.WriteContext(context.Background())
+}
+
+// WriteContext is like Write but cancels the write to outputs implementing
+// telegraf.ContextOutput when the context is done.
+func (ro *RunningOutput) WriteContext(ctx context.Context) error {
 	if output, ok := ro.Output.(telegraf.AggregatingOutput); ok {
 		ro.aggMutex.Lock()
 		metrics := output.Push()
@@ -179,7 +186,7 @@ func (ro *RunningOutput) Write() error {
 			break
 		}
 
-		err := ro.write(batch)
+		err := ro.write(ctx, batch)
 		if err != nil {
 			ro.buffer.Reject(batch)
 			return err
@@ -191,12 +198,18 @@ func (ro *RunningOutput) Write() error {
 
 // WriteBatch writes only the batch metrics to the output.
 func (ro *RunningOutput) WriteBatch() error {
+	return ro.WriteBatchContext(context.Background())
+}
+
+// WriteBatchContext is like WriteBatch but cancels the write to outputs
+// implementing telegraf.ContextOutput when the context is done.
+func (ro *RunningOutput) WriteBatchContext(ctx context.Context) error {
 	batch := ro.buffer.Batch(ro.MetricBatchSize)
 	if len(batch) == 0 {
 		return nil
 	}
 
-	err := ro.write(batch)
+	err := ro.write(ctx, batch)
 	if err != nil {
 		ro.buffer.Reject(batch)
 		return err
@@ -206,9 +219,14 @@ func (ro *RunningOutput) WriteBatch() error {
 	return nil
 }
 
-func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
+func (ro *RunningOutput) write(ctx context.Context, metrics []telegraf.Metric) error {
 	start := time.Now()
-	err := ro.Output.Write(metrics)
+	var err error
+	if output, ok := ro.Output.(telegraf.ContextOutput); ok {
+		err = output.WriteContext(ctx, metrics)
+	} else {
+		err = ro.Output.Write(metrics)
+	}
 	elapsed := time.Since(start)
 	ro.WriteTime.Incr(elapsed.Nanoseconds())
 
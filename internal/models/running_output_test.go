@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
@@ -412,6 +413,29 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+func TestRunningOutputWriteContext(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &mockContextOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := ro.WriteContext(ctx)
+	require.Equal(t, context.Canceled, err)
+	assert.Len(t, m.Metrics(), 0)
+
+	err = ro.Write()
+	require.NoError(t, err)
+	assert.Equal(t, first5, m.Metrics())
+}
+
 type mockOutput struct {
 	sync.Mutex
 
@@ -460,6 +484,17 @@ func (m *mockOutput) Metrics() []telegraf.Metric {
 	return m.metrics
 }
 
+type mockContextOutput struct {
+	mockOutput
+}
+
+func (m *mockContextOutput) WriteContext(ctx context.Context, metrics []telegraf.Metric) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return m.Write(metrics)
+}
+
 type perfOutput struct {
 	// if true, mock a write failure
 	failWrite bool
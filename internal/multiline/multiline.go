@@ -0,0 +1,123 @@
+// Package multiline groups consecutive log lines belonging to the same event,
+// such as stack traces, into a single record.
+package multiline
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+const (
+	// Previous joins a matching line to the lines before it.
+	Previous = "previous"
+	// Next joins a matching line to the lines after it.
+	Next = "next"
+
+	defaultTimeout = 5 * time.Second
+)
+
+// Config is the multiline configuration shared by the inputs reading log
+// files.
+type Config struct {
+	// Pattern is the regular expression lines are matched against.
+	Pattern string
+	// MatchWhichLine is either "previous" or "next", the line a matching
+	// line is joined to.
+	MatchWhichLine string
+	// InvertMatch applies MatchWhichLine to lines not matching the pattern.
+	InvertMatch bool
+	// MaxLines limits the number of lines in a group, 0 is unlimited.
+	MaxLines int
+	// Timeout is the time after which a pending group is flushed.
+	Timeout *internal.Duration
+}
+
+// Multiline holds the pending lines of a single file.
+type Multiline struct {
+	config  *Config
+	pattern *regexp.Regexp
+	lines   []string
+}
+
+// NewMultiline validates the config and creates a Multiline.  Each source of
+// lines needs its own Multiline.
+func (c *Config) NewMultiline() (*Multiline, error) {
+	if c.Pattern == "" {
+		return nil, fmt.Errorf("multiline pattern is required")
+	}
+
+	pattern, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multiline pattern: %s", err)
+	}
+
+	switch c.MatchWhichLine {
+	case "":
+		c.MatchWhichLine = Previous
+	case Previous, Next:
+	default:
+		return nil, fmt.Errorf("invalid multiline match_which_line: %q", c.MatchWhichLine)
+	}
+
+	if c.MaxLines < 0 {
+		return nil, fmt.Errorf("invalid multiline max_lines: %d", c.MaxLines)
+	}
+
+	if c.Timeout == nil || c.Timeout.Duration <= 0 {
+		c.Timeout = &internal.Duration{Duration: defaultTimeout}
+	}
+
+	return &Multiline{
+		config:  c,
+		pattern: pattern,
+	}, nil
+}
+
+// Timeout returns the time after which pending lines should be flushed.
+func (m *Multiline) Timeout() time.Duration {
+	return m.config.Timeout.Duration
+}
+
+// ProcessLine adds the line and returns the completed group, ok is false if
+// no group was completed.  A group may be a single empty line.
+func (m *Multiline) ProcessLine(text string) (group string, ok bool) {
+	matched := m.pattern.MatchString(text) != m.config.InvertMatch
+
+	switch m.config.MatchWhichLine {
+	case Next:
+		m.lines = append(m.lines, text)
+		if !matched || m.full() {
+			group, ok = m.Flush()
+		}
+	default:
+		if !matched || m.full() {
+			group, ok = m.Flush()
+		}
+		m.lines = append(m.lines, text)
+	}
+	return group, ok
+}
+
+// Pending reports if lines are waiting for their group to be completed.
+func (m *Multiline) Pending() bool {
+	return len(m.lines) > 0
+}
+
+func (m *Multiline) full() bool {
+	return m.config.MaxLines > 0 && len(m.lines) >= m.config.MaxLines
+}
+
+// Flush returns the pending lines as a single record and clears them, ok is
+// false if no lines were pending.
+func (m *Multiline) Flush() (group string, ok bool) {
+	if len(m.lines) == 0 {
+		return "", false
+	}
+	group = strings.Join(m.lines, "\n")
+	m.lines = m.lines[:0]
+	return group, true
+}
@@ -0,0 +1,127 @@
+package multiline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func process(m *Multiline, lines []string) []string {
+	var groups []string
+	for _, line := range lines {
+		if group, ok := m.ProcessLine(line); ok {
+			groups = append(groups, group)
+		}
+	}
+	if group, ok := m.Flush(); ok {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func TestMultilinePrevious(t *testing.T) {
+	c := &Config{
+		Pattern:        `^\s`,
+		MatchWhichLine: Previous,
+	}
+	m, err := c.NewMultiline()
+	require.NoError(t, err)
+
+	groups := process(m, []string{
+		"Exception in thread main",
+		"  at com.example.Foo(Foo.java:1)",
+		"  at com.example.Bar(Bar.java:2)",
+		"next event",
+	})
+	require.Equal(t, []string{
+		"Exception in thread main\n  at com.example.Foo(Foo.java:1)\n  at com.example.Bar(Bar.java:2)",
+		"next event",
+	}, groups)
+}
+
+func TestMultilineInvertMatch(t *testing.T) {
+	c := &Config{
+		Pattern:     `^\d{4}-\d{2}-\d{2}`,
+		InvertMatch: true,
+	}
+	m, err := c.NewMultiline()
+	require.NoError(t, err)
+
+	groups := process(m, []string{
+		"2018-10-01 error",
+		"Traceback (most recent call last):",
+		"ValueError",
+		"2018-10-01 info",
+	})
+	require.Equal(t, []string{
+		"2018-10-01 error\nTraceback (most recent call last):\nValueError",
+		"2018-10-01 info",
+	}, groups)
+}
+
+func TestMultilineNext(t *testing.T) {
+	c := &Config{
+		Pattern:        `\\$`,
+		MatchWhichLine: Next,
+	}
+	m, err := c.NewMultiline()
+	require.NoError(t, err)
+
+	groups := process(m, []string{
+		`first \`,
+		`second \`,
+		"third",
+		"fourth",
+	})
+	require.Equal(t, []string{
+		"first \\\nsecond \\\nthird",
+		"fourth",
+	}, groups)
+}
+
+func TestMultilineMaxLines(t *testing.T) {
+	c := &Config{
+		Pattern:  `^\s`,
+		MaxLines: 2,
+	}
+	m, err := c.NewMultiline()
+	require.NoError(t, err)
+
+	groups := process(m, []string{
+		"event",
+		" 1",
+		" 2",
+		" 3",
+	})
+	require.Equal(t, []string{
+		"event\n 1",
+		" 2\n 3",
+	}, groups)
+}
+
+func TestMultilineInvalidConfig(t *testing.T) {
+	_, err := (&Config{}).NewMultiline()
+	require.Error(t, err)
+
+	_, err = (&Config{Pattern: "("}).NewMultiline()
+	require.Error(t, err)
+
+	_, err = (&Config{Pattern: "^a", MatchWhichLine: "after"}).NewMultiline()
+	require.Error(t, err)
+}
+
+func TestMultilineEmptyLine(t *testing.T) {
+	c := &Config{
+		Pattern: `^\s`,
+	}
+	m, err := c.NewMultiline()
+	require.NoError(t, err)
+
+	// An empty line is a group of its own, not an incomplete one.
+	groups := process(m, []string{
+		"event",
+		"",
+		"next event",
+	})
+	require.Equal(t, []string{"event", "", "next event"}, groups)
+}
@@ -0,0 +1,258 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: opentelemetry/proto/collector/metrics/v1/metrics_service.proto
+
+package collector // import "github.com/influxdata/telegraf/internal/otlp/collector"
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import metrics "github.com/influxdata/telegraf/internal/otlp/metrics"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type ExportMetricsServiceRequest struct {
+	ResourceMetrics      []*metrics.ResourceMetrics `protobuf:"bytes,1,rep,name=resource_metrics,json=resourceMetrics" json:"resource_metrics,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *ExportMetricsServiceRequest) Reset()         { *m = ExportMetricsServiceRequest{} }
+func (m *ExportMetricsServiceRequest) String() string { return proto.CompactTextString(m) }
+func (*ExportMetricsServiceRequest) ProtoMessage()    {}
+func (*ExportMetricsServiceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_service_186afa8cb4e38daa, []int{0}
+}
+func (m *ExportMetricsServiceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportMetricsServiceRequest.Unmarshal(m, b)
+}
+func (m *ExportMetricsServiceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportMetricsServiceRequest.Marshal(b, m, deterministic)
+}
+func (dst *ExportMetricsServiceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportMetricsServiceRequest.Merge(dst, src)
+}
+func (m *ExportMetricsServiceRequest) XXX_Size() int {
+	return xxx_messageInfo_ExportMetricsServiceRequest.Size(m)
+}
+func (m *ExportMetricsServiceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportMetricsServiceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportMetricsServiceRequest proto.InternalMessageInfo
+
+func (m *ExportMetricsServiceRequest) GetResourceMetrics() []*metrics.ResourceMetrics {
+	if m != nil {
+		return m.ResourceMetrics
+	}
+	return nil
+}
+
+type ExportMetricsServiceResponse struct {
+	PartialSuccess       *ExportMetricsPartialSuccess `protobuf:"bytes,1,opt,name=partial_success,json=partialSuccess" json:"partial_success,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                     `json:"-"`
+	XXX_unrecognized     []byte                       `json:"-"`
+	XXX_sizecache        int32                        `json:"-"`
+}
+
+func (m *ExportMetricsServiceResponse) Reset()         { *m = ExportMetricsServiceResponse{} }
+func (m *ExportMetricsServiceResponse) String() string { return proto.CompactTextString(m) }
+func (*ExportMetricsServiceResponse) ProtoMessage()    {}
+func (*ExportMetricsServiceResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_service_186afa8cb4e38daa, []int{1}
+}
+func (m *ExportMetricsServiceResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportMetricsServiceResponse.Unmarshal(m, b)
+}
+func (m *ExportMetricsServiceResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportMetricsServiceResponse.Marshal(b, m, deterministic)
+}
+func (dst *ExportMetricsServiceResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportMetricsServiceResponse.Merge(dst, src)
+}
+func (m *ExportMetricsServiceResponse) XXX_Size() int {
+	return xxx_messageInfo_ExportMetricsServiceResponse.Size(m)
+}
+func (m *ExportMetricsServiceResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportMetricsServiceResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportMetricsServiceResponse proto.InternalMessageInfo
+
+func (m *ExportMetricsServiceResponse) GetPartialSuccess() *ExportMetricsPartialSuccess {
+	if m != nil {
+		return m.PartialSuccess
+	}
+	return nil
+}
+
+type ExportMetricsPartialSuccess struct {
+	RejectedDataPoints   int64    `protobuf:"varint,1,opt,name=rejected_data_points,json=rejectedDataPoints" json:"rejected_data_points,omitempty"`
+	ErrorMessage         string   `protobuf:"bytes,2,opt,name=error_message,json=errorMessage" json:"error_message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExportMetricsPartialSuccess) Reset()         { *m = ExportMetricsPartialSuccess{} }
+func (m *ExportMetricsPartialSuccess) String() string { return proto.CompactTextString(m) }
+func (*ExportMetricsPartialSuccess) ProtoMessage()    {}
+func (*ExportMetricsPartialSuccess) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_service_186afa8cb4e38daa, []int{2}
+}
+func (m *ExportMetricsPartialSuccess) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportMetricsPartialSuccess.Unmarshal(m, b)
+}
+func (m *ExportMetricsPartialSuccess) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportMetricsPartialSuccess.Marshal(b, m, deterministic)
+}
+func (dst *ExportMetricsPartialSuccess) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportMetricsPartialSuccess.Merge(dst, src)
+}
+func (m *ExportMetricsPartialSuccess) XXX_Size() int {
+	return xxx_messageInfo_ExportMetricsPartialSuccess.Size(m)
+}
+func (m *ExportMetricsPartialSuccess) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportMetricsPartialSuccess.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportMetricsPartialSuccess proto.InternalMessageInfo
+
+func (m *ExportMetricsPartialSuccess) GetRejectedDataPoints() int64 {
+	if m != nil {
+		return m.RejectedDataPoints
+	}
+	return 0
+}
+
+func (m *ExportMetricsPartialSuccess) GetErrorMessage() string {
+	if m != nil {
+		return m.ErrorMessage
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*ExportMetricsServiceRequest)(nil), "opentelemetry.proto.collector.metrics.v1.ExportMetricsServiceRequest")
+	proto.RegisterType((*ExportMetricsServiceResponse)(nil), "opentelemetry.proto.collector.metrics.v1.ExportMetricsServiceResponse")
+	proto.RegisterType((*ExportMetricsPartialSuccess)(nil), "opentelemetry.proto.collector.metrics.v1.ExportMetricsPartialSuccess")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for MetricsService service
+
+type MetricsServiceClient interface {
+	Export(ctx context.Context, in *ExportMetricsServiceRequest, opts ...grpc.CallOption) (*ExportMetricsServiceResponse, error)
+}
+
+type metricsServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewMetricsServiceClient(cc *grpc.ClientConn) MetricsServiceClient {
+	return &metricsServiceClient{cc}
+}
+
+func (c *metricsServiceClient) Export(ctx context.Context, in *ExportMetricsServiceRequest, opts ...grpc.CallOption) (*ExportMetricsServiceResponse, error) {
+	out := new(ExportMetricsServiceResponse)
+	err := grpc.Invoke(ctx, "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for MetricsService service
+
+type MetricsServiceServer interface {
+	Export(context.Context, *ExportMetricsServiceRequest) (*ExportMetricsServiceResponse, error)
+}
+
+func RegisterMetricsServiceServer(s *grpc.Server, srv MetricsServiceServer) {
+	s.RegisterService(&_MetricsService_serviceDesc, srv)
+}
+
+func _MetricsService_Export_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportMetricsServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).Export(ctx, req.(*ExportMetricsServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _MetricsService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "opentelemetry.proto.collector.metrics.v1.MetricsService",
+	HandlerType: (*MetricsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Export",
+			Handler:    _MetricsService_Export_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "opentelemetry/proto/collector/metrics/v1/metrics_service.proto",
+}
+
+func init() {
+	proto.RegisterFile("opentelemetry/proto/collector/metrics/v1/metrics_service.proto", fileDescriptor_metrics_service_186afa8cb4e38daa)
+}
+
+var fileDescriptor_metrics_service_186afa8cb4e38daa = []byte{
+	// 392 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x93, 0xc1, 0xaa, 0x13, 0x31,
+	0x18, 0x85, 0xcd, 0xbd, 0x70, 0xc1, 0x5c, 0x6d, 0x25, 0xba, 0x28, 0xad, 0x8b, 0x32, 0x6e, 0x06,
+	0x94, 0xc4, 0xd6, 0xa5, 0x20, 0x52, 0xad, 0xbb, 0xe2, 0x30, 0x15, 0x17, 0xdd, 0x0c, 0x69, 0xfa,
+	0xb7, 0x46, 0xa6, 0x49, 0x4c, 0x32, 0xa5, 0x7d, 0x09, 0xf7, 0xbe, 0x82, 0xb8, 0xf1, 0x0d, 0x65,
+	0x26, 0xd3, 0x29, 0x83, 0x45, 0x8a, 0x77, 0x97, 0x9e, 0xfc, 0xe7, 0x3b, 0xa7, 0x7f, 0x18, 0xfc,
+	0x46, 0x1b, 0x50, 0x1e, 0x72, 0xd8, 0x82, 0xb7, 0x07, 0x66, 0xac, 0xf6, 0x9a, 0x09, 0x9d, 0xe7,
+	0x20, 0xbc, 0xb6, 0xac, 0x54, 0xa5, 0x70, 0x6c, 0x37, 0x3a, 0x1e, 0x33, 0x07, 0x76, 0x27, 0x05,
+	0xd0, 0x6a, 0x94, 0xc4, 0x2d, 0x7f, 0x10, 0x69, 0xe3, 0xa7, 0xb5, 0x89, 0xee, 0x46, 0xfd, 0x17,
+	0xe7, 0x92, 0xfe, 0xe6, 0x07, 0x44, 0x74, 0xc0, 0x83, 0xe9, 0xde, 0x68, 0xeb, 0x67, 0x41, 0x9e,
+	0x87, 0xd4, 0x14, 0xbe, 0x15, 0xe0, 0x3c, 0x59, 0xe0, 0x47, 0x16, 0x9c, 0x2e, 0xac, 0x80, 0xac,
+	0x36, 0xf6, 0xd0, 0xf0, 0x3a, 0xbe, 0x1d, 0x33, 0x7a, 0xae, 0xd1, 0xa9, 0x07, 0x4d, 0x6b, 0x5f,
+	0x0d, 0x4e, 0xbb, 0xb6, 0x2d, 0x44, 0xdf, 0x11, 0x7e, 0x7a, 0x3e, 0xdb, 0x19, 0xad, 0x1c, 0x10,
+	0x85, 0xbb, 0x86, 0x5b, 0x2f, 0x79, 0x9e, 0xb9, 0x42, 0x08, 0x70, 0x65, 0x36, 0x8a, 0x6f, 0xc7,
+	0x53, 0x7a, 0xe9, 0x36, 0x68, 0x2b, 0x20, 0x09, 0xb4, 0x79, 0x80, 0xa5, 0x1d, 0xd3, 0xfa, 0x1d,
+	0x79, 0x3c, 0xf8, 0xc7, 0x38, 0x79, 0x89, 0x9f, 0x58, 0xf8, 0x0a, 0xc2, 0xc3, 0x2a, 0x5b, 0x71,
+	0xcf, 0x33, 0xa3, 0xa5, 0xf2, 0xa1, 0xd3, 0x75, 0x4a, 0x8e, 0x77, 0xef, 0xb9, 0xe7, 0x49, 0x75,
+	0x43, 0x9e, 0xe1, 0x87, 0x60, 0xad, 0xb6, 0xd9, 0x16, 0x9c, 0xe3, 0x1b, 0xe8, 0x5d, 0x0d, 0x51,
+	0x7c, 0x3f, 0x7d, 0x50, 0x89, 0xb3, 0xa0, 0x8d, 0x7f, 0x21, 0xdc, 0x69, 0x2f, 0x80, 0xfc, 0x40,
+	0xf8, 0x26, 0x34, 0x21, 0xff, 0xfb, 0x57, 0xdb, 0xef, 0xd8, 0xff, 0x70, 0x57, 0x4c, 0x78, 0x92,
+	0xe8, 0xde, 0xe4, 0x37, 0xc2, 0xcf, 0xa5, 0xbe, 0x18, 0x37, 0x79, 0xdc, 0x26, 0x25, 0xe5, 0x64,
+	0x82, 0x16, 0x6f, 0x37, 0xd2, 0x7f, 0x29, 0x96, 0x54, 0xe8, 0x2d, 0x93, 0x6a, 0x9d, 0x17, 0xfb,
+	0x72, 0xa5, 0xac, 0x44, 0x6e, 0x2c, 0x5f, 0x33, 0xa9, 0x3c, 0x58, 0xc5, 0x73, 0xa6, 0x7d, 0x6e,
+	0x4e, 0x1f, 0xca, 0xeb, 0xe6, 0xf4, 0xf3, 0x2a, 0xfe, 0x68, 0x40, 0x7d, 0x6a, 0x3a, 0x54, 0x64,
+	0xfa, 0xae, 0xe9, 0x50, 0xe7, 0xd2, 0xcf, 0xa3, 0xe5, 0x4d, 0xd5, 0xef, 0xd5, 0x9f, 0x01, 0x00,
+	0x3d, 0xe7, 0xa3, 0x9d, 0x85, 0x03, 0x00, 0x00,
+}
@@ -0,0 +1,1886 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: opentelemetry/proto/metrics/v1/metrics.proto
+
+package metrics // import "github.com/influxdata/telegraf/internal/otlp/metrics"
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import common "github.com/influxdata/telegraf/internal/otlp/common"
+import resource "github.com/influxdata/telegraf/internal/otlp/resource"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type AggregationTemporality int32
+
+const (
+	AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED AggregationTemporality = 0
+	AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA       AggregationTemporality = 1
+	AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE  AggregationTemporality = 2
+)
+
+var AggregationTemporality_name = map[int32]string{
+	0: "AGGREGATION_TEMPORALITY_UNSPECIFIED",
+	1: "AGGREGATION_TEMPORALITY_DELTA",
+	2: "AGGREGATION_TEMPORALITY_CUMULATIVE",
+}
+var AggregationTemporality_value = map[string]int32{
+	"AGGREGATION_TEMPORALITY_UNSPECIFIED": 0,
+	"AGGREGATION_TEMPORALITY_DELTA":       1,
+	"AGGREGATION_TEMPORALITY_CUMULATIVE":  2,
+}
+
+func (x AggregationTemporality) String() string {
+	return proto.EnumName(AggregationTemporality_name, int32(x))
+}
+func (AggregationTemporality) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{0}
+}
+
+type DataPointFlags int32
+
+const (
+	DataPointFlags_FLAG_NONE              DataPointFlags = 0
+	DataPointFlags_FLAG_NO_RECORDED_VALUE DataPointFlags = 1
+)
+
+var DataPointFlags_name = map[int32]string{
+	0: "FLAG_NONE",
+	1: "FLAG_NO_RECORDED_VALUE",
+}
+var DataPointFlags_value = map[string]int32{
+	"FLAG_NONE":              0,
+	"FLAG_NO_RECORDED_VALUE": 1,
+}
+
+func (x DataPointFlags) String() string {
+	return proto.EnumName(DataPointFlags_name, int32(x))
+}
+func (DataPointFlags) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{1}
+}
+
+type MetricsData struct {
+	ResourceMetrics      []*ResourceMetrics `protobuf:"bytes,1,rep,name=resource_metrics,json=resourceMetrics" json:"resource_metrics,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *MetricsData) Reset()         { *m = MetricsData{} }
+func (m *MetricsData) String() string { return proto.CompactTextString(m) }
+func (*MetricsData) ProtoMessage()    {}
+func (*MetricsData) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{0}
+}
+func (m *MetricsData) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MetricsData.Unmarshal(m, b)
+}
+func (m *MetricsData) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MetricsData.Marshal(b, m, deterministic)
+}
+func (dst *MetricsData) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetricsData.Merge(dst, src)
+}
+func (m *MetricsData) XXX_Size() int {
+	return xxx_messageInfo_MetricsData.Size(m)
+}
+func (m *MetricsData) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetricsData.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetricsData proto.InternalMessageInfo
+
+func (m *MetricsData) GetResourceMetrics() []*ResourceMetrics {
+	if m != nil {
+		return m.ResourceMetrics
+	}
+	return nil
+}
+
+type ResourceMetrics struct {
+	Resource             *resource.Resource `protobuf:"bytes,1,opt,name=resource" json:"resource,omitempty"`
+	ScopeMetrics         []*ScopeMetrics    `protobuf:"bytes,2,rep,name=scope_metrics,json=scopeMetrics" json:"scope_metrics,omitempty"`
+	SchemaUrl            string             `protobuf:"bytes,3,opt,name=schema_url,json=schemaUrl" json:"schema_url,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *ResourceMetrics) Reset()         { *m = ResourceMetrics{} }
+func (m *ResourceMetrics) String() string { return proto.CompactTextString(m) }
+func (*ResourceMetrics) ProtoMessage()    {}
+func (*ResourceMetrics) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{1}
+}
+func (m *ResourceMetrics) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResourceMetrics.Unmarshal(m, b)
+}
+func (m *ResourceMetrics) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResourceMetrics.Marshal(b, m, deterministic)
+}
+func (dst *ResourceMetrics) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResourceMetrics.Merge(dst, src)
+}
+func (m *ResourceMetrics) XXX_Size() int {
+	return xxx_messageInfo_ResourceMetrics.Size(m)
+}
+func (m *ResourceMetrics) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResourceMetrics.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResourceMetrics proto.InternalMessageInfo
+
+func (m *ResourceMetrics) GetResource() *resource.Resource {
+	if m != nil {
+		return m.Resource
+	}
+	return nil
+}
+
+func (m *ResourceMetrics) GetScopeMetrics() []*ScopeMetrics {
+	if m != nil {
+		return m.ScopeMetrics
+	}
+	return nil
+}
+
+func (m *ResourceMetrics) GetSchemaUrl() string {
+	if m != nil {
+		return m.SchemaUrl
+	}
+	return ""
+}
+
+type ScopeMetrics struct {
+	Scope                *common.InstrumentationScope `protobuf:"bytes,1,opt,name=scope" json:"scope,omitempty"`
+	Metrics              []*Metric                    `protobuf:"bytes,2,rep,name=metrics" json:"metrics,omitempty"`
+	SchemaUrl            string                       `protobuf:"bytes,3,opt,name=schema_url,json=schemaUrl" json:"schema_url,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                     `json:"-"`
+	XXX_unrecognized     []byte                       `json:"-"`
+	XXX_sizecache        int32                        `json:"-"`
+}
+
+func (m *ScopeMetrics) Reset()         { *m = ScopeMetrics{} }
+func (m *ScopeMetrics) String() string { return proto.CompactTextString(m) }
+func (*ScopeMetrics) ProtoMessage()    {}
+func (*ScopeMetrics) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{2}
+}
+func (m *ScopeMetrics) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ScopeMetrics.Unmarshal(m, b)
+}
+func (m *ScopeMetrics) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ScopeMetrics.Marshal(b, m, deterministic)
+}
+func (dst *ScopeMetrics) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ScopeMetrics.Merge(dst, src)
+}
+func (m *ScopeMetrics) XXX_Size() int {
+	return xxx_messageInfo_ScopeMetrics.Size(m)
+}
+func (m *ScopeMetrics) XXX_DiscardUnknown() {
+	xxx_messageInfo_ScopeMetrics.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ScopeMetrics proto.InternalMessageInfo
+
+func (m *ScopeMetrics) GetScope() *common.InstrumentationScope {
+	if m != nil {
+		return m.Scope
+	}
+	return nil
+}
+
+func (m *ScopeMetrics) GetMetrics() []*Metric {
+	if m != nil {
+		return m.Metrics
+	}
+	return nil
+}
+
+func (m *ScopeMetrics) GetSchemaUrl() string {
+	if m != nil {
+		return m.SchemaUrl
+	}
+	return ""
+}
+
+type Metric struct {
+	Name        string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Description string `protobuf:"bytes,2,opt,name=description" json:"description,omitempty"`
+	Unit        string `protobuf:"bytes,3,opt,name=unit" json:"unit,omitempty"`
+	// Types that are valid to be assigned to Data:
+	//	*Metric_Gauge
+	//	*Metric_Sum
+	//	*Metric_Histogram
+	//	*Metric_ExponentialHistogram
+	//	*Metric_Summary
+	Data                 isMetric_Data `protobuf_oneof:"data"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *Metric) Reset()         { *m = Metric{} }
+func (m *Metric) String() string { return proto.CompactTextString(m) }
+func (*Metric) ProtoMessage()    {}
+func (*Metric) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{3}
+}
+func (m *Metric) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Metric.Unmarshal(m, b)
+}
+func (m *Metric) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Metric.Marshal(b, m, deterministic)
+}
+func (dst *Metric) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Metric.Merge(dst, src)
+}
+func (m *Metric) XXX_Size() int {
+	return xxx_messageInfo_Metric.Size(m)
+}
+func (m *Metric) XXX_DiscardUnknown() {
+	xxx_messageInfo_Metric.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Metric proto.InternalMessageInfo
+
+type isMetric_Data interface {
+	isMetric_Data()
+}
+
+type Metric_Gauge struct {
+	Gauge *Gauge `protobuf:"bytes,5,opt,name=gauge,oneof"`
+}
+type Metric_Sum struct {
+	Sum *Sum `protobuf:"bytes,7,opt,name=sum,oneof"`
+}
+type Metric_Histogram struct {
+	Histogram *Histogram `protobuf:"bytes,9,opt,name=histogram,oneof"`
+}
+type Metric_ExponentialHistogram struct {
+	ExponentialHistogram *ExponentialHistogram `protobuf:"bytes,10,opt,name=exponential_histogram,json=exponentialHistogram,oneof"`
+}
+type Metric_Summary struct {
+	Summary *Summary `protobuf:"bytes,11,opt,name=summary,oneof"`
+}
+
+func (*Metric_Gauge) isMetric_Data()                {}
+func (*Metric_Sum) isMetric_Data()                  {}
+func (*Metric_Histogram) isMetric_Data()            {}
+func (*Metric_ExponentialHistogram) isMetric_Data() {}
+func (*Metric_Summary) isMetric_Data()              {}
+
+func (m *Metric) GetData() isMetric_Data {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *Metric) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Metric) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *Metric) GetUnit() string {
+	if m != nil {
+		return m.Unit
+	}
+	return ""
+}
+
+func (m *Metric) GetGauge() *Gauge {
+	if x, ok := m.GetData().(*Metric_Gauge); ok {
+		return x.Gauge
+	}
+	return nil
+}
+
+func (m *Metric) GetSum() *Sum {
+	if x, ok := m.GetData().(*Metric_Sum); ok {
+		return x.Sum
+	}
+	return nil
+}
+
+func (m *Metric) GetHistogram() *Histogram {
+	if x, ok := m.GetData().(*Metric_Histogram); ok {
+		return x.Histogram
+	}
+	return nil
+}
+
+func (m *Metric) GetExponentialHistogram() *ExponentialHistogram {
+	if x, ok := m.GetData().(*Metric_ExponentialHistogram); ok {
+		return x.ExponentialHistogram
+	}
+	return nil
+}
+
+func (m *Metric) GetSummary() *Summary {
+	if x, ok := m.GetData().(*Metric_Summary); ok {
+		return x.Summary
+	}
+	return nil
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*Metric) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _Metric_OneofMarshaler, _Metric_OneofUnmarshaler, _Metric_OneofSizer, []interface{}{
+		(*Metric_Gauge)(nil),
+		(*Metric_Sum)(nil),
+		(*Metric_Histogram)(nil),
+		(*Metric_ExponentialHistogram)(nil),
+		(*Metric_Summary)(nil),
+	}
+}
+
+func _Metric_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*Metric)
+	// data
+	switch x := m.Data.(type) {
+	case *Metric_Gauge:
+		b.EncodeVarint(5<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Gauge); err != nil {
+			return err
+		}
+	case *Metric_Sum:
+		b.EncodeVarint(7<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Sum); err != nil {
+			return err
+		}
+	case *Metric_Histogram:
+		b.EncodeVarint(9<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Histogram); err != nil {
+			return err
+		}
+	case *Metric_ExponentialHistogram:
+		b.EncodeVarint(10<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.ExponentialHistogram); err != nil {
+			return err
+		}
+	case *Metric_Summary:
+		b.EncodeVarint(11<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Summary); err != nil {
+			return err
+		}
+	case nil:
+	default:
+		return fmt.Errorf("Metric.Data has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _Metric_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*Metric)
+	switch tag {
+	case 5: // data.gauge
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Gauge)
+		err := b.DecodeMessage(msg)
+		m.Data = &Metric_Gauge{msg}
+		return true, err
+	case 7: // data.sum
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Sum)
+		err := b.DecodeMessage(msg)
+		m.Data = &Metric_Sum{msg}
+		return true, err
+	case 9: // data.histogram
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Histogram)
+		err := b.DecodeMessage(msg)
+		m.Data = &Metric_Histogram{msg}
+		return true, err
+	case 10: // data.exponential_histogram
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(ExponentialHistogram)
+		err := b.DecodeMessage(msg)
+		m.Data = &Metric_ExponentialHistogram{msg}
+		return true, err
+	case 11: // data.summary
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(Summary)
+		err := b.DecodeMessage(msg)
+		m.Data = &Metric_Summary{msg}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _Metric_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*Metric)
+	// data
+	switch x := m.Data.(type) {
+	case *Metric_Gauge:
+		s := proto.Size(x.Gauge)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Metric_Sum:
+		s := proto.Size(x.Sum)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Metric_Histogram:
+		s := proto.Size(x.Histogram)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Metric_ExponentialHistogram:
+		s := proto.Size(x.ExponentialHistogram)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Metric_Summary:
+		s := proto.Size(x.Summary)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+type Gauge struct {
+	DataPoints           []*NumberDataPoint `protobuf:"bytes,1,rep,name=data_points,json=dataPoints" json:"data_points,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *Gauge) Reset()         { *m = Gauge{} }
+func (m *Gauge) String() string { return proto.CompactTextString(m) }
+func (*Gauge) ProtoMessage()    {}
+func (*Gauge) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{4}
+}
+func (m *Gauge) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Gauge.Unmarshal(m, b)
+}
+func (m *Gauge) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Gauge.Marshal(b, m, deterministic)
+}
+func (dst *Gauge) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Gauge.Merge(dst, src)
+}
+func (m *Gauge) XXX_Size() int {
+	return xxx_messageInfo_Gauge.Size(m)
+}
+func (m *Gauge) XXX_DiscardUnknown() {
+	xxx_messageInfo_Gauge.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Gauge proto.InternalMessageInfo
+
+func (m *Gauge) GetDataPoints() []*NumberDataPoint {
+	if m != nil {
+		return m.DataPoints
+	}
+	return nil
+}
+
+type Sum struct {
+	DataPoints             []*NumberDataPoint     `protobuf:"bytes,1,rep,name=data_points,json=dataPoints" json:"data_points,omitempty"`
+	AggregationTemporality AggregationTemporality `protobuf:"varint,2,opt,name=aggregation_temporality,json=aggregationTemporality,enum=opentelemetry.proto.metrics.v1.AggregationTemporality" json:"aggregation_temporality,omitempty"`
+	IsMonotonic            bool                   `protobuf:"varint,3,opt,name=is_monotonic,json=isMonotonic" json:"is_monotonic,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{}               `json:"-"`
+	XXX_unrecognized       []byte                 `json:"-"`
+	XXX_sizecache          int32                  `json:"-"`
+}
+
+func (m *Sum) Reset()         { *m = Sum{} }
+func (m *Sum) String() string { return proto.CompactTextString(m) }
+func (*Sum) ProtoMessage()    {}
+func (*Sum) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{5}
+}
+func (m *Sum) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Sum.Unmarshal(m, b)
+}
+func (m *Sum) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Sum.Marshal(b, m, deterministic)
+}
+func (dst *Sum) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Sum.Merge(dst, src)
+}
+func (m *Sum) XXX_Size() int {
+	return xxx_messageInfo_Sum.Size(m)
+}
+func (m *Sum) XXX_DiscardUnknown() {
+	xxx_messageInfo_Sum.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Sum proto.InternalMessageInfo
+
+func (m *Sum) GetDataPoints() []*NumberDataPoint {
+	if m != nil {
+		return m.DataPoints
+	}
+	return nil
+}
+
+func (m *Sum) GetAggregationTemporality() AggregationTemporality {
+	if m != nil {
+		return m.AggregationTemporality
+	}
+	return AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED
+}
+
+func (m *Sum) GetIsMonotonic() bool {
+	if m != nil {
+		return m.IsMonotonic
+	}
+	return false
+}
+
+type Histogram struct {
+	DataPoints             []*HistogramDataPoint  `protobuf:"bytes,1,rep,name=data_points,json=dataPoints" json:"data_points,omitempty"`
+	AggregationTemporality AggregationTemporality `protobuf:"varint,2,opt,name=aggregation_temporality,json=aggregationTemporality,enum=opentelemetry.proto.metrics.v1.AggregationTemporality" json:"aggregation_temporality,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{}               `json:"-"`
+	XXX_unrecognized       []byte                 `json:"-"`
+	XXX_sizecache          int32                  `json:"-"`
+}
+
+func (m *Histogram) Reset()         { *m = Histogram{} }
+func (m *Histogram) String() string { return proto.CompactTextString(m) }
+func (*Histogram) ProtoMessage()    {}
+func (*Histogram) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{6}
+}
+func (m *Histogram) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Histogram.Unmarshal(m, b)
+}
+func (m *Histogram) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Histogram.Marshal(b, m, deterministic)
+}
+func (dst *Histogram) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Histogram.Merge(dst, src)
+}
+func (m *Histogram) XXX_Size() int {
+	return xxx_messageInfo_Histogram.Size(m)
+}
+func (m *Histogram) XXX_DiscardUnknown() {
+	xxx_messageInfo_Histogram.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Histogram proto.InternalMessageInfo
+
+func (m *Histogram) GetDataPoints() []*HistogramDataPoint {
+	if m != nil {
+		return m.DataPoints
+	}
+	return nil
+}
+
+func (m *Histogram) GetAggregationTemporality() AggregationTemporality {
+	if m != nil {
+		return m.AggregationTemporality
+	}
+	return AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED
+}
+
+type ExponentialHistogram struct {
+	DataPoints             []*ExponentialHistogramDataPoint `protobuf:"bytes,1,rep,name=data_points,json=dataPoints" json:"data_points,omitempty"`
+	AggregationTemporality AggregationTemporality           `protobuf:"varint,2,opt,name=aggregation_temporality,json=aggregationTemporality,enum=opentelemetry.proto.metrics.v1.AggregationTemporality" json:"aggregation_temporality,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{}                         `json:"-"`
+	XXX_unrecognized       []byte                           `json:"-"`
+	XXX_sizecache          int32                            `json:"-"`
+}
+
+func (m *ExponentialHistogram) Reset()         { *m = ExponentialHistogram{} }
+func (m *ExponentialHistogram) String() string { return proto.CompactTextString(m) }
+func (*ExponentialHistogram) ProtoMessage()    {}
+func (*ExponentialHistogram) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{7}
+}
+func (m *ExponentialHistogram) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExponentialHistogram.Unmarshal(m, b)
+}
+func (m *ExponentialHistogram) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExponentialHistogram.Marshal(b, m, deterministic)
+}
+func (dst *ExponentialHistogram) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExponentialHistogram.Merge(dst, src)
+}
+func (m *ExponentialHistogram) XXX_Size() int {
+	return xxx_messageInfo_ExponentialHistogram.Size(m)
+}
+func (m *ExponentialHistogram) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExponentialHistogram.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExponentialHistogram proto.InternalMessageInfo
+
+func (m *ExponentialHistogram) GetDataPoints() []*ExponentialHistogramDataPoint {
+	if m != nil {
+		return m.DataPoints
+	}
+	return nil
+}
+
+func (m *ExponentialHistogram) GetAggregationTemporality() AggregationTemporality {
+	if m != nil {
+		return m.AggregationTemporality
+	}
+	return AggregationTemporality_AGGREGATION_TEMPORALITY_UNSPECIFIED
+}
+
+type Summary struct {
+	DataPoints           []*SummaryDataPoint `protobuf:"bytes,1,rep,name=data_points,json=dataPoints" json:"data_points,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *Summary) Reset()         { *m = Summary{} }
+func (m *Summary) String() string { return proto.CompactTextString(m) }
+func (*Summary) ProtoMessage()    {}
+func (*Summary) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{8}
+}
+func (m *Summary) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Summary.Unmarshal(m, b)
+}
+func (m *Summary) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Summary.Marshal(b, m, deterministic)
+}
+func (dst *Summary) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Summary.Merge(dst, src)
+}
+func (m *Summary) XXX_Size() int {
+	return xxx_messageInfo_Summary.Size(m)
+}
+func (m *Summary) XXX_DiscardUnknown() {
+	xxx_messageInfo_Summary.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Summary proto.InternalMessageInfo
+
+func (m *Summary) GetDataPoints() []*SummaryDataPoint {
+	if m != nil {
+		return m.DataPoints
+	}
+	return nil
+}
+
+type NumberDataPoint struct {
+	Attributes        []*common.KeyValue `protobuf:"bytes,7,rep,name=attributes" json:"attributes,omitempty"`
+	StartTimeUnixNano uint64             `protobuf:"fixed64,2,opt,name=start_time_unix_nano,json=startTimeUnixNano" json:"start_time_unix_nano,omitempty"`
+	TimeUnixNano      uint64             `protobuf:"fixed64,3,opt,name=time_unix_nano,json=timeUnixNano" json:"time_unix_nano,omitempty"`
+	// Types that are valid to be assigned to Value:
+	//	*NumberDataPoint_AsDouble
+	//	*NumberDataPoint_AsInt
+	Value                isNumberDataPoint_Value `protobuf_oneof:"value"`
+	Exemplars            []*Exemplar             `protobuf:"bytes,5,rep,name=exemplars" json:"exemplars,omitempty"`
+	Flags                uint32                  `protobuf:"varint,8,opt,name=flags" json:"flags,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
+}
+
+func (m *NumberDataPoint) Reset()         { *m = NumberDataPoint{} }
+func (m *NumberDataPoint) String() string { return proto.CompactTextString(m) }
+func (*NumberDataPoint) ProtoMessage()    {}
+func (*NumberDataPoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{9}
+}
+func (m *NumberDataPoint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NumberDataPoint.Unmarshal(m, b)
+}
+func (m *NumberDataPoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NumberDataPoint.Marshal(b, m, deterministic)
+}
+func (dst *NumberDataPoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NumberDataPoint.Merge(dst, src)
+}
+func (m *NumberDataPoint) XXX_Size() int {
+	return xxx_messageInfo_NumberDataPoint.Size(m)
+}
+func (m *NumberDataPoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_NumberDataPoint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NumberDataPoint proto.InternalMessageInfo
+
+type isNumberDataPoint_Value interface {
+	isNumberDataPoint_Value()
+}
+
+type NumberDataPoint_AsDouble struct {
+	AsDouble float64 `protobuf:"fixed64,4,opt,name=as_double,json=asDouble,oneof"`
+}
+type NumberDataPoint_AsInt struct {
+	AsInt int64 `protobuf:"fixed64,6,opt,name=as_int,json=asInt,oneof"`
+}
+
+func (*NumberDataPoint_AsDouble) isNumberDataPoint_Value() {}
+func (*NumberDataPoint_AsInt) isNumberDataPoint_Value()    {}
+
+func (m *NumberDataPoint) GetValue() isNumberDataPoint_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *NumberDataPoint) GetAttributes() []*common.KeyValue {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
+func (m *NumberDataPoint) GetStartTimeUnixNano() uint64 {
+	if m != nil {
+		return m.StartTimeUnixNano
+	}
+	return 0
+}
+
+func (m *NumberDataPoint) GetTimeUnixNano() uint64 {
+	if m != nil {
+		return m.TimeUnixNano
+	}
+	return 0
+}
+
+func (m *NumberDataPoint) GetAsDouble() float64 {
+	if x, ok := m.GetValue().(*NumberDataPoint_AsDouble); ok {
+		return x.AsDouble
+	}
+	return 0
+}
+
+func (m *NumberDataPoint) GetAsInt() int64 {
+	if x, ok := m.GetValue().(*NumberDataPoint_AsInt); ok {
+		return x.AsInt
+	}
+	return 0
+}
+
+func (m *NumberDataPoint) GetExemplars() []*Exemplar {
+	if m != nil {
+		return m.Exemplars
+	}
+	return nil
+}
+
+func (m *NumberDataPoint) GetFlags() uint32 {
+	if m != nil {
+		return m.Flags
+	}
+	return 0
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*NumberDataPoint) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _NumberDataPoint_OneofMarshaler, _NumberDataPoint_OneofUnmarshaler, _NumberDataPoint_OneofSizer, []interface{}{
+		(*NumberDataPoint_AsDouble)(nil),
+		(*NumberDataPoint_AsInt)(nil),
+	}
+}
+
+func _NumberDataPoint_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*NumberDataPoint)
+	// value
+	switch x := m.Value.(type) {
+	case *NumberDataPoint_AsDouble:
+		b.EncodeVarint(4<<3 | proto.WireFixed64)
+		b.EncodeFixed64(math.Float64bits(x.AsDouble))
+	case *NumberDataPoint_AsInt:
+		b.EncodeVarint(6<<3 | proto.WireFixed64)
+		b.EncodeFixed64(uint64(x.AsInt))
+	case nil:
+	default:
+		return fmt.Errorf("NumberDataPoint.Value has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _NumberDataPoint_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*NumberDataPoint)
+	switch tag {
+	case 4: // value.as_double
+		if wire != proto.WireFixed64 {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeFixed64()
+		m.Value = &NumberDataPoint_AsDouble{math.Float64frombits(x)}
+		return true, err
+	case 6: // value.as_int
+		if wire != proto.WireFixed64 {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeFixed64()
+		m.Value = &NumberDataPoint_AsInt{int64(x)}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _NumberDataPoint_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*NumberDataPoint)
+	// value
+	switch x := m.Value.(type) {
+	case *NumberDataPoint_AsDouble:
+		n += 1 // tag and wire
+		n += 8
+	case *NumberDataPoint_AsInt:
+		n += 1 // tag and wire
+		n += 8
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+type HistogramDataPoint struct {
+	Attributes        []*common.KeyValue `protobuf:"bytes,9,rep,name=attributes" json:"attributes,omitempty"`
+	StartTimeUnixNano uint64             `protobuf:"fixed64,2,opt,name=start_time_unix_nano,json=startTimeUnixNano" json:"start_time_unix_nano,omitempty"`
+	TimeUnixNano      uint64             `protobuf:"fixed64,3,opt,name=time_unix_nano,json=timeUnixNano" json:"time_unix_nano,omitempty"`
+	Count             uint64             `protobuf:"fixed64,4,opt,name=count" json:"count,omitempty"`
+	// Types that are valid to be assigned to XSum:
+	//	*HistogramDataPoint_Sum
+	XSum           isHistogramDataPoint_XSum `protobuf_oneof:"_sum"`
+	BucketCounts   []uint64                  `protobuf:"fixed64,6,rep,packed,name=bucket_counts,json=bucketCounts" json:"bucket_counts,omitempty"`
+	ExplicitBounds []float64                 `protobuf:"fixed64,7,rep,packed,name=explicit_bounds,json=explicitBounds" json:"explicit_bounds,omitempty"`
+	Exemplars      []*Exemplar               `protobuf:"bytes,8,rep,name=exemplars" json:"exemplars,omitempty"`
+	Flags          uint32                    `protobuf:"varint,10,opt,name=flags" json:"flags,omitempty"`
+	// Types that are valid to be assigned to XMin:
+	//	*HistogramDataPoint_Min
+	XMin isHistogramDataPoint_XMin `protobuf_oneof:"_min"`
+	// Types that are valid to be assigned to XMax:
+	//	*HistogramDataPoint_Max
+	XMax                 isHistogramDataPoint_XMax `protobuf_oneof:"_max"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
+}
+
+func (m *HistogramDataPoint) Reset()         { *m = HistogramDataPoint{} }
+func (m *HistogramDataPoint) String() string { return proto.CompactTextString(m) }
+func (*HistogramDataPoint) ProtoMessage()    {}
+func (*HistogramDataPoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{10}
+}
+func (m *HistogramDataPoint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HistogramDataPoint.Unmarshal(m, b)
+}
+func (m *HistogramDataPoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HistogramDataPoint.Marshal(b, m, deterministic)
+}
+func (dst *HistogramDataPoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HistogramDataPoint.Merge(dst, src)
+}
+func (m *HistogramDataPoint) XXX_Size() int {
+	return xxx_messageInfo_HistogramDataPoint.Size(m)
+}
+func (m *HistogramDataPoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_HistogramDataPoint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HistogramDataPoint proto.InternalMessageInfo
+
+type isHistogramDataPoint_XSum interface {
+	isHistogramDataPoint_XSum()
+}
+type isHistogramDataPoint_XMin interface {
+	isHistogramDataPoint_XMin()
+}
+type isHistogramDataPoint_XMax interface {
+	isHistogramDataPoint_XMax()
+}
+
+type HistogramDataPoint_Sum struct {
+	Sum float64 `protobuf:"fixed64,5,opt,name=sum,oneof"`
+}
+type HistogramDataPoint_Min struct {
+	Min float64 `protobuf:"fixed64,11,opt,name=min,oneof"`
+}
+type HistogramDataPoint_Max struct {
+	Max float64 `protobuf:"fixed64,12,opt,name=max,oneof"`
+}
+
+func (*HistogramDataPoint_Sum) isHistogramDataPoint_XSum() {}
+func (*HistogramDataPoint_Min) isHistogramDataPoint_XMin() {}
+func (*HistogramDataPoint_Max) isHistogramDataPoint_XMax() {}
+
+func (m *HistogramDataPoint) GetXSum() isHistogramDataPoint_XSum {
+	if m != nil {
+		return m.XSum
+	}
+	return nil
+}
+func (m *HistogramDataPoint) GetXMin() isHistogramDataPoint_XMin {
+	if m != nil {
+		return m.XMin
+	}
+	return nil
+}
+func (m *HistogramDataPoint) GetXMax() isHistogramDataPoint_XMax {
+	if m != nil {
+		return m.XMax
+	}
+	return nil
+}
+
+func (m *HistogramDataPoint) GetAttributes() []*common.KeyValue {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
+func (m *HistogramDataPoint) GetStartTimeUnixNano() uint64 {
+	if m != nil {
+		return m.StartTimeUnixNano
+	}
+	return 0
+}
+
+func (m *HistogramDataPoint) GetTimeUnixNano() uint64 {
+	if m != nil {
+		return m.TimeUnixNano
+	}
+	return 0
+}
+
+func (m *HistogramDataPoint) GetCount() uint64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *HistogramDataPoint) GetSum() float64 {
+	if x, ok := m.GetXSum().(*HistogramDataPoint_Sum); ok {
+		return x.Sum
+	}
+	return 0
+}
+
+func (m *HistogramDataPoint) GetBucketCounts() []uint64 {
+	if m != nil {
+		return m.BucketCounts
+	}
+	return nil
+}
+
+func (m *HistogramDataPoint) GetExplicitBounds() []float64 {
+	if m != nil {
+		return m.ExplicitBounds
+	}
+	return nil
+}
+
+func (m *HistogramDataPoint) GetExemplars() []*Exemplar {
+	if m != nil {
+		return m.Exemplars
+	}
+	return nil
+}
+
+func (m *HistogramDataPoint) GetFlags() uint32 {
+	if m != nil {
+		return m.Flags
+	}
+	return 0
+}
+
+func (m *HistogramDataPoint) GetMin() float64 {
+	if x, ok := m.GetXMin().(*HistogramDataPoint_Min); ok {
+		return x.Min
+	}
+	return 0
+}
+
+func (m *HistogramDataPoint) GetMax() float64 {
+	if x, ok := m.GetXMax().(*HistogramDataPoint_Max); ok {
+		return x.Max
+	}
+	return 0
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*HistogramDataPoint) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _HistogramDataPoint_OneofMarshaler, _HistogramDataPoint_OneofUnmarshaler, _HistogramDataPoint_OneofSizer, []interface{}{
+		(*HistogramDataPoint_Sum)(nil),
+		(*HistogramDataPoint_Min)(nil),
+		(*HistogramDataPoint_Max)(nil),
+	}
+}
+
+func _HistogramDataPoint_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*HistogramDataPoint)
+	// _sum
+	switch x := m.XSum.(type) {
+	case *HistogramDataPoint_Sum:
+		b.EncodeVarint(5<<3 | proto.WireFixed64)
+		b.EncodeFixed64(math.Float64bits(x.Sum))
+	case nil:
+	default:
+		return fmt.Errorf("HistogramDataPoint.XSum has unexpected type %T", x)
+	}
+	// _min
+	switch x := m.XMin.(type) {
+	case *HistogramDataPoint_Min:
+		b.EncodeVarint(11<<3 | proto.WireFixed64)
+		b.EncodeFixed64(math.Float64bits(x.Min))
+	case nil:
+	default:
+		return fmt.Errorf("HistogramDataPoint.XMin has unexpected type %T", x)
+	}
+	// _max
+	switch x := m.XMax.(type) {
+	case *HistogramDataPoint_Max:
+		b.EncodeVarint(12<<3 | proto.WireFixed64)
+		b.EncodeFixed64(math.Float64bits(x.Max))
+	case nil:
+	default:
+		return fmt.Errorf("HistogramDataPoint.XMax has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _HistogramDataPoint_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*HistogramDataPoint)
+	switch tag {
+	case 5: // _sum.sum
+		if wire != proto.WireFixed64 {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeFixed64()
+		m.XSum = &HistogramDataPoint_Sum{math.Float64frombits(x)}
+		return true, err
+	case 11: // _min.min
+		if wire != proto.WireFixed64 {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeFixed64()
+		m.XMin = &HistogramDataPoint_Min{math.Float64frombits(x)}
+		return true, err
+	case 12: // _max.max
+		if wire != proto.WireFixed64 {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeFixed64()
+		m.XMax = &HistogramDataPoint_Max{math.Float64frombits(x)}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _HistogramDataPoint_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*HistogramDataPoint)
+	// _sum
+	switch x := m.XSum.(type) {
+	case *HistogramDataPoint_Sum:
+		n += 1 // tag and wire
+		n += 8
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	// _min
+	switch x := m.XMin.(type) {
+	case *HistogramDataPoint_Min:
+		n += 1 // tag and wire
+		n += 8
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	// _max
+	switch x := m.XMax.(type) {
+	case *HistogramDataPoint_Max:
+		n += 1 // tag and wire
+		n += 8
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+type ExponentialHistogramDataPoint struct {
+	Attributes        []*common.KeyValue `protobuf:"bytes,1,rep,name=attributes" json:"attributes,omitempty"`
+	StartTimeUnixNano uint64             `protobuf:"fixed64,2,opt,name=start_time_unix_nano,json=startTimeUnixNano" json:"start_time_unix_nano,omitempty"`
+	TimeUnixNano      uint64             `protobuf:"fixed64,3,opt,name=time_unix_nano,json=timeUnixNano" json:"time_unix_nano,omitempty"`
+	Count             uint64             `protobuf:"fixed64,4,opt,name=count" json:"count,omitempty"`
+	// Types that are valid to be assigned to XSum:
+	//	*ExponentialHistogramDataPoint_Sum
+	XSum      isExponentialHistogramDataPoint_XSum   `protobuf_oneof:"_sum"`
+	Scale     int32                                  `protobuf:"zigzag32,6,opt,name=scale" json:"scale,omitempty"`
+	ZeroCount uint64                                 `protobuf:"fixed64,7,opt,name=zero_count,json=zeroCount" json:"zero_count,omitempty"`
+	Positive  *ExponentialHistogramDataPoint_Buckets `protobuf:"bytes,8,opt,name=positive" json:"positive,omitempty"`
+	Negative  *ExponentialHistogramDataPoint_Buckets `protobuf:"bytes,9,opt,name=negative" json:"negative,omitempty"`
+	Flags     uint32                                 `protobuf:"varint,10,opt,name=flags" json:"flags,omitempty"`
+	Exemplars []*Exemplar                            `protobuf:"bytes,11,rep,name=exemplars" json:"exemplars,omitempty"`
+	// Types that are valid to be assigned to XMin:
+	//	*ExponentialHistogramDataPoint_Min
+	XMin isExponentialHistogramDataPoint_XMin `protobuf_oneof:"_min"`
+	// Types that are valid to be assigned to XMax:
+	//	*ExponentialHistogramDataPoint_Max
+	XMax                 isExponentialHistogramDataPoint_XMax `protobuf_oneof:"_max"`
+	XXX_NoUnkeyedLiteral struct{}                             `json:"-"`
+	XXX_unrecognized     []byte                               `json:"-"`
+	XXX_sizecache        int32                                `json:"-"`
+}
+
+func (m *ExponentialHistogramDataPoint) Reset()         { *m = ExponentialHistogramDataPoint{} }
+func (m *ExponentialHistogramDataPoint) String() string { return proto.CompactTextString(m) }
+func (*ExponentialHistogramDataPoint) ProtoMessage()    {}
+func (*ExponentialHistogramDataPoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{11}
+}
+func (m *ExponentialHistogramDataPoint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExponentialHistogramDataPoint.Unmarshal(m, b)
+}
+func (m *ExponentialHistogramDataPoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExponentialHistogramDataPoint.Marshal(b, m, deterministic)
+}
+func (dst *ExponentialHistogramDataPoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExponentialHistogramDataPoint.Merge(dst, src)
+}
+func (m *ExponentialHistogramDataPoint) XXX_Size() int {
+	return xxx_messageInfo_ExponentialHistogramDataPoint.Size(m)
+}
+func (m *ExponentialHistogramDataPoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExponentialHistogramDataPoint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExponentialHistogramDataPoint proto.InternalMessageInfo
+
+type isExponentialHistogramDataPoint_XSum interface {
+	isExponentialHistogramDataPoint_XSum()
+}
+type isExponentialHistogramDataPoint_XMin interface {
+	isExponentialHistogramDataPoint_XMin()
+}
+type isExponentialHistogramDataPoint_XMax interface {
+	isExponentialHistogramDataPoint_XMax()
+}
+
+type ExponentialHistogramDataPoint_Sum struct {
+	Sum float64 `protobuf:"fixed64,5,opt,name=sum,oneof"`
+}
+type ExponentialHistogramDataPoint_Min struct {
+	Min float64 `protobuf:"fixed64,12,opt,name=min,oneof"`
+}
+type ExponentialHistogramDataPoint_Max struct {
+	Max float64 `protobuf:"fixed64,13,opt,name=max,oneof"`
+}
+
+func (*ExponentialHistogramDataPoint_Sum) isExponentialHistogramDataPoint_XSum() {}
+func (*ExponentialHistogramDataPoint_Min) isExponentialHistogramDataPoint_XMin() {}
+func (*ExponentialHistogramDataPoint_Max) isExponentialHistogramDataPoint_XMax() {}
+
+func (m *ExponentialHistogramDataPoint) GetXSum() isExponentialHistogramDataPoint_XSum {
+	if m != nil {
+		return m.XSum
+	}
+	return nil
+}
+func (m *ExponentialHistogramDataPoint) GetXMin() isExponentialHistogramDataPoint_XMin {
+	if m != nil {
+		return m.XMin
+	}
+	return nil
+}
+func (m *ExponentialHistogramDataPoint) GetXMax() isExponentialHistogramDataPoint_XMax {
+	if m != nil {
+		return m.XMax
+	}
+	return nil
+}
+
+func (m *ExponentialHistogramDataPoint) GetAttributes() []*common.KeyValue {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
+func (m *ExponentialHistogramDataPoint) GetStartTimeUnixNano() uint64 {
+	if m != nil {
+		return m.StartTimeUnixNano
+	}
+	return 0
+}
+
+func (m *ExponentialHistogramDataPoint) GetTimeUnixNano() uint64 {
+	if m != nil {
+		return m.TimeUnixNano
+	}
+	return 0
+}
+
+func (m *ExponentialHistogramDataPoint) GetCount() uint64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *ExponentialHistogramDataPoint) GetSum() float64 {
+	if x, ok := m.GetXSum().(*ExponentialHistogramDataPoint_Sum); ok {
+		return x.Sum
+	}
+	return 0
+}
+
+func (m *ExponentialHistogramDataPoint) GetScale() int32 {
+	if m != nil {
+		return m.Scale
+	}
+	return 0
+}
+
+func (m *ExponentialHistogramDataPoint) GetZeroCount() uint64 {
+	if m != nil {
+		return m.ZeroCount
+	}
+	return 0
+}
+
+func (m *ExponentialHistogramDataPoint) GetPositive() *ExponentialHistogramDataPoint_Buckets {
+	if m != nil {
+		return m.Positive
+	}
+	return nil
+}
+
+func (m *ExponentialHistogramDataPoint) GetNegative() *ExponentialHistogramDataPoint_Buckets {
+	if m != nil {
+		return m.Negative
+	}
+	return nil
+}
+
+func (m *ExponentialHistogramDataPoint) GetFlags() uint32 {
+	if m != nil {
+		return m.Flags
+	}
+	return 0
+}
+
+func (m *ExponentialHistogramDataPoint) GetExemplars() []*Exemplar {
+	if m != nil {
+		return m.Exemplars
+	}
+	return nil
+}
+
+func (m *ExponentialHistogramDataPoint) GetMin() float64 {
+	if x, ok := m.GetXMin().(*ExponentialHistogramDataPoint_Min); ok {
+		return x.Min
+	}
+	return 0
+}
+
+func (m *ExponentialHistogramDataPoint) GetMax() float64 {
+	if x, ok := m.GetXMax().(*ExponentialHistogramDataPoint_Max); ok {
+		return x.Max
+	}
+	return 0
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*ExponentialHistogramDataPoint) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _ExponentialHistogramDataPoint_OneofMarshaler, _ExponentialHistogramDataPoint_OneofUnmarshaler, _ExponentialHistogramDataPoint_OneofSizer, []interface{}{
+		(*ExponentialHistogramDataPoint_Sum)(nil),
+		(*ExponentialHistogramDataPoint_Min)(nil),
+		(*ExponentialHistogramDataPoint_Max)(nil),
+	}
+}
+
+func _ExponentialHistogramDataPoint_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*ExponentialHistogramDataPoint)
+	// _sum
+	switch x := m.XSum.(type) {
+	case *ExponentialHistogramDataPoint_Sum:
+		b.EncodeVarint(5<<3 | proto.WireFixed64)
+		b.EncodeFixed64(math.Float64bits(x.Sum))
+	case nil:
+	default:
+		return fmt.Errorf("ExponentialHistogramDataPoint.XSum has unexpected type %T", x)
+	}
+	// _min
+	switch x := m.XMin.(type) {
+	case *ExponentialHistogramDataPoint_Min:
+		b.EncodeVarint(12<<3 | proto.WireFixed64)
+		b.EncodeFixed64(math.Float64bits(x.Min))
+	case nil:
+	default:
+		return fmt.Errorf("ExponentialHistogramDataPoint.XMin has unexpected type %T", x)
+	}
+	// _max
+	switch x := m.XMax.(type) {
+	case *ExponentialHistogramDataPoint_Max:
+		b.EncodeVarint(13<<3 | proto.WireFixed64)
+		b.EncodeFixed64(math.Float64bits(x.Max))
+	case nil:
+	default:
+		return fmt.Errorf("ExponentialHistogramDataPoint.XMax has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _ExponentialHistogramDataPoint_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*ExponentialHistogramDataPoint)
+	switch tag {
+	case 5: // _sum.sum
+		if wire != proto.WireFixed64 {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeFixed64()
+		m.XSum = &ExponentialHistogramDataPoint_Sum{math.Float64frombits(x)}
+		return true, err
+	case 12: // _min.min
+		if wire != proto.WireFixed64 {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeFixed64()
+		m.XMin = &ExponentialHistogramDataPoint_Min{math.Float64frombits(x)}
+		return true, err
+	case 13: // _max.max
+		if wire != proto.WireFixed64 {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeFixed64()
+		m.XMax = &ExponentialHistogramDataPoint_Max{math.Float64frombits(x)}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _ExponentialHistogramDataPoint_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*ExponentialHistogramDataPoint)
+	// _sum
+	switch x := m.XSum.(type) {
+	case *ExponentialHistogramDataPoint_Sum:
+		n += 1 // tag and wire
+		n += 8
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	// _min
+	switch x := m.XMin.(type) {
+	case *ExponentialHistogramDataPoint_Min:
+		n += 1 // tag and wire
+		n += 8
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	// _max
+	switch x := m.XMax.(type) {
+	case *ExponentialHistogramDataPoint_Max:
+		n += 1 // tag and wire
+		n += 8
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+type ExponentialHistogramDataPoint_Buckets struct {
+	Offset               int32    `protobuf:"zigzag32,1,opt,name=offset" json:"offset,omitempty"`
+	BucketCounts         []uint64 `protobuf:"varint,2,rep,packed,name=bucket_counts,json=bucketCounts" json:"bucket_counts,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExponentialHistogramDataPoint_Buckets) Reset()         { *m = ExponentialHistogramDataPoint_Buckets{} }
+func (m *ExponentialHistogramDataPoint_Buckets) String() string { return proto.CompactTextString(m) }
+func (*ExponentialHistogramDataPoint_Buckets) ProtoMessage()    {}
+func (*ExponentialHistogramDataPoint_Buckets) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{11, 0}
+}
+func (m *ExponentialHistogramDataPoint_Buckets) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExponentialHistogramDataPoint_Buckets.Unmarshal(m, b)
+}
+func (m *ExponentialHistogramDataPoint_Buckets) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExponentialHistogramDataPoint_Buckets.Marshal(b, m, deterministic)
+}
+func (dst *ExponentialHistogramDataPoint_Buckets) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExponentialHistogramDataPoint_Buckets.Merge(dst, src)
+}
+func (m *ExponentialHistogramDataPoint_Buckets) XXX_Size() int {
+	return xxx_messageInfo_ExponentialHistogramDataPoint_Buckets.Size(m)
+}
+func (m *ExponentialHistogramDataPoint_Buckets) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExponentialHistogramDataPoint_Buckets.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExponentialHistogramDataPoint_Buckets proto.InternalMessageInfo
+
+func (m *ExponentialHistogramDataPoint_Buckets) GetOffset() int32 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *ExponentialHistogramDataPoint_Buckets) GetBucketCounts() []uint64 {
+	if m != nil {
+		return m.BucketCounts
+	}
+	return nil
+}
+
+type SummaryDataPoint struct {
+	Attributes           []*common.KeyValue                  `protobuf:"bytes,7,rep,name=attributes" json:"attributes,omitempty"`
+	StartTimeUnixNano    uint64                              `protobuf:"fixed64,2,opt,name=start_time_unix_nano,json=startTimeUnixNano" json:"start_time_unix_nano,omitempty"`
+	TimeUnixNano         uint64                              `protobuf:"fixed64,3,opt,name=time_unix_nano,json=timeUnixNano" json:"time_unix_nano,omitempty"`
+	Count                uint64                              `protobuf:"fixed64,4,opt,name=count" json:"count,omitempty"`
+	Sum                  float64                             `protobuf:"fixed64,5,opt,name=sum" json:"sum,omitempty"`
+	QuantileValues       []*SummaryDataPoint_ValueAtQuantile `protobuf:"bytes,6,rep,name=quantile_values,json=quantileValues" json:"quantile_values,omitempty"`
+	Flags                uint32                              `protobuf:"varint,8,opt,name=flags" json:"flags,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                            `json:"-"`
+	XXX_unrecognized     []byte                              `json:"-"`
+	XXX_sizecache        int32                               `json:"-"`
+}
+
+func (m *SummaryDataPoint) Reset()         { *m = SummaryDataPoint{} }
+func (m *SummaryDataPoint) String() string { return proto.CompactTextString(m) }
+func (*SummaryDataPoint) ProtoMessage()    {}
+func (*SummaryDataPoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{12}
+}
+func (m *SummaryDataPoint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SummaryDataPoint.Unmarshal(m, b)
+}
+func (m *SummaryDataPoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SummaryDataPoint.Marshal(b, m, deterministic)
+}
+func (dst *SummaryDataPoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SummaryDataPoint.Merge(dst, src)
+}
+func (m *SummaryDataPoint) XXX_Size() int {
+	return xxx_messageInfo_SummaryDataPoint.Size(m)
+}
+func (m *SummaryDataPoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_SummaryDataPoint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SummaryDataPoint proto.InternalMessageInfo
+
+func (m *SummaryDataPoint) GetAttributes() []*common.KeyValue {
+	if m != nil {
+		return m.Attributes
+	}
+	return nil
+}
+
+func (m *SummaryDataPoint) GetStartTimeUnixNano() uint64 {
+	if m != nil {
+		return m.StartTimeUnixNano
+	}
+	return 0
+}
+
+func (m *SummaryDataPoint) GetTimeUnixNano() uint64 {
+	if m != nil {
+		return m.TimeUnixNano
+	}
+	return 0
+}
+
+func (m *SummaryDataPoint) GetCount() uint64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *SummaryDataPoint) GetSum() float64 {
+	if m != nil {
+		return m.Sum
+	}
+	return 0
+}
+
+func (m *SummaryDataPoint) GetQuantileValues() []*SummaryDataPoint_ValueAtQuantile {
+	if m != nil {
+		return m.QuantileValues
+	}
+	return nil
+}
+
+func (m *SummaryDataPoint) GetFlags() uint32 {
+	if m != nil {
+		return m.Flags
+	}
+	return 0
+}
+
+type SummaryDataPoint_ValueAtQuantile struct {
+	Quantile             float64  `protobuf:"fixed64,1,opt,name=quantile" json:"quantile,omitempty"`
+	Value                float64  `protobuf:"fixed64,2,opt,name=value" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SummaryDataPoint_ValueAtQuantile) Reset()         { *m = SummaryDataPoint_ValueAtQuantile{} }
+func (m *SummaryDataPoint_ValueAtQuantile) String() string { return proto.CompactTextString(m) }
+func (*SummaryDataPoint_ValueAtQuantile) ProtoMessage()    {}
+func (*SummaryDataPoint_ValueAtQuantile) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{12, 0}
+}
+func (m *SummaryDataPoint_ValueAtQuantile) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SummaryDataPoint_ValueAtQuantile.Unmarshal(m, b)
+}
+func (m *SummaryDataPoint_ValueAtQuantile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SummaryDataPoint_ValueAtQuantile.Marshal(b, m, deterministic)
+}
+func (dst *SummaryDataPoint_ValueAtQuantile) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SummaryDataPoint_ValueAtQuantile.Merge(dst, src)
+}
+func (m *SummaryDataPoint_ValueAtQuantile) XXX_Size() int {
+	return xxx_messageInfo_SummaryDataPoint_ValueAtQuantile.Size(m)
+}
+func (m *SummaryDataPoint_ValueAtQuantile) XXX_DiscardUnknown() {
+	xxx_messageInfo_SummaryDataPoint_ValueAtQuantile.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SummaryDataPoint_ValueAtQuantile proto.InternalMessageInfo
+
+func (m *SummaryDataPoint_ValueAtQuantile) GetQuantile() float64 {
+	if m != nil {
+		return m.Quantile
+	}
+	return 0
+}
+
+func (m *SummaryDataPoint_ValueAtQuantile) GetValue() float64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+type Exemplar struct {
+	FilteredAttributes []*common.KeyValue `protobuf:"bytes,7,rep,name=filtered_attributes,json=filteredAttributes" json:"filtered_attributes,omitempty"`
+	TimeUnixNano       uint64             `protobuf:"fixed64,2,opt,name=time_unix_nano,json=timeUnixNano" json:"time_unix_nano,omitempty"`
+	// Types that are valid to be assigned to Value:
+	//	*Exemplar_AsDouble
+	//	*Exemplar_AsInt
+	Value                isExemplar_Value `protobuf_oneof:"value"`
+	SpanId               []byte           `protobuf:"bytes,4,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`
+	TraceId              []byte           `protobuf:"bytes,5,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *Exemplar) Reset()         { *m = Exemplar{} }
+func (m *Exemplar) String() string { return proto.CompactTextString(m) }
+func (*Exemplar) ProtoMessage()    {}
+func (*Exemplar) Descriptor() ([]byte, []int) {
+	return fileDescriptor_metrics_e6819e63c2f66f37, []int{13}
+}
+func (m *Exemplar) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Exemplar.Unmarshal(m, b)
+}
+func (m *Exemplar) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Exemplar.Marshal(b, m, deterministic)
+}
+func (dst *Exemplar) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Exemplar.Merge(dst, src)
+}
+func (m *Exemplar) XXX_Size() int {
+	return xxx_messageInfo_Exemplar.Size(m)
+}
+func (m *Exemplar) XXX_DiscardUnknown() {
+	xxx_messageInfo_Exemplar.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Exemplar proto.InternalMessageInfo
+
+type isExemplar_Value interface {
+	isExemplar_Value()
+}
+
+type Exemplar_AsDouble struct {
+	AsDouble float64 `protobuf:"fixed64,3,opt,name=as_double,json=asDouble,oneof"`
+}
+type Exemplar_AsInt struct {
+	AsInt int64 `protobuf:"fixed64,6,opt,name=as_int,json=asInt,oneof"`
+}
+
+func (*Exemplar_AsDouble) isExemplar_Value() {}
+func (*Exemplar_AsInt) isExemplar_Value()    {}
+
+func (m *Exemplar) GetValue() isExemplar_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *Exemplar) GetFilteredAttributes() []*common.KeyValue {
+	if m != nil {
+		return m.FilteredAttributes
+	}
+	return nil
+}
+
+func (m *Exemplar) GetTimeUnixNano() uint64 {
+	if m != nil {
+		return m.TimeUnixNano
+	}
+	return 0
+}
+
+func (m *Exemplar) GetAsDouble() float64 {
+	if x, ok := m.GetValue().(*Exemplar_AsDouble); ok {
+		return x.AsDouble
+	}
+	return 0
+}
+
+func (m *Exemplar) GetAsInt() int64 {
+	if x, ok := m.GetValue().(*Exemplar_AsInt); ok {
+		return x.AsInt
+	}
+	return 0
+}
+
+func (m *Exemplar) GetSpanId() []byte {
+	if m != nil {
+		return m.SpanId
+	}
+	return nil
+}
+
+func (m *Exemplar) GetTraceId() []byte {
+	if m != nil {
+		return m.TraceId
+	}
+	return nil
+}
+
+// XXX_OneofFuncs is for the internal use of the proto package.
+func (*Exemplar) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
+	return _Exemplar_OneofMarshaler, _Exemplar_OneofUnmarshaler, _Exemplar_OneofSizer, []interface{}{
+		(*Exemplar_AsDouble)(nil),
+		(*Exemplar_AsInt)(nil),
+	}
+}
+
+func _Exemplar_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
+	m := msg.(*Exemplar)
+	// value
+	switch x := m.Value.(type) {
+	case *Exemplar_AsDouble:
+		b.EncodeVarint(3<<3 | proto.WireFixed64)
+		b.EncodeFixed64(math.Float64bits(x.AsDouble))
+	case *Exemplar_AsInt:
+		b.EncodeVarint(6<<3 | proto.WireFixed64)
+		b.EncodeFixed64(uint64(x.AsInt))
+	case nil:
+	default:
+		return fmt.Errorf("Exemplar.Value has unexpected type %T", x)
+	}
+	return nil
+}
+
+func _Exemplar_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error) {
+	m := msg.(*Exemplar)
+	switch tag {
+	case 3: // value.as_double
+		if wire != proto.WireFixed64 {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeFixed64()
+		m.Value = &Exemplar_AsDouble{math.Float64frombits(x)}
+		return true, err
+	case 6: // value.as_int
+		if wire != proto.WireFixed64 {
+			return true, proto.ErrInternalBadWireType
+		}
+		x, err := b.DecodeFixed64()
+		m.Value = &Exemplar_AsInt{int64(x)}
+		return true, err
+	default:
+		return false, nil
+	}
+}
+
+func _Exemplar_OneofSizer(msg proto.Message) (n int) {
+	m := msg.(*Exemplar)
+	// value
+	switch x := m.Value.(type) {
+	case *Exemplar_AsDouble:
+		n += 1 // tag and wire
+		n += 8
+	case *Exemplar_AsInt:
+		n += 1 // tag and wire
+		n += 8
+	case nil:
+	default:
+		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
+	}
+	return n
+}
+
+func init() {
+	proto.RegisterType((*MetricsData)(nil), "opentelemetry.proto.metrics.v1.MetricsData")
+	proto.RegisterType((*ResourceMetrics)(nil), "opentelemetry.proto.metrics.v1.ResourceMetrics")
+	proto.RegisterType((*ScopeMetrics)(nil), "opentelemetry.proto.metrics.v1.ScopeMetrics")
+	proto.RegisterType((*Metric)(nil), "opentelemetry.proto.metrics.v1.Metric")
+	proto.RegisterType((*Gauge)(nil), "opentelemetry.proto.metrics.v1.Gauge")
+	proto.RegisterType((*Sum)(nil), "opentelemetry.proto.metrics.v1.Sum")
+	proto.RegisterType((*Histogram)(nil), "opentelemetry.proto.metrics.v1.Histogram")
+	proto.RegisterType((*ExponentialHistogram)(nil), "opentelemetry.proto.metrics.v1.ExponentialHistogram")
+	proto.RegisterType((*Summary)(nil), "opentelemetry.proto.metrics.v1.Summary")
+	proto.RegisterType((*NumberDataPoint)(nil), "opentelemetry.proto.metrics.v1.NumberDataPoint")
+	proto.RegisterType((*HistogramDataPoint)(nil), "opentelemetry.proto.metrics.v1.HistogramDataPoint")
+	proto.RegisterType((*ExponentialHistogramDataPoint)(nil), "opentelemetry.proto.metrics.v1.ExponentialHistogramDataPoint")
+	proto.RegisterType((*ExponentialHistogramDataPoint_Buckets)(nil), "opentelemetry.proto.metrics.v1.ExponentialHistogramDataPoint.Buckets")
+	proto.RegisterType((*SummaryDataPoint)(nil), "opentelemetry.proto.metrics.v1.SummaryDataPoint")
+	proto.RegisterType((*SummaryDataPoint_ValueAtQuantile)(nil), "opentelemetry.proto.metrics.v1.SummaryDataPoint.ValueAtQuantile")
+	proto.RegisterType((*Exemplar)(nil), "opentelemetry.proto.metrics.v1.Exemplar")
+	proto.RegisterEnum("opentelemetry.proto.metrics.v1.AggregationTemporality", AggregationTemporality_name, AggregationTemporality_value)
+	proto.RegisterEnum("opentelemetry.proto.metrics.v1.DataPointFlags", DataPointFlags_name, DataPointFlags_value)
+}
+
+func init() {
+	proto.RegisterFile("opentelemetry/proto/metrics/v1/metrics.proto", fileDescriptor_metrics_e6819e63c2f66f37)
+}
+
+var fileDescriptor_metrics_e6819e63c2f66f37 = []byte{
+	// 1421 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x58, 0x4f, 0x6f, 0x1b, 0xb7,
+	0x12, 0x37, 0xf5, 0x77, 0x35, 0x92, 0x6d, 0x85, 0xcf, 0x71, 0xf6, 0x19, 0x70, 0xa0, 0x28, 0xef,
+	0x25, 0x7e, 0x41, 0x20, 0x3d, 0x3b, 0x0f, 0xaf, 0x87, 0x34, 0x40, 0x64, 0x5b, 0xb6, 0xe5, 0xfa,
+	0x5f, 0x68, 0xd9, 0x68, 0x82, 0xa2, 0x0b, 0x5a, 0xa2, 0x15, 0x22, 0xbb, 0x5c, 0x75, 0x97, 0x6b,
+	0xc8, 0xbd, 0x17, 0xc8, 0xa1, 0xa7, 0x7e, 0x88, 0x1e, 0xfa, 0x11, 0xfa, 0x2d, 0xda, 0x02, 0x05,
+	0x7a, 0xec, 0xa9, 0x2d, 0xfa, 0x25, 0x0a, 0x72, 0x77, 0xad, 0x3f, 0x91, 0x23, 0x37, 0xcd, 0xc1,
+	0x3d, 0x2d, 0x67, 0x38, 0xf3, 0xe3, 0x0c, 0xe7, 0x47, 0x0e, 0x25, 0x78, 0xe8, 0x76, 0x99, 0x90,
+	0xcc, 0x66, 0x0e, 0x93, 0xde, 0x79, 0xb5, 0xeb, 0xb9, 0xd2, 0xad, 0xaa, 0x31, 0x6f, 0xf9, 0xd5,
+	0xb3, 0xe5, 0x78, 0x58, 0xd1, 0x13, 0xf8, 0xf6, 0x90, 0x75, 0xa8, 0xac, 0xc4, 0x26, 0x67, 0xcb,
+	0x0b, 0x0f, 0xc6, 0xa1, 0xb5, 0x5c, 0xc7, 0x71, 0x85, 0x02, 0x0b, 0x47, 0xa1, 0xdb, 0x42, 0x65,
+	0x9c, 0xad, 0xc7, 0x7c, 0x37, 0xf0, 0x5a, 0x4c, 0x59, 0xc7, 0xe3, 0xd0, 0xbe, 0xcc, 0x21, 0xbf,
+	0x1b, 0xae, 0xb4, 0x4e, 0x25, 0xc5, 0x2f, 0xa0, 0x18, 0x1b, 0x58, 0x51, 0x04, 0x26, 0x2a, 0x25,
+	0x97, 0xf2, 0x2b, 0xd5, 0xca, 0xdb, 0xa3, 0xac, 0x90, 0xc8, 0x2f, 0x82, 0x23, 0xb3, 0xde, 0xb0,
+	0xa2, 0xfc, 0x3d, 0x82, 0xd9, 0x11, 0x23, 0x5c, 0x07, 0x23, 0x36, 0x33, 0x51, 0x09, 0x2d, 0xe5,
+	0x57, 0xfe, 0x33, 0x76, 0x9d, 0x8b, 0xa8, 0x07, 0x16, 0x22, 0x17, 0xae, 0xf8, 0x19, 0x4c, 0xfb,
+	0x2d, 0xb7, 0xdb, 0x8f, 0x39, 0xa1, 0x63, 0x7e, 0x38, 0x29, 0xe6, 0x43, 0xe5, 0x14, 0x07, 0x5c,
+	0xf0, 0x07, 0x24, 0xbc, 0x08, 0xe0, 0xb7, 0x5e, 0x32, 0x87, 0x5a, 0x81, 0x67, 0x9b, 0xc9, 0x12,
+	0x5a, 0xca, 0x91, 0x5c, 0xa8, 0x39, 0xf2, 0xec, 0xed, 0x8c, 0xf1, 0x6b, 0xb6, 0xf8, 0x5b, 0xb6,
+	0xfc, 0x2d, 0x82, 0xc2, 0x20, 0x0a, 0x6e, 0x40, 0x5a, 0xe3, 0x44, 0xe9, 0x3c, 0x1a, 0x1b, 0x42,
+	0x54, 0xb2, 0xb3, 0xe5, 0x4a, 0x43, 0xf8, 0xd2, 0x0b, 0x1c, 0x26, 0x24, 0x95, 0xdc, 0x15, 0x1a,
+	0x8a, 0x84, 0x08, 0xf8, 0x29, 0x64, 0x87, 0xf3, 0xb9, 0x37, 0x29, 0x9f, 0x30, 0x08, 0x92, 0x75,
+	0xae, 0x94, 0x44, 0xf9, 0xe7, 0x24, 0x64, 0x42, 0x17, 0x8c, 0x21, 0x25, 0xa8, 0x13, 0x46, 0x9d,
+	0x23, 0x7a, 0x8c, 0x4b, 0x90, 0x6f, 0x33, 0xbf, 0xe5, 0xf1, 0xae, 0x0a, 0xcd, 0x4c, 0xe8, 0xa9,
+	0x41, 0x95, 0xf2, 0x0a, 0x04, 0x97, 0x11, 0xb2, 0x1e, 0xe3, 0x27, 0x90, 0xee, 0xd0, 0xa0, 0xc3,
+	0xcc, 0xb4, 0xde, 0x80, 0x7f, 0x4f, 0x8a, 0x79, 0x53, 0x19, 0x6f, 0x4d, 0x91, 0xd0, 0x0b, 0x7f,
+	0x00, 0x49, 0x3f, 0x70, 0xcc, 0xac, 0x76, 0xbe, 0x3b, 0xb1, 0x80, 0x81, 0xb3, 0x35, 0x45, 0x94,
+	0x07, 0x6e, 0x40, 0xee, 0x25, 0xf7, 0xa5, 0xdb, 0xf1, 0xa8, 0x63, 0xe6, 0xde, 0xc2, 0xa5, 0x01,
+	0xf7, 0xad, 0xd8, 0x61, 0x6b, 0x8a, 0xf4, 0xbd, 0xf1, 0x2b, 0xb8, 0xc9, 0x7a, 0x5d, 0x57, 0x30,
+	0x21, 0x39, 0xb5, 0xad, 0x3e, 0x2c, 0x68, 0xd8, 0xff, 0x4d, 0x82, 0xad, 0xf7, 0x9d, 0x07, 0x57,
+	0x98, 0x63, 0x63, 0xf4, 0x78, 0x0d, 0xb2, 0x7e, 0xe0, 0x38, 0xd4, 0x3b, 0x37, 0xf3, 0x1a, 0xfe,
+	0xfe, 0x15, 0x92, 0x56, 0xe6, 0x5b, 0x53, 0x24, 0xf6, 0x5c, 0xcd, 0x40, 0xaa, 0x4d, 0x25, 0xdd,
+	0x4e, 0x19, 0xa9, 0x62, 0x7a, 0x3b, 0x65, 0x64, 0x8a, 0xd9, 0xed, 0x94, 0x61, 0x14, 0x73, 0xe5,
+	0xe7, 0x90, 0xd6, 0x3b, 0x8c, 0x0f, 0x20, 0xaf, 0x4c, 0xac, 0xae, 0xcb, 0x85, 0xbc, 0xf2, 0xa9,
+	0xde, 0x0b, 0x9c, 0x13, 0xe6, 0xa9, 0xbb, 0xe1, 0x40, 0xf9, 0x11, 0x68, 0xc7, 0x43, 0xbf, 0xfc,
+	0x3b, 0x82, 0xe4, 0x61, 0xe0, 0xbc, 0x7f, 0x64, 0xec, 0xc2, 0x2d, 0xda, 0xe9, 0x78, 0xac, 0xa3,
+	0x0f, 0x85, 0x25, 0x99, 0xd3, 0x75, 0x3d, 0x6a, 0x73, 0x79, 0xae, 0x59, 0x38, 0xb3, 0xf2, 0xff,
+	0x49, 0xe8, 0xb5, 0xbe, 0x7b, 0xb3, 0xef, 0x4d, 0xe6, 0xe9, 0x58, 0x3d, 0xbe, 0x03, 0x05, 0xee,
+	0x5b, 0x8e, 0x2b, 0x5c, 0xe9, 0x0a, 0xde, 0xd2, 0x84, 0x36, 0x48, 0x9e, 0xfb, 0xbb, 0xb1, 0xaa,
+	0xfc, 0x1d, 0x82, 0x5c, 0xbf, 0x6a, 0x87, 0xe3, 0x72, 0x5e, 0xb9, 0x32, 0xdf, 0xae, 0x47, 0xda,
+	0xe5, 0x5f, 0x10, 0xcc, 0x8d, 0x23, 0x2b, 0xfe, 0x74, 0x5c, 0x7a, 0x4f, 0xde, 0x85, 0xf7, 0xd7,
+	0x24, 0xd3, 0x4f, 0x20, 0x1b, 0x1d, 0x1b, 0xfc, 0x6c, 0x5c, 0x6e, 0xff, 0xbd, 0xe2, 0xa1, 0x1b,
+	0x7f, 0x12, 0x7e, 0x4c, 0xc0, 0xec, 0x08, 0x9f, 0xf1, 0x26, 0x00, 0x95, 0xd2, 0xe3, 0x27, 0x81,
+	0x64, 0xbe, 0x99, 0x2d, 0x25, 0x2f, 0x3d, 0xda, 0xfd, 0x6e, 0xf0, 0x11, 0x3b, 0x3f, 0xa6, 0x76,
+	0xc0, 0xc8, 0x80, 0x2b, 0xae, 0xc2, 0x9c, 0x2f, 0xa9, 0x27, 0x2d, 0xc9, 0x1d, 0x66, 0x05, 0x82,
+	0xf7, 0x2c, 0x41, 0x85, 0xab, 0x37, 0x2a, 0x43, 0x6e, 0xe8, 0xb9, 0x26, 0x77, 0xd8, 0x91, 0xe0,
+	0xbd, 0x3d, 0x2a, 0x5c, 0xfc, 0x2f, 0x98, 0x19, 0x31, 0x4d, 0x6a, 0xd3, 0x82, 0x1c, 0xb4, 0x5a,
+	0x84, 0x1c, 0xf5, 0xad, 0xb6, 0x1b, 0x9c, 0xd8, 0xcc, 0x4c, 0x95, 0xd0, 0x12, 0xda, 0x9a, 0x22,
+	0x06, 0xf5, 0xd7, 0xb5, 0x06, 0xdf, 0x82, 0x0c, 0xf5, 0x2d, 0x2e, 0xa4, 0x99, 0x29, 0xa1, 0xa5,
+	0xa2, 0xba, 0xa0, 0xa9, 0xdf, 0x10, 0x12, 0x6f, 0x40, 0x8e, 0xf5, 0x98, 0xd3, 0xb5, 0xa9, 0xe7,
+	0x9b, 0x69, 0x9d, 0xd6, 0xd2, 0x64, 0x62, 0x84, 0x0e, 0xa4, 0xef, 0x8a, 0xe7, 0x20, 0x7d, 0x6a,
+	0xd3, 0x8e, 0x6f, 0x1a, 0x25, 0xb4, 0x34, 0x4d, 0x42, 0x61, 0x35, 0x0b, 0xe9, 0x33, 0xb5, 0x03,
+	0xdb, 0x29, 0x03, 0x15, 0x13, 0xe5, 0x9f, 0x92, 0x80, 0xdf, 0xa4, 0xd2, 0xc8, 0xde, 0xe6, 0xae,
+	0xdd, 0xde, 0xce, 0x41, 0xba, 0xe5, 0x06, 0x42, 0xea, 0x7d, 0xcd, 0x90, 0x50, 0xc0, 0x37, 0xc3,
+	0xd6, 0x96, 0x8e, 0xf6, 0x5a, 0x09, 0xaf, 0x11, 0xc2, 0x77, 0x61, 0xfa, 0x24, 0x68, 0xbd, 0x62,
+	0xd2, 0xd2, 0x66, 0xbe, 0x99, 0x29, 0x25, 0x15, 0x62, 0xa8, 0x5c, 0xd3, 0x3a, 0x7c, 0x1f, 0x66,
+	0x59, 0xaf, 0x6b, 0xf3, 0x16, 0x97, 0xd6, 0x89, 0x1b, 0x88, 0x76, 0x48, 0x29, 0x44, 0x66, 0x62,
+	0xf5, 0xaa, 0xd6, 0x0e, 0x97, 0xc7, 0x78, 0x0f, 0xe5, 0x81, 0x81, 0xf2, 0xa8, 0x14, 0x1c, 0x2e,
+	0x74, 0xa3, 0x42, 0x5b, 0x88, 0x28, 0x41, 0xa5, 0xa0, 0xd4, 0xb4, 0x67, 0x16, 0xb4, 0x3a, 0x41,
+	0x94, 0xf0, 0x1a, 0x21, 0xd5, 0x95, 0x2c, 0x3f, 0x70, 0xf4, 0xd7, 0xe1, 0x22, 0xfc, 0xd2, 0x5e,
+	0x54, 0xdb, 0x1f, 0xd2, 0xb0, 0xf8, 0xd6, 0x1b, 0x63, 0xa4, 0xcc, 0xe8, 0xef, 0x5d, 0xe6, 0x39,
+	0xf5, 0x30, 0xa4, 0x36, 0xd3, 0xe7, 0xe9, 0x06, 0x09, 0x05, 0xf5, 0x42, 0xfb, 0x9c, 0x79, 0x6e,
+	0x58, 0x7a, 0xfd, 0xea, 0xc9, 0x90, 0x9c, 0xd2, 0xe8, 0xba, 0x63, 0x0a, 0x46, 0xd7, 0xf5, 0xb9,
+	0xe4, 0x67, 0x4c, 0x9f, 0x93, 0xfc, 0x4a, 0xfd, 0x2f, 0x5d, 0xc2, 0x95, 0x55, 0x4d, 0x2a, 0x9f,
+	0x5c, 0xc0, 0xaa, 0x25, 0x84, 0xbe, 0x30, 0xcf, 0x98, 0x99, 0x7b, 0xaf, 0x4b, 0xc4, 0xb0, 0x97,
+	0x70, 0x69, 0x88, 0xa9, 0xf9, 0x77, 0x67, 0x6a, 0xc4, 0xc9, 0xc2, 0x78, 0x4e, 0x4e, 0x0f, 0x73,
+	0x72, 0x61, 0x03, 0xb2, 0x51, 0x80, 0x78, 0x1e, 0x32, 0xee, 0xe9, 0xa9, 0xcf, 0xa4, 0x7e, 0xf5,
+	0xde, 0x20, 0x91, 0xf4, 0xe6, 0x81, 0x54, 0xaf, 0xef, 0xd4, 0xf0, 0x81, 0xbc, 0x8c, 0xdb, 0xe5,
+	0xaf, 0x93, 0x50, 0x1c, 0xed, 0x15, 0xd7, 0xbe, 0x17, 0x8c, 0x27, 0x72, 0x71, 0x80, 0xc8, 0xe1,
+	0x1b, 0x9b, 0xc3, 0xec, 0x67, 0x01, 0x15, 0x92, 0xdb, 0xcc, 0xd2, 0xd7, 0x74, 0x78, 0x59, 0xe5,
+	0x57, 0x9e, 0xfe, 0xd9, 0xf6, 0x59, 0xd1, 0xb9, 0xd5, 0xe4, 0xb3, 0x08, 0x8e, 0xcc, 0xc4, 0xc0,
+	0x7a, 0xe2, 0x92, 0xf6, 0xb0, 0xb0, 0x06, 0xb3, 0x23, 0x8e, 0x78, 0x01, 0x8c, 0xd8, 0x55, 0xd7,
+	0x11, 0x91, 0x0b, 0x59, 0x81, 0xe8, 0x30, 0xf5, 0xfe, 0x20, 0x32, 0xd4, 0x5a, 0xbe, 0x48, 0x80,
+	0x11, 0xd3, 0x09, 0x7f, 0x0c, 0xff, 0x38, 0xe5, 0xb6, 0x64, 0x1e, 0x6b, 0x5b, 0xef, 0x5e, 0x29,
+	0x1c, 0x63, 0xd4, 0xfa, 0x15, 0x7b, 0xb3, 0x00, 0x89, 0x49, 0xcd, 0x38, 0x79, 0xf5, 0x66, 0x7c,
+	0x0b, 0xb2, 0x7e, 0x97, 0x0a, 0x8b, 0xb7, 0x75, 0xe9, 0x0a, 0x24, 0xa3, 0xc4, 0x46, 0x1b, 0xff,
+	0x13, 0x0c, 0xe9, 0xd1, 0x16, 0x53, 0x33, 0x69, 0x3d, 0x93, 0xd5, 0x72, 0xa3, 0x3d, 0xd2, 0x62,
+	0x1f, 0x7c, 0x89, 0x60, 0x7e, 0xfc, 0x63, 0x0a, 0xdf, 0x87, 0xbb, 0xb5, 0xcd, 0x4d, 0x52, 0xdf,
+	0xac, 0x35, 0x1b, 0xfb, 0x7b, 0x56, 0xb3, 0xbe, 0x7b, 0xb0, 0x4f, 0x6a, 0x3b, 0x8d, 0xe6, 0x73,
+	0xeb, 0x68, 0xef, 0xf0, 0xa0, 0xbe, 0xd6, 0xd8, 0x68, 0xd4, 0xd7, 0x8b, 0x53, 0xf8, 0x0e, 0x2c,
+	0x5e, 0x66, 0xb8, 0x5e, 0xdf, 0x69, 0xd6, 0x8a, 0x08, 0xdf, 0x83, 0xf2, 0x65, 0x26, 0x6b, 0x47,
+	0xbb, 0x47, 0x3b, 0xb5, 0x66, 0xe3, 0xb8, 0x5e, 0x4c, 0x3c, 0x78, 0x0c, 0x33, 0x17, 0x24, 0xd9,
+	0xd0, 0xf7, 0xc4, 0x34, 0xe4, 0x36, 0x76, 0x6a, 0x9b, 0xd6, 0xde, 0xfe, 0x5e, 0xbd, 0x38, 0x85,
+	0x17, 0x60, 0x3e, 0x12, 0x2d, 0x52, 0x5f, 0xdb, 0x27, 0xeb, 0xf5, 0x75, 0xeb, 0xb8, 0xb6, 0x73,
+	0x54, 0x2f, 0xa2, 0xd5, 0xaf, 0x10, 0xdc, 0xe1, 0xee, 0x04, 0x2e, 0xae, 0x16, 0xa2, 0xdf, 0xea,
+	0x07, 0x6a, 0xe2, 0x00, 0xbd, 0xf8, 0xb0, 0xc3, 0xe5, 0xcb, 0xe0, 0x44, 0x55, 0xb4, 0xca, 0xc5,
+	0xa9, 0x1d, 0xf4, 0xd4, 0xd3, 0xae, 0xaa, 0x10, 0x3a, 0x1e, 0x3d, 0xad, 0x72, 0x21, 0x99, 0x27,
+	0xa8, 0x5d, 0x75, 0xa5, 0xdd, 0x8d, 0xff, 0xbb, 0x79, 0x1c, 0x7d, 0xbf, 0x49, 0xdc, 0xde, 0xef,
+	0x32, 0xd1, 0xbc, 0x58, 0x4e, 0xa3, 0x46, 0xbf, 0xc4, 0xfd, 0xca, 0xf1, 0xf2, 0x49, 0x46, 0x07,
+	0xf0, 0xe8, 0x8f, 0x01, 0x00, 0xbb, 0xf4, 0x76, 0x5f, 0x15, 0x12, 0x00, 0x00,
+}
@@ -0,0 +1,357 @@
+// Package router distributes the writes of an output between several
+// endpoints, such as the nodes of a cluster, and stops writing to the
+// endpoints that keep failing until they are healthy again.
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math/rand"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// Routing modes.
+const (
+	// Random writes to a random endpoint, trying the others on errors.
+	Random = "random"
+	// Failover writes to the first healthy endpoint in the list.
+	Failover = "failover"
+	// RoundRobin writes to the next healthy endpoint each time.
+	RoundRobin = "round_robin"
+	// Hash splits the metrics between the endpoints by consistent hashing of
+	// the value of a tag.
+	Hash = "hash"
+	// All writes to all the endpoints and succeeds if a quorum of them
+	// succeed.
+	All = "all"
+)
+
+// ErrNoHealthyEndpoint is returned when all the endpoints are unhealthy.
+var ErrNoHealthyEndpoint = errors.New("no healthy endpoint")
+
+// Endpoint is a server metrics are written to.
+type Endpoint interface {
+	Write(ctx context.Context, metrics []telegraf.Metric) error
+
+	// URL identifies the endpoint in logs and stats.
+	URL() string
+}
+
+// HealthChecker is an Endpoint able to tell if it is healthy without
+// writing to it.  It is checked before an unhealthy endpoint is used again.
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// Config is the routing configuration of an output.
+type Config struct {
+	// Mode is one of the routing modes, Random by default.
+	Mode string
+	// Tag is the tag hashed by the Hash mode.
+	Tag string
+	// Quorum is the number of successful writes needed by the All mode, by
+	// default a majority of the endpoints.
+	Quorum int
+	// UnhealthyThreshold is the number of consecutive failed writes after
+	// which an endpoint is unhealthy, 0 disables health tracking.
+	UnhealthyThreshold int
+	// UnhealthyTimeout is the time an unhealthy endpoint is not written to,
+	// before it is checked again.
+	UnhealthyTimeout time.Duration
+}
+
+// Router writes metrics to endpoints according to its Config.
+type Router struct {
+	config    Config
+	name      string
+	endpoints []*endpoint
+
+	mu   sync.Mutex
+	next int
+}
+
+// endpoint is an Endpoint and its health.
+type endpoint struct {
+	Endpoint
+
+	// url is the URL without password for logs and stats.
+	url string
+
+	failures       int
+	unhealthySince time.Time
+
+	writes      selfstat.Stat
+	writeErrors selfstat.Stat
+	writeTime   selfstat.Stat
+	healthy     selfstat.Stat
+}
+
+// New returns a Router for the endpoints of the output, name is the name of
+// the output used in logs and stats.
+func New(name string, config Config, endpoints []Endpoint) (*Router, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no endpoint")
+	}
+
+	switch config.Mode {
+	case "":
+		config.Mode = Random
+	case Random, Failover, RoundRobin:
+	case Hash:
+		if config.Tag == "" {
+			return nil, errors.New("routing_tag is required with hash routing")
+		}
+	case All:
+		if config.Quorum == 0 {
+			config.Quorum = len(endpoints)/2 + 1
+		}
+		if config.Quorum < 0 || config.Quorum > len(endpoints) {
+			return nil, fmt.Errorf("write_quorum must be between 1 and the number of urls, %d", len(endpoints))
+		}
+	default:
+		return nil, fmt.Errorf("unknown routing mode %q", config.Mode)
+	}
+
+	r := &Router{
+		config: config,
+		name:   name,
+	}
+	for _, e := range endpoints {
+		u := redact(e.URL())
+		tags := map[string]string{
+			"output": name,
+			"url":    u,
+		}
+		ep := &endpoint{
+			Endpoint:    e,
+			url:         u,
+			writes:      selfstat.Register("output_endpoint", "writes", tags),
+			writeErrors: selfstat.Register("output_endpoint", "write_errors", tags),
+			writeTime:   selfstat.RegisterTiming("output_endpoint", "write_time_ns", tags),
+			healthy:     selfstat.Register("output_endpoint", "healthy", tags),
+		}
+		ep.healthy.Set(1)
+		r.endpoints = append(r.endpoints, ep)
+	}
+	return r, nil
+}
+
+// Write writes the metrics to the endpoints chosen by the routing mode.
+func (r *Router) Write(ctx context.Context, metrics []telegraf.Metric) error {
+	switch r.config.Mode {
+	case Failover:
+		return r.writeAny(ctx, metrics, r.endpoints)
+	case RoundRobin:
+		r.mu.Lock()
+		start := r.next
+		r.next = (r.next + 1) % len(r.endpoints)
+		r.mu.Unlock()
+
+		order := make([]*endpoint, 0, len(r.endpoints))
+		order = append(order, r.endpoints[start:]...)
+		order = append(order, r.endpoints[:start]...)
+		return r.writeAny(ctx, metrics, order)
+	case Hash:
+		return r.writeHashed(ctx, metrics)
+	case All:
+		return r.writeAll(ctx, metrics)
+	default:
+		order := make([]*endpoint, len(r.endpoints))
+		for i, n := range rand.Perm(len(r.endpoints)) {
+			order[i] = r.endpoints[n]
+		}
+		return r.writeAny(ctx, metrics, order)
+	}
+}
+
+// writeAny writes to the first of the healthy endpoints that succeeds.
+func (r *Router) writeAny(ctx context.Context, metrics []telegraf.Metric, order []*endpoint) error {
+	tried := false
+	for _, e := range order {
+		if !r.available(ctx, e) {
+			continue
+		}
+		tried = true
+
+		if err := r.write(ctx, e, metrics); err == nil {
+			return nil
+		}
+	}
+
+	if !tried {
+		return ErrNoHealthyEndpoint
+	}
+	return errors.New("could not write any address")
+}
+
+// writeHashed writes each metric to the healthy endpoint with the highest
+// rendezvous hash of its tag, so that a metric moves only when its endpoint
+// becomes unhealthy.
+func (r *Router) writeHashed(ctx context.Context, metrics []telegraf.Metric) error {
+	available := make([]*endpoint, 0, len(r.endpoints))
+	for _, e := range r.endpoints {
+		if r.available(ctx, e) {
+			available = append(available, e)
+		}
+	}
+	if len(available) == 0 {
+		return ErrNoHealthyEndpoint
+	}
+
+	batches := make(map[*endpoint][]telegraf.Metric)
+	for _, m := range metrics {
+		key, _ := m.GetTag(r.config.Tag)
+
+		var best *endpoint
+		var bestScore uint64
+		for _, e := range available {
+			score := rendezvousHash(key, e.URL())
+			if best == nil || score > bestScore {
+				best, bestScore = e, score
+			}
+		}
+		batches[best] = append(batches[best], m)
+	}
+
+	var failed int
+	for _, e := range available {
+		batch, ok := batches[e]
+		if !ok {
+			continue
+		}
+		if err := r.write(ctx, e, batch); err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("could not write to %d of %d addresses", failed, len(batches))
+	}
+	return nil
+}
+
+// writeAll writes to all the healthy endpoints concurrently.
+func (r *Router) writeAll(ctx context.Context, metrics []telegraf.Metric) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for _, e := range r.endpoints {
+		if !r.available(ctx, e) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			if err := r.write(ctx, e, metrics); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}(e)
+	}
+	wg.Wait()
+
+	if succeeded < r.config.Quorum {
+		return fmt.Errorf("wrote to %d of %d addresses, quorum is %d",
+			succeeded, len(r.endpoints), r.config.Quorum)
+	}
+	return nil
+}
+
+// write writes to the endpoint and updates its health.
+func (r *Router) write(ctx context.Context, e *endpoint, metrics []telegraf.Metric) error {
+	start := time.Now()
+	err := e.Write(ctx, metrics)
+	e.writeTime.Incr(time.Since(start).Nanoseconds())
+	e.writes.Incr(1)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		if e.failures >= r.config.UnhealthyThreshold && r.config.UnhealthyThreshold > 0 {
+			log.Printf("I! [outputs.%s] [%s] is healthy again", r.name, e.url)
+		}
+		e.failures = 0
+		e.healthy.Set(1)
+		return nil
+	}
+
+	e.writeErrors.Incr(1)
+	log.Printf("E! [outputs.%s] when writing to [%s]: %v", r.name, e.url, err)
+
+	e.failures++
+	if r.config.UnhealthyThreshold > 0 && e.failures >= r.config.UnhealthyThreshold {
+		if e.failures == r.config.UnhealthyThreshold {
+			log.Printf("W! [outputs.%s] [%s] is unhealthy after %d failed writes, skipping it for %s",
+				r.name, e.url, e.failures, r.config.UnhealthyTimeout)
+		}
+		e.unhealthySince = time.Now()
+		e.healthy.Set(0)
+	}
+	return err
+}
+
+// available reports if the endpoint can be written to.  An unhealthy
+// endpoint is available again once the unhealthy timeout has passed and its
+// health check, if any, succeeds; a failed write then makes it unhealthy
+// for another timeout.
+func (r *Router) available(ctx context.Context, e *endpoint) bool {
+	r.mu.Lock()
+	unhealthy := r.config.UnhealthyThreshold > 0 && e.failures >= r.config.UnhealthyThreshold
+	if !unhealthy {
+		r.mu.Unlock()
+		return true
+	}
+	if time.Since(e.unhealthySince) < r.config.UnhealthyTimeout {
+		r.mu.Unlock()
+		return false
+	}
+	// Only one write or health check until the next timeout.
+	e.unhealthySince = time.Now()
+	r.mu.Unlock()
+
+	if checker, ok := e.Endpoint.(HealthChecker); ok {
+		if err := checker.Health(ctx); err != nil {
+			log.Printf("D! [outputs.%s] [%s] is still unhealthy: %v", r.name, e.url, err)
+			return false
+		}
+	}
+	return true
+}
+
+// rendezvousHash returns the score of the endpoint for the key.
+func rendezvousHash(key, endpoint string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(endpoint))
+
+	// FNV doesn't spread similar inputs well enough to compare the sums,
+	// finish with the mixer of MurmurHash3.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// redact removes the password from the URL of an endpoint.
+func redact(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.User == nil {
+		return u
+	}
+	parsed.User = nil
+	return parsed.String()
+}
@@ -0,0 +1,531 @@
+package snmp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// module is a parsed MIB module.
+type module struct {
+	name string
+	// imports maps an imported symbol to the module it is imported from.
+	imports map[string]string
+	defs    map[string]*definition
+	// order holds the definitions in the order they were found.
+	order []*definition
+	types map[string]*typeRef
+}
+
+// definition is a value assignment in a MIB module, such as an
+// OBJECT IDENTIFIER or an OBJECT-TYPE.
+type definition struct {
+	module string
+	name   string
+	// kind is the macro used to define the object, e.g. "OBJECT-TYPE".
+	kind     string
+	access   string
+	syntax   *typeRef
+	index    []string
+	augments string
+	// enterprise is set for SNMPv1 TRAP-TYPE definitions.
+	enterprise string
+	value      []oidComponent
+
+	oid       []uint32
+	resolving bool
+}
+
+// typeRef is a reference to a type, along with the named numbers of
+// enumerated INTEGER and BITS types.
+type typeRef struct {
+	name  string
+	enums map[int]string
+	// tc is set if the type was defined as a TEXTUAL-CONVENTION.
+	tc bool
+}
+
+// oidComponent is a single element of an OBJECT IDENTIFIER value. Either or
+// both of name and number are set.
+type oidComponent struct {
+	name      string
+	number    uint32
+	hasNumber bool
+}
+
+// tokenize splits the contents of a MIB file into tokens, dropping comments.
+func tokenize(data string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f':
+			i++
+		case c == '-' && i+1 < len(data) && data[i+1] == '-':
+			// A comment ends at the end of the line or at the next "--".
+			i += 2
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				if data[i] == '-' && i+1 < len(data) && data[i+1] == '-' {
+					i += 2
+					break
+				}
+				i++
+			}
+		case c == '"':
+			end := strings.IndexByte(data[i+1:], '"')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, data[i:i+end+2])
+			i += end + 2
+		case c == '\'':
+			end := strings.IndexByte(data[i+1:], '\'')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated binary or hex string")
+			}
+			end += i + 2
+			// consume the 'B or 'H suffix
+			if end < len(data) && isWordChar(data[end]) {
+				end++
+			}
+			tokens = append(tokens, data[i:end])
+			i = end
+		case strings.HasPrefix(data[i:], "::="):
+			tokens = append(tokens, "::=")
+			i += 3
+		case strings.HasPrefix(data[i:], ".."):
+			tokens = append(tokens, "..")
+			i += 2
+		case isWordChar(c):
+			start := i
+			for i < len(data) && (isWordChar(data[i]) || data[i] == '-' && !strings.HasPrefix(data[i:], "--")) {
+				i++
+			}
+			tokens = append(tokens, data[start:i])
+		case c == '-':
+			// negative number
+			start := i
+			i++
+			for i < len(data) && isWordChar(data[i]) {
+				i++
+			}
+			tokens = append(tokens, data[start:i])
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peek() string {
+	return p.peekAt(0)
+}
+
+func (p *parser) peekAt(n int) string {
+	if p.pos+n >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() string {
+	tok := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) expect(tok string) error {
+	if got := p.next(); got != tok {
+		return fmt.Errorf("expected %q, got %q", tok, got)
+	}
+	return nil
+}
+
+// skipBalanced skips a bracketed block starting at the current token.
+func (p *parser) skipBalanced(open, close string) error {
+	depth := 0
+	for !p.eof() {
+		switch p.next() {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unbalanced %q", open)
+}
+
+// parseModules parses all of the MIB modules found in data.
+func parseModules(data string) ([]*module, error) {
+	tokens, err := tokenize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	var modules []*module
+	for !p.eof() {
+		m, err := p.parseModule()
+		if err != nil {
+			if m != nil {
+				return nil, fmt.Errorf("module %s: %s", m.name, err)
+			}
+			return nil, err
+		}
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+func (p *parser) parseModule() (*module, error) {
+	m := &module{
+		name:    p.next(),
+		imports: map[string]string{},
+		defs:    map[string]*definition{},
+		types:   map[string]*typeRef{},
+	}
+	if p.next() != "DEFINITIONS" {
+		return nil, fmt.Errorf("expected module definition")
+	}
+	for p.peek() != "BEGIN" {
+		if p.eof() {
+			return m, fmt.Errorf("missing BEGIN")
+		}
+		p.next()
+	}
+	p.next()
+
+	for {
+		switch p.peek() {
+		case "":
+			return m, fmt.Errorf("missing END")
+		case "END":
+			p.next()
+			return m, nil
+		case "IMPORTS":
+			p.next()
+			p.parseImports(m)
+		case "EXPORTS":
+			for !p.eof() && p.next() != ";" {
+			}
+		default:
+			if err := p.parseAssignment(m); err != nil {
+				return m, err
+			}
+		}
+	}
+}
+
+func (p *parser) parseImports(m *module) {
+	var symbols []string
+	for !p.eof() {
+		tok := p.next()
+		switch tok {
+		case ";":
+			return
+		case ",":
+		case "FROM":
+			from := p.next()
+			for _, s := range symbols {
+				m.imports[s] = from
+			}
+			symbols = symbols[:0]
+		default:
+			symbols = append(symbols, tok)
+		}
+	}
+}
+
+func (p *parser) parseAssignment(m *module) error {
+	name := p.next()
+
+	switch {
+	case p.peek() == "MACRO":
+		for !p.eof() && p.next() != "END" {
+		}
+		return nil
+	case p.peek() == "OBJECT" && p.peekAt(1) == "IDENTIFIER" && p.peekAt(2) == "::=":
+		p.pos += 3
+		value, err := p.parseOidValue()
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		m.add(&definition{name: name, kind: "OBJECT IDENTIFIER", value: value})
+		return nil
+	case p.peek() == "::=":
+		p.next()
+		if p.peek() == "{" {
+			value, err := p.parseOidValue()
+			if err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+			m.add(&definition{name: name, kind: "OBJECT IDENTIFIER", value: value})
+			return nil
+		}
+		if p.peek() == "TEXTUAL-CONVENTION" {
+			p.next()
+			t, err := p.parseTextualConvention()
+			if err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+			m.types[name] = t
+			return nil
+		}
+		t, err := p.parseType()
+		if err != nil {
+			return fmt.Errorf("%s: %s", name, err)
+		}
+		m.types[name] = t
+		return nil
+	}
+
+	d := &definition{name: name, kind: p.next()}
+	for {
+		if p.eof() {
+			return fmt.Errorf("%s: unexpected end of file", name)
+		}
+		switch tok := p.peek(); tok {
+		case "::=":
+			p.next()
+			if p.peek() != "{" {
+				n, err := strconv.ParseUint(p.next(), 10, 32)
+				if err != nil || d.enterprise == "" {
+					// plain value assignment
+					return nil
+				}
+				// A v1 TRAP-TYPE is identified by its enterprise and number,
+				// as described in RFC 3584 section 3.
+				d.value = []oidComponent{
+					{name: d.enterprise},
+					{number: 0, hasNumber: true},
+					{number: uint32(n), hasNumber: true},
+				}
+				m.add(d)
+				return nil
+			}
+			value, err := p.parseOidValue()
+			if err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+			d.value = value
+			m.add(d)
+			return nil
+		case "SYNTAX":
+			p.next()
+			t, err := p.parseType()
+			if err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+			// MODULE-COMPLIANCE may refine the syntax of other objects.
+			if d.syntax == nil {
+				d.syntax = t
+			}
+		case "MAX-ACCESS", "ACCESS":
+			p.next()
+			d.access = p.next()
+		case "INDEX":
+			p.next()
+			if err := p.expect("{"); err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+			for !p.eof() {
+				tok := p.next()
+				if tok == "}" {
+					break
+				}
+				if tok == "," || tok == "IMPLIED" {
+					continue
+				}
+				d.index = append(d.index, tok)
+			}
+		case "ENTERPRISE":
+			p.next()
+			d.enterprise = p.next()
+		case "AUGMENTS":
+			p.next()
+			if err := p.expect("{"); err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+			d.augments = p.next()
+			if err := p.expect("}"); err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+		case "{":
+			if err := p.skipBalanced("{", "}"); err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+		case "(":
+			if err := p.skipBalanced("(", ")"); err != nil {
+				return fmt.Errorf("%s: %s", name, err)
+			}
+		default:
+			p.next()
+		}
+	}
+}
+
+func (p *parser) parseTextualConvention() (*typeRef, error) {
+	for !p.eof() {
+		if p.next() == "SYNTAX" {
+			t, err := p.parseType()
+			if err != nil {
+				return nil, err
+			}
+			t.tc = true
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("missing SYNTAX")
+}
+
+// parseType parses a type, such as `INTEGER { up(1), down(2) }` or
+// `OCTET STRING (SIZE (0..255))`.
+func (p *parser) parseType() (*typeRef, error) {
+	if p.peek() == "[" {
+		if err := p.skipBalanced("[", "]"); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek() == "IMPLICIT" || p.peek() == "EXPLICIT" {
+		p.next()
+	}
+
+	t := &typeRef{name: p.next()}
+	switch t.name {
+	case "OCTET":
+		if err := p.expect("STRING"); err != nil {
+			return nil, err
+		}
+		t.name = "OCTET STRING"
+	case "OBJECT":
+		if err := p.expect("IDENTIFIER"); err != nil {
+			return nil, err
+		}
+		t.name = "OBJECT IDENTIFIER"
+	case "SEQUENCE":
+		if p.peek() == "OF" {
+			p.next()
+			t.name = "SEQUENCE OF"
+			p.next()
+			return t, nil
+		}
+		return t, p.skipBalanced("{", "}")
+	case "CHOICE":
+		return t, p.skipBalanced("{", "}")
+	case "", "{", "}", "(", ")", "::=":
+		return nil, fmt.Errorf("expected type, got %q", t.name)
+	}
+
+	if p.peek() == "{" {
+		enums, err := p.parseNamedNumbers()
+		if err != nil {
+			return nil, err
+		}
+		t.enums = enums
+	}
+	if p.peek() == "(" {
+		if err := p.skipBalanced("(", ")"); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// parseNamedNumbers parses a list of the form `{ up(1), down(2) }`.
+func (p *parser) parseNamedNumbers() (map[int]string, error) {
+	p.next()
+	enums := map[int]string{}
+	for {
+		name := p.next()
+		switch name {
+		case "}":
+			return enums, nil
+		case ",":
+			continue
+		case "":
+			return nil, fmt.Errorf("unterminated enumeration")
+		}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(p.next())
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %q: %s", name, err)
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		enums[n] = name
+	}
+}
+
+// parseOidValue parses an OBJECT IDENTIFIER value of the form
+// `{ parent 1 }` or `{ iso org(3) dod(6) 1 }`.
+func (p *parser) parseOidValue() ([]oidComponent, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var value []oidComponent
+	for {
+		tok := p.next()
+		switch tok {
+		case "}":
+			if len(value) == 0 {
+				return nil, fmt.Errorf("empty OBJECT IDENTIFIER")
+			}
+			return value, nil
+		case "":
+			return nil, fmt.Errorf("unterminated OBJECT IDENTIFIER")
+		}
+
+		var c oidComponent
+		if unicode.IsDigit(rune(tok[0])) {
+			n, err := strconv.ParseUint(tok, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			c.number, c.hasNumber = uint32(n), true
+		} else {
+			c.name = tok
+			if p.peek() == "(" {
+				p.next()
+				n, err := strconv.ParseUint(p.next(), 10, 32)
+				if err != nil {
+					return nil, err
+				}
+				c.number, c.hasNumber = uint32(n), true
+				if err := p.expect(")"); err != nil {
+					return nil, err
+				}
+			}
+		}
+		value = append(value, c)
+	}
+}
+
+func (m *module) add(d *definition) {
+	d.module = m.name
+	m.defs[d.name] = d
+	m.order = append(m.order, d)
+}
@@ -0,0 +1,99 @@
+package snmp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	tokens, err := tokenize(`foo-bar OBJECT IDENTIFIER ::= { iso 3 } -- comment
+	x -- inline -- y "a
+	string" 'ff00'H (-1..10)`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"foo-bar", "OBJECT", "IDENTIFIER", "::=", "{", "iso", "3", "}",
+		"x", "y", "\"a\n\tstring\"", "'ff00'H", "(", "-1", "..", "10", ")",
+	}, tokens)
+
+	_, err = tokenize(`x "unterminated`)
+	require.Error(t, err)
+}
+
+func TestParseModules(t *testing.T) {
+	modules, err := parseModules(`
+TEST-MIB DEFINITIONS ::= BEGIN
+IMPORTS
+    OBJECT-TYPE, enterprises FROM SNMPv2-SMI
+    DisplayString            FROM SNMPv2-TC;
+
+test OBJECT IDENTIFIER ::= { enterprises 1 }
+
+Status ::= TEXTUAL-CONVENTION
+    STATUS current
+    DESCRIPTION "status"
+    SYNTAX INTEGER { ok(1), failed(2) }
+
+testTable OBJECT-TYPE
+    SYNTAX SEQUENCE OF TestEntry
+    MAX-ACCESS not-accessible
+    STATUS current
+    ::= { test 1 }
+
+testEntry OBJECT-TYPE
+    SYNTAX TestEntry
+    MAX-ACCESS not-accessible
+    STATUS current
+    INDEX { testIndex, IMPLIED testName }
+    ::= { testTable 1 }
+
+TestEntry ::= SEQUENCE { testIndex INTEGER, testName DisplayString }
+
+testName OBJECT-TYPE
+    SYNTAX DisplayString (SIZE (0..32))
+    MAX-ACCESS read-only
+    STATUS current
+    DEFVAL { "foo" }
+    ::= { testEntry 2 }
+END
+`)
+	require.NoError(t, err)
+	require.Len(t, modules, 1)
+
+	m := modules[0]
+	assert.Equal(t, "TEST-MIB", m.name)
+	assert.Equal(t, "SNMPv2-SMI", m.imports["enterprises"])
+	assert.Equal(t, "SNMPv2-TC", m.imports["DisplayString"])
+
+	require.Contains(t, m.defs, "test")
+	assert.Equal(t, []oidComponent{{name: "enterprises"}, {number: 1, hasNumber: true}}, m.defs["test"].value)
+
+	require.Contains(t, m.types, "Status")
+	assert.True(t, m.types["Status"].tc)
+	assert.Equal(t, "INTEGER", m.types["Status"].name)
+	assert.Equal(t, map[int]string{1: "ok", 2: "failed"}, m.types["Status"].enums)
+
+	assert.Equal(t, "SEQUENCE OF", m.defs["testTable"].syntax.name)
+	assert.Equal(t, []string{"testIndex", "testName"}, m.defs["testEntry"].index)
+	assert.Equal(t, "DisplayString", m.defs["testName"].syntax.name)
+	assert.Equal(t, "read-only", m.defs["testName"].access)
+}
+
+func TestParseModules_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		mib  string
+	}{
+		{"missing END", "TEST DEFINITIONS ::= BEGIN\nfoo OBJECT IDENTIFIER ::= { iso 1 }"},
+		{"not a module", "foo bar baz"},
+		{"bad enum", "TEST DEFINITIONS ::= BEGIN\nFoo ::= INTEGER { a(x) }\nEND"},
+		{"bad oid", "TEST DEFINITIONS ::= BEGIN\nfoo OBJECT IDENTIFIER ::= { }\nEND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseModules(tt.mib)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,661 @@
+package snmp
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// builtinSMI holds the core object identifiers of SNMPv2-SMI, so that MIBs
+// can be resolved when the module itself is not installed.
+const builtinSMI = `
+SNMPv2-SMI DEFINITIONS ::= BEGIN
+org            OBJECT IDENTIFIER ::= { iso 3 }
+dod            OBJECT IDENTIFIER ::= { org 6 }
+internet       OBJECT IDENTIFIER ::= { dod 1 }
+directory      OBJECT IDENTIFIER ::= { internet 1 }
+mgmt           OBJECT IDENTIFIER ::= { internet 2 }
+mib-2          OBJECT IDENTIFIER ::= { mgmt 1 }
+transmission   OBJECT IDENTIFIER ::= { mib-2 10 }
+experimental   OBJECT IDENTIFIER ::= { internet 3 }
+private        OBJECT IDENTIFIER ::= { internet 4 }
+enterprises    OBJECT IDENTIFIER ::= { private 1 }
+security       OBJECT IDENTIFIER ::= { internet 5 }
+snmpV2         OBJECT IDENTIFIER ::= { internet 6 }
+snmpDomains    OBJECT IDENTIFIER ::= { snmpV2 1 }
+snmpProxys     OBJECT IDENTIFIER ::= { snmpV2 2 }
+snmpModules    OBJECT IDENTIFIER ::= { snmpV2 3 }
+zeroDotZero    OBJECT IDENTIFIER ::= { 0 0 }
+END
+`
+
+// roots are the top level arcs of the OID tree.
+var roots = map[string]uint32{
+	"ccitt":           0,
+	"iso":             1,
+	"joint-iso-ccitt": 2,
+}
+
+// conversions maps textual conventions onto field conversions.
+var conversions = map[string]string{
+	"MacAddress":      "hwaddr",
+	"PhysAddress":     "hwaddr",
+	"InetAddressIPv4": "ipaddr",
+	"InetAddressIPv6": "ipaddr",
+	"InetAddress":     "ipaddr",
+	"IPSIpAddress":    "ipaddr",
+}
+
+// defaultMibPaths are searched when MIBDIRS is not set, as in net-snmp.
+var defaultMibPaths = []string{
+	"/usr/share/snmp/mibs",
+	"/usr/local/share/snmp/mibs",
+}
+
+// Column is a column of a MIB table.
+type Column struct {
+	Name  string
+	Oid   string
+	IsTag bool
+}
+
+// node is an object in the OID tree.
+type node struct {
+	module     string
+	name       string
+	oid        []uint32
+	kind       string
+	access     string
+	syntax     string
+	conversion string
+	enums      map[int]string
+	index      []string
+	augments   string
+	children   map[uint32]*node
+}
+
+type mibTree struct {
+	modules  map[string]*module
+	root     *node
+	byModule map[string]map[string]*node
+	byName   map[string]*node
+	defs     map[string]*definition
+	// names holds the module names in order of priority.
+	names []string
+}
+
+type translateCache struct {
+	mibName    string
+	oidNum     string
+	oidText    string
+	conversion string
+	err        error
+}
+
+type tableCache struct {
+	mibName string
+	oidNum  string
+	oidText string
+	columns []Column
+	err     error
+}
+
+var mibLock sync.Mutex
+var mibPaths = map[string]bool{}
+var mibModules = map[string]*module{}
+var tree *mibTree
+var translateCaches map[string]translateCache
+var tableCaches map[string]tableCache
+
+// DefaultMibPaths returns the directories MIBs are loaded from if none are
+// configured. Like net-snmp, the MIBDIRS environment variable overrides the
+// defaults, or is appended to them if it starts with a '+'.
+func DefaultMibPaths() []string {
+	paths := defaultMibPaths
+	if home := os.Getenv("HOME"); home != "" {
+		paths = append([]string{filepath.Join(home, ".snmp", "mibs")}, paths...)
+	}
+	if env := os.Getenv("MIBDIRS"); env != "" {
+		if strings.HasPrefix(env, "+") {
+			paths = append(paths, filepath.SplitList(env[1:])...)
+		} else {
+			paths = filepath.SplitList(env)
+		}
+	}
+
+	var existing []string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			existing = append(existing, path)
+		}
+	}
+	return existing
+}
+
+// LoadMibsFromPath loads every MIB module found in the given directories.
+// Each directory is only read once, and the loaded modules are shared by all
+// callers. Where several files define the same module, the first one loaded
+// is used.
+func LoadMibsFromPath(paths []string) error {
+	mibLock.Lock()
+	defer mibLock.Unlock()
+
+	changed := tree == nil
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		if mibPaths[path] {
+			continue
+		}
+
+		err := filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if strings.HasPrefix(info.Name(), ".") && name != path {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			data, err := ioutil.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			modules, err := parseModules(string(data))
+			if err != nil {
+				log.Printf("W! [snmp] Unable to parse MIB file %s: %s", name, err)
+				return nil
+			}
+			for _, m := range modules {
+				if _, ok := mibModules[m.name]; !ok {
+					mibModules[m.name] = m
+					changed = true
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("loading MIBs from %s: %s", path, err)
+		}
+		mibPaths[path] = true
+	}
+
+	if changed {
+		tree = newMibTree(mibModules)
+		translateCaches = nil
+		tableCaches = nil
+	}
+	return nil
+}
+
+// TranslateOid resolves the given OID, which may be numeric, such as
+// ".1.3.6.1.2.1.2.2.1.2.1", or textual, such as "IF-MIB::ifDescr.1". It
+// returns the MIB module, numeric OID, textual name and the conversion
+// suggested by the object's textual convention.
+func TranslateOid(oid string) (mibName string, oidNum string, oidText string, conversion string, err error) {
+	mibLock.Lock()
+	defer mibLock.Unlock()
+
+	if translateCaches == nil {
+		translateCaches = map[string]translateCache{}
+	}
+
+	tc, ok := translateCaches[oid]
+	if !ok {
+		tc.mibName, tc.oidNum, tc.oidText, tc.conversion, tc.err = getTree().translate(oid)
+		translateCaches[oid] = tc
+	}
+	return tc.mibName, tc.oidNum, tc.oidText, tc.conversion, tc.err
+}
+
+// TranslateTable resolves the given OID as a table, returning information
+// about the table and its columns. Columns which are part of the table index
+// are flagged as tags.
+func TranslateTable(oid string) (mibName string, oidNum string, oidText string, columns []Column, err error) {
+	mibLock.Lock()
+	defer mibLock.Unlock()
+
+	if tableCaches == nil {
+		tableCaches = map[string]tableCache{}
+	}
+
+	tc, ok := tableCaches[oid]
+	if !ok {
+		tc.mibName, tc.oidNum, tc.oidText, tc.columns, tc.err = getTree().table(oid)
+		tableCaches[oid] = tc
+	}
+	return tc.mibName, tc.oidNum, tc.oidText, tc.columns, tc.err
+}
+
+// TranslateEnums returns the named values of the enumerated object the given
+// OID belongs to, or nil if it has none.
+func TranslateEnums(oid string) map[int]string {
+	mibLock.Lock()
+	defer mibLock.Unlock()
+
+	t := getTree()
+	num, err := t.parse(oid)
+	if err != nil {
+		return nil
+	}
+	n, _ := t.longestPrefix(num)
+	if n == nil {
+		return nil
+	}
+	return n.enums
+}
+
+// getTree returns the current tree, building an empty one if no MIBs have
+// been loaded. The caller must hold mibLock.
+func getTree() *mibTree {
+	if tree == nil {
+		tree = newMibTree(mibModules)
+	}
+	return tree
+}
+
+// newMibTree resolves the definitions of all modules into an OID tree.
+func newMibTree(modules map[string]*module) *mibTree {
+	t := &mibTree{
+		modules:  map[string]*module{},
+		root:     &node{children: map[uint32]*node{}},
+		byModule: map[string]map[string]*node{},
+		byName:   map[string]*node{},
+		defs:     map[string]*definition{},
+	}
+	for name, m := range modules {
+		t.modules[name] = m
+	}
+	if _, ok := t.modules["SNMPv2-SMI"]; !ok {
+		smi, err := parseModules(builtinSMI)
+		if err != nil {
+			panic(err)
+		}
+		t.modules["SNMPv2-SMI"] = smi[0]
+	}
+
+	// SMIv1 modules such as RFC1213-MIB are superseded by their SMIv2
+	// versions, so give them the lowest priority when names clash.
+	for name := range t.modules {
+		t.names = append(t.names, name)
+	}
+	names := t.names
+	sort.Slice(names, func(i, j int) bool {
+		vi, vj := strings.HasPrefix(names[i], "RFC"), strings.HasPrefix(names[j], "RFC")
+		if vi != vj {
+			return vj
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		for _, d := range t.modules[name].order {
+			if _, ok := t.defs[d.name]; !ok {
+				t.defs[d.name] = d
+			}
+		}
+	}
+
+	for _, name := range names {
+		for _, d := range t.modules[name].order {
+			oid, err := t.resolve(d)
+			if err != nil {
+				continue
+			}
+			t.insert(d, oid)
+		}
+	}
+	return t
+}
+
+// lookup finds the definition of name as seen from the given module, using
+// its imports and falling back to any module defining the name.
+func (t *mibTree) lookup(module string, name string) *definition {
+	if m, ok := t.modules[module]; ok {
+		if d, ok := m.defs[name]; ok {
+			return d
+		}
+		if from, ok := m.imports[name]; ok {
+			if fm, ok := t.modules[from]; ok {
+				if d, ok := fm.defs[name]; ok {
+					return d
+				}
+			}
+		}
+	}
+	return t.defs[name]
+}
+
+// lookupType finds the type called name as seen from the given module.
+func (t *mibTree) lookupType(module string, name string) (string, *typeRef) {
+	if m, ok := t.modules[module]; ok {
+		if tr, ok := m.types[name]; ok {
+			return module, tr
+		}
+		if from, ok := m.imports[name]; ok {
+			if fm, ok := t.modules[from]; ok {
+				if tr, ok := fm.types[name]; ok {
+					return from, tr
+				}
+			}
+		}
+	}
+	for _, mname := range t.names {
+		if tr, ok := t.modules[mname].types[name]; ok {
+			return mname, tr
+		}
+	}
+	return "", nil
+}
+
+// resolve returns the numeric OID of a definition.
+func (t *mibTree) resolve(d *definition) ([]uint32, error) {
+	if d.oid != nil {
+		return d.oid, nil
+	}
+	if d.resolving {
+		return nil, fmt.Errorf("loop resolving %s::%s", d.module, d.name)
+	}
+	d.resolving = true
+	defer func() { d.resolving = false }()
+
+	var oid []uint32
+	for i, c := range d.value {
+		if i == 0 {
+			if c.hasNumber {
+				oid = []uint32{c.number}
+				continue
+			}
+			if n, ok := roots[c.name]; ok {
+				oid = []uint32{n}
+				continue
+			}
+			parent := t.lookup(d.module, c.name)
+			if parent == nil {
+				return nil, fmt.Errorf("unknown object %s in %s::%s", c.name, d.module, d.name)
+			}
+			parentOid, err := t.resolve(parent)
+			if err != nil {
+				return nil, err
+			}
+			oid = append([]uint32{}, parentOid...)
+			continue
+		}
+
+		if !c.hasNumber {
+			return nil, fmt.Errorf("invalid OBJECT IDENTIFIER for %s::%s", d.module, d.name)
+		}
+		oid = append(oid, c.number)
+		if c.name != "" {
+			// named intermediate arc, e.g. `{ iso org(3) dod(6) }`
+			if _, ok := roots[c.name]; !ok && t.lookup(d.module, c.name) == nil {
+				implicit := &definition{
+					module: d.module,
+					name:   c.name,
+					kind:   "OBJECT IDENTIFIER",
+					oid:    append([]uint32{}, oid...),
+				}
+				t.defs[c.name] = implicit
+				t.insert(implicit, implicit.oid)
+			}
+		}
+	}
+
+	d.oid = oid
+	return oid, nil
+}
+
+// insert adds a resolved definition to the tree. The first definition of an
+// OID wins.
+func (t *mibTree) insert(d *definition, oid []uint32) {
+	n := t.root
+	for _, sub := range oid {
+		child, ok := n.children[sub]
+		if !ok {
+			child = &node{
+				oid:      append(append([]uint32{}, n.oid...), sub),
+				children: map[uint32]*node{},
+			}
+			n.children[sub] = child
+		}
+		n = child
+	}
+
+	if n.name == "" {
+		n.module = d.module
+		n.name = d.name
+		n.kind = d.kind
+		n.access = d.access
+		n.index = d.index
+		n.augments = d.augments
+		if d.syntax != nil {
+			n.syntax = d.syntax.name
+			n.enums = d.syntax.enums
+			t.resolveSyntax(n, d.module, d.syntax)
+		}
+	}
+
+	if _, ok := t.byModule[d.module]; !ok {
+		t.byModule[d.module] = map[string]*node{}
+	}
+	if _, ok := t.byModule[d.module][d.name]; !ok {
+		t.byModule[d.module][d.name] = n
+	}
+	if _, ok := t.byName[d.name]; !ok {
+		t.byName[d.name] = n
+	}
+}
+
+// resolveSyntax follows the chain of textual conventions of an object to find
+// its conversion and enumerated values.
+func (t *mibTree) resolveSyntax(n *node, module string, syntax *typeRef) {
+	name := syntax.name
+	for i := 0; i < 16; i++ {
+		if conversion, ok := conversions[name]; ok && n.conversion == "" {
+			n.conversion = conversion
+		}
+		var tr *typeRef
+		module, tr = t.lookupType(module, name)
+		if tr == nil {
+			return
+		}
+		if n.enums == nil {
+			n.enums = tr.enums
+		}
+		name = tr.name
+	}
+}
+
+// parse converts a numeric, textual or mixed OID into its numeric form.
+func (t *mibTree) parse(oid string) ([]uint32, error) {
+	var num []uint32
+	var rest string
+	n := t.root
+
+	if i := strings.Index(oid, "::"); i != -1 {
+		mibName := oid[:i]
+		name := oid[i+2:]
+		if j := strings.Index(name, "."); j != -1 {
+			name, rest = name[:j], name[j+1:]
+		}
+
+		m, ok := t.byModule[mibName]
+		if !ok {
+			return nil, fmt.Errorf("unknown MIB module %q", mibName)
+		}
+		if n, ok = m[name]; !ok {
+			return nil, fmt.Errorf("unknown object %q in MIB module %q", name, mibName)
+		}
+		num = append(num, n.oid...)
+	} else {
+		rest = strings.TrimPrefix(oid, ".")
+	}
+
+	if rest == "" {
+		return num, nil
+	}
+
+	for i, part := range strings.Split(rest, ".") {
+		if v, err := strconv.ParseUint(part, 10, 32); err == nil {
+			num = append(num, uint32(v))
+			if n != nil {
+				n = n.children[uint32(v)]
+			}
+			continue
+		}
+
+		// A name is looked up among the children of the previous arc, or
+		// globally if it comes first.
+		var found *node
+		if i == 0 && len(num) == 0 {
+			if r, ok := roots[part]; ok {
+				found = t.root.children[r]
+				if found == nil {
+					found = &node{oid: []uint32{r}}
+				}
+			} else {
+				found = t.byName[part]
+			}
+		} else if n != nil {
+			for _, child := range n.children {
+				if child.name == part {
+					found = child
+					break
+				}
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("unknown object %q in %q", part, oid)
+		}
+		num = append(num[:0], found.oid...)
+		n = found
+	}
+	return num, nil
+}
+
+// longestPrefix returns the deepest named object containing the OID, along
+// with the depth of the object.
+func (t *mibTree) longestPrefix(oid []uint32) (*node, int) {
+	var found *node
+	var depth int
+	n := t.root
+	for i, sub := range oid {
+		child, ok := n.children[sub]
+		if !ok {
+			break
+		}
+		n = child
+		if n.module != "" {
+			found, depth = n, i+1
+		}
+	}
+	return found, depth
+}
+
+func (t *mibTree) translate(oid string) (mibName string, oidNum string, oidText string, conversion string, err error) {
+	num, err := t.parse(oid)
+	if err != nil {
+		return "", "", "", "", err
+	}
+	oidNum = formatOid(num)
+
+	n, depth := t.longestPrefix(num)
+	if n == nil {
+		// not found in any MIB
+		return "", oidNum, oid, "", nil
+	}
+
+	oidText = n.name
+	if depth < len(num) {
+		oidText += formatOid(num[depth:])
+	}
+	return n.module, oidNum, oidText, n.conversion, nil
+}
+
+func (t *mibTree) table(oid string) (mibName string, oidNum string, oidText string, columns []Column, err error) {
+	mibName, oidNum, oidText, _, err = t.translate(oid)
+	if err != nil {
+		return "", "", "", nil, err
+	}
+
+	num, _ := t.parse(oid)
+	tbl, depth := t.longestPrefix(num)
+	if tbl == nil || depth != len(num) {
+		return "", "", "", nil, fmt.Errorf("could not find any columns in table")
+	}
+
+	// The entry is the row object of the table, which is normally its
+	// first child.
+	entry := tbl
+	if tbl.syntax == "SEQUENCE OF" {
+		entry = nil
+		for _, sub := range sortedChildren(tbl) {
+			if child := tbl.children[sub]; child.name != "" {
+				entry = child
+				break
+			}
+		}
+	}
+	if entry == nil {
+		return "", "", "", nil, fmt.Errorf("could not find any columns in table")
+	}
+
+	index := entry.index
+	if entry.augments != "" {
+		if d := t.lookup(entry.module, entry.augments); d != nil {
+			if n, _ := t.longestPrefix(d.oid); n != nil {
+				index = n.index
+			}
+		}
+	}
+	tags := map[string]bool{}
+	for _, name := range index {
+		tags[name] = true
+	}
+
+	for _, sub := range sortedChildren(entry) {
+		col := entry.children[sub]
+		if col.name == "" || col.access == "not-accessible" {
+			continue
+		}
+		columns = append(columns, Column{
+			Name:  col.name,
+			Oid:   formatOid(col.oid),
+			IsTag: tags[col.name],
+		})
+	}
+	if len(columns) == 0 {
+		return "", "", "", nil, fmt.Errorf("could not find any columns in table")
+	}
+
+	return mibName, oidNum, oidText, columns, nil
+}
+
+func sortedChildren(n *node) []uint32 {
+	subs := make([]uint32, 0, len(n.children))
+	for sub := range n.children {
+		subs = append(subs, sub)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i] < subs[j] })
+	return subs
+}
+
+func formatOid(oid []uint32) string {
+	var buf bytes.Buffer
+	for _, sub := range oid {
+		buf.WriteByte('.')
+		buf.WriteString(strconv.FormatUint(uint64(sub), 10))
+	}
+	return buf.String()
+}
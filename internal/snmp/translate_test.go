@@ -0,0 +1,180 @@
+package snmp
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	if err := LoadMibsFromPath([]string{"testdata"}); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestTranslateOid(t *testing.T) {
+	tests := []struct {
+		oid        string
+		mibName    string
+		oidNum     string
+		oidText    string
+		conversion string
+	}{
+		{"IF-MIB::ifDescr", "IF-MIB", ".1.3.6.1.2.1.2.2.1.2", "ifDescr", ""},
+		{"IF-MIB::ifDescr.3", "IF-MIB", ".1.3.6.1.2.1.2.2.1.2.3", "ifDescr.3", ""},
+		{".1.3.6.1.2.1.2.2.1.2.3", "IF-MIB", ".1.3.6.1.2.1.2.2.1.2.3", "ifDescr.3", ""},
+		{"1.3.6.1.2.1.2.2.1.2", "IF-MIB", ".1.3.6.1.2.1.2.2.1.2", "ifDescr", ""},
+		{"ifDescr.3", "IF-MIB", ".1.3.6.1.2.1.2.2.1.2.3", "ifDescr.3", ""},
+		{".iso.3.6.1.2.1.1.3.0", "SNMPv2-MIB", ".1.3.6.1.2.1.1.3.0", "sysUpTime.0", ""},
+		{".iso.org.dod.internet.mgmt.mib-2.system", "SNMPv2-MIB", ".1.3.6.1.2.1.1", "system", ""},
+		{"IF-MIB::ifPhysAddress.1", "IF-MIB", ".1.3.6.1.2.1.2.2.1.6.1", "ifPhysAddress.1", "hwaddr"},
+		{"IF-MIB::linkDown", "IF-MIB", ".1.3.6.1.6.3.1.1.5.3", "linkDown", ""},
+		{".1.3.6.1.4.1.99999.0.17", "TEST-TRAP-MIB", ".1.3.6.1.4.1.99999.0.17", "testAlarm", ""},
+		{".1.3.6.1.4.1.99999.2", "TEST-TRAP-MIB", ".1.3.6.1.4.1.99999.2", "test", ""},
+		{".1.3.6.1.4.1.12345", "SNMPv2-SMI", ".1.3.6.1.4.1.12345", "enterprises.12345", ""},
+		{".1.2.3", "", ".1.2.3", ".1.2.3", ""},
+		{".999", "", ".999", ".999", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.oid, func(t *testing.T) {
+			mibName, oidNum, oidText, conversion, err := TranslateOid(tt.oid)
+			require.NoError(t, err)
+			assert.Equal(t, tt.mibName, mibName)
+			assert.Equal(t, tt.oidNum, oidNum)
+			assert.Equal(t, tt.oidText, oidText)
+			assert.Equal(t, tt.conversion, conversion)
+		})
+	}
+}
+
+func TestTranslateOid_errors(t *testing.T) {
+	for _, oid := range []string{"FOO-MIB::bar", "IF-MIB::ifFoo", "IF-MIB::ifDescr.x", "ifFoo.1"} {
+		_, _, _, _, err := TranslateOid(oid)
+		assert.Error(t, err, oid)
+	}
+}
+
+func TestTranslateTable(t *testing.T) {
+	mibName, oidNum, oidText, columns, err := TranslateTable("IF-MIB::ifTable")
+	require.NoError(t, err)
+	assert.Equal(t, "IF-MIB", mibName)
+	assert.Equal(t, ".1.3.6.1.2.1.2.2", oidNum)
+	assert.Equal(t, "ifTable", oidText)
+	assert.Equal(t, []Column{
+		{Name: "ifIndex", Oid: ".1.3.6.1.2.1.2.2.1.1", IsTag: true},
+		{Name: "ifDescr", Oid: ".1.3.6.1.2.1.2.2.1.2"},
+		{Name: "ifPhysAddress", Oid: ".1.3.6.1.2.1.2.2.1.6"},
+		{Name: "ifAdminStatus", Oid: ".1.3.6.1.2.1.2.2.1.7"},
+		{Name: "ifOperStatus", Oid: ".1.3.6.1.2.1.2.2.1.8"},
+	}, columns)
+
+	_, _, oidText, columns, err = TranslateTable(".1.3.6.1.2.1.31.1.1")
+	require.NoError(t, err)
+	assert.Equal(t, "ifXTable", oidText)
+	assert.Equal(t, []Column{
+		{Name: "ifName", Oid: ".1.3.6.1.2.1.31.1.1.1.1"},
+		{Name: "ifHCInOctets", Oid: ".1.3.6.1.2.1.31.1.1.1.6"},
+		{Name: "ifPromiscuousMode", Oid: ".1.3.6.1.2.1.31.1.1.1.16"},
+	}, columns)
+
+	_, _, _, _, err = TranslateTable("IF-MIB::ifDescr")
+	require.Error(t, err)
+}
+
+func TestTranslateEnums(t *testing.T) {
+	assert.Equal(t, map[int]string{1: "up", 2: "down", 3: "testing"}, TranslateEnums("IF-MIB::ifAdminStatus.1"))
+	assert.Equal(t, map[int]string{1: "true", 2: "false"}, TranslateEnums("IF-MIB::ifPromiscuousMode"))
+	assert.Equal(t, map[int]string{0: "ok", 1: "warning", 2: "critical"}, TranslateEnums(".1.3.6.1.4.1.99999.1.0"))
+	assert.Nil(t, TranslateEnums("IF-MIB::ifDescr"))
+	assert.Nil(t, TranslateEnums(".999"))
+}
+
+func TestTranslateCache_miss(t *testing.T) {
+	translateCaches = nil
+	oid := "IF-MIB::ifPhysAddress.1"
+	mibName, oidNum, oidText, conversion, err := TranslateOid(oid)
+	assert.Len(t, translateCaches, 1)
+	tc := translateCaches[oid]
+	require.NotNil(t, tc)
+	assert.Equal(t, mibName, tc.mibName)
+	assert.Equal(t, oidNum, tc.oidNum)
+	assert.Equal(t, oidText, tc.oidText)
+	assert.Equal(t, conversion, tc.conversion)
+	assert.Equal(t, err, tc.err)
+}
+
+func TestTranslateCache_hit(t *testing.T) {
+	translateCaches = map[string]translateCache{
+		"foo": {
+			mibName:    "a",
+			oidNum:     "b",
+			oidText:    "c",
+			conversion: "d",
+			err:        fmt.Errorf("e"),
+		},
+	}
+	mibName, oidNum, oidText, conversion, err := TranslateOid("foo")
+	assert.Equal(t, "a", mibName)
+	assert.Equal(t, "b", oidNum)
+	assert.Equal(t, "c", oidText)
+	assert.Equal(t, "d", conversion)
+	assert.Equal(t, fmt.Errorf("e"), err)
+	translateCaches = nil
+}
+
+func TestTableCache_miss(t *testing.T) {
+	tableCaches = nil
+	oid := "IF-MIB::ifTable"
+	mibName, oidNum, oidText, columns, err := TranslateTable(oid)
+	assert.Len(t, tableCaches, 1)
+	tc := tableCaches[oid]
+	require.NotNil(t, tc)
+	assert.Equal(t, mibName, tc.mibName)
+	assert.Equal(t, oidNum, tc.oidNum)
+	assert.Equal(t, oidText, tc.oidText)
+	assert.Equal(t, columns, tc.columns)
+	assert.Equal(t, err, tc.err)
+}
+
+func TestTableCache_hit(t *testing.T) {
+	tableCaches = map[string]tableCache{
+		"foo": {
+			mibName: "a",
+			oidNum:  "b",
+			oidText: "c",
+			columns: []Column{{Name: "d"}},
+			err:     fmt.Errorf("e"),
+		},
+	}
+	mibName, oidNum, oidText, columns, err := TranslateTable("foo")
+	assert.Equal(t, "a", mibName)
+	assert.Equal(t, "b", oidNum)
+	assert.Equal(t, "c", oidText)
+	assert.Equal(t, []Column{{Name: "d"}}, columns)
+	assert.Equal(t, fmt.Errorf("e"), err)
+	tableCaches = nil
+}
+
+func TestLoadMibsFromPath(t *testing.T) {
+	// already loaded paths are skipped
+	require.NoError(t, LoadMibsFromPath([]string{"testdata", "./testdata/"}))
+	require.Error(t, LoadMibsFromPath([]string{"testdata/nonexistent"}))
+}
+
+func TestDefaultMibPaths(t *testing.T) {
+	defer os.Setenv("MIBDIRS", os.Getenv("MIBDIRS"))
+
+	os.Setenv("MIBDIRS", "testdata:testdata/nonexistent")
+	assert.Equal(t, []string{"testdata"}, DefaultMibPaths())
+
+	os.Setenv("MIBDIRS", "+testdata")
+	paths := DefaultMibPaths()
+	require.NotEmpty(t, paths)
+	assert.Equal(t, "testdata", paths[len(paths)-1])
+}
@@ -0,0 +1,40 @@
+// Package sqldriver registers the database/sql drivers shared by the sql
+// plugins and maps the driver names of the configuration to them.
+package sqldriver
+
+import (
+	"sort"
+	"strings"
+
+	_ "github.com/denisenkom/go-mssqldb"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/stdlib"
+)
+
+// aliases maps the driver names of the configuration to the names the drivers
+// are registered with.  Drivers depending on cgo add their names when built.
+var aliases = map[string]string{
+	"postgres":   "pgx",
+	"postgresql": "pgx",
+	"pgx":        "pgx",
+	"mysql":      "mysql",
+	"sqlserver":  "sqlserver",
+	"mssql":      "sqlserver",
+}
+
+// Lookup returns the name the driver is registered with, the driver name of
+// the configuration is case insensitive.
+func Lookup(name string) (string, bool) {
+	driver, ok := aliases[strings.ToLower(name)]
+	return driver, ok
+}
+
+// Names returns the valid driver names of the configuration.
+func Names() []string {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
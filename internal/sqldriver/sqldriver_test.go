@@ -0,0 +1,24 @@
+package sqldriver
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	driver, ok := Lookup("PostgreSQL")
+	require.True(t, ok)
+	require.Equal(t, "pgx", driver)
+
+	_, ok = Lookup("oracle")
+	require.False(t, ok)
+}
+
+func TestNames(t *testing.T) {
+	names := Names()
+	require.Contains(t, names, "mysql")
+	require.Contains(t, names, "mssql")
+	require.True(t, sort.StringsAreSorted(names))
+}
@@ -0,0 +1,13 @@
+// +build cgo
+
+package sqldriver
+
+import (
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The SQLite driver is only available when built with cgo.
+func init() {
+	aliases["sqlite"] = "sqlite3"
+	aliases["sqlite3"] = "sqlite3"
+}
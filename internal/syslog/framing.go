@@ -0,0 +1,106 @@
+package syslog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Framing represents the framing technique used to send syslog messages over
+// a stream transport, as described in RFC6587.
+type Framing int
+
+const (
+	// OctetCounting prefixes each message with its length (RFC5425).
+	OctetCounting Framing = iota
+	// NonTransparent terminates each message with a trailer character.
+	NonTransparent
+)
+
+var framingNames = []string{"octet-counting", "non-transparent"}
+
+func (f Framing) String() string {
+	if f < OctetCounting || f > NonTransparent {
+		return ""
+	}
+	return framingNames[f]
+}
+
+// UnmarshalTOML implements toml.Unmarshaler.
+func (f *Framing) UnmarshalTOML(data []byte) error {
+	return f.UnmarshalText(data)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (f *Framing) UnmarshalText(data []byte) error {
+	s := strings.ToLower(strings.Trim(string(data), `"'`))
+	for i, name := range framingNames {
+		if s == name {
+			*f = Framing(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown framing %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (f Framing) MarshalText() ([]byte, error) {
+	s := f.String()
+	if s == "" {
+		return nil, fmt.Errorf("unknown framing %d", f)
+	}
+	return []byte(s), nil
+}
+
+// Trailer is the character terminating a message with non-transparent framing.
+type Trailer int
+
+const (
+	// LF is the line feed character, the default trailer.
+	LF Trailer = iota
+	// NUL is the null character.
+	NUL
+)
+
+var trailerNames = []string{"LF", "NUL"}
+var trailerBytes = []byte{'\n', 0}
+
+func (t Trailer) String() string {
+	if t < LF || t > NUL {
+		return ""
+	}
+	return trailerNames[t]
+}
+
+// Byte returns the character represented by the trailer.
+func (t Trailer) Byte() byte {
+	if t < LF || t > NUL {
+		return trailerBytes[LF]
+	}
+	return trailerBytes[t]
+}
+
+// UnmarshalTOML implements toml.Unmarshaler.
+func (t *Trailer) UnmarshalTOML(data []byte) error {
+	return t.UnmarshalText(data)
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *Trailer) UnmarshalText(data []byte) error {
+	s := strings.ToUpper(strings.Trim(string(data), `"'`))
+	for i, name := range trailerNames {
+		if s == name {
+			*t = Trailer(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown trailer %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t Trailer) MarshalText() ([]byte, error) {
+	s := t.String()
+	if s == "" {
+		return nil, fmt.Errorf("unknown trailer %d", t)
+	}
+	return []byte(s), nil
+}
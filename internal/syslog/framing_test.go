@@ -0,0 +1,47 @@
+package syslog
+
+import (
+	"testing"
+
+	"github.com/influxdata/toml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFraming(t *testing.T) {
+	var f Framing
+	require.NoError(t, f.UnmarshalTOML([]byte(`"non-transparent"`)))
+	assert.Equal(t, NonTransparent, f)
+	require.NoError(t, f.UnmarshalTOML([]byte(`'Octet-Counting'`)))
+	assert.Equal(t, OctetCounting, f)
+	require.Error(t, f.UnmarshalTOML([]byte(`"foo"`)))
+
+	b, err := NonTransparent.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "non-transparent", string(b))
+
+	_, err = Framing(42).MarshalText()
+	require.Error(t, err)
+}
+
+func TestTrailer(t *testing.T) {
+	var tr Trailer
+	require.NoError(t, tr.UnmarshalTOML([]byte(`"nul"`)))
+	assert.Equal(t, NUL, tr)
+	assert.Equal(t, byte(0), tr.Byte())
+	require.NoError(t, tr.UnmarshalTOML([]byte(`"LF"`)))
+	assert.Equal(t, LF, tr)
+	assert.Equal(t, byte('\n'), tr.Byte())
+	require.Error(t, tr.UnmarshalTOML([]byte(`"CRLF"`)))
+}
+
+func TestUnmarshalConfig(t *testing.T) {
+	var conf struct {
+		Framing Framing `toml:"framing"`
+		Trailer Trailer `toml:"trailer"`
+	}
+	err := toml.Unmarshal([]byte("framing = \"non-transparent\"\ntrailer = \"NUL\"\n"), &conf)
+	require.NoError(t, err)
+	assert.Equal(t, NonTransparent, conf.Framing)
+	assert.Equal(t, NUL, conf.Trailer)
+}
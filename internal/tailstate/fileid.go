@@ -0,0 +1,15 @@
+// +build !windows
+
+package tailstate
+
+import (
+	"os"
+	"syscall"
+)
+
+func fileID(stat os.FileInfo) (uint64, uint64) {
+	if sys, ok := stat.Sys().(*syscall.Stat_t); ok {
+		return uint64(sys.Ino), uint64(sys.Dev)
+	}
+	return 0, 0
+}
@@ -0,0 +1,36 @@
+package tailstate
+
+import (
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+// ReopenLogger is a logger for the tail package reporting when a tailed file
+// is reopened after being rotated or truncated, which the tail package does
+// not signal otherwise.  The lines read afterwards start at the beginning of
+// the reopened file.
+type ReopenLogger struct {
+	*log.Logger
+
+	// Reopened receives a value once the file is reopened.  It is not
+	// buffered, so it is received after the lines of the previous file and
+	// before the lines of the reopened one.
+	Reopened chan struct{}
+}
+
+// NewReopenLogger returns a ReopenLogger discarding the messages.
+func NewReopenLogger() *ReopenLogger {
+	return &ReopenLogger{
+		Logger:   log.New(ioutil.Discard, "", 0),
+		Reopened: make(chan struct{}),
+	}
+}
+
+// Printf signals Reopened on the messages logged by the tail package once a
+// file was reopened.
+func (l *ReopenLogger) Printf(format string, v ...interface{}) {
+	if strings.HasPrefix(format, "Successfully reopened") {
+		l.Reopened <- struct{}{}
+	}
+}
@@ -0,0 +1,188 @@
+// Package tailstate persists the read position of tailed files, allowing
+// them to be resumed after a restart.
+package tailstate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fingerprintSize is the maximum number of bytes at the start of a file used
+// to identify it.
+const fingerprintSize = 1024
+
+// Status describes how a file changed since its position was saved.
+type Status int
+
+const (
+	// NotFound means no position was saved for the file.
+	NotFound Status = iota
+	// Unchanged means the file can be resumed from the saved position.
+	Unchanged
+	// Truncated means the file is smaller than the saved position or its
+	// content was replaced.
+	Truncated
+	// Rotated means the path now refers to a different file.
+	Rotated
+)
+
+func (s Status) String() string {
+	switch s {
+	case Unchanged:
+		return "unchanged"
+	case Truncated:
+		return "truncated"
+	case Rotated:
+		return "rotated"
+	}
+	return "not found"
+}
+
+// FileState is the saved position and identity of a file.
+type FileState struct {
+	Offset          int64  `json:"offset"`
+	Inode           uint64 `json:"inode"`
+	Device          uint64 `json:"device"`
+	Fingerprint     string `json:"fingerprint"`
+	FingerprintSize int64  `json:"fingerprint_size"`
+}
+
+// State holds the positions of the tailed files and stores them in a file.
+type State struct {
+	path  string
+	files map[string]*FileState
+
+	sync.Mutex
+}
+
+// Load reads the state stored at path.  A missing file results in an empty
+// state.
+func Load(path string) (*State, error) {
+	s := &State{
+		path:  path,
+		files: make(map[string]*FileState),
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bytes.TrimSpace(buf)) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(buf, &s.files); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Resume returns the offset to start reading the file from and how the file
+// changed since its position was saved.  A truncated or rotated file is read
+// from the beginning.
+func (s *State) Resume(filename string) (int64, Status) {
+	s.Lock()
+	defer s.Unlock()
+
+	saved, ok := s.files[filename]
+	if !ok {
+		return 0, NotFound
+	}
+
+	current, size, err := identify(filename, saved.FingerprintSize)
+	if err != nil {
+		return 0, NotFound
+	}
+
+	if current.Inode != saved.Inode || current.Device != saved.Device {
+		return 0, Rotated
+	}
+	if current.Fingerprint != saved.Fingerprint || size < saved.Offset {
+		return 0, Truncated
+	}
+	return saved.Offset, Unchanged
+}
+
+// Set records the offset of the file along with its current identity.
+func (s *State) Set(filename string, offset int64) error {
+	current, _, err := identify(filename, fingerprintSize)
+	if err != nil {
+		return err
+	}
+	current.Offset = offset
+
+	s.Lock()
+	s.files[filename] = current
+	s.Unlock()
+	return nil
+}
+
+// Save writes the state, dropping the files that no longer exist.
+func (s *State) Save() error {
+	s.Lock()
+	for filename := range s.files {
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			delete(s.files, filename)
+		}
+	}
+	buf, err := json.Marshal(s.files)
+	s.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so the state is never partially
+	// written.
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// identify returns the identity of the file, using up to size bytes of its
+// content, and the size of the file.
+func identify(filename string, size int64) (*FileState, int64, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	head := make([]byte, size)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, 0, err
+	}
+
+	state := &FileState{
+		Fingerprint:     fmt.Sprintf("%08x", crc32.ChecksumIEEE(head[:n])),
+		FingerprintSize: int64(n),
+	}
+	state.Inode, state.Device = fileID(stat)
+	return state, stat.Size(), nil
+}
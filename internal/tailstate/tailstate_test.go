@@ -0,0 +1,131 @@
+package tailstate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	statePath := filepath.Join(dir, "state")
+	logPath := filepath.Join(dir, "test.log")
+	require.NoError(t, ioutil.WriteFile(logPath, []byte("line 1\nline 2\n"), 0644))
+
+	s, err := Load(statePath)
+	require.NoError(t, err)
+
+	_, status := s.Resume(logPath)
+	require.Equal(t, NotFound, status)
+
+	require.NoError(t, s.Set(logPath, 7))
+	require.NoError(t, s.Save())
+
+	s, err = Load(statePath)
+	require.NoError(t, err)
+
+	offset, status := s.Resume(logPath)
+	require.Equal(t, Unchanged, status)
+	require.Equal(t, int64(7), offset)
+}
+
+func TestResumeAppended(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "test.log")
+	require.NoError(t, ioutil.WriteFile(logPath, []byte("line 1\n"), 0644))
+
+	s, err := Load(filepath.Join(dir, "state"))
+	require.NoError(t, err)
+	require.NoError(t, s.Set(logPath, 7))
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("line 2\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	offset, status := s.Resume(logPath)
+	require.Equal(t, Unchanged, status)
+	require.Equal(t, int64(7), offset)
+}
+
+func TestResumeTruncated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "test.log")
+	require.NoError(t, ioutil.WriteFile(logPath, []byte("line 1\nline 2\n"), 0644))
+
+	s, err := Load(filepath.Join(dir, "state"))
+	require.NoError(t, err)
+	require.NoError(t, s.Set(logPath, 14))
+
+	require.NoError(t, os.Truncate(logPath, 0))
+
+	offset, status := s.Resume(logPath)
+	require.Equal(t, Truncated, status)
+	require.Equal(t, int64(0), offset)
+}
+
+func TestResumeRotated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "test.log")
+	require.NoError(t, ioutil.WriteFile(logPath, []byte("line 1\nline 2\n"), 0644))
+
+	s, err := Load(filepath.Join(dir, "state"))
+	require.NoError(t, err)
+	require.NoError(t, s.Set(logPath, 14))
+
+	require.NoError(t, os.Rename(logPath, logPath+".1"))
+	require.NoError(t, ioutil.WriteFile(logPath, []byte("line 3\nline 4\nline 5\n"), 0644))
+
+	offset, status := s.Resume(logPath)
+	require.Equal(t, Rotated, status)
+	require.Equal(t, int64(0), offset)
+}
+
+func TestSaveDropsMissingFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	statePath := filepath.Join(dir, "state")
+	logPath := filepath.Join(dir, "test.log")
+	require.NoError(t, ioutil.WriteFile(logPath, []byte("line 1\n"), 0644))
+
+	s, err := Load(statePath)
+	require.NoError(t, err)
+	require.NoError(t, s.Set(logPath, 7))
+	require.NoError(t, os.Remove(logPath))
+	require.NoError(t, s.Save())
+
+	buf, err := ioutil.ReadFile(statePath)
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(buf))
+}
+
+func TestReopenLogger(t *testing.T) {
+	l := NewReopenLogger()
+	l.Printf("Seeked %s - %+v\n", "test.log", nil)
+
+	done := make(chan struct{})
+	go func() {
+		l.Printf("Successfully reopened truncated %s", "test.log")
+		close(done)
+	}()
+	<-l.Reopened
+	<-done
+}
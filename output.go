@@ -1,5 +1,7 @@
 package telegraf
 
+import "context"
+
 type Output interface {
 	// Connect to the Output
 	Connect() error
@@ -13,6 +15,16 @@ type Output interface {
 	Write(metrics []Metric) error
 }
 
+// ContextOutput is an Output whose writes can be cancelled.
+type ContextOutput interface {
+	Output
+
+	// WriteContext is called instead of Write.  The context is done when the
+	// agent is shutting down, the Output should then abort the write and
+	// return an error so the metrics are kept for the final flush.
+	WriteContext(ctx context.Context, metrics []Metric) error
+}
+
 // AggregatingOutput adds aggregating functionality to an Output.  May be used
 // if the Output only accepts a fixed set of aggregations over a time period.
 // These functions may be called concurrently to the Write function.
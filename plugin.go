@@ -0,0 +1,11 @@
+package telegraf
+
+// Initializer is an interface that all plugin types: Inputs, Outputs,
+// Processors, and Aggregators can optionally implement to initialize the
+// plugin.
+type Initializer interface {
+	// Init performs one time setup of the plugin and returns an error if the
+	// configuration is invalid.  It is called once the configuration has been
+	// loaded, before any other method of the plugin.
+	Init() error
+}
@@ -0,0 +1,601 @@
+package docker_log
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	defaultEndpoint = "unix:///var/run/docker.sock"
+
+	// retryInterval is the time to wait before reconnecting to the events
+	// stream after an error.
+	retryInterval = 5 * time.Second
+)
+
+var sampleConfig = `
+  ## Docker Endpoint
+  ##   To use TCP, set endpoint = "tcp://[ip]:[port]"
+  ##   To use environment variables (ie, docker-machine), set endpoint = "ENV"
+  # endpoint = "unix:///var/run/docker.sock"
+
+  ## Report container lifecycle events from the events API.
+  # gather_events = true
+
+  ## Tail the stdout and stderr of the containers.
+  # gather_logs = true
+
+  ## When true, read the logs of the containers running at startup from
+  ## the beginning instead of only new lines.  Containers started later are
+  ## always read from the beginning.
+  # from_beginning = false
+
+  ## File used to remember the time of the last event and log line of each
+  ## container, so that they are resumed instead of skipped or repeated
+  ## when Telegraf restarts.  The file is updated every interval.
+  # state_file = "/var/lib/telegraf/docker_log.json"
+
+  ## Containers to include and exclude. Globs accepted.
+  ## Note that an empty array for both will include all containers
+  # container_name_include = []
+  # container_name_exclude = []
+
+  ## Event actions to include and exclude. Globs accepted.
+  ## Note that an empty array for both will include all container events
+  # event_action_include = ["start", "die", "oom", "health_status"]
+  # event_action_exclude = []
+
+  ## Timeout for docker list and inspect commands
+  # timeout = "5s"
+
+  ## docker labels to include and exclude as tags.  Globs accepted.
+  ## Note that an empty array for both will include all labels as tags
+  # docker_label_include = []
+  # docker_label_exclude = []
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+// DockerLogs streams container events and logs from the Docker daemon.
+type DockerLogs struct {
+	Endpoint      string            `toml:"endpoint"`
+	Timeout       internal.Duration `toml:"timeout"`
+	GatherEvents  bool              `toml:"gather_events"`
+	GatherLogs    bool              `toml:"gather_logs"`
+	FromBeginning bool              `toml:"from_beginning"`
+	StateFile     string            `toml:"state_file"`
+
+	ContainerInclude []string `toml:"container_name_include"`
+	ContainerExclude []string `toml:"container_name_exclude"`
+
+	EventActionInclude []string `toml:"event_action_include"`
+	EventActionExclude []string `toml:"event_action_exclude"`
+
+	LabelInclude []string `toml:"docker_label_include"`
+	LabelExclude []string `toml:"docker_label_exclude"`
+
+	tlsint.ClientConfig
+
+	newEnvClient func() (Client, error)
+	newClient    func(string, *tls.Config) (Client, error)
+
+	client          Client
+	containerFilter filter.Filter
+	actionFilter    filter.Filter
+	labelFilter     filter.Filter
+
+	acc    telegraf.Accumulator
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	tailing map[string]bool
+	state   state
+}
+
+// state is the position in the event and log streams.
+type state struct {
+	Events     time.Time            `json:"events"`
+	Containers map[string]time.Time `json:"containers"`
+}
+
+func (d *DockerLogs) Description() string {
+	return "Read container events and logs from the Docker daemon"
+}
+
+func (d *DockerLogs) SampleConfig() string { return sampleConfig }
+
+// Init creates the client and filters.
+func (d *DockerLogs) Init() error {
+	if !d.GatherEvents && !d.GatherLogs {
+		return errors.New("either gather_events or gather_logs must be enabled")
+	}
+
+	var err error
+	d.containerFilter, err = filter.NewIncludeExcludeFilter(d.ContainerInclude, d.ContainerExclude)
+	if err != nil {
+		return err
+	}
+	d.actionFilter, err = filter.NewIncludeExcludeFilter(d.EventActionInclude, d.EventActionExclude)
+	if err != nil {
+		return err
+	}
+	d.labelFilter, err = filter.NewIncludeExcludeFilter(d.LabelInclude, d.LabelExclude)
+	if err != nil {
+		return err
+	}
+
+	if d.Endpoint == "ENV" {
+		d.client, err = d.newEnvClient()
+		return err
+	}
+
+	tlsConfig, err := d.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	d.client, err = d.newClient(d.Endpoint, tlsConfig)
+	return err
+}
+
+// Gather saves the stream positions to the state file.
+func (d *DockerLogs) Gather(acc telegraf.Accumulator) error {
+	return d.saveState()
+}
+
+func (d *DockerLogs) Start(acc telegraf.Accumulator) error {
+	if d.client == nil {
+		if err := d.Init(); err != nil {
+			return err
+		}
+	}
+
+	if err := d.loadState(); err != nil {
+		return err
+	}
+
+	d.acc = acc
+	d.tailing = make(map[string]bool)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	now := time.Now()
+
+	if d.GatherLogs {
+		listCtx, listCancel := context.WithTimeout(ctx, d.Timeout.Duration)
+		defer listCancel()
+		containers, err := d.client.ContainerList(listCtx, types.ContainerListOptions{})
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		since := now
+		if d.FromBeginning {
+			since = time.Time{}
+		}
+		for _, c := range containers {
+			d.tail(ctx, c.ID, since)
+		}
+	}
+
+	d.mu.Lock()
+	if d.state.Events.IsZero() {
+		d.state.Events = now
+	}
+	d.mu.Unlock()
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.watchEvents(ctx)
+	}()
+
+	return nil
+}
+
+func (d *DockerLogs) Stop() {
+	d.cancel()
+	d.wg.Wait()
+
+	if err := d.saveState(); err != nil {
+		log.Printf("E! [inputs.docker_log] %v", err)
+	}
+}
+
+// watchEvents reads the events stream until the context is done,
+// reconnecting on errors.
+func (d *DockerLogs) watchEvents(ctx context.Context) {
+	for {
+		err := d.readEvents(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		d.acc.AddError(fmt.Errorf("reading events: %v", err))
+
+		if err := internal.SleepContext(ctx, retryInterval); err != nil {
+			return
+		}
+	}
+}
+
+func (d *DockerLogs) readEvents(ctx context.Context) error {
+	d.mu.Lock()
+	since := d.state.Events
+	d.mu.Unlock()
+
+	args := filters.NewArgs()
+	args.Add("type", events.ContainerEventType)
+	msgs, errs := d.client.Events(ctx, types.EventsOptions{
+		Since:   formatTime(since),
+		Filters: args,
+	})
+
+	for {
+		select {
+		case msg := <-msgs:
+			d.handleEvent(ctx, msg)
+		case err := <-errs:
+			return err
+		}
+	}
+}
+
+func (d *DockerLogs) handleEvent(ctx context.Context, msg events.Message) {
+	if msg.Type != events.ContainerEventType {
+		return
+	}
+
+	ts := time.Unix(0, msg.TimeNano)
+	d.mu.Lock()
+	if !ts.After(d.state.Events) {
+		d.mu.Unlock()
+		return
+	}
+	d.state.Events = ts
+	if msg.Action == "destroy" {
+		delete(d.state.Containers, msg.Actor.ID)
+	}
+	d.mu.Unlock()
+
+	name := msg.Actor.Attributes["name"]
+	if !d.containerFilter.Match(name) {
+		return
+	}
+
+	// Some actions carry details, such as "health_status: healthy".
+	action, detail := msg.Action, ""
+	if i := strings.Index(action, ":"); i >= 0 {
+		action, detail = action[:i], strings.TrimSpace(action[i+1:])
+	}
+
+	if d.GatherLogs && action == "start" {
+		d.tail(ctx, msg.Actor.ID, time.Time{})
+	}
+
+	if !d.GatherEvents || !d.actionFilter.Match(action) {
+		return
+	}
+
+	imageName, imageVersion := parseImage(msg.Actor.Attributes["image"])
+	tags := map[string]string{
+		"container_name":    name,
+		"container_image":   imageName,
+		"container_version": imageVersion,
+		"action":            action,
+	}
+	fields := map[string]interface{}{
+		"container_id": msg.Actor.ID,
+	}
+
+	for k, v := range msg.Actor.Attributes {
+		switch k {
+		case "name", "image":
+		case "exitCode":
+			if code, err := strconv.Atoi(v); err == nil {
+				fields["exit_code"] = code
+			}
+		case "signal":
+			fields["signal"] = v
+		default:
+			// The remaining attributes are the labels of the container.
+			if d.labelFilter.Match(k) {
+				tags[k] = v
+			}
+		}
+	}
+
+	if action == "health_status" {
+		fields["health_status"] = detail
+	}
+
+	d.acc.AddFields("docker_event", fields, tags, ts)
+}
+
+// tail starts reading the logs of a container if they are not read already.
+// Lines before since are skipped unless the position of the container is
+// known from the state.
+func (d *DockerLogs) tail(ctx context.Context, id string, since time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.tailing[id] {
+		return
+	}
+	d.tailing[id] = true
+
+	if last, ok := d.state.Containers[id]; ok {
+		since = last
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+
+		err := d.tailContainer(ctx, id, since)
+		if err != nil && ctx.Err() == nil {
+			d.acc.AddError(fmt.Errorf("reading logs of container %s: %v", id, err))
+		}
+
+		d.mu.Lock()
+		delete(d.tailing, id)
+		d.mu.Unlock()
+	}()
+}
+
+func (d *DockerLogs) tailContainer(ctx context.Context, id string, since time.Time) error {
+	inspectCtx, cancel := context.WithTimeout(ctx, d.Timeout.Duration)
+	defer cancel()
+	info, err := d.client.ContainerInspect(inspectCtx, id)
+	if err != nil {
+		return err
+	}
+	if info.ContainerJSONBase == nil || info.Config == nil {
+		return errors.New("incomplete container information")
+	}
+
+	name := strings.TrimPrefix(info.Name, "/")
+	if !d.containerFilter.Match(name) {
+		return nil
+	}
+
+	imageName, imageVersion := parseImage(info.Config.Image)
+	tags := map[string]string{
+		"container_name":    name,
+		"container_image":   imageName,
+		"container_version": imageVersion,
+	}
+	for k, v := range info.Config.Labels {
+		if d.labelFilter.Match(k) {
+			tags[k] = v
+		}
+	}
+
+	r, err := d.client.ContainerLogs(ctx, id, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Timestamps: true,
+		Since:      formatTime(since),
+	})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	addLine := func(stream string, line []byte) {
+		d.addLog(id, tags, stream, line)
+	}
+
+	// Without a terminal stdout and stderr are multiplexed in one stream.
+	if info.Config.Tty {
+		return readLines(r, "tty", addLine)
+	}
+	return readMultiplexed(r, addLine)
+}
+
+// addLog adds a log line prefixed with its timestamp, lines that are not
+// newer than the last line of the container are skipped.
+func (d *DockerLogs) addLog(id string, tags map[string]string, stream string, line []byte) {
+	ts := time.Now()
+	if i := bytes.IndexByte(line, ' '); i > 0 {
+		if t, err := time.Parse(time.RFC3339Nano, string(line[:i])); err == nil {
+			ts = t
+			line = line[i+1:]
+		}
+	}
+
+	d.mu.Lock()
+	if !ts.After(d.state.Containers[id]) {
+		d.mu.Unlock()
+		return
+	}
+	d.state.Containers[id] = ts
+	d.mu.Unlock()
+
+	ltags := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		ltags[k] = v
+	}
+	ltags["stream"] = stream
+
+	fields := map[string]interface{}{
+		"container_id": id,
+		"message":      string(line),
+	}
+	d.acc.AddFields("docker_log", fields, ltags, ts)
+}
+
+func (d *DockerLogs) loadState() error {
+	d.state = state{Containers: make(map[string]time.Time)}
+	if d.StateFile == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(d.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &d.state); err != nil {
+		return fmt.Errorf("parsing state file %s: %v", d.StateFile, err)
+	}
+	if d.state.Containers == nil {
+		d.state.Containers = make(map[string]time.Time)
+	}
+	return nil
+}
+
+func (d *DockerLogs) saveState() error {
+	if d.StateFile == "" {
+		return nil
+	}
+
+	d.mu.Lock()
+	data, err := json.Marshal(d.state)
+	d.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that the state is never truncated.
+	tmp := d.StateFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, d.StateFile)
+}
+
+// readMultiplexed reads a log stream in which stdout and stderr are sent in
+// frames with an 8 byte header: the stream type, three bytes padding and the
+// big endian frame size.  The frames are split into lines.
+func readMultiplexed(r io.Reader, fn func(stream string, line []byte)) error {
+	var header [8]byte
+	partial := make(map[string][]byte)
+
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if err == io.EOF {
+				for stream, data := range partial {
+					if len(data) > 0 {
+						fn(stream, data)
+					}
+				}
+				return nil
+			}
+			return err
+		}
+
+		var stream string
+		switch header[0] {
+		case 1:
+			stream = "stdout"
+		case 2:
+			stream = "stderr"
+		default:
+			return fmt.Errorf("unknown stream type %d", header[0])
+		}
+
+		frame := make([]byte, binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return err
+		}
+
+		data := append(partial[stream], frame...)
+		for {
+			i := bytes.IndexByte(data, '\n')
+			if i < 0 {
+				break
+			}
+			fn(stream, bytes.TrimSuffix(data[:i], []byte("\r")))
+			data = data[i+1:]
+		}
+		partial[stream] = data
+	}
+}
+
+// readLines reads a log stream of a container with a terminal.
+func readLines(r io.Reader, stream string, fn func(stream string, line []byte)) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+		if len(line) > 0 {
+			fn(stream, line)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// parseImage splits an image into its name and version.  The image name may
+// include a private repo with a port, such as
+// docker.someco.net:4443/rabbitmq:3-management.
+func parseImage(image string) (string, string) {
+	imageName := image
+	imageVersion := "unknown"
+	i := strings.LastIndex(image, ":")
+	if i > -1 && !strings.Contains(image[i+1:], "/") {
+		imageVersion = image[i+1:]
+		imageName = image[:i]
+	}
+	return imageName, imageVersion
+}
+
+// formatTime formats a time as expected by the since parameter of the Docker
+// API, the zero time is formatted as the empty string.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
+func init() {
+	inputs.Add("docker_log", func() telegraf.Input {
+		return &DockerLogs{
+			Endpoint:     defaultEndpoint,
+			Timeout:      internal.Duration{Duration: time.Second * 5},
+			GatherEvents: true,
+			GatherLogs:   true,
+			newEnvClient: NewEnvClient,
+			newClient:    NewClient,
+		}
+	})
+}
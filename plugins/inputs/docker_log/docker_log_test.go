@@ -0,0 +1,394 @@
+package docker_log
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type MockClient struct {
+	sync.Mutex
+
+	containers []types.Container
+	inspect    map[string]types.ContainerJSON
+	logs       map[string][]byte
+	events     chan events.Message
+
+	logOptions    map[string]types.ContainerLogsOptions
+	eventsOptions []types.EventsOptions
+}
+
+func newMockClient() *MockClient {
+	return &MockClient{
+		inspect:    make(map[string]types.ContainerJSON),
+		logs:       make(map[string][]byte),
+		events:     make(chan events.Message),
+		logOptions: make(map[string]types.ContainerLogsOptions),
+	}
+}
+
+func (c *MockClient) addContainer(id, name string, tty bool, logs []byte) {
+	c.inspect[id] = types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:   id,
+			Name: "/" + name,
+		},
+		Config: &container.Config{
+			Image:  "registry.local:5000/app:1.2",
+			Labels: map[string]string{"com.example.team": "ops"},
+			Tty:    tty,
+		},
+	}
+	c.logs[id] = logs
+}
+
+func (c *MockClient) ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+	return c.containers, nil
+}
+
+func (c *MockClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	return c.inspect[containerID], nil
+}
+
+func (c *MockClient) ContainerLogs(ctx context.Context, containerID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	c.Lock()
+	defer c.Unlock()
+	c.logOptions[containerID] = options
+	return ioutil.NopCloser(bytes.NewReader(c.logs[containerID])), nil
+}
+
+func (c *MockClient) Events(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
+	c.Lock()
+	c.eventsOptions = append(c.eventsOptions, options)
+	c.Unlock()
+
+	errs := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		errs <- ctx.Err()
+	}()
+	return c.events, errs
+}
+
+func (c *MockClient) LogOptions(id string) types.ContainerLogsOptions {
+	c.Lock()
+	defer c.Unlock()
+	return c.logOptions[id]
+}
+
+func frame(stream byte, data string) []byte {
+	header := []byte{stream, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	return append(header, data...)
+}
+
+func newDockerLogs(client *MockClient) *DockerLogs {
+	return &DockerLogs{
+		Endpoint:     defaultEndpoint,
+		Timeout:      internal.Duration{Duration: 5 * time.Second},
+		GatherEvents: true,
+		GatherLogs:   true,
+		newClient: func(string, *tls.Config) (Client, error) {
+			return client, nil
+		},
+	}
+}
+
+func TestReadMultiplexed(t *testing.T) {
+	var data []byte
+	data = append(data, frame(1, "first\nsec")...)
+	data = append(data, frame(2, "error\r\n")...)
+	data = append(data, frame(1, "ond\nlast")...)
+
+	var lines []string
+	err := readMultiplexed(bytes.NewReader(data), func(stream string, line []byte) {
+		lines = append(lines, stream+": "+string(line))
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"stdout: first",
+		"stderr: error",
+		"stdout: second",
+		"stdout: last",
+	}, lines)
+}
+
+func TestReadMultiplexedInvalidStream(t *testing.T) {
+	err := readMultiplexed(bytes.NewReader(frame(5, "x\n")), func(string, []byte) {})
+	require.Error(t, err)
+}
+
+func TestReadLines(t *testing.T) {
+	var lines []string
+	err := readLines(bytes.NewBufferString("one\r\ntwo\nthree"), "tty", func(stream string, line []byte) {
+		lines = append(lines, stream+": "+string(line))
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tty: one", "tty: two", "tty: three"}, lines)
+}
+
+func TestParseImage(t *testing.T) {
+	tests := []struct {
+		image   string
+		name    string
+		version string
+	}{
+		{"rabbitmq:3-management", "rabbitmq", "3-management"},
+		{"docker.someco.net:4443/rabbitmq:3-management", "docker.someco.net:4443/rabbitmq", "3-management"},
+		{"docker.someco.net:4443/rabbitmq", "docker.someco.net:4443/rabbitmq", "unknown"},
+		{"rabbitmq", "rabbitmq", "unknown"},
+	}
+	for _, tt := range tests {
+		name, version := parseImage(tt.image)
+		assert.Equal(t, tt.name, name)
+		assert.Equal(t, tt.version, version)
+	}
+}
+
+func TestLogs(t *testing.T) {
+	client := newMockClient()
+	var logs []byte
+	logs = append(logs, frame(1, "2018-10-01T12:00:00.000000001Z hello\n")...)
+	logs = append(logs, frame(2, "2018-10-01T12:00:01.000000002Z oops\n")...)
+	client.addContainer("abc", "app", false, logs)
+	client.addContainer("tty", "console", true,
+		[]byte("2018-10-01T12:00:02Z $ ls\r\n"))
+	client.containers = []types.Container{{ID: "abc"}, {ID: "tty"}}
+
+	d := newDockerLogs(client)
+	d.GatherEvents = false
+	d.FromBeginning = true
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+	acc.Wait(3)
+	d.Stop()
+
+	assert.Equal(t, "", client.LogOptions("abc").Since)
+	assert.True(t, client.LogOptions("abc").Timestamps)
+
+	tags := map[string]string{
+		"container_name":    "app",
+		"container_image":   "registry.local:5000/app",
+		"container_version": "1.2",
+		"com.example.team":  "ops",
+		"stream":            "stdout",
+	}
+	acc.AssertContainsTaggedFields(t, "docker_log",
+		map[string]interface{}{
+			"container_id": "abc",
+			"message":      "hello",
+		}, tags)
+
+	tags["stream"] = "stderr"
+	acc.AssertContainsTaggedFields(t, "docker_log",
+		map[string]interface{}{
+			"container_id": "abc",
+			"message":      "oops",
+		}, tags)
+
+	tags["container_name"] = "console"
+	tags["stream"] = "tty"
+	acc.AssertContainsTaggedFields(t, "docker_log",
+		map[string]interface{}{
+			"container_id": "tty",
+			"message":      "$ ls",
+		}, tags)
+
+	m, ok := acc.Get("docker_log")
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2018, 10, 1, 12, 0, 0, 1, time.UTC), m.Time)
+}
+
+func TestLogsContainerFilter(t *testing.T) {
+	client := newMockClient()
+	client.addContainer("abc", "app", false,
+		frame(1, "2018-10-01T12:00:00Z hello\n"))
+	client.addContainer("def", "db", false,
+		frame(1, "2018-10-01T12:00:00Z hello\n"))
+	client.containers = []types.Container{{ID: "abc"}, {ID: "def"}}
+
+	d := newDockerLogs(client)
+	d.GatherEvents = false
+	d.FromBeginning = true
+	d.ContainerExclude = []string{"db"}
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+	acc.Wait(1)
+	d.Stop()
+
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t, "app", acc.Metrics[0].Tags["container_name"])
+	_, ok := client.logOptions["def"]
+	assert.False(t, ok)
+}
+
+func TestEvents(t *testing.T) {
+	client := newMockClient()
+	client.addContainer("abc", "app", false,
+		frame(1, "2018-10-01T12:00:00Z started\n"))
+
+	d := newDockerLogs(client)
+	d.EventActionInclude = []string{"start", "die", "health_status"}
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+
+	attributes := map[string]string{
+		"name":             "app",
+		"image":            "app:1.2",
+		"com.example.team": "ops",
+	}
+	now := time.Now().UnixNano()
+	client.events <- events.Message{
+		Type:     events.ContainerEventType,
+		Action:   "start",
+		Actor:    events.Actor{ID: "abc", Attributes: attributes},
+		TimeNano: now + 1,
+	}
+	client.events <- events.Message{
+		Type:     events.ContainerEventType,
+		Action:   "health_status: healthy",
+		Actor:    events.Actor{ID: "abc", Attributes: attributes},
+		TimeNano: now + 2,
+	}
+	client.events <- events.Message{
+		Type:     events.ContainerEventType,
+		Action:   "exec_create: sh",
+		Actor:    events.Actor{ID: "abc", Attributes: attributes},
+		TimeNano: now + 3,
+	}
+	dieAttributes := map[string]string{"exitCode": "137"}
+	for k, v := range attributes {
+		dieAttributes[k] = v
+	}
+	client.events <- events.Message{
+		Type:     events.ContainerEventType,
+		Action:   "die",
+		Actor:    events.Actor{ID: "abc", Attributes: dieAttributes},
+		TimeNano: now + 4,
+	}
+	// Events already seen are skipped.
+	client.events <- events.Message{
+		Type:     events.ContainerEventType,
+		Action:   "start",
+		Actor:    events.Actor{ID: "abc", Attributes: attributes},
+		TimeNano: now + 1,
+	}
+
+	acc.Wait(4)
+	d.Stop()
+
+	tags := map[string]string{
+		"container_name":    "app",
+		"container_image":   "app",
+		"container_version": "1.2",
+		"com.example.team":  "ops",
+	}
+
+	tags["action"] = "start"
+	acc.AssertContainsTaggedFields(t, "docker_event",
+		map[string]interface{}{
+			"container_id": "abc",
+		}, tags)
+
+	tags["action"] = "health_status"
+	acc.AssertContainsTaggedFields(t, "docker_event",
+		map[string]interface{}{
+			"container_id":  "abc",
+			"health_status": "healthy",
+		}, tags)
+
+	tags["action"] = "die"
+	acc.AssertContainsTaggedFields(t, "docker_event",
+		map[string]interface{}{
+			"container_id": "abc",
+			"exit_code":    137,
+		}, tags)
+
+	var eventCount int
+	for _, m := range acc.Metrics {
+		if m.Measurement == "docker_event" {
+			eventCount++
+		}
+	}
+	assert.Equal(t, 3, eventCount)
+
+	// The log of the started container is read from the beginning.
+	assert.Equal(t, "", client.LogOptions("abc").Since)
+	acc.AssertContainsFields(t, "docker_log",
+		map[string]interface{}{
+			"container_id": "abc",
+			"message":      "started",
+		})
+}
+
+func TestStateResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker_log")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "state.json")
+
+	client := newMockClient()
+	var logs []byte
+	logs = append(logs, frame(1, "2018-10-01T12:00:00Z one\n")...)
+	logs = append(logs, frame(1, "2018-10-01T12:00:01Z two\n")...)
+	client.addContainer("abc", "app", false, logs)
+	client.containers = []types.Container{{ID: "abc"}}
+
+	d := newDockerLogs(client)
+	d.FromBeginning = true
+	d.StateFile = stateFile
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+	acc.Wait(2)
+	require.NoError(t, d.Gather(&acc))
+	d.Stop()
+
+	// Restart with a log containing an old and a new line.
+	logs = append(logs, frame(1, "2018-10-01T12:00:02Z three\n")...)
+	client = newMockClient()
+	client.addContainer("abc", "app", false, logs)
+	client.containers = []types.Container{{ID: "abc"}}
+
+	d = newDockerLogs(client)
+	d.StateFile = stateFile
+
+	acc = testutil.Accumulator{}
+	require.NoError(t, d.Start(&acc))
+	acc.Wait(1)
+	d.Stop()
+
+	expected := time.Date(2018, 10, 1, 12, 0, 1, 0, time.UTC)
+	assert.Equal(t, formatTime(expected), client.LogOptions("abc").Since)
+	require.Len(t, client.eventsOptions, 1)
+	assert.NotEmpty(t, client.eventsOptions[0].Since)
+
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t, "three", acc.Metrics[0].Fields["message"])
+}
+
+func TestInitNothingEnabled(t *testing.T) {
+	d := newDockerLogs(newMockClient())
+	d.GatherEvents = false
+	d.GatherLogs = false
+	require.Error(t, d.Init())
+}
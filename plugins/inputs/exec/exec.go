@@ -2,6 +2,7 @@ package exec
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os/exec"
 	"path/filepath"
@@ -61,7 +62,7 @@ func NewExec() *Exec {
 }
 
 type Runner interface {
-	Run(*Exec, string, telegraf.Accumulator) ([]byte, error)
+	Run(context.Context, *Exec, string, telegraf.Accumulator) ([]byte, error)
 }
 
 type CommandRunner struct{}
@@ -87,6 +88,7 @@ func AddNagiosState(exitCode error, acc telegraf.Accumulator) error {
 }
 
 func (c CommandRunner) Run(
+	ctx context.Context,
 	e *Exec,
 	command string,
 	acc telegraf.Accumulator,
@@ -96,7 +98,8 @@ func (c CommandRunner) Run(
 		return nil, fmt.Errorf("exec: unable to parse command, %s", err)
 	}
 
-	cmd := exec.Command(split_cmd[0], split_cmd[1:]...)
+	// The command is killed when the context is done, like on timeout.
+	cmd := exec.CommandContext(ctx, split_cmd[0], split_cmd[1:]...)
 
 	var (
 		out    bytes.Buffer
@@ -106,6 +109,9 @@ func (c CommandRunner) Run(
 	cmd.Stderr = &stderr
 
 	if err := internal.RunTimeout(cmd, e.Timeout.Duration); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("exec: %s for command '%s'", ctx.Err(), command)
+		}
 		switch e.parser.(type) {
 		case *nagios.NagiosParser:
 			AddNagiosState(err, acc)
@@ -171,10 +177,10 @@ func removeCarriageReturns(b bytes.Buffer) bytes.Buffer {
 
 }
 
-func (e *Exec) ProcessCommand(command string, acc telegraf.Accumulator, wg *sync.WaitGroup) {
+func (e *Exec) ProcessCommand(ctx context.Context, command string, acc telegraf.Accumulator, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	out, err := e.runner.Run(e, command, acc)
+	out, err := e.runner.Run(ctx, e, command, acc)
 	if err != nil {
 		acc.AddError(err)
 		return
@@ -203,6 +209,12 @@ func (e *Exec) SetParser(parser parsers.Parser) {
 }
 
 func (e *Exec) Gather(acc telegraf.Accumulator) error {
+	return e.GatherContext(context.Background(), acc)
+}
+
+// GatherContext is like Gather but kills the commands still running when the
+// context is done.
+func (e *Exec) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
 	var wg sync.WaitGroup
 	// Legacy single command support
 	if e.Command != "" {
@@ -243,7 +255,7 @@ func (e *Exec) Gather(acc telegraf.Accumulator) error {
 
 	wg.Add(len(commands))
 	for _, command := range commands {
-		go e.ProcessCommand(command, acc, &wg)
+		go e.ProcessCommand(ctx, command, acc, &wg)
 	}
 	wg.Wait()
 	return nil
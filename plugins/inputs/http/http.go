@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -79,25 +80,40 @@ func (*HTTP) Description() string {
 	return "Read formatted metrics from one or more HTTP endpoints"
 }
 
+// Init creates the HTTP client, failing if the TLS settings are invalid.
+func (h *HTTP) Init() error {
+	tlsCfg, err := h.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	h.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+		Timeout: h.Timeout.Duration,
+	}
+	return nil
+}
+
 // Gather takes in an accumulator and adds the metrics that the Input
 // gathers. This is called every "interval"
 func (h *HTTP) Gather(acc telegraf.Accumulator) error {
+	return h.GatherContext(context.Background(), acc)
+}
+
+// GatherContext is like Gather but aborts outstanding requests when the
+// context is done.
+func (h *HTTP) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
 	if h.parser == nil {
 		return errors.New("Parser is not set")
 	}
 
 	if h.client == nil {
-		tlsCfg, err := h.ClientConfig.TLSConfig()
-		if err != nil {
+		if err := h.Init(); err != nil {
 			return err
 		}
-		h.client = &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: tlsCfg,
-				Proxy:           http.ProxyFromEnvironment,
-			},
-			Timeout: h.Timeout.Duration,
-		}
 	}
 
 	var wg sync.WaitGroup
@@ -105,7 +121,7 @@ func (h *HTTP) Gather(acc telegraf.Accumulator) error {
 		wg.Add(1)
 		go func(url string) {
 			defer wg.Done()
-			if err := h.gatherURL(acc, url); err != nil {
+			if err := h.gatherURL(ctx, acc, url); err != nil {
 				acc.AddError(fmt.Errorf("[url=%s]: %s", url, err))
 			}
 		}(u)
@@ -123,12 +139,14 @@ func (h *HTTP) SetParser(parser parsers.Parser) {
 
 // Gathers data from a particular URL
 // Parameters:
+//     ctx    : Context cancelling the request
 //     acc    : The telegraf Accumulator to use
 //     url    : endpoint to send request to
 //
 // Returns:
 //     error: Any error that may have occurred
 func (h *HTTP) gatherURL(
+	ctx context.Context,
 	acc telegraf.Accumulator,
 	url string,
 ) error {
@@ -136,6 +154,7 @@ func (h *HTTP) gatherURL(
 	if err != nil {
 		return err
 	}
+	request = request.WithContext(ctx)
 
 	for k, v := range h.Headers {
 		if strings.ToLower(k) == "host" {
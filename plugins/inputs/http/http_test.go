@@ -1,6 +1,7 @@
 package http_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -149,3 +150,37 @@ const simpleJSON = `
     "a": 1.2
 }
 `
+
+func TestInvalidTLSConfig(t *testing.T) {
+	plugin := &plugin.HTTP{
+		URLs: []string{"https://localhost/endpoint"},
+	}
+	plugin.TLSCA = "/nonexistent/ca.pem"
+
+	require.Error(t, plugin.Init())
+}
+
+func TestGatherContextCancelled(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(simpleJSON))
+	}))
+	defer fakeServer.Close()
+
+	plugin := &plugin.HTTP{
+		URLs: []string{fakeServer.URL + "/endpoint"},
+	}
+	p, _ := parsers.NewParser(&parsers.Config{
+		DataFormat: "json",
+		MetricName: "metricName",
+	})
+	plugin.SetParser(p)
+	require.NoError(t, plugin.Init())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.GatherContext(ctx, &acc))
+	require.Len(t, acc.Errors, 1)
+	require.Len(t, acc.Metrics, 0)
+}
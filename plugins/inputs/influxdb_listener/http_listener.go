@@ -5,11 +5,13 @@ import (
 	"compress/gzip"
 	"crypto/subtle"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -48,6 +50,10 @@ type HTTPListener struct {
 	BasicUsername string
 	BasicPassword string
 
+	Tokens    []string
+	BucketTag string
+	OrgTag    string
+
 	TimeFunc
 
 	mu sync.Mutex
@@ -103,6 +109,16 @@ const sampleConfig = `
   ## You probably want to make sure you have TLS configured above for this.
   # basic_username = "foobar"
   # basic_password = "barfoo"
+
+  ## Optional tokens to accept on the InfluxDB v2 write endpoint,
+  ## /api/v2/write, in the "Authorization: Token <token>" header.  If not
+  ## set, the endpoint uses the basic authentication above.
+  # tokens = ["my-token"]
+
+  ## Optional tag names to add the bucket and org of InfluxDB v2 writes to
+  ## the metrics as.
+  # bucket_tag = "bucket"
+  # org_tag = "org"
 `
 
 func (h *HTTPListener) SampleConfig() string {
@@ -214,6 +230,10 @@ func (h *HTTPListener) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 		h.WritesRecv.Incr(1)
 		defer h.WritesServed.Incr(1)
 		h.AuthenticateIfSet(h.serveWrite, res, req)
+	case "/api/v2/write":
+		h.WritesRecv.Incr(1)
+		defer h.WritesServed.Incr(1)
+		h.authenticateToken(h.serveWriteV2, res, req)
 	case "/query":
 		h.QueriesRecv.Incr(1)
 		defer h.QueriesServed.Incr(1)
@@ -239,16 +259,61 @@ func (h *HTTPListener) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// responder writes the error responses of a version of the write API.
+type responder struct {
+	tooLarge   func(res http.ResponseWriter)
+	badRequest func(res http.ResponseWriter, errString string)
+}
+
+var (
+	v1Responder = responder{tooLarge: tooLarge, badRequest: badRequest}
+	v2Responder = responder{tooLarge: tooLargeV2, badRequest: badRequestV2}
+)
+
 func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
+	precision := req.URL.Query().Get("precision")
+	h.write(res, req, precision, nil, v1Responder)
+}
+
+// serveWriteV2 handles the write endpoint of the InfluxDB v2 API.
+func (h *HTTPListener) serveWriteV2(res http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+
+	bucket := params.Get("bucket")
+	if bucket == "" {
+		badRequestV2(res, "bucket is required")
+		return
+	}
+
+	precision := params.Get("precision")
+	switch precision {
+	case "", "ns", "us", "ms", "s":
+	default:
+		badRequestV2(res, fmt.Sprintf("invalid precision %q, must be one of ns, us, ms or s", precision))
+		return
+	}
+
+	tags := make(map[string]string)
+	if h.BucketTag != "" {
+		tags[h.BucketTag] = bucket
+	}
+	if org := params.Get("org"); h.OrgTag != "" && org != "" {
+		tags[h.OrgTag] = org
+	}
+
+	h.write(res, req, precision, tags, v2Responder)
+}
+
+// write parses the line protocol in the request body, tags are added to the
+// metrics.
+func (h *HTTPListener) write(res http.ResponseWriter, req *http.Request, precision string, tags map[string]string, r responder) {
 	// Check that the content length is not too large for us to handle.
 	if req.ContentLength > h.MaxBodySize.Size {
-		tooLarge(res)
+		r.tooLarge(res)
 		return
 	}
 	now := h.TimeFunc()
 
-	precision := req.URL.Query().Get("precision")
-
 	// Handle gzip request bodies
 	body := req.Body
 	if req.Header.Get("Content-Encoding") == "gzip" {
@@ -257,7 +322,7 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 		defer body.Close()
 		if err != nil {
 			log.Println("D! " + err.Error())
-			badRequest(res, err.Error())
+			r.badRequest(res, err.Error())
 			return
 		}
 	}
@@ -273,14 +338,14 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
 			log.Println("D! " + err.Error())
 			// problem reading the request body
-			badRequest(res, err.Error())
+			r.badRequest(res, err.Error())
 			return
 		}
 		h.BytesRecv.Incr(int64(n))
 
 		if err == io.EOF {
 			if return400 {
-				badRequest(res, "")
+				r.badRequest(res, "")
 			} else {
 				res.WriteHeader(http.StatusNoContent)
 			}
@@ -305,16 +370,16 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 
 		if err == io.ErrUnexpectedEOF {
 			// finished reading the request body
-			err = h.parse(buf[:n+bufStart], now, precision)
+			err = h.parse(buf[:n+bufStart], now, precision, tags)
 			if err != nil {
 				log.Println("D! "+err.Error(), bufStart+n)
 				return400 = true
 			}
 			if return400 {
 				if err != nil {
-					badRequest(res, err.Error())
+					r.badRequest(res, err.Error())
 				} else {
-					badRequest(res, "")
+					r.badRequest(res, "")
 				}
 			} else {
 				res.WriteHeader(http.StatusNoContent)
@@ -335,7 +400,7 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 			bufStart = 0
 			continue
 		}
-		if err := h.parse(buf[:i+1], now, precision); err != nil {
+		if err := h.parse(buf[:i+1], now, precision, tags); err != nil {
 			log.Println("D! " + err.Error())
 			return400 = true
 		}
@@ -348,7 +413,7 @@ func (h *HTTPListener) serveWrite(res http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func (h *HTTPListener) parse(b []byte, t time.Time, precision string) error {
+func (h *HTTPListener) parse(b []byte, t time.Time, precision string, tags map[string]string) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -360,6 +425,9 @@ func (h *HTTPListener) parse(b []byte, t time.Time, precision string) error {
 	}
 
 	for _, m := range metrics {
+		for k, v := range tags {
+			m.AddTag(k, v)
+		}
 		h.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
 	}
 
@@ -385,6 +453,58 @@ func badRequest(res http.ResponseWriter, errString string) {
 	res.Write([]byte(fmt.Sprintf(`{"error":%q}`, errString)))
 }
 
+// tooLargeV2 and badRequestV2 write the errors of the InfluxDB v2 API.
+func tooLargeV2(res http.ResponseWriter) {
+	errorV2(res, http.StatusRequestEntityTooLarge, "too large", "http: request body too large")
+}
+
+func badRequestV2(res http.ResponseWriter, errString string) {
+	if errString == "" {
+		errString = "http: bad request"
+	}
+	errorV2(res, http.StatusBadRequest, "invalid", errString)
+}
+
+// errorV2Body is the body of the errors of the InfluxDB v2 API.
+type errorV2Body struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func errorV2(res http.ResponseWriter, status int, code string, message string) {
+	body, err := json.Marshal(errorV2Body{Code: code, Message: message})
+	if err != nil {
+		http.Error(res, message, status)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json; charset=utf-8")
+	res.WriteHeader(status)
+	res.Write(body)
+}
+
+// authenticateToken checks the token of InfluxDB v2 requests, if no tokens
+// are set the basic authentication is used.
+func (h *HTTPListener) authenticateToken(handler http.HandlerFunc, res http.ResponseWriter, req *http.Request) {
+	if len(h.Tokens) == 0 {
+		h.AuthenticateIfSet(handler, res, req)
+		return
+	}
+
+	auth := req.Header.Get("Authorization")
+	if strings.HasPrefix(auth, "Token ") {
+		token := []byte(strings.TrimPrefix(auth, "Token "))
+		for _, t := range h.Tokens {
+			if subtle.ConstantTimeCompare(token, []byte(t)) == 1 {
+				handler(res, req)
+				return
+			}
+		}
+	}
+
+	h.AuthFailures.Incr(1)
+	errorV2(res, http.StatusUnauthorized, "unauthorized", "unauthorized access")
+}
+
 func (h *HTTPListener) AuthenticateIfSet(handler http.HandlerFunc, res http.ResponseWriter, req *http.Request) {
 	if h.BasicUsername != "" && h.BasicPassword != "" {
 		reqUsername, reqPassword, ok := req.BasicAuth()
@@ -405,7 +525,7 @@ func (h *HTTPListener) AuthenticateIfSet(handler http.HandlerFunc, res http.Resp
 func getPrecisionMultiplier(precision string) time.Duration {
 	d := time.Nanosecond
 	switch precision {
-	case "u":
+	case "u", "us":
 		d = time.Microsecond
 	case "ms":
 		d = time.Millisecond
@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/Shopify/sarama"
-	cluster "github.com/bsm/sarama-cluster"
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -17,15 +19,20 @@ import (
 
 const (
 	defaultMaxUndeliveredMessages = 1000
+
+	// reconnectDelay is the delay before rejoining the consumer group after
+	// a session failed.
+	reconnectDelay = 5 * time.Second
 )
 
 type empty struct{}
 type semaphore chan empty
 
-type Consumer interface {
+// ConsumerGroup is a member of a consumer group, as implemented by
+// sarama.ConsumerGroup.
+type ConsumerGroup interface {
+	Consume(ctx context.Context, topics []string, handler sarama.ConsumerGroupHandler) error
 	Errors() <-chan error
-	Messages() <-chan *sarama.ConsumerMessage
-	MarkOffset(msg *sarama.ConsumerMessage, metadata string)
 	Close() error
 }
 
@@ -42,17 +49,16 @@ type Kafka struct {
 	SASLPassword           string   `toml:"sasl_password"`
 	tls.ClientConfig
 
-	cluster Consumer
-	parser  parsers.Parser
-	wg      *sync.WaitGroup
-	cancel  context.CancelFunc
-
-	// Unconfirmed messages
-	messages map[telegraf.TrackingID]*sarama.ConsumerMessage
+	HeaderTags   []string `toml:"header_tags"`
+	HeaderFields []string `toml:"header_fields"`
+	TopicTag     string   `toml:"topic_tag"`
+	PartitionTag string   `toml:"partition_tag"`
+	OffsetField  string   `toml:"offset_field"`
 
-	// doNotCommitMsgs tells the parser not to call CommitUpTo on the consumer
-	// this is mostly for test purposes, but there may be a use-case for it later.
-	doNotCommitMsgs bool
+	consumer ConsumerGroup
+	parser   parsers.Parser
+	wg       *sync.WaitGroup
+	cancel   context.CancelFunc
 }
 
 var sampleConfig = `
@@ -65,8 +71,8 @@ var sampleConfig = `
   # client_id = "Telegraf"
 
   ## Set the minimal supported Kafka version.  Setting this enables the use of new
-  ## Kafka features and APIs.  Of particular interest, lz4 compression
-  ## requires at least version 0.10.0.0.
+  ## Kafka features and APIs.  Consumer groups require at least version
+  ## 0.10.2.0, the default, and record headers at least version 0.11.0.0.
   ##   ex: version = "1.1.0"
   # version = ""
 
@@ -99,6 +105,17 @@ var sampleConfig = `
   ## waiting until the next flush_interval.
   # max_undelivered_messages = 1000
 
+  ## Record headers added as tags or string fields to the metrics of the
+  ## messages, requires version 0.11.0.0 or later.
+  # header_tags = []
+  # header_fields = []
+
+  ## Tags set to the topic and partition of the messages, and field set to
+  ## their offset.  Not added when empty.
+  # topic_tag = ""
+  # partition_tag = ""
+  # offset_field = ""
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -119,10 +136,10 @@ func (k *Kafka) SetParser(parser parsers.Parser) {
 }
 
 func (k *Kafka) Start(acc telegraf.Accumulator) error {
-	var clusterErr error
-
-	config := cluster.NewConfig()
+	config := sarama.NewConfig()
 
+	// Consumer groups require at least Kafka 0.10.2.0.
+	config.Version = sarama.V0_10_2_0
 	if k.Version != "" {
 		version, err := sarama.ParseKafkaVersion(k.Version)
 		if err != nil {
@@ -131,6 +148,10 @@ func (k *Kafka) Start(acc telegraf.Accumulator) error {
 		config.Version = version
 	}
 
+	if (len(k.HeaderTags) > 0 || len(k.HeaderFields) > 0) && !config.Version.IsAtLeast(sarama.V0_11_0_0) {
+		return fmt.Errorf("header_tags and header_fields require version 0.11.0.0 or later")
+	}
+
 	config.Consumer.Return.Errors = true
 
 	tlsConfig, err := k.ClientConfig.TLSConfig()
@@ -168,30 +189,32 @@ func (k *Kafka) Start(acc telegraf.Accumulator) error {
 		config.Consumer.Offsets.Initial = sarama.OffsetOldest
 	}
 
-	if k.cluster == nil {
-		k.cluster, clusterErr = cluster.NewConsumer(
-			k.Brokers,
-			k.ConsumerGroup,
-			k.Topics,
-			config,
-		)
-
-		if clusterErr != nil {
+	if k.consumer == nil {
+		consumer, err := sarama.NewConsumerGroup(k.Brokers, k.ConsumerGroup, config)
+		if err != nil {
 			log.Printf("E! Error when creating Kafka Consumer, brokers: %v, topics: %v",
 				k.Brokers, k.Topics)
-			return clusterErr
+			return err
 		}
+		k.consumer = consumer
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	k.cancel = cancel
 
-	// Start consumer goroutine
 	k.wg = &sync.WaitGroup{}
 	k.wg.Add(1)
 	go func() {
 		defer k.wg.Done()
-		k.receiver(ctx, acc)
+		k.consume(ctx, acc)
+	}()
+
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		for err := range k.consumer.Errors() {
+			acc.AddError(err)
+		}
 	}()
 
 	log.Printf("I! Started the kafka consumer service, brokers: %v, topics: %v",
@@ -199,93 +222,290 @@ func (k *Kafka) Start(acc telegraf.Accumulator) error {
 	return nil
 }
 
-// receiver() reads all incoming messages from the consumer, and parses them into
-// influxdb metric points.
-func (k *Kafka) receiver(ctx context.Context, ac telegraf.Accumulator) {
-	k.messages = make(map[telegraf.TrackingID]*sarama.ConsumerMessage)
-
-	acc := ac.WithTracking(k.MaxUndeliveredMessages)
-	sem := make(semaphore, k.MaxUndeliveredMessages)
+// consume is a member of the consumer group until the context is done.  A
+// session ends on each rebalance of the group, or when a message is not
+// delivered, the consumer then joins the group again with a new handler.
+func (k *Kafka) consume(ctx context.Context, acc telegraf.Accumulator) {
+	defer func() {
+		if err := k.consumer.Close(); err != nil {
+			log.Printf("E! [inputs.kafka_consumer] Error closing consumer: %v", err)
+		}
+	}()
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case track := <-acc.Delivered():
-			<-sem
-			k.onDelivery(track)
-		case err := <-k.cluster.Errors():
+	for ctx.Err() == nil {
+		sessionCtx, endSession := context.WithCancel(ctx)
+		handler := newConsumerGroupHandler(k, acc, endSession)
+		err := k.consumer.Consume(sessionCtx, k.Topics, handler)
+		endSession()
+		switch {
+		case handler.Rejected():
+			// The undelivered messages are consumed again from the last
+			// committed offsets, after a delay as the outputs may still
+			// be unable to accept them.
+			internal.SleepContext(ctx, reconnectDelay)
+		case err != nil:
 			acc.AddError(err)
-		case sem <- empty{}:
+			internal.SleepContext(ctx, reconnectDelay)
+		}
+	}
+}
+
+// addMessageInfo adds the configured headers, topic, partition and offset of
+// the message to the metric.
+func (k *Kafka) addMessageInfo(m telegraf.Metric, msg *sarama.ConsumerMessage) {
+	for _, key := range k.HeaderTags {
+		if value, ok := header(msg, key); ok {
+			m.AddTag(key, value)
+		}
+	}
+	for _, key := range k.HeaderFields {
+		if value, ok := header(msg, key); ok {
+			m.AddField(key, value)
+		}
+	}
+	if k.TopicTag != "" {
+		m.AddTag(k.TopicTag, msg.Topic)
+	}
+	if k.PartitionTag != "" {
+		m.AddTag(k.PartitionTag, strconv.FormatInt(int64(msg.Partition), 10))
+	}
+	if k.OffsetField != "" {
+		m.AddField(k.OffsetField, msg.Offset)
+	}
+}
+
+// header returns the value of the last record header of the message with
+// the key.
+func header(msg *sarama.ConsumerMessage, key string) (string, bool) {
+	var value string
+	var ok bool
+	for _, h := range msg.Headers {
+		if h != nil && string(h.Key) == key {
+			value, ok = string(h.Value), true
+		}
+	}
+	return value, ok
+}
+
+func (k *Kafka) Stop() {
+	k.cancel()
+	k.wg.Wait()
+}
+
+func (k *Kafka) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// partitionOffsets are the offsets of the messages of a partition which are
+// not marked yet, in the order they were consumed.
+type partitionOffsets struct {
+	pending []int64
+	done    map[int64]bool
+
+	// rejected is set once a message of the partition is not delivered, the
+	// offsets of the partition are not marked anymore during the session.
+	rejected bool
+}
+
+// consumerGroupHandler handles the claims of a consumer group session.  The
+// offset of a message is marked for commit once all the metrics of the
+// message, and of the messages before it in its partition, are delivered.
+type consumerGroupHandler struct {
+	k   *Kafka
+	acc telegraf.TrackingAccumulator
+	sem semaphore
+
+	mu          sync.Mutex
+	undelivered map[telegraf.TrackingID]*sarama.ConsumerMessage
+	partitions  map[topicPartition]*partitionOffsets
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+
+	// endSession ends the session once a message is not delivered, so that
+	// it is consumed again instead of holding back the offsets of its
+	// partition until the next rebalance.
+	endSession context.CancelFunc
+	rejected   bool
+}
+
+func newConsumerGroupHandler(k *Kafka, acc telegraf.Accumulator, endSession context.CancelFunc) *consumerGroupHandler {
+	return &consumerGroupHandler{
+		k:           k,
+		acc:         acc.WithTracking(k.MaxUndeliveredMessages),
+		sem:         make(semaphore, k.MaxUndeliveredMessages),
+		undelivered: make(map[telegraf.TrackingID]*sarama.ConsumerMessage),
+		partitions:  make(map[topicPartition]*partitionOffsets),
+		endSession:  endSession,
+	}
+}
+
+// Rejected reports if the session was ended because a message was not
+// delivered.
+func (h *consumerGroupHandler) Rejected() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.rejected
+}
+
+// Setup starts marking the offsets of the delivered messages.
+func (h *consumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		for {
 			select {
 			case <-ctx.Done():
 				return
-			case track := <-acc.Delivered():
-				// Once for the delivered message, once to leave the case
-				<-sem
-				<-sem
-				k.onDelivery(track)
-			case err := <-k.cluster.Errors():
-				<-sem
-				acc.AddError(err)
-			case msg := <-k.cluster.Messages():
-				err := k.onMessage(acc, msg)
-				if err != nil {
-					acc.AddError(err)
-					<-sem
-				}
+			case info := <-h.acc.Delivered():
+				h.onDelivery(session, info)
 			}
 		}
-	}
+	}()
+	return nil
 }
 
-func (k *Kafka) markOffset(msg *sarama.ConsumerMessage) {
-	if !k.doNotCommitMsgs {
-		k.cluster.MarkOffset(msg, "")
+// Cleanup stops marking offsets.  The messages still undelivered are
+// consumed again by the member the partitions are assigned to next.
+func (h *consumerGroupHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	h.cancel()
+	h.wg.Wait()
+	return nil
+}
+
+// ConsumeClaim reads the messages of a partition until the session ends,
+// with at most max_undelivered_messages undelivered messages in the session.
+func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case h.sem <- empty{}:
+		}
+
+		select {
+		case <-ctx.Done():
+			<-h.sem
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				<-h.sem
+				return nil
+			}
+			h.onMessage(session, msg)
+		}
 	}
 }
 
-func (k *Kafka) onMessage(acc telegraf.TrackingAccumulator, msg *sarama.ConsumerMessage) error {
-	if k.MaxMessageLen != 0 && len(msg.Value) > k.MaxMessageLen {
-		k.markOffset(msg)
-		return fmt.Errorf("Message longer than max_message_len (%d > %d)",
-			len(msg.Value), k.MaxMessageLen)
+// onMessage adds the metrics of the message for tracking.  Messages which
+// can not be parsed are dropped and handled as delivered.
+func (h *consumerGroupHandler) onMessage(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	p := h.partition(msg)
+	if !p.rejected {
+		p.pending = append(p.pending, msg.Offset)
 	}
 
-	metrics, err := k.parser.Parse(msg.Value)
+	metrics, err := h.parse(msg)
 	if err != nil {
-		return err
+		h.acc.AddError(err)
+	}
+	if len(metrics) == 0 {
+		<-h.sem
+		h.done(session, msg, true)
+		return
 	}
 
-	id := acc.AddTrackingMetricGroup(metrics)
-	k.messages[id] = msg
+	id := h.acc.AddTrackingMetricGroup(metrics)
+	h.undelivered[id] = msg
+}
 
-	return nil
+func (h *consumerGroupHandler) parse(msg *sarama.ConsumerMessage) ([]telegraf.Metric, error) {
+	if h.k.MaxMessageLen != 0 && len(msg.Value) > h.k.MaxMessageLen {
+		return nil, fmt.Errorf("Message longer than max_message_len (%d > %d)",
+			len(msg.Value), h.k.MaxMessageLen)
+	}
+
+	metrics, err := h.k.parser.Parse(msg.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range metrics {
+		h.k.addMessageInfo(m, msg)
+	}
+	return metrics, nil
 }
 
-func (k *Kafka) onDelivery(track telegraf.DeliveryInfo) {
-	msg, ok := k.messages[track.ID()]
+func (h *consumerGroupHandler) onDelivery(session sarama.ConsumerGroupSession, info telegraf.DeliveryInfo) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	<-h.sem
+
+	msg, ok := h.undelivered[info.ID()]
 	if !ok {
-		log.Printf("E! [inputs.kafka_consumer] Could not mark message delivered: %d", track.ID())
+		log.Printf("E! [inputs.kafka_consumer] Could not mark message delivered: %d", info.ID())
+		return
 	}
+	delete(h.undelivered, info.ID())
+
+	h.done(session, msg, info.Delivered())
+}
 
-	if track.Delivered() {
-		k.markOffset(msg)
+func (h *consumerGroupHandler) partition(msg *sarama.ConsumerMessage) *partitionOffsets {
+	key := topicPartition{topic: msg.Topic, partition: msg.Partition}
+	p, ok := h.partitions[key]
+	if !ok {
+		p = &partitionOffsets{done: make(map[int64]bool)}
+		h.partitions[key] = p
 	}
-	delete(k.messages, track.ID())
+	return p
 }
 
-func (k *Kafka) Stop() {
-	k.cancel()
-	k.wg.Wait()
+// done records the message as handled, and marks the offset following the
+// handled messages at the start of its partition.  When a message is not
+// delivered its offset is never marked and the session is ended, so that
+// it's consumed again from the last committed offset in a new session.
+func (h *consumerGroupHandler) done(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage, delivered bool) {
+	p := h.partition(msg)
+	if p.rejected {
+		return
+	}
 
-	if err := k.cluster.Close(); err != nil {
-		log.Printf("E! [inputs.kafka_consumer] Error closing consumer: %v", err)
+	if !delivered {
+		log.Printf("W! [inputs.kafka_consumer] Message at offset %d of topic %q partition %d was not delivered, "+
+			"consuming again from the last committed offset",
+			msg.Offset, msg.Topic, msg.Partition)
+		p.rejected = true
+		p.pending = nil
+		p.done = nil
+		h.rejected = true
+		h.endSession()
+		return
 	}
-}
 
-func (k *Kafka) Gather(acc telegraf.Accumulator) error {
-	return nil
+	p.done[msg.Offset] = true
+	next := int64(-1)
+	for len(p.pending) > 0 && p.done[p.pending[0]] {
+		delete(p.done, p.pending[0])
+		next = p.pending[0] + 1
+		p.pending = p.pending[1:]
+	}
+	if next >= 0 {
+		session.MarkOffset(msg.Topic, msg.Partition, next, "")
+	}
 }
 
 func init() {
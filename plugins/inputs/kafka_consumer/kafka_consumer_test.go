@@ -3,13 +3,17 @@ package kafka_consumer
 import (
 	"context"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Shopify/sarama"
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -19,57 +23,207 @@ const (
 	invalidMsg      = "cpu_load_short,host=server01 1422568543702900257\n"
 )
 
-type TestConsumer struct {
-	errors   chan error
+type topicPartitionOffset struct {
+	topic     string
+	partition int32
+	offset    int64
+}
+
+// TestSession is a consumer group session recording the marked offsets.
+type TestSession struct {
+	ctx context.Context
+
+	sync.Mutex
+	marked []topicPartitionOffset
+
+	// ended is closed when the handler ends the session
+	ended   chan struct{}
+	endOnce sync.Once
+}
+
+func (s *TestSession) End() {
+	s.endOnce.Do(func() { close(s.ended) })
+}
+
+func (s *TestSession) Claims() map[string][]int32 {
+	return map[string][]int32{"telegraf": {0, 1}}
+}
+
+func (s *TestSession) MemberID() string {
+	return "test"
+}
+
+func (s *TestSession) GenerationID() int32 {
+	return 1
+}
+
+func (s *TestSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+	s.Lock()
+	defer s.Unlock()
+	s.marked = append(s.marked, topicPartitionOffset{topic, partition, offset})
+}
+
+func (s *TestSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {
+}
+
+func (s *TestSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.MarkOffset(msg.Topic, msg.Partition, msg.Offset+1, metadata)
+}
+
+func (s *TestSession) Context() context.Context {
+	return s.ctx
+}
+
+func (s *TestSession) Marked() []topicPartitionOffset {
+	s.Lock()
+	defer s.Unlock()
+	return append([]topicPartitionOffset(nil), s.marked...)
+}
+
+// WaitMarked waits until n offsets are marked.
+func (s *TestSession) WaitMarked(t *testing.T, n int) []topicPartitionOffset {
+	for i := 0; i < 500; i++ {
+		if marked := s.Marked(); len(marked) >= n {
+			return marked
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Waited for %d marked offsets, got %v", n, s.Marked())
+	return nil
+}
+
+// TestClaim is a claim of the messages injected in the test.
+type TestClaim struct {
 	messages chan *sarama.ConsumerMessage
 }
 
-func (c *TestConsumer) Errors() <-chan error {
-	return c.errors
+func (c *TestClaim) Topic() string {
+	return "telegraf"
+}
+
+func (c *TestClaim) Partition() int32 {
+	return 0
 }
 
-func (c *TestConsumer) Messages() <-chan *sarama.ConsumerMessage {
+func (c *TestClaim) InitialOffset() int64 {
+	return 0
+}
+
+func (c *TestClaim) HighWaterMarkOffset() int64 {
+	return 0
+}
+
+func (c *TestClaim) Messages() <-chan *sarama.ConsumerMessage {
 	return c.messages
 }
 
-func (c *TestConsumer) MarkOffset(msg *sarama.ConsumerMessage, metadata string) {
+func (c *TestClaim) Inject(msg *sarama.ConsumerMessage) {
+	c.messages <- msg
+}
+
+// TrackingAccumulator tracks the delivery of the metrics like the agent,
+// the metrics are accepted or rejected by the tests.
+type TrackingAccumulator struct {
+	testutil.Accumulator
+	delivered chan telegraf.DeliveryInfo
+
+	mu     sync.Mutex
+	groups [][]telegraf.Metric
+}
+
+func NewTrackingAccumulator() *TrackingAccumulator {
+	return &TrackingAccumulator{delivered: make(chan telegraf.DeliveryInfo, 1000)}
+}
+
+func (a *TrackingAccumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator {
+	return a
 }
 
-func (c *TestConsumer) Close() error {
+func (a *TrackingAccumulator) AddTrackingMetricGroup(group []telegraf.Metric) telegraf.TrackingID {
+	group, id := metric.WithGroupTracking(group, func(info telegraf.DeliveryInfo) {
+		a.delivered <- info
+	})
+	a.mu.Lock()
+	a.groups = append(a.groups, group)
+	a.mu.Unlock()
+	a.AddMetrics(group)
+	return id
+}
+
+func (a *TrackingAccumulator) Delivered() <-chan telegraf.DeliveryInfo {
+	return a.delivered
+}
+
+// Group returns the metrics of the i-th tracked message.
+func (a *TrackingAccumulator) Group(t *testing.T, i int) []telegraf.Metric {
+	for n := 0; n < 500; n++ {
+		a.mu.Lock()
+		if i < len(a.groups) {
+			group := a.groups[i]
+			a.mu.Unlock()
+			return group
+		}
+		a.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Waited for tracked message %d", i)
 	return nil
 }
 
-func (c *TestConsumer) Inject(msg *sarama.ConsumerMessage) {
-	c.messages <- msg
+func accept(group []telegraf.Metric) {
+	for _, m := range group {
+		m.Accept()
+	}
 }
 
-func newTestKafka() (*Kafka, *TestConsumer) {
-	consumer := &TestConsumer{
-		errors:   make(chan error),
-		messages: make(chan *sarama.ConsumerMessage, 1000),
+func reject(group []telegraf.Metric) {
+	for _, m := range group {
+		m.Reject()
 	}
+}
+
+func newTestKafka() *Kafka {
 	k := Kafka{
-		cluster:                consumer,
 		ConsumerGroup:          "test",
 		Topics:                 []string{"telegraf"},
 		Brokers:                []string{"localhost:9092"},
 		Offset:                 "oldest",
 		MaxUndeliveredMessages: defaultMaxUndeliveredMessages,
-		doNotCommitMsgs:        true,
-		messages:               make(map[telegraf.TrackingID]*sarama.ConsumerMessage),
 	}
-	return &k, consumer
+	k.parser, _ = parsers.NewInfluxParser()
+	return &k
+}
+
+// startHandler consumes the messages injected in the claim in a session,
+// until the returned function is called.
+func startHandler(t *testing.T, k *Kafka, acc telegraf.Accumulator) (*TestSession, *TestClaim, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &TestSession{ctx: ctx, ended: make(chan struct{})}
+	claim := &TestClaim{messages: make(chan *sarama.ConsumerMessage, 1000)}
+
+	handler := newConsumerGroupHandler(k, acc, session.End)
+	require.NoError(t, handler.Setup(session))
+
+	done := make(chan error)
+	go func() {
+		done <- handler.ConsumeClaim(session, claim)
+	}()
+
+	return session, claim, func() {
+		cancel()
+		require.NoError(t, <-done)
+		require.NoError(t, handler.Cleanup(session))
+	}
 }
 
 // Test that the parser parses kafka messages into points
 func TestRunParser(t *testing.T) {
-	k, consumer := newTestKafka()
+	k := newTestKafka()
 	acc := testutil.Accumulator{}
-	ctx := context.Background()
+	_, claim, stop := startHandler(t, k, &acc)
+	defer stop()
 
-	k.parser, _ = parsers.NewInfluxParser()
-	go k.receiver(ctx, &acc)
-	consumer.Inject(saramaMsg(testMsg))
+	claim.Inject(saramaMsg(testMsg))
 	acc.Wait(1)
 
 	assert.Equal(t, acc.NFields(), 1)
@@ -77,13 +231,12 @@ func TestRunParser(t *testing.T) {
 
 // Test that the parser ignores invalid messages
 func TestRunParserInvalidMsg(t *testing.T) {
-	k, consumer := newTestKafka()
+	k := newTestKafka()
 	acc := testutil.Accumulator{}
-	ctx := context.Background()
+	_, claim, stop := startHandler(t, k, &acc)
+	defer stop()
 
-	k.parser, _ = parsers.NewInfluxParser()
-	go k.receiver(ctx, &acc)
-	consumer.Inject(saramaMsg(invalidMsg))
+	claim.Inject(saramaMsg(invalidMsg))
 	acc.WaitError(1)
 
 	assert.Equal(t, acc.NFields(), 0)
@@ -92,14 +245,14 @@ func TestRunParserInvalidMsg(t *testing.T) {
 // Test that overlong messages are dropped
 func TestDropOverlongMsg(t *testing.T) {
 	const maxMessageLen = 64 * 1024
-	k, consumer := newTestKafka()
+	k := newTestKafka()
 	k.MaxMessageLen = maxMessageLen
 	acc := testutil.Accumulator{}
-	ctx := context.Background()
 	overlongMsg := strings.Repeat("v", maxMessageLen+1)
+	_, claim, stop := startHandler(t, k, &acc)
+	defer stop()
 
-	go k.receiver(ctx, &acc)
-	consumer.Inject(saramaMsg(overlongMsg))
+	claim.Inject(saramaMsg(overlongMsg))
 	acc.WaitError(1)
 
 	assert.Equal(t, acc.NFields(), 0)
@@ -107,13 +260,12 @@ func TestDropOverlongMsg(t *testing.T) {
 
 // Test that the parser parses kafka messages into points
 func TestRunParserAndGather(t *testing.T) {
-	k, consumer := newTestKafka()
+	k := newTestKafka()
 	acc := testutil.Accumulator{}
-	ctx := context.Background()
+	_, claim, stop := startHandler(t, k, &acc)
+	defer stop()
 
-	k.parser, _ = parsers.NewInfluxParser()
-	go k.receiver(ctx, &acc)
-	consumer.Inject(saramaMsg(testMsg))
+	claim.Inject(saramaMsg(testMsg))
 	acc.Wait(1)
 
 	acc.GatherError(k.Gather)
@@ -125,13 +277,13 @@ func TestRunParserAndGather(t *testing.T) {
 
 // Test that the parser parses kafka messages into points
 func TestRunParserAndGatherGraphite(t *testing.T) {
-	k, consumer := newTestKafka()
+	k := newTestKafka()
+	k.parser, _ = parsers.NewGraphiteParser("_", []string{}, nil)
 	acc := testutil.Accumulator{}
-	ctx := context.Background()
+	_, claim, stop := startHandler(t, k, &acc)
+	defer stop()
 
-	k.parser, _ = parsers.NewGraphiteParser("_", []string{}, nil)
-	go k.receiver(ctx, &acc)
-	consumer.Inject(saramaMsg(testMsgGraphite))
+	claim.Inject(saramaMsg(testMsgGraphite))
 	acc.Wait(1)
 
 	acc.GatherError(k.Gather)
@@ -143,16 +295,16 @@ func TestRunParserAndGatherGraphite(t *testing.T) {
 
 // Test that the parser parses kafka messages into points
 func TestRunParserAndGatherJSON(t *testing.T) {
-	k, consumer := newTestKafka()
-	acc := testutil.Accumulator{}
-	ctx := context.Background()
-
+	k := newTestKafka()
 	k.parser, _ = parsers.NewParser(&parsers.Config{
 		DataFormat: "json",
 		MetricName: "kafka_json_test",
 	})
-	go k.receiver(ctx, &acc)
-	consumer.Inject(saramaMsg(testMsgJSON))
+	acc := testutil.Accumulator{}
+	_, claim, stop := startHandler(t, k, &acc)
+	defer stop()
+
+	claim.Inject(saramaMsg(testMsgJSON))
 	acc.Wait(1)
 
 	acc.GatherError(k.Gather)
@@ -165,10 +317,250 @@ func TestRunParserAndGatherJSON(t *testing.T) {
 		})
 }
 
+func TestMessageInfo(t *testing.T) {
+	k := newTestKafka()
+	k.HeaderTags = []string{"source", "missing"}
+	k.HeaderFields = []string{"trace_id"}
+	k.TopicTag = "topic"
+	k.PartitionTag = "partition"
+	k.OffsetField = "offset"
+	acc := testutil.Accumulator{}
+	_, claim, stop := startHandler(t, k, &acc)
+	defer stop()
+
+	msg := saramaMsg(testMsg)
+	msg.Topic = "telegraf"
+	msg.Partition = 3
+	msg.Offset = 42
+	msg.Headers = []*sarama.RecordHeader{
+		{Key: []byte("source"), Value: []byte("sensor")},
+		{Key: []byte("trace_id"), Value: []byte("abc")},
+		{Key: []byte("ignored"), Value: []byte("x")},
+	}
+	claim.Inject(msg)
+	acc.Wait(1)
+
+	acc.AssertContainsTaggedFields(t, "cpu_load_short",
+		map[string]interface{}{
+			"value":    float64(23422),
+			"trace_id": "abc",
+			"offset":   int64(42),
+		},
+		map[string]string{
+			"host":      "server01",
+			"source":    "sensor",
+			"topic":     "telegraf",
+			"partition": "3",
+		})
+}
+
+// Test that an offset is marked once the message and all the prior messages
+// of its partition are delivered.
+func TestMarkOffsetAfterDelivery(t *testing.T) {
+	k := newTestKafka()
+	acc := NewTrackingAccumulator()
+	session, claim, stop := startHandler(t, k, acc)
+	defer stop()
+
+	for offset := int64(0); offset < 3; offset++ {
+		msg := saramaMsg(testMsg)
+		msg.Offset = offset
+		claim.Inject(msg)
+	}
+
+	accept(acc.Group(t, 1))
+	accept(acc.Group(t, 0))
+	require.Equal(t, []topicPartitionOffset{{"telegraf", 0, 2}}, session.WaitMarked(t, 1))
+
+	accept(acc.Group(t, 2))
+	require.Equal(t, []topicPartitionOffset{
+		{"telegraf", 0, 2},
+		{"telegraf", 0, 3},
+	}, session.WaitMarked(t, 2))
+}
+
+// Test that messages which can not be parsed do not block the commits.
+func TestMarkOffsetInvalidMsg(t *testing.T) {
+	k := newTestKafka()
+	acc := NewTrackingAccumulator()
+	session, claim, stop := startHandler(t, k, acc)
+	defer stop()
+
+	msg := saramaMsg(invalidMsg)
+	claim.Inject(msg)
+	msg = saramaMsg(testMsg)
+	msg.Offset = 1
+	claim.Inject(msg)
+
+	accept(acc.Group(t, 0))
+	require.Equal(t, []topicPartitionOffset{
+		{"telegraf", 0, 1},
+		{"telegraf", 0, 2},
+	}, session.WaitMarked(t, 2))
+}
+
+// Test that the offsets of a partition are not marked anymore once a message
+// is rejected, and that the session is ended to consume it again.
+func TestMarkOffsetRejected(t *testing.T) {
+	k := newTestKafka()
+	acc := NewTrackingAccumulator()
+	session, claim, stop := startHandler(t, k, acc)
+	defer stop()
+
+	for offset := int64(0); offset < 2; offset++ {
+		msg := saramaMsg(testMsg)
+		msg.Offset = offset
+		claim.Inject(msg)
+	}
+	msg := saramaMsg(testMsg)
+	msg.Partition = 1
+	claim.Inject(msg)
+
+	reject(acc.Group(t, 0))
+	accept(acc.Group(t, 1))
+	// Deliveries are handled in order, the offset of the other partition is
+	// marked last.
+	accept(acc.Group(t, 2))
+	require.Equal(t, []topicPartitionOffset{{"telegraf", 1, 1}}, session.WaitMarked(t, 1))
+
+	select {
+	case <-session.ended:
+	case <-time.After(5 * time.Second):
+		t.Fatal("session not ended")
+	}
+}
+
+func TestStartHeadersRequireVersion(t *testing.T) {
+	k := newTestKafka()
+	k.Version = "0.10.2.0"
+	k.HeaderTags = []string{"source"}
+
+	err := k.Start(&testutil.Accumulator{})
+	require.Error(t, err)
+}
+
+// newTestBroker returns an in-process broker which is the coordinator of the
+// "test" consumer group and leader of the telegraf topic, assigning its
+// partition to the consumer.  The partition has a message at each of the
+// offsets 0 to 2, with a source header.
+func newTestBroker(t *testing.T) *sarama.MockBroker {
+	broker := sarama.NewMockBroker(t, 1)
+
+	var assignment sarama.SyncGroupRequest
+	require.NoError(t, assignment.AddGroupAssignmentMember("member", &sarama.ConsumerGroupMemberAssignment{
+		Topics: map[string][]int32{"telegraf": {0}},
+	}))
+
+	fetch := &sarama.FetchResponse{Version: 4}
+	for offset := int64(0); offset < 3; offset++ {
+		fetch.AddRecord("telegraf", 0, nil, sarama.StringEncoder(testMsg), offset)
+	}
+	fetch.SetLastOffsetDelta("telegraf", 0, 2)
+	block := fetch.GetBlock("telegraf", 0)
+	block.HighWaterMarkOffset = 3
+	for _, record := range block.RecordsSet[0].RecordBatch.Records {
+		record.Headers = []*sarama.RecordHeader{{Key: []byte("source"), Value: []byte("sensor")}}
+	}
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("telegraf", 0, broker.BrokerID()),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "test", broker),
+		"JoinGroupRequest": sarama.NewMockWrapper(&sarama.JoinGroupResponse{
+			Version:      1,
+			GenerationId: 1,
+			LeaderId:     "leader",
+			MemberId:     "member",
+		}),
+		"SyncGroupRequest": sarama.NewMockWrapper(&sarama.SyncGroupResponse{
+			MemberAssignment: assignment.GroupAssignments["member"],
+		}),
+		"HeartbeatRequest":  sarama.NewMockWrapper(&sarama.HeartbeatResponse{}),
+		"LeaveGroupRequest": sarama.NewMockWrapper(&sarama.LeaveGroupResponse{}),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("test", "telegraf", 0, -1, "", sarama.ErrNoError),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("telegraf", 0, sarama.OffsetOldest, 0).
+			SetOffset("telegraf", 0, sarama.OffsetNewest, 3),
+		"FetchRequest":        sarama.NewMockWrapper(fetch),
+		"OffsetCommitRequest": sarama.NewMockOffsetCommitResponse(t),
+	})
+	return broker
+}
+
+// committedOffsets returns the offsets of the partition committed to the
+// broker.
+func committedOffsets(broker *sarama.MockBroker) []int64 {
+	var offsets []int64
+	for _, rr := range broker.History() {
+		req, ok := rr.Request.(*sarama.OffsetCommitRequest)
+		if !ok {
+			continue
+		}
+		if offset, _, err := req.Offset("telegraf", 0); err == nil {
+			offsets = append(offsets, offset)
+		}
+	}
+	return offsets
+}
+
+// Test that the consumer joins the group and commits the offsets of the
+// delivered messages.
+func TestConsumerGroup(t *testing.T) {
+	broker := newTestBroker(t)
+	defer broker.Close()
+
+	k := newTestKafka()
+	k.Brokers = []string{broker.Addr()}
+	k.Version = "0.11.0.0"
+	k.HeaderTags = []string{"source"}
+	k.OffsetField = "offset"
+
+	acc := NewTrackingAccumulator()
+	require.NoError(t, k.Start(acc))
+	stopped := false
+	defer func() {
+		if !stopped {
+			k.Stop()
+		}
+	}()
+
+	accept(acc.Group(t, 0))
+	accept(acc.Group(t, 1))
+	acc.Group(t, 2)
+
+	var committed []int64
+	for i := 0; i < 500; i++ {
+		if committed = committedOffsets(broker); len(committed) > 0 && committed[len(committed)-1] == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NotEmpty(t, committed)
+	require.Equal(t, int64(2), committed[len(committed)-1])
+
+	k.Stop()
+	stopped = true
+
+	// The last message is not delivered, its offset is not committed.
+	for _, offset := range committedOffsets(broker) {
+		require.True(t, offset <= 2)
+	}
+
+	require.Equal(t, uint64(3), acc.NMetrics())
+	acc.AssertContainsTaggedFields(t, "cpu_load_short",
+		map[string]interface{}{"value": float64(23422), "offset": int64(0)},
+		map[string]string{"host": "server01", "source": "sensor"})
+}
+
 func saramaMsg(val string) *sarama.ConsumerMessage {
 	return &sarama.ConsumerMessage{
 		Key:       nil,
 		Value:     []byte(val),
+		Topic:     "telegraf",
 		Offset:    0,
 		Partition: 0,
 	}
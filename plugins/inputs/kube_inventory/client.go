@@ -0,0 +1,104 @@
+package kube_inventory
+
+import (
+	"context"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	extv1beta1 "github.com/ericchiang/k8s/apis/extensions/v1beta1"
+
+	"github.com/influxdata/telegraf/internal/tls"
+)
+
+type client struct {
+	namespace string
+	*k8s.Client
+}
+
+// newClient returns a client for the API server at baseURL, or for the
+// cluster the pod is running in if baseURL is empty.
+func newClient(
+	baseURL string,
+	namespace string,
+	bearerToken string,
+	timeout time.Duration,
+	tlsConfig tls.ClientConfig,
+) (*client, error) {
+	var c *k8s.Client
+	var err error
+	if baseURL == "" {
+		c, err = k8s.NewInClusterClient()
+	} else {
+		c, err = k8s.NewClient(&k8s.Config{
+			Clusters: []k8s.NamedCluster{{Name: "cluster", Cluster: k8s.Cluster{
+				Server:                baseURL,
+				InsecureSkipTLSVerify: tlsConfig.InsecureSkipVerify,
+				CertificateAuthority:  tlsConfig.TLSCA,
+			}}},
+			Contexts: []k8s.NamedContext{{Name: "context", Context: k8s.Context{
+				Cluster:   "cluster",
+				AuthInfo:  "auth",
+				Namespace: namespace,
+			}}},
+			AuthInfos: []k8s.NamedAuthInfo{{Name: "auth", AuthInfo: k8s.AuthInfo{
+				TokenFile:         bearerToken,
+				ClientCertificate: tlsConfig.TLSCert,
+				ClientKey:         tlsConfig.TLSKey,
+			}}},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The client does not pass the context on to its requests, so the
+	// timeout is enforced by the http client.
+	c.Client.Timeout = timeout
+
+	return &client{
+		namespace: namespace,
+		Client:    c,
+	}, nil
+}
+
+func (c *client) getDaemonSets(ctx context.Context) (*appsv1.DaemonSetList, error) {
+	list := new(appsv1.DaemonSetList)
+	return list, c.List(ctx, c.namespace, list)
+}
+
+func (c *client) getDeployments(ctx context.Context) (*appsv1.DeploymentList, error) {
+	list := new(appsv1.DeploymentList)
+	return list, c.List(ctx, c.namespace, list)
+}
+
+func (c *client) getIngresses(ctx context.Context) (*extv1beta1.IngressList, error) {
+	list := new(extv1beta1.IngressList)
+	return list, c.List(ctx, c.namespace, list)
+}
+
+func (c *client) getNodes(ctx context.Context) (*corev1.NodeList, error) {
+	list := new(corev1.NodeList)
+	return list, c.List(ctx, k8s.AllNamespaces, list)
+}
+
+func (c *client) getPersistentVolumeClaims(ctx context.Context) (*corev1.PersistentVolumeClaimList, error) {
+	list := new(corev1.PersistentVolumeClaimList)
+	return list, c.List(ctx, c.namespace, list)
+}
+
+func (c *client) getPods(ctx context.Context) (*corev1.PodList, error) {
+	list := new(corev1.PodList)
+	return list, c.List(ctx, c.namespace, list)
+}
+
+func (c *client) getServices(ctx context.Context) (*corev1.ServiceList, error) {
+	list := new(corev1.ServiceList)
+	return list, c.List(ctx, c.namespace, list)
+}
+
+func (c *client) getStatefulSets(ctx context.Context) (*appsv1.StatefulSetList, error) {
+	list := new(appsv1.StatefulSetList)
+	return list, c.List(ctx, c.namespace, list)
+}
@@ -0,0 +1,46 @@
+package kube_inventory
+
+import (
+	"context"
+
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectDaemonSets(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getDaemonSets(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for _, d := range list.Items {
+		ki.gatherDaemonSet(d, acc)
+	}
+}
+
+func (ki *KubernetesInventory) gatherDaemonSet(d *appsv1.DaemonSet, acc telegraf.Accumulator) {
+	status := d.GetStatus()
+	fields := map[string]interface{}{
+		"generation":               d.GetMetadata().GetGeneration(),
+		"current_number_scheduled": status.GetCurrentNumberScheduled(),
+		"desired_number_scheduled": status.GetDesiredNumberScheduled(),
+		"number_available":         status.GetNumberAvailable(),
+		"number_misscheduled":      status.GetNumberMisscheduled(),
+		"number_ready":             status.GetNumberReady(),
+		"number_unavailable":       status.GetNumberUnavailable(),
+		"updated_number_scheduled": status.GetUpdatedNumberScheduled(),
+		"age_seconds":              age(d.GetMetadata().GetCreationTimestamp()),
+	}
+	tags := map[string]string{
+		"daemonset_name": d.GetMetadata().GetName(),
+		"namespace":      d.GetMetadata().GetNamespace(),
+	}
+
+	acc.AddFields(daemonSetMeasurement, fields, tags)
+
+	for _, c := range status.GetConditions() {
+		addCondition(acc, daemonSetMeasurement, tags,
+			c.GetType(), c.GetStatus(), c.GetLastTransitionTime())
+	}
+}
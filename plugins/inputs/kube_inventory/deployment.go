@@ -0,0 +1,43 @@
+package kube_inventory
+
+import (
+	"context"
+
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectDeployments(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getDeployments(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for _, d := range list.Items {
+		ki.gatherDeployment(d, acc)
+	}
+}
+
+func (ki *KubernetesInventory) gatherDeployment(d *appsv1.Deployment, acc telegraf.Accumulator) {
+	status := d.GetStatus()
+	fields := map[string]interface{}{
+		"replicas_desired":     d.GetSpec().GetReplicas(),
+		"replicas_available":   status.GetAvailableReplicas(),
+		"replicas_unavailable": status.GetUnavailableReplicas(),
+		"replicas_ready":       status.GetReadyReplicas(),
+		"replicas_updated":     status.GetUpdatedReplicas(),
+		"age_seconds":          age(d.GetMetadata().GetCreationTimestamp()),
+	}
+	tags := map[string]string{
+		"deployment_name": d.GetMetadata().GetName(),
+		"namespace":       d.GetMetadata().GetNamespace(),
+	}
+
+	acc.AddFields(deploymentMeasurement, fields, tags)
+
+	for _, c := range status.GetConditions() {
+		addCondition(acc, deploymentMeasurement, tags,
+			c.GetType(), c.GetStatus(), c.GetLastTransitionTime())
+	}
+}
@@ -0,0 +1,41 @@
+package kube_inventory
+
+import (
+	"context"
+
+	extv1beta1 "github.com/ericchiang/k8s/apis/extensions/v1beta1"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectIngresses(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getIngresses(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for _, i := range list.Items {
+		ki.gatherIngress(i, acc)
+	}
+}
+
+func (ki *KubernetesInventory) gatherIngress(i *extv1beta1.Ingress, acc telegraf.Accumulator) {
+	var paths int
+	for _, rule := range i.GetSpec().GetRules() {
+		paths += len(rule.GetIngressRuleValue().GetHttp().GetPaths())
+	}
+
+	fields := map[string]interface{}{
+		"rules":                 len(i.GetSpec().GetRules()),
+		"paths":                 paths,
+		"tls":                   len(i.GetSpec().GetTls()),
+		"load_balancer_ingress": len(i.GetStatus().GetLoadBalancer().GetIngress()),
+		"age_seconds":           age(i.GetMetadata().GetCreationTimestamp()),
+	}
+	tags := map[string]string{
+		"ingress_name": i.GetMetadata().GetName(),
+		"namespace":    i.GetMetadata().GetNamespace(),
+	}
+
+	acc.AddFields(ingressMeasurement, fields, tags)
+}
@@ -0,0 +1,275 @@
+package kube_inventory
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/apis/resource"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// KubernetesInventory represents the config object for the plugin.
+type KubernetesInventory struct {
+	URL             string            `toml:"url"`
+	BearerToken     string            `toml:"bearer_token"`
+	Namespace       string            `toml:"namespace"`
+	ResponseTimeout internal.Duration `toml:"response_timeout"`
+	ResourceExclude []string          `toml:"resource_exclude"`
+	ResourceInclude []string          `toml:"resource_include"`
+
+	tls.ClientConfig
+
+	client *client
+	filter filter.Filter
+}
+
+var sampleConfig = `
+  ## URL for the Kubernetes API server, if empty the in-cluster
+  ## configuration of the pod's service account is used.
+  # url = "https://127.0.0.1:6443"
+
+  ## Namespace to use, if empty objects of all namespaces are collected.
+  ## Nodes are not namespaced and always collected.
+  # namespace = ""
+
+  ## Use bearer token for authorization
+  # bearer_token = "/path/to/bearer/token"
+
+  ## Set response_timeout (default 5 seconds)
+  # response_timeout = "5s"
+
+  ## Optional Resources to exclude from gathering
+  ## Leave blank to gather everything available.
+  ## Values can be - "daemonsets", "deployments", "ingresses", "nodes",
+  ## "persistentvolumeclaims", "pods", "services", "statefulsets"
+  # resource_exclude = [ "deployments", "nodes", "statefulsets" ]
+
+  ## Optional Resources to include when gathering
+  # resource_include = [ "deployments", "nodes", "statefulsets" ]
+
+  ## Optional TLS Config
+  # tls_ca = "/path/to/cafile"
+  # tls_cert = "/path/to/certfile"
+  # tls_key = "/path/to/keyfile"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+const (
+	daemonSetMeasurement             = "kubernetes_daemonset"
+	deploymentMeasurement            = "kubernetes_deployment"
+	ingressMeasurement               = "kubernetes_ingress"
+	nodeMeasurement                  = "kubernetes_node"
+	persistentVolumeClaimMeasurement = "kubernetes_persistentvolumeclaim"
+	podMeasurement                   = "kubernetes_pod"
+	podContainerMeasurement          = "kubernetes_pod_container"
+	serviceMeasurement               = "kubernetes_service"
+	statefulSetMeasurement           = "kubernetes_statefulset"
+)
+
+// collector gathers the metrics of one kind of resource.
+type collector func(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory)
+
+var availableCollectors = map[string]collector{
+	"daemonsets":             collectDaemonSets,
+	"deployments":            collectDeployments,
+	"ingresses":              collectIngresses,
+	"nodes":                  collectNodes,
+	"persistentvolumeclaims": collectPersistentVolumeClaims,
+	"pods":                   collectPods,
+	"services":               collectServices,
+	"statefulsets":           collectStatefulSets,
+}
+
+// timeNow returns the current time, ages are computed relative to it.
+var timeNow = time.Now
+
+// SampleConfig returns a sample config
+func (ki *KubernetesInventory) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns the description of this plugin
+func (ki *KubernetesInventory) Description() string {
+	return "Read the state of objects in a Kubernetes cluster from the API server"
+}
+
+// Init creates the API client and the resource filter.
+func (ki *KubernetesInventory) Init() error {
+	for _, resources := range [][]string{ki.ResourceInclude, ki.ResourceExclude} {
+		for _, name := range resources {
+			_, ok := availableCollectors[name]
+			if !ok && !strings.ContainsAny(name, "*?[") {
+				return fmt.Errorf("unknown resource %q", name)
+			}
+		}
+	}
+
+	f, err := filter.NewIncludeExcludeFilter(ki.ResourceInclude, ki.ResourceExclude)
+	if err != nil {
+		return err
+	}
+	ki.filter = f
+
+	ki.client, err = newClient(ki.URL, ki.Namespace, ki.BearerToken,
+		ki.ResponseTimeout.Duration, ki.ClientConfig)
+	return err
+}
+
+// Gather collects the state of the selected resources.
+func (ki *KubernetesInventory) Gather(acc telegraf.Accumulator) error {
+	if ki.client == nil {
+		if err := ki.Init(); err != nil {
+			return err
+		}
+	}
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for name, collect := range availableCollectors {
+		if !ki.filter.Match(name) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(collect collector) {
+			defer wg.Done()
+			collect(ctx, acc, ki)
+		}(collect)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// conditionStatus converts the status of a condition to an integer.
+func conditionStatus(status string) int {
+	switch status {
+	case "True":
+		return 1
+	case "False":
+		return 0
+	default:
+		return -1
+	}
+}
+
+// addCondition adds a point for the condition of an object, the tags of the
+// object are extended by the condition type.
+func addCondition(
+	acc telegraf.Accumulator,
+	measurement string,
+	tags map[string]string,
+	condition string,
+	status string,
+	transition *metav1.Time,
+) {
+	ctags := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		ctags[k] = v
+	}
+	ctags["condition"] = condition
+
+	fields := map[string]interface{}{
+		"status": conditionStatus(status),
+	}
+	if transition != nil && transition.GetSeconds() != 0 {
+		fields["last_transition_seconds"] = age(transition)
+	}
+	acc.AddFields(measurement+"_condition", fields, ctags)
+}
+
+// age returns the number of seconds elapsed since the given time.
+func age(t *metav1.Time) int64 {
+	if t == nil || t.GetSeconds() == 0 {
+		return 0
+	}
+	return timeNow().Unix() - t.GetSeconds()
+}
+
+// quantity returns the value of a resource quantity in the given unit, e.g. a
+// unit of 0.001 returns millicores for a quantity of cpu cores.  Quantities
+// that cannot be parsed are reported as 0.
+func quantity(q *resource.Quantity, unit float64) int64 {
+	if q == nil {
+		return 0
+	}
+
+	v, err := parseQuantity(q.GetString_())
+	if err != nil {
+		log.Printf("D! [inputs.kube_inventory] %v", err)
+		return 0
+	}
+	return int64(math.Ceil(v / unit))
+}
+
+var quantitySuffixes = map[string]float64{
+	"n":  1e-9,
+	"u":  1e-6,
+	"m":  1e-3,
+	"":   1,
+	"k":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
+// parseQuantity parses the serialized form of a Kubernetes resource quantity,
+// a decimal number followed by a binary or decimal SI suffix or an exponent.
+func parseQuantity(s string) (float64, error) {
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '+' && r != '-'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	number, suffix := s[:i], s[i:]
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+
+	if len(suffix) > 1 && (suffix[0] == 'e' || suffix[0] == 'E') {
+		exp, err := strconv.Atoi(suffix[1:])
+		if err != nil {
+			return 0, fmt.Errorf("invalid quantity %q", s)
+		}
+		return v * math.Pow10(exp), nil
+	}
+
+	multiplier, ok := quantitySuffixes[suffix]
+	if !ok {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	return v * multiplier, nil
+}
+
+func init() {
+	inputs.Add("kube_inventory", func() telegraf.Input {
+		return &KubernetesInventory{
+			ResponseTimeout: internal.Duration{Duration: time.Second * 5},
+		}
+	})
+}
@@ -0,0 +1,549 @@
+package kube_inventory
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ericchiang/k8s"
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+	extv1beta1 "github.com/ericchiang/k8s/apis/extensions/v1beta1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/ericchiang/k8s/apis/resource"
+	"github.com/ericchiang/k8s/runtime"
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	now     = time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)
+	created = &metav1.Time{Seconds: int64p(now.Unix() - 3600)}
+)
+
+func int64p(i int64) *int64 {
+	return &i
+}
+
+func quantityp(s string) *resource.Quantity {
+	return &resource.Quantity{String_: k8s.String(s)}
+}
+
+func objectMeta(name string) *metav1.ObjectMeta {
+	return &metav1.ObjectMeta{
+		Name:              k8s.String(name),
+		Namespace:         k8s.String("ns1"),
+		Generation:        int64p(11),
+		CreationTimestamp: created,
+	}
+}
+
+// apiServer is a stub of the Kubernetes API server returning protobuf
+// encoded lists.
+type apiServer struct {
+	sync.Mutex
+	*httptest.Server
+
+	lists    map[string]proto.Message
+	requests []string
+	auth     string
+}
+
+func newAPIServer(t *testing.T, lists map[string]proto.Message) *apiServer {
+	s := &apiServer{lists: lists}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		s.requests = append(s.requests, r.URL.Path)
+		s.auth = r.Header.Get("Authorization")
+		s.Unlock()
+
+		list, ok := s.lists[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		raw, err := proto.Marshal(list)
+		require.NoError(t, err)
+		body, err := (&runtime.Unknown{Raw: raw}).Marshal()
+		require.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/vnd.kubernetes.protobuf")
+		w.Write(append([]byte{0x6b, 0x38, 0x73, 0x00}, body...))
+	}))
+	return s
+}
+
+func (s *apiServer) Requests() []string {
+	s.Lock()
+	defer s.Unlock()
+	return s.requests
+}
+
+func inventoryLists() map[string]proto.Message {
+	return map[string]proto.Message{
+		"/apis/apps/v1/deployments": &appsv1.DeploymentList{
+			Items: []*appsv1.Deployment{{
+				Metadata: objectMeta("deploy1"),
+				Spec:     &appsv1.DeploymentSpec{Replicas: k8s.Int32(3)},
+				Status: &appsv1.DeploymentStatus{
+					AvailableReplicas:   k8s.Int32(2),
+					UnavailableReplicas: k8s.Int32(1),
+					ReadyReplicas:       k8s.Int32(2),
+					UpdatedReplicas:     k8s.Int32(3),
+					Conditions: []*appsv1.DeploymentCondition{{
+						Type:   k8s.String("Available"),
+						Status: k8s.String("False"),
+					}},
+				},
+			}},
+		},
+		"/apis/apps/v1/daemonsets": &appsv1.DaemonSetList{
+			Items: []*appsv1.DaemonSet{{
+				Metadata: objectMeta("daemon1"),
+				Status: &appsv1.DaemonSetStatus{
+					CurrentNumberScheduled: k8s.Int32(3),
+					DesiredNumberScheduled: k8s.Int32(3),
+					NumberAvailable:        k8s.Int32(2),
+					NumberMisscheduled:     k8s.Int32(0),
+					NumberReady:            k8s.Int32(2),
+					NumberUnavailable:      k8s.Int32(1),
+					UpdatedNumberScheduled: k8s.Int32(3),
+				},
+			}},
+		},
+		"/apis/apps/v1/statefulsets": &appsv1.StatefulSetList{
+			Items: []*appsv1.StatefulSet{{
+				Metadata: objectMeta("sts1"),
+				Spec:     &appsv1.StatefulSetSpec{Replicas: k8s.Int32(2)},
+				Status: &appsv1.StatefulSetStatus{
+					ObservedGeneration: int64p(10),
+					Replicas:           k8s.Int32(2),
+					CurrentReplicas:    k8s.Int32(2),
+					ReadyReplicas:      k8s.Int32(1),
+					UpdatedReplicas:    k8s.Int32(2),
+				},
+			}},
+		},
+		"/api/v1/nodes": &corev1.NodeList{
+			Items: []*corev1.Node{{
+				Metadata: &metav1.ObjectMeta{
+					Name:              k8s.String("node1"),
+					CreationTimestamp: created,
+				},
+				Spec: &corev1.NodeSpec{},
+				Status: &corev1.NodeStatus{
+					Capacity: map[string]*resource.Quantity{
+						"cpu":    quantityp("4"),
+						"memory": quantityp("16Gi"),
+						"pods":   quantityp("110"),
+					},
+					Allocatable: map[string]*resource.Quantity{
+						"cpu":    quantityp("3800m"),
+						"memory": quantityp("15Gi"),
+						"pods":   quantityp("110"),
+					},
+					Conditions: []*corev1.NodeCondition{{
+						Type:               k8s.String("Ready"),
+						Status:             k8s.String("True"),
+						LastTransitionTime: &metav1.Time{Seconds: int64p(now.Unix() - 60)},
+					}},
+				},
+			}},
+		},
+		"/api/v1/pods": &corev1.PodList{
+			Items: []*corev1.Pod{{
+				Metadata: objectMeta("pod1"),
+				Spec: &corev1.PodSpec{
+					NodeName: k8s.String("node1"),
+					Containers: []*corev1.Container{{
+						Name: k8s.String("app"),
+						Resources: &corev1.ResourceRequirements{
+							Requests: map[string]*resource.Quantity{
+								"cpu":    quantityp("250m"),
+								"memory": quantityp("64Mi"),
+							},
+							Limits: map[string]*resource.Quantity{
+								"cpu":    quantityp("1"),
+								"memory": quantityp("128Mi"),
+							},
+						},
+					}, {
+						Name: k8s.String("sidecar"),
+					}},
+				},
+				Status: &corev1.PodStatus{
+					Phase: k8s.String("Running"),
+					ContainerStatuses: []*corev1.ContainerStatus{{
+						Name:         k8s.String("app"),
+						Ready:        k8s.Bool(true),
+						RestartCount: k8s.Int32(3),
+						State: &corev1.ContainerState{
+							Running: &corev1.ContainerStateRunning{},
+						},
+					}, {
+						Name:         k8s.String("sidecar"),
+						RestartCount: k8s.Int32(1),
+						State: &corev1.ContainerState{
+							Waiting: &corev1.ContainerStateWaiting{
+								Reason: k8s.String("CrashLoopBackOff"),
+							},
+						},
+					}},
+					Conditions: []*corev1.PodCondition{{
+						Type:   k8s.String("Ready"),
+						Status: k8s.String("False"),
+					}},
+				},
+			}},
+		},
+		"/api/v1/persistentvolumeclaims": &corev1.PersistentVolumeClaimList{
+			Items: []*corev1.PersistentVolumeClaim{{
+				Metadata: objectMeta("pvc1"),
+				Spec: &corev1.PersistentVolumeClaimSpec{
+					StorageClassName: k8s.String("ebs"),
+					VolumeName:       k8s.String("pv1"),
+					Resources: &corev1.ResourceRequirements{
+						Requests: map[string]*resource.Quantity{
+							"storage": quantityp("10Gi"),
+						},
+					},
+				},
+				Status: &corev1.PersistentVolumeClaimStatus{
+					Phase: k8s.String("Bound"),
+					Capacity: map[string]*resource.Quantity{
+						"storage": quantityp("10Gi"),
+					},
+				},
+			}},
+		},
+		"/api/v1/services": &corev1.ServiceList{
+			Items: []*corev1.Service{{
+				Metadata: objectMeta("svc1"),
+				Spec: &corev1.ServiceSpec{
+					Type:        k8s.String("LoadBalancer"),
+					Ports:       []*corev1.ServicePort{{Port: k8s.Int32(80)}, {Port: k8s.Int32(443)}},
+					ExternalIPs: []string{"1.2.3.4"},
+				},
+				Status: &corev1.ServiceStatus{
+					LoadBalancer: &corev1.LoadBalancerStatus{
+						Ingress: []*corev1.LoadBalancerIngress{{Ip: k8s.String("1.2.3.4")}},
+					},
+				},
+			}},
+		},
+		"/apis/extensions/v1beta1/ingresses": &extv1beta1.IngressList{
+			Items: []*extv1beta1.Ingress{{
+				Metadata: objectMeta("ingress1"),
+				Spec: &extv1beta1.IngressSpec{
+					Rules: []*extv1beta1.IngressRule{{
+						Host: k8s.String("example.org"),
+						IngressRuleValue: &extv1beta1.IngressRuleValue{
+							Http: &extv1beta1.HTTPIngressRuleValue{
+								Paths: []*extv1beta1.HTTPIngressPath{
+									{Path: k8s.String("/")},
+									{Path: k8s.String("/api")},
+								},
+							},
+						},
+					}},
+					Tls: []*extv1beta1.IngressTLS{{}},
+				},
+			}},
+		},
+	}
+}
+
+func TestGather(t *testing.T) {
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	ts := newAPIServer(t, inventoryLists())
+	defer ts.Close()
+
+	token, err := ioutil.TempFile("", "token")
+	require.NoError(t, err)
+	defer os.Remove(token.Name())
+	_, err = token.WriteString("secret")
+	require.NoError(t, err)
+	require.NoError(t, token.Close())
+
+	ki := &KubernetesInventory{
+		URL:         ts.URL,
+		BearerToken: token.Name(),
+	}
+	require.NoError(t, ki.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(ki.Gather))
+	assert.Equal(t, "Bearer secret", ts.auth)
+
+	acc.AssertContainsTaggedFields(t, "kubernetes_deployment",
+		map[string]interface{}{
+			"replicas_desired":     int32(3),
+			"replicas_available":   int32(2),
+			"replicas_unavailable": int32(1),
+			"replicas_ready":       int32(2),
+			"replicas_updated":     int32(3),
+			"age_seconds":          int64(3600),
+		},
+		map[string]string{
+			"deployment_name": "deploy1",
+			"namespace":       "ns1",
+		})
+	acc.AssertContainsTaggedFields(t, "kubernetes_deployment_condition",
+		map[string]interface{}{
+			"status": 0,
+		},
+		map[string]string{
+			"deployment_name": "deploy1",
+			"namespace":       "ns1",
+			"condition":       "Available",
+		})
+	acc.AssertContainsTaggedFields(t, "kubernetes_daemonset",
+		map[string]interface{}{
+			"generation":               int64(11),
+			"current_number_scheduled": int32(3),
+			"desired_number_scheduled": int32(3),
+			"number_available":         int32(2),
+			"number_misscheduled":      int32(0),
+			"number_ready":             int32(2),
+			"number_unavailable":       int32(1),
+			"updated_number_scheduled": int32(3),
+			"age_seconds":              int64(3600),
+		},
+		map[string]string{
+			"daemonset_name": "daemon1",
+			"namespace":      "ns1",
+		})
+	acc.AssertContainsTaggedFields(t, "kubernetes_statefulset",
+		map[string]interface{}{
+			"generation":          int64(11),
+			"observed_generation": int64(10),
+			"replicas_desired":    int32(2),
+			"replicas":            int32(2),
+			"replicas_current":    int32(2),
+			"replicas_ready":      int32(1),
+			"replicas_updated":    int32(2),
+			"age_seconds":         int64(3600),
+		},
+		map[string]string{
+			"statefulset_name": "sts1",
+			"namespace":        "ns1",
+		})
+	acc.AssertContainsTaggedFields(t, "kubernetes_node",
+		map[string]interface{}{
+			"unschedulable":              false,
+			"capacity_millicpu_units":    int64(4000),
+			"capacity_memory_bytes":      int64(16 << 30),
+			"capacity_pods":              int64(110),
+			"allocatable_millicpu_units": int64(3800),
+			"allocatable_memory_bytes":   int64(15 << 30),
+			"allocatable_pods":           int64(110),
+			"age_seconds":                int64(3600),
+		},
+		map[string]string{
+			"node_name": "node1",
+		})
+	acc.AssertContainsTaggedFields(t, "kubernetes_node_condition",
+		map[string]interface{}{
+			"status":                  1,
+			"last_transition_seconds": int64(60),
+		},
+		map[string]string{
+			"node_name": "node1",
+			"condition": "Ready",
+		})
+	acc.AssertContainsTaggedFields(t, "kubernetes_pod",
+		map[string]interface{}{
+			"containers":     2,
+			"restarts_total": int32(4),
+			"age_seconds":    int64(3600),
+		},
+		map[string]string{
+			"pod_name":  "pod1",
+			"namespace": "ns1",
+			"node_name": "node1",
+			"phase":     "Running",
+		})
+	acc.AssertContainsTaggedFields(t, "kubernetes_pod_condition",
+		map[string]interface{}{
+			"status": 0,
+		},
+		map[string]string{
+			"pod_name":  "pod1",
+			"namespace": "ns1",
+			"node_name": "node1",
+			"condition": "Ready",
+		})
+	acc.AssertContainsTaggedFields(t, "kubernetes_pod_container",
+		map[string]interface{}{
+			"restarts_total":                   int32(3),
+			"ready":                            true,
+			"state_code":                       containerRunning,
+			"resource_requests_millicpu_units": int64(250),
+			"resource_requests_memory_bytes":   int64(64 << 20),
+			"resource_limits_millicpu_units":   int64(1000),
+			"resource_limits_memory_bytes":     int64(128 << 20),
+		},
+		map[string]string{
+			"container_name": "app",
+			"pod_name":       "pod1",
+			"namespace":      "ns1",
+			"node_name":      "node1",
+		})
+	acc.AssertContainsTaggedFields(t, "kubernetes_pod_container",
+		map[string]interface{}{
+			"restarts_total": int32(1),
+			"ready":          false,
+			"state_code":     containerWaiting,
+			"state_reason":   "CrashLoopBackOff",
+		},
+		map[string]string{
+			"container_name": "sidecar",
+			"pod_name":       "pod1",
+			"namespace":      "ns1",
+			"node_name":      "node1",
+		})
+	acc.AssertContainsTaggedFields(t, "kubernetes_persistentvolumeclaim",
+		map[string]interface{}{
+			"requests_storage_bytes": int64(10 << 30),
+			"capacity_storage_bytes": int64(10 << 30),
+			"age_seconds":            int64(3600),
+		},
+		map[string]string{
+			"pvc_name":     "pvc1",
+			"namespace":    "ns1",
+			"phase":        "Bound",
+			"storageclass": "ebs",
+			"volume_name":  "pv1",
+		})
+	acc.AssertContainsTaggedFields(t, "kubernetes_service",
+		map[string]interface{}{
+			"ports":                 2,
+			"external_ips":          1,
+			"load_balancer_ingress": 1,
+			"age_seconds":           int64(3600),
+		},
+		map[string]string{
+			"service_name": "svc1",
+			"namespace":    "ns1",
+			"type":         "LoadBalancer",
+		})
+	acc.AssertContainsTaggedFields(t, "kubernetes_ingress",
+		map[string]interface{}{
+			"rules":                 1,
+			"paths":                 2,
+			"tls":                   1,
+			"load_balancer_ingress": 0,
+			"age_seconds":           int64(3600),
+		},
+		map[string]string{
+			"ingress_name": "ingress1",
+			"namespace":    "ns1",
+		})
+}
+
+func TestGatherNamespace(t *testing.T) {
+	ts := newAPIServer(t, map[string]proto.Message{
+		"/api/v1/namespaces/ns1/pods": &corev1.PodList{},
+		"/api/v1/nodes":               &corev1.NodeList{},
+	})
+	defer ts.Close()
+
+	ki := &KubernetesInventory{
+		URL:             ts.URL,
+		Namespace:       "ns1",
+		ResourceInclude: []string{"pods", "nodes"},
+	}
+	require.NoError(t, ki.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(ki.Gather))
+	assert.ElementsMatch(t,
+		[]string{"/api/v1/namespaces/ns1/pods", "/api/v1/nodes"},
+		ts.Requests())
+}
+
+func TestGatherResourceExclude(t *testing.T) {
+	ts := newAPIServer(t, inventoryLists())
+	defer ts.Close()
+
+	ki := &KubernetesInventory{
+		URL:             ts.URL,
+		ResourceExclude: []string{"pods", "nodes", "services", "ingresses"},
+	}
+	require.NoError(t, ki.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(ki.Gather))
+	assert.ElementsMatch(t,
+		[]string{
+			"/apis/apps/v1/daemonsets",
+			"/apis/apps/v1/deployments",
+			"/api/v1/persistentvolumeclaims",
+			"/apis/apps/v1/statefulsets",
+		},
+		ts.Requests())
+	assert.False(t, acc.HasMeasurement("kubernetes_pod"))
+}
+
+func TestGatherError(t *testing.T) {
+	ts := newAPIServer(t, map[string]proto.Message{})
+	defer ts.Close()
+
+	ki := &KubernetesInventory{
+		URL:             ts.URL,
+		ResourceInclude: []string{"deployments"},
+	}
+	require.NoError(t, ki.Init())
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(ki.Gather))
+}
+
+func TestInitUnknownResource(t *testing.T) {
+	ki := &KubernetesInventory{
+		URL:             "http://127.0.0.1:6443",
+		ResourceInclude: []string{"replicasets"},
+	}
+	require.Error(t, ki.Init())
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+		err      bool
+	}{
+		{input: "4", expected: 4},
+		{input: "250m", expected: 0.25},
+		{input: "1.5", expected: 1.5},
+		{input: "64Mi", expected: 64 * 1024 * 1024},
+		{input: "1Gi", expected: 1024 * 1024 * 1024},
+		{input: "2k", expected: 2000},
+		{input: "1G", expected: 1e9},
+		{input: "12e3", expected: 12000},
+		{input: "100n", expected: 100e-9},
+		{input: "1Xi", err: true},
+		{input: "", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			v, err := parseQuantity(tt.input)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, tt.expected, v, 1e-12)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package kube_inventory
+
+import (
+	"context"
+
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectNodes(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getNodes(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for _, n := range list.Items {
+		ki.gatherNode(n, acc)
+	}
+}
+
+func (ki *KubernetesInventory) gatherNode(n *corev1.Node, acc telegraf.Accumulator) {
+	status := n.GetStatus()
+	fields := map[string]interface{}{
+		"unschedulable": n.GetSpec().GetUnschedulable(),
+		"age_seconds":   age(n.GetMetadata().GetCreationTimestamp()),
+	}
+	tags := map[string]string{
+		"node_name": n.GetMetadata().GetName(),
+	}
+
+	for resourceName, val := range status.GetCapacity() {
+		switch resourceName {
+		case "cpu":
+			fields["capacity_millicpu_units"] = quantity(val, 1e-3)
+		case "memory":
+			fields["capacity_memory_bytes"] = quantity(val, 1)
+		case "pods":
+			fields["capacity_pods"] = quantity(val, 1)
+		}
+	}
+
+	for resourceName, val := range status.GetAllocatable() {
+		switch resourceName {
+		case "cpu":
+			fields["allocatable_millicpu_units"] = quantity(val, 1e-3)
+		case "memory":
+			fields["allocatable_memory_bytes"] = quantity(val, 1)
+		case "pods":
+			fields["allocatable_pods"] = quantity(val, 1)
+		}
+	}
+
+	acc.AddFields(nodeMeasurement, fields, tags)
+
+	for _, c := range status.GetConditions() {
+		addCondition(acc, nodeMeasurement, tags,
+			c.GetType(), c.GetStatus(), c.GetLastTransitionTime())
+	}
+}
@@ -0,0 +1,49 @@
+package kube_inventory
+
+import (
+	"context"
+
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectPersistentVolumeClaims(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getPersistentVolumeClaims(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for _, pvc := range list.Items {
+		ki.gatherPersistentVolumeClaim(pvc, acc)
+	}
+}
+
+func (ki *KubernetesInventory) gatherPersistentVolumeClaim(pvc *corev1.PersistentVolumeClaim, acc telegraf.Accumulator) {
+	status := pvc.GetStatus()
+	fields := map[string]interface{}{
+		"age_seconds": age(pvc.GetMetadata().GetCreationTimestamp()),
+	}
+	if q, ok := pvc.GetSpec().GetResources().GetRequests()["storage"]; ok {
+		fields["requests_storage_bytes"] = quantity(q, 1)
+	}
+	if q, ok := status.GetCapacity()["storage"]; ok {
+		fields["capacity_storage_bytes"] = quantity(q, 1)
+	}
+
+	tags := map[string]string{
+		"pvc_name":     pvc.GetMetadata().GetName(),
+		"namespace":    pvc.GetMetadata().GetNamespace(),
+		"phase":        status.GetPhase(),
+		"storageclass": pvc.GetSpec().GetStorageClassName(),
+		"volume_name":  pvc.GetSpec().GetVolumeName(),
+	}
+
+	acc.AddFields(persistentVolumeClaimMeasurement, fields, tags)
+
+	delete(tags, "phase")
+	for _, c := range status.GetConditions() {
+		addCondition(acc, persistentVolumeClaimMeasurement, tags,
+			c.GetType(), c.GetStatus(), c.GetLastTransitionTime())
+	}
+}
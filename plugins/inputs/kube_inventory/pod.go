@@ -0,0 +1,111 @@
+package kube_inventory
+
+import (
+	"context"
+
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectPods(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getPods(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for _, p := range list.Items {
+		ki.gatherPod(p, acc)
+	}
+}
+
+func (ki *KubernetesInventory) gatherPod(p *corev1.Pod, acc telegraf.Accumulator) {
+	status := p.GetStatus()
+	tags := map[string]string{
+		"pod_name":  p.GetMetadata().GetName(),
+		"namespace": p.GetMetadata().GetNamespace(),
+		"node_name": p.GetSpec().GetNodeName(),
+		"phase":     status.GetPhase(),
+	}
+
+	statuses := make(map[string]*corev1.ContainerStatus)
+	for _, cs := range status.GetContainerStatuses() {
+		statuses[cs.GetName()] = cs
+	}
+
+	var restarts int32
+	for _, c := range p.GetSpec().GetContainers() {
+		cs := statuses[c.GetName()]
+		restarts += cs.GetRestartCount()
+		gatherPodContainer(p, c, cs, acc)
+	}
+
+	fields := map[string]interface{}{
+		"containers":     len(p.GetSpec().GetContainers()),
+		"restarts_total": restarts,
+		"age_seconds":    age(p.GetMetadata().GetCreationTimestamp()),
+	}
+	acc.AddFields(podMeasurement, fields, tags)
+
+	delete(tags, "phase")
+	for _, c := range status.GetConditions() {
+		addCondition(acc, podMeasurement, tags,
+			c.GetType(), c.GetStatus(), c.GetLastTransitionTime())
+	}
+}
+
+// Container states reported in the state_code field.
+const (
+	containerRunning = iota
+	containerTerminated
+	containerWaiting
+	containerUnknown
+)
+
+func gatherPodContainer(p *corev1.Pod, c *corev1.Container, cs *corev1.ContainerStatus, acc telegraf.Accumulator) {
+	fields := map[string]interface{}{
+		"restarts_total": cs.GetRestartCount(),
+		"ready":          cs.GetReady(),
+	}
+
+	state := cs.GetState()
+	switch {
+	case state.GetRunning() != nil:
+		fields["state_code"] = containerRunning
+	case state.GetTerminated() != nil:
+		fields["state_code"] = containerTerminated
+		fields["state_reason"] = state.GetTerminated().GetReason()
+		fields["exit_code"] = state.GetTerminated().GetExitCode()
+	case state.GetWaiting() != nil:
+		fields["state_code"] = containerWaiting
+		fields["state_reason"] = state.GetWaiting().GetReason()
+	default:
+		fields["state_code"] = containerUnknown
+	}
+
+	for resourceName, val := range c.GetResources().GetRequests() {
+		switch resourceName {
+		case "cpu":
+			fields["resource_requests_millicpu_units"] = quantity(val, 1e-3)
+		case "memory":
+			fields["resource_requests_memory_bytes"] = quantity(val, 1)
+		}
+	}
+	for resourceName, val := range c.GetResources().GetLimits() {
+		switch resourceName {
+		case "cpu":
+			fields["resource_limits_millicpu_units"] = quantity(val, 1e-3)
+		case "memory":
+			fields["resource_limits_memory_bytes"] = quantity(val, 1)
+		}
+	}
+
+	tags := map[string]string{
+		"container_name": c.GetName(),
+		"pod_name":       p.GetMetadata().GetName(),
+		"namespace":      p.GetMetadata().GetNamespace(),
+		"node_name":      p.GetSpec().GetNodeName(),
+	}
+
+	acc.AddFields(podContainerMeasurement, fields, tags)
+}
@@ -0,0 +1,37 @@
+package kube_inventory
+
+import (
+	"context"
+
+	corev1 "github.com/ericchiang/k8s/apis/core/v1"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectServices(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getServices(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for _, s := range list.Items {
+		ki.gatherService(s, acc)
+	}
+}
+
+func (ki *KubernetesInventory) gatherService(s *corev1.Service, acc telegraf.Accumulator) {
+	spec := s.GetSpec()
+	fields := map[string]interface{}{
+		"ports":                 len(spec.GetPorts()),
+		"external_ips":          len(spec.GetExternalIPs()),
+		"load_balancer_ingress": len(s.GetStatus().GetLoadBalancer().GetIngress()),
+		"age_seconds":           age(s.GetMetadata().GetCreationTimestamp()),
+	}
+	tags := map[string]string{
+		"service_name": s.GetMetadata().GetName(),
+		"namespace":    s.GetMetadata().GetNamespace(),
+		"type":         spec.GetType(),
+	}
+
+	acc.AddFields(serviceMeasurement, fields, tags)
+}
@@ -0,0 +1,45 @@
+package kube_inventory
+
+import (
+	"context"
+
+	appsv1 "github.com/ericchiang/k8s/apis/apps/v1"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectStatefulSets(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getStatefulSets(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for _, s := range list.Items {
+		ki.gatherStatefulSet(s, acc)
+	}
+}
+
+func (ki *KubernetesInventory) gatherStatefulSet(s *appsv1.StatefulSet, acc telegraf.Accumulator) {
+	status := s.GetStatus()
+	fields := map[string]interface{}{
+		"generation":          s.GetMetadata().GetGeneration(),
+		"observed_generation": status.GetObservedGeneration(),
+		"replicas_desired":    s.GetSpec().GetReplicas(),
+		"replicas":            status.GetReplicas(),
+		"replicas_current":    status.GetCurrentReplicas(),
+		"replicas_ready":      status.GetReadyReplicas(),
+		"replicas_updated":    status.GetUpdatedReplicas(),
+		"age_seconds":         age(s.GetMetadata().GetCreationTimestamp()),
+	}
+	tags := map[string]string{
+		"statefulset_name": s.GetMetadata().GetName(),
+		"namespace":        s.GetMetadata().GetNamespace(),
+	}
+
+	acc.AddFields(statefulSetMeasurement, fields, tags)
+
+	for _, c := range status.GetConditions() {
+		addCondition(acc, statefulSetMeasurement, tags,
+			c.GetType(), c.GetStatus(), c.GetLastTransitionTime())
+	}
+}
@@ -3,14 +3,19 @@
 package logparser
 
 import (
+	"fmt"
 	"log"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/influxdata/tail"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/internal/multiline"
+	"github.com/influxdata/telegraf/internal/tailstate"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	// Parsers
@@ -33,6 +38,8 @@ type GrokConfig struct {
 type logEntry struct {
 	path string
 	line string
+	// offset is the position in the file following the entry
+	offset int64
 }
 
 // LogParserPlugin is the primary struct to implement the interface for logparser plugin
@@ -40,13 +47,25 @@ type LogParserPlugin struct {
 	Files         []string
 	FromBeginning bool
 	WatchMethod   string
+	StateFile     string
+	Multiline     *multiline.Config
 
 	tailers map[string]*tail.Tail
+	state   *tailstate.State
 	lines   chan logEntry
 	done    chan struct{}
 	wg      sync.WaitGroup
 	acc     telegraf.Accumulator
 
+	// receivers tracks the goroutines reading the files, they are waited
+	// for before the parser is stopped so that pending records are sent
+	receivers sync.WaitGroup
+
+	// offsets holds the position following the last entry parsed from each
+	// file, it is updated by the parser
+	offsets   map[string]int64
+	offsetsMu sync.Mutex
+
 	sync.Mutex
 
 	GrokParser parsers.Parser
@@ -70,6 +89,33 @@ const sampleConfig = `
   ## Method used to watch for file updates.  Can be either "inotify" or "poll".
   # watch_method = "inotify"
 
+  ## File used to save the position in each tailed file, allowing files to be
+  ## resumed after a restart.  Files that were truncated or rotated while
+  ## telegraf was not running are read from the beginning.  Each plugin
+  ## instance requires its own state file.
+  # state_file = ""
+
+  ## Group lines belonging to the same event, such as stack traces, into a
+  ## single record before parsing.
+  # [inputs.logparser.multiline]
+    ## Regular expression lines are matched against.
+    # pattern = '^\s'
+
+    ## Either "previous" or "next".  A matching line is joined to the lines
+    ## before it with "previous", or to the lines after it with "next".
+    # match_which_line = "previous"
+
+    ## Apply match_which_line to lines not matching the pattern instead, for
+    ## example to join all lines to the last one matching a start pattern.
+    # invert_match = false
+
+    ## Maximum number of lines in a record, 0 is unlimited.
+    # max_lines = 0
+
+    ## Time after which a pending record is parsed even if no new line
+    ## completed it.
+    # timeout = "5s"
+
   ## Parse logstash-style "grok" patterns:
   [inputs.logparser.grok]
     ## This is a list of patterns to check the given log file(s) for.
@@ -117,6 +163,8 @@ func (l *LogParserPlugin) Gather(acc telegraf.Accumulator) error {
 	l.Lock()
 	defer l.Unlock()
 
+	l.saveState()
+
 	// always start from the beginning of files that appear while we're running
 	return l.tailNewfiles(true)
 }
@@ -126,10 +174,25 @@ func (l *LogParserPlugin) Start(acc telegraf.Accumulator) error {
 	l.Lock()
 	defer l.Unlock()
 
+	if l.Multiline != nil {
+		if _, err := l.Multiline.NewMultiline(); err != nil {
+			return err
+		}
+	}
+
+	if l.StateFile != "" {
+		state, err := tailstate.Load(l.StateFile)
+		if err != nil {
+			return fmt.Errorf("error loading state file %s: %s", l.StateFile, err)
+		}
+		l.state = state
+	}
+
 	l.acc = acc
 	l.lines = make(chan logEntry, 1000)
 	l.done = make(chan struct{})
 	l.tailers = make(map[string]*tail.Tail)
+	l.offsets = make(map[string]int64)
 
 	mName := "logparser"
 	if l.GrokConfig.MeasurementName != "" {
@@ -188,14 +251,40 @@ func (l *LogParserPlugin) tailNewfiles(fromBeginning bool) error {
 				continue
 			}
 
+			location := seek
+			var offset int64
+			if location.Whence == 2 {
+				// seek to the current size so that the position of the
+				// lines read is known
+				if stat, err := os.Stat(file); err == nil {
+					offset = stat.Size()
+					location = tail.SeekInfo{
+						Whence: 0,
+						Offset: offset,
+					}
+				}
+			}
+			if l.state != nil {
+				resume, status := l.state.Resume(file)
+				if status != tailstate.NotFound {
+					log.Printf("D! [inputs.logparser] resuming file %s at offset %d, file %s", file, resume, status)
+					offset = resume
+					location = tail.SeekInfo{
+						Whence: 0,
+						Offset: offset,
+					}
+				}
+			}
+
+			logger := tailstate.NewReopenLogger()
 			tailer, err := tail.TailFile(file,
 				tail.Config{
 					ReOpen:    true,
 					Follow:    true,
-					Location:  &seek,
+					Location:  &location,
 					MustExist: true,
 					Poll:      poll,
-					Logger:    tail.DiscardingLogger,
+					Logger:    logger,
 				})
 			if err != nil {
 				l.acc.AddError(err)
@@ -205,8 +294,8 @@ func (l *LogParserPlugin) tailNewfiles(fromBeginning bool) error {
 			log.Printf("D! [inputs.logparser] tail added for file: %v", file)
 
 			// create a goroutine for each "tailer"
-			l.wg.Add(1)
-			go l.receiver(tailer)
+			l.receivers.Add(1)
+			go l.receiver(tailer, offset, logger.Reopened)
 			l.tailers[file] = tailer
 		}
 	}
@@ -215,12 +304,48 @@ func (l *LogParserPlugin) tailNewfiles(fromBeginning bool) error {
 }
 
 // receiver is launched as a goroutine to continuously watch a tailed logfile
-// for changes and send any log lines down the l.lines channel.
-func (l *LogParserPlugin) receiver(tailer *tail.Tail) {
-	defer l.wg.Done()
+// for changes and send any log lines down the l.lines channel.  offset is the
+// position in the file of the first line read, it is reset when reopened
+// receives a value.
+func (l *LogParserPlugin) receiver(tailer *tail.Tail, offset int64, reopened <-chan struct{}) {
+	defer l.receivers.Done()
+
+	var mline *multiline.Multiline
+	var timer *time.Timer
+	var timeout <-chan time.Time
+	if l.Multiline != nil {
+		// the config was validated on start
+		mline, _ = l.Multiline.NewMultiline()
+		timer = time.NewTimer(mline.Timeout())
+		defer timer.Stop()
+		timeout = timer.C
+	}
 
-	var line *tail.Line
-	for line = range tailer.Lines {
+	for {
+		var line *tail.Line
+		var ok bool
+		select {
+		case line, ok = <-tailer.Lines:
+		case <-timeout:
+			if text, ok := mline.Flush(); ok {
+				l.send(tailer.Filename, text, offset)
+			}
+			continue
+		case <-reopened:
+			// The file was rotated or truncated, the pending record belongs
+			// to the previous file and the next lines start at the beginning.
+			if mline != nil {
+				if text, ok := mline.Flush(); ok {
+					l.send(tailer.Filename, text, offset)
+				}
+			}
+			offset = 0
+			l.send(tailer.Filename, "", offset)
+			continue
+		}
+		if !ok {
+			break
+		}
 
 		if line.Err != nil {
 			log.Printf("E! Error tailing file %s, Error: %s\n",
@@ -228,50 +353,134 @@ func (l *LogParserPlugin) receiver(tailer *tail.Tail) {
 			continue
 		}
 
+		// The line ending is stripped by the tailer.
+		start := offset
+		offset += int64(len(line.Text)) + 1
+		emitted := offset
+
 		// Fix up files with Windows line endings.
 		text := strings.TrimRight(line.Text, "\r")
 
-		entry := logEntry{
-			path: tailer.Filename,
-			line: text,
+		if mline != nil {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(mline.Timeout())
+
+			var complete bool
+			text, complete = mline.ProcessLine(text)
+			if !complete {
+				continue
+			}
+			// the line starts the next group
+			if mline.Pending() {
+				emitted = start
+			}
 		}
 
-		select {
-		case <-l.done:
-		case l.lines <- entry:
+		l.send(tailer.Filename, text, emitted)
+	}
+
+	if mline != nil {
+		if text, ok := mline.Flush(); ok {
+			l.send(tailer.Filename, text, offset)
 		}
 	}
 }
 
+// send queues the text for parsing.  offset is the position in the file
+// following the text, empty text is not parsed but still updates it.
+func (l *LogParserPlugin) send(path string, text string, offset int64) {
+	entry := logEntry{
+		path:   path,
+		line:   text,
+		offset: offset,
+	}
+
+	select {
+	case <-l.done:
+	case l.lines <- entry:
+	}
+}
+
 // parse is launched as a goroutine to watch the l.lines channel.
 // when a line is available, parse parses it and adds the metric(s) to the
 // accumulator.
 func (l *LogParserPlugin) parser() {
 	defer l.wg.Done()
 
-	var m telegraf.Metric
-	var err error
 	var entry logEntry
 	for {
 		select {
 		case <-l.done:
-			return
-		case entry = <-l.lines:
-			if entry.line == "" || entry.line == "\n" {
-				continue
+			// parse the lines already read, their position may have been
+			// saved
+			for {
+				select {
+				case entry = <-l.lines:
+					l.parse(entry)
+					l.setOffset(entry.path, entry.offset)
+				default:
+					return
+				}
 			}
+		case entry = <-l.lines:
+			l.parse(entry)
+			l.setOffset(entry.path, entry.offset)
 		}
-		m, err = l.GrokParser.ParseLine(entry.line)
-		if err == nil {
-			if m != nil {
-				tags := m.Tags()
-				tags["path"] = entry.path
-				l.acc.AddFields(m.Name(), m.Fields(), tags, m.Time())
-			}
-		} else {
-			log.Println("E! Error parsing log line: " + err.Error())
+	}
+}
+
+// setOffset records the position following the last entry parsed from the
+// file.
+func (l *LogParserPlugin) setOffset(path string, offset int64) {
+	l.offsetsMu.Lock()
+	l.offsets[path] = offset
+	l.offsetsMu.Unlock()
+}
+
+func (l *LogParserPlugin) parse(entry logEntry) {
+	if entry.line == "" || entry.line == "\n" {
+		return
+	}
+
+	m, err := l.GrokParser.ParseLine(entry.line)
+	if err == nil {
+		if m != nil {
+			tags := m.Tags()
+			tags["path"] = entry.path
+			l.acc.AddFields(m.Name(), m.Fields(), tags, m.Time())
 		}
+	} else {
+		log.Println("E! Error parsing log line: " + err.Error())
+	}
+}
 
+// saveState records the position following the last entry parsed from each
+// tailed file in the state file.
+func (l *LogParserPlugin) saveState() {
+	if l.state == nil {
+		return
+	}
+
+	l.offsetsMu.Lock()
+	defer l.offsetsMu.Unlock()
+
+	for filename := range l.tailers {
+		offset, ok := l.offsets[filename]
+		if !ok {
+			continue
+		}
+		if err := l.state.Set(filename, offset); err != nil {
+			log.Printf("D! [inputs.logparser] unable to record position of %s: %s", filename, err)
+		}
+	}
+
+	if err := l.state.Save(); err != nil {
+		log.Printf("E! Error saving state file %s: %s", l.StateFile, err)
 	}
 }
 
@@ -291,8 +500,15 @@ func (l *LogParserPlugin) Stop() {
 		}
 		t.Cleanup()
 	}
+
+	// The receivers flush their pending multiline records once their tailer
+	// is stopped, the parser must still be running to accept them.
+	l.receivers.Wait()
 	close(l.done)
 	l.wg.Wait()
+
+	// the parser is done, the offsets include the entries flushed on stop
+	l.saveState()
 }
 
 func init() {
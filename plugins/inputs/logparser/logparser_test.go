@@ -3,10 +3,15 @@ package logparser
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/multiline"
+	"github.com/influxdata/telegraf/internal/tailstate"
 	"github.com/influxdata/telegraf/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -161,3 +166,242 @@ func getCurrentDir() string {
 	_, filename, _, _ := runtime.Caller(1)
 	return strings.Replace(filename, "logparser_test.go", "", 1)
 }
+
+func TestGrokParseLogFilesMultiline(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	assert.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString("ERROR: Traceback (most recent call last):\n  File \"x.py\", line 1\nValueError\nINFO: done\n")
+	assert.NoError(t, err)
+	tmpfile.Close()
+
+	logparser := &LogParserPlugin{
+		FromBeginning: true,
+		Files:         []string{tmpfile.Name()},
+		Multiline: &multiline.Config{
+			Pattern:     `^[A-Z]+: `,
+			InvertMatch: true,
+			Timeout:     &internal.Duration{Duration: 100 * time.Millisecond},
+		},
+		GrokConfig: GrokConfig{
+			MeasurementName: "log",
+			Patterns:        []string{"%{WORD:level:tag}: %{MULTILINE:message}"},
+			CustomPatterns:  "MULTILINE (?s:.*)",
+		},
+	}
+
+	acc := testutil.Accumulator{}
+	assert.NoError(t, logparser.Start(&acc))
+	acc.Wait(2)
+
+	logparser.Stop()
+
+	acc.AssertContainsTaggedFields(t, "log",
+		map[string]interface{}{
+			"message": "Traceback (most recent call last):\n  File \"x.py\", line 1\nValueError",
+		},
+		map[string]string{
+			"level": "ERROR",
+			"path":  tmpfile.Name(),
+		})
+
+	acc.AssertContainsTaggedFields(t, "log",
+		map[string]interface{}{
+			"message": "done",
+		},
+		map[string]string{
+			"level": "INFO",
+			"path":  tmpfile.Name(),
+		})
+}
+
+func TestGrokParseLogFilesMultilineFlushOnStop(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	assert.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString("ERROR: first\nINFO: last\n")
+	assert.NoError(t, err)
+	tmpfile.Close()
+
+	logparser := &LogParserPlugin{
+		FromBeginning: true,
+		Files:         []string{tmpfile.Name()},
+		Multiline: &multiline.Config{
+			Pattern:     `^[A-Z]+: `,
+			InvertMatch: true,
+			Timeout:     &internal.Duration{Duration: time.Hour},
+		},
+		GrokConfig: GrokConfig{
+			MeasurementName: "log",
+			Patterns:        []string{"%{WORD:level:tag}: %{GREEDYDATA:message}"},
+		},
+	}
+
+	acc := testutil.Accumulator{}
+	assert.NoError(t, logparser.Start(&acc))
+	acc.Wait(1)
+
+	// The last record is still pending and is parsed on stop.
+	logparser.Stop()
+
+	assert.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "log",
+		map[string]interface{}{
+			"message": "last",
+		},
+		map[string]string{
+			"level": "INFO",
+			"path":  tmpfile.Name(),
+		})
+}
+
+func TestGrokParseLogFilesStateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "test.log")
+	statePath := filepath.Join(dir, "state")
+	assert.NoError(t, ioutil.WriteFile(logPath, []byte("value 1\n"), 0644))
+
+	newLogParser := func() *LogParserPlugin {
+		return &LogParserPlugin{
+			FromBeginning: true,
+			StateFile:     statePath,
+			Files:         []string{logPath},
+			GrokConfig: GrokConfig{
+				MeasurementName: "log",
+				Patterns:        []string{"value %{NUMBER:value:int}"},
+			},
+		}
+	}
+
+	logparser := newLogParser()
+	acc := testutil.Accumulator{}
+	assert.NoError(t, logparser.Start(&acc))
+	acc.Wait(1)
+	logparser.Stop()
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString("value 2\n")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	logparser = newLogParser()
+	acc = testutil.Accumulator{}
+	assert.NoError(t, logparser.Start(&acc))
+	acc.Wait(1)
+	logparser.Stop()
+
+	assert.Len(t, acc.Metrics, 1)
+	acc.AssertContainsFields(t, "log",
+		map[string]interface{}{
+			"value": int64(2),
+		})
+}
+
+func TestGrokParseLogFilesStateFileMultiline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "test.log")
+	statePath := filepath.Join(dir, "state")
+	assert.NoError(t, ioutil.WriteFile(logPath, []byte("ERROR: first\nINFO: last\n"), 0644))
+
+	logparser := &LogParserPlugin{
+		FromBeginning: true,
+		StateFile:     statePath,
+		Files:         []string{logPath},
+		Multiline: &multiline.Config{
+			Pattern:     `^[A-Z]+: `,
+			InvertMatch: true,
+			Timeout:     &internal.Duration{Duration: time.Hour},
+		},
+		GrokConfig: GrokConfig{
+			MeasurementName: "log",
+			Patterns:        []string{"%{WORD:level:tag}: %{GREEDYDATA:message}"},
+		},
+	}
+
+	acc := testutil.Accumulator{}
+	assert.NoError(t, logparser.Start(&acc))
+	defer logparser.Stop()
+	acc.Wait(1)
+
+	// The last line was read but is still pending, the saved position is
+	// the start of that line.
+	var offset int64
+	for i := 0; i < 100 && offset == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		logparser.offsetsMu.Lock()
+		offset = logparser.offsets[logPath]
+		logparser.offsetsMu.Unlock()
+	}
+	assert.Equal(t, int64(len("ERROR: first\n")), offset)
+
+	assert.NoError(t, logparser.Gather(&acc))
+	state, err := tailstate.Load(statePath)
+	assert.NoError(t, err)
+	resume, status := state.Resume(logPath)
+	assert.Equal(t, tailstate.Unchanged, status)
+	assert.Equal(t, offset, resume)
+}
+
+func TestGrokParseLogFilesStateFileRotated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "test.log")
+	statePath := filepath.Join(dir, "state")
+	assert.NoError(t, ioutil.WriteFile(logPath, []byte("value 1\nvalue 2\n"), 0644))
+
+	newLogParser := func() *LogParserPlugin {
+		return &LogParserPlugin{
+			FromBeginning: true,
+			StateFile:     statePath,
+			Files:         []string{logPath},
+			GrokConfig: GrokConfig{
+				MeasurementName: "log",
+				Patterns:        []string{"value %{NUMBER:value:int}"},
+			},
+		}
+	}
+
+	logparser := newLogParser()
+	acc := testutil.Accumulator{}
+	assert.NoError(t, logparser.Start(&acc))
+	acc.Wait(2)
+
+	// The lines of the new file are counted from its beginning.
+	assert.NoError(t, os.Rename(logPath, logPath+".1"))
+	assert.NoError(t, ioutil.WriteFile(logPath, []byte("value 3\n"), 0644))
+	acc.Wait(3)
+	logparser.Stop()
+
+	state, err := tailstate.Load(statePath)
+	assert.NoError(t, err)
+	offset, status := state.Resume(logPath)
+	assert.Equal(t, tailstate.Unchanged, status)
+	assert.Equal(t, int64(len("value 3\n")), offset)
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	_, err = f.WriteString("value 4\n")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	logparser = newLogParser()
+	acc = testutil.Accumulator{}
+	assert.NoError(t, logparser.Start(&acc))
+	acc.Wait(1)
+	logparser.Stop()
+
+	assert.Len(t, acc.Metrics, 1)
+	acc.AssertContainsFields(t, "log",
+		map[string]interface{}{
+			"value": int64(4),
+		})
+}
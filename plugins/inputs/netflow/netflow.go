@@ -0,0 +1,179 @@
+package netflow
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// protocolDecoder decodes the flow records of a single UDP packet.
+type protocolDecoder interface {
+	Init() error
+	Decode(src net.IP, payload []byte) ([]telegraf.Metric, error)
+}
+
+type NetFlow struct {
+	ServiceAddress string        `toml:"service_address"`
+	ReadBufferSize internal.Size `toml:"read_buffer_size"`
+	Protocol       string        `toml:"protocol"`
+
+	conn    *net.UDPConn
+	decoder protocolDecoder
+	acc     telegraf.Accumulator
+	wg      sync.WaitGroup
+
+	PacketsRecv  selfstat.Stat
+	BytesRecv    selfstat.Stat
+	DecodeErrors selfstat.Stat
+}
+
+const sampleConfig = `
+  ## Address to listen for flow packets.
+  ##   example: service_address = "udp://:2055"
+  ##            service_address = "udp4://:2055"
+  ##            service_address = "udp6://:2055"
+  service_address = "udp://:2055"
+
+  ## Set the size of the operating system's receive buffer.
+  ##   example: read_buffer_size = "64KiB"
+  ## Uses the system's default if not set.
+  # read_buffer_size = ""
+
+  ## Protocol version to use for decoding.
+  ## Available options are
+  ##   "netflow" -- NetFlow v5, NetFlow v9 and IPFIX, detected from each packet
+  ##   "sflow"   -- sFlow v5 flow samples
+  # protocol = "netflow"
+`
+
+func (n *NetFlow) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *NetFlow) Description() string {
+	return "NetFlow v5, NetFlow v9, IPFIX and sFlow v5 collector"
+}
+
+func (n *NetFlow) Gather(_ telegraf.Accumulator) error {
+	return nil
+}
+
+func (n *NetFlow) Start(acc telegraf.Accumulator) error {
+	switch strings.ToLower(n.Protocol) {
+	case "", "netflow":
+		n.decoder = &netflowDecoder{}
+	case "sflow":
+		n.decoder = &sflowDecoder{}
+	default:
+		return fmt.Errorf("invalid protocol %q", n.Protocol)
+	}
+	if err := n.decoder.Init(); err != nil {
+		return err
+	}
+
+	u, err := parseAddress(n.ServiceAddress)
+	if err != nil {
+		return err
+	}
+	addr, err := net.ResolveUDPAddr(u[0], u[1])
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP(u[0], addr)
+	if err != nil {
+		return err
+	}
+	if n.ReadBufferSize.Size > 0 {
+		if err := conn.SetReadBuffer(int(n.ReadBufferSize.Size)); err != nil {
+			conn.Close()
+			return fmt.Errorf("unable to set read buffer: %v", err)
+		}
+	}
+	n.conn = conn
+	n.acc = acc
+
+	tags := map[string]string{
+		"address":  n.ServiceAddress,
+		"protocol": n.protocol(),
+	}
+	n.PacketsRecv = selfstat.Register("netflow", "packets_received", tags)
+	n.BytesRecv = selfstat.Register("netflow", "bytes_received", tags)
+	n.DecodeErrors = selfstat.Register("netflow", "decode_errors", tags)
+
+	n.wg.Add(1)
+	go n.read(conn)
+
+	log.Printf("I! [inputs.netflow] listening for %s packets on %s", n.protocol(), conn.LocalAddr())
+	return nil
+}
+
+func (n *NetFlow) Stop() {
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn = nil
+	}
+	n.wg.Wait()
+}
+
+func (n *NetFlow) read(conn *net.UDPConn) {
+	defer n.wg.Done()
+
+	buf := make([]byte, 64*1024) // 64kb - maximum size of IP packet
+	for {
+		count, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
+				n.acc.AddError(err)
+			}
+			break
+		}
+		n.PacketsRecv.Incr(1)
+		n.BytesRecv.Incr(int64(count))
+
+		metrics, err := n.decoder.Decode(src.IP, buf[:count])
+		for _, m := range metrics {
+			n.acc.AddMetric(m)
+		}
+		if err != nil {
+			n.DecodeErrors.Incr(1)
+			n.acc.AddError(fmt.Errorf("unable to decode packet from %s: %v", src.IP, err))
+		}
+	}
+}
+
+func (n *NetFlow) protocol() string {
+	if n.Protocol == "" {
+		return "netflow"
+	}
+	return strings.ToLower(n.Protocol)
+}
+
+// parseAddress splits the service address into network and address, the
+// network defaulting to "udp".
+func parseAddress(address string) ([]string, error) {
+	u := strings.SplitN(address, "://", 2)
+	if len(u) == 1 {
+		return []string{"udp", address}, nil
+	}
+	switch u[0] {
+	case "udp", "udp4", "udp6":
+		return u, nil
+	}
+	return nil, fmt.Errorf("unsupported network %q in service address %q", u[0], address)
+}
+
+func init() {
+	inputs.Add("netflow", func() telegraf.Input {
+		return &NetFlow{
+			ServiceAddress: "udp://:2055",
+			Protocol:       "netflow",
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package netflow
+
+import (
+	"net"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetFlow_udp(t *testing.T) {
+	n := &NetFlow{ServiceAddress: "udp://127.0.0.1:0"}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, n.Start(acc))
+	defer n.Stop()
+
+	conn, err := net.Dial("udp", n.conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write(decodeHexPacket(t, v9Header+"00000001"+v9Template+v9Data))
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "netflow",
+		map[string]interface{}{
+			"in_bytes": uint64(1024),
+		},
+		map[string]string{
+			"source":   "127.0.0.1",
+			"version":  "NetFlowV9",
+			"src":      "192.168.0.1",
+			"dst":      "192.168.0.2",
+			"protocol": "udp",
+		},
+	)
+}
+
+func TestSflow_udp(t *testing.T) {
+	n := &NetFlow{ServiceAddress: "udp://127.0.0.1:0", Protocol: "sflow"}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, n.Start(acc))
+	defer n.Stop()
+
+	conn, err := net.Dial("udp", n.conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write(sflowDatagram(t))
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	require.True(t, acc.HasMeasurement("sflow"))
+	require.Len(t, acc.Errors, 0)
+}
+
+func TestNetFlow_decodeError(t *testing.T) {
+	n := &NetFlow{ServiceAddress: "udp://127.0.0.1:0"}
+	acc := &testutil.Accumulator{}
+	require.NoError(t, n.Start(acc))
+	defer n.Stop()
+
+	conn, err := net.Dial("udp", n.conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte{0, 42})
+	require.NoError(t, err)
+
+	acc.WaitError(1)
+	require.Equal(t, int64(1), n.DecodeErrors.Get())
+}
+
+func TestNetFlow_invalidConfig(t *testing.T) {
+	n := &NetFlow{ServiceAddress: "udp://127.0.0.1:0", Protocol: "jflow"}
+	require.Error(t, n.Start(&testutil.Accumulator{}))
+
+	n = &NetFlow{ServiceAddress: "tcp://127.0.0.1:0"}
+	require.Error(t, n.Start(&testutil.Accumulator{}))
+}
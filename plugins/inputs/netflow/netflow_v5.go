@@ -0,0 +1,73 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const (
+	netflowV5HeaderLength = 24
+	netflowV5RecordLength = 48
+)
+
+// decodeNetflowV5 decodes a NetFlow v5 packet.  Version 5 uses a fixed record
+// layout, so no templates are involved.
+func decodeNetflowV5(src net.IP, payload []byte, t time.Time) ([]telegraf.Metric, error) {
+	if len(payload) < netflowV5HeaderLength {
+		return nil, fmt.Errorf("netflow v5 packet too short (%d bytes)", len(payload))
+	}
+
+	count := int(binary.BigEndian.Uint16(payload[2:]))
+	if len(payload) < netflowV5HeaderLength+count*netflowV5RecordLength {
+		return nil, fmt.Errorf("netflow v5 packet too short for %d records (%d bytes)", count, len(payload))
+	}
+	seq := uint64(binary.BigEndian.Uint32(payload[16:]))
+	engineType := uint64(payload[20])
+	engineID := uint64(payload[21])
+	samplingInterval := uint64(binary.BigEndian.Uint16(payload[22:]) & 0x3fff)
+
+	tags := map[string]string{
+		"source":  src.String(),
+		"version": "NetFlowV5",
+	}
+
+	metrics := make([]telegraf.Metric, 0, count)
+	for i := 0; i < count; i++ {
+		r := payload[netflowV5HeaderLength+i*netflowV5RecordLength:]
+		fields := map[string]interface{}{
+			"src":               net.IP(r[0:4]).String(),
+			"dst":               net.IP(r[4:8]).String(),
+			"next_hop":          net.IP(r[8:12]).String(),
+			"in_snmp":           uint64(binary.BigEndian.Uint16(r[12:])),
+			"out_snmp":          uint64(binary.BigEndian.Uint16(r[14:])),
+			"in_packets":        uint64(binary.BigEndian.Uint32(r[16:])),
+			"in_bytes":          uint64(binary.BigEndian.Uint32(r[20:])),
+			"first_switched":    uint64(binary.BigEndian.Uint32(r[24:])),
+			"last_switched":     uint64(binary.BigEndian.Uint32(r[28:])),
+			"src_port":          uint64(binary.BigEndian.Uint16(r[32:])),
+			"dst_port":          uint64(binary.BigEndian.Uint16(r[34:])),
+			"tcp_flags":         tcpFlags(uint64(r[37])),
+			"protocol":          l4ProtoName(uint64(r[38])),
+			"src_tos":           uint64(r[39]),
+			"bgp_src_as":        uint64(binary.BigEndian.Uint16(r[40:])),
+			"bgp_dst_as":        uint64(binary.BigEndian.Uint16(r[42:])),
+			"src_mask":          uint64(r[44]),
+			"dst_mask":          uint64(r[45]),
+			"flow_sequence":     seq,
+			"engine_type":       engineType,
+			"engine_id":         engineID,
+			"sampling_interval": samplingInterval,
+		}
+		m, err := metric.New("netflow", flowTags(tags, fields), fields, t)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
@@ -0,0 +1,69 @@
+package netflow
+
+import (
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeNetflowV5(t *testing.T) {
+	payload, err := hex.DecodeString(
+		"0005000100000100" + "5bfe8f000000000000000007" + "01020003" + // header
+			"c0a80001" + "c0a80002" + "00000000" + // src, dst, next hop
+			"00030004" + "0000000a" + "00000400" + // in/out interface, packets, bytes
+			"00000010" + "00000020" + // first, last
+			"d43500500012" + "0600" + // ports, pad, flags, proto, tos
+			"fde8fde9" + "18180000") // as, masks, pad
+	require.NoError(t, err)
+
+	d := &netflowDecoder{}
+	require.NoError(t, d.Init())
+	metrics, err := d.Decode(net.IPv4(127, 0, 0, 1), payload)
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+
+	m := metrics[0]
+	require.Equal(t, "netflow", m.Name())
+	require.Equal(t, map[string]string{
+		"source":   "127.0.0.1",
+		"version":  "NetFlowV5",
+		"src":      "192.168.0.1",
+		"dst":      "192.168.0.2",
+		"src_port": "54325",
+		"dst_port": "80",
+		"protocol": "tcp",
+		"in_snmp":  "3",
+		"out_snmp": "4",
+	}, m.Tags())
+	require.Equal(t, map[string]interface{}{
+		"next_hop":          "0.0.0.0",
+		"in_packets":        uint64(10),
+		"in_bytes":          uint64(1024),
+		"first_switched":    uint64(16),
+		"last_switched":     uint64(32),
+		"tcp_flags":         "...A..S.",
+		"src_tos":           uint64(0),
+		"bgp_src_as":        uint64(65000),
+		"bgp_dst_as":        uint64(65001),
+		"src_mask":          uint64(24),
+		"dst_mask":          uint64(24),
+		"flow_sequence":     uint64(7),
+		"engine_type":       uint64(1),
+		"engine_id":         uint64(2),
+		"sampling_interval": uint64(3),
+	}, m.Fields())
+	require.WithinDuration(t, time.Now(), m.Time(), time.Minute)
+}
+
+func TestDecodeNetflowV5_truncated(t *testing.T) {
+	payload, err := hex.DecodeString("0005000200000100" + "5bfe8f000000000000000007" + "01020003")
+	require.NoError(t, err)
+
+	d := &netflowDecoder{}
+	require.NoError(t, d.Init())
+	_, err = d.Decode(net.IPv4(127, 0, 0, 1), payload)
+	require.Error(t, err)
+}
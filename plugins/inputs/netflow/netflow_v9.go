@@ -0,0 +1,339 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const (
+	netflowV9HeaderLength = 20
+	ipfixHeaderLength     = 16
+
+	// variableLength marks IPFIX fields whose length is given in the record.
+	variableLength = 0xffff
+)
+
+type templateField struct {
+	length  uint16
+	mapping fieldMapping
+}
+
+type template struct {
+	fields []templateField
+}
+
+// minLength returns the smallest possible length of a record.
+func (t *template) minLength() int {
+	n := 0
+	for _, f := range t.fields {
+		if f.length == variableLength {
+			n++
+		} else {
+			n += int(f.length)
+		}
+	}
+	return n
+}
+
+// netflowDecoder decodes NetFlow v5, NetFlow v9 and IPFIX packets.  The
+// version is taken from the packet header, so exporters may use any of them.
+//
+// Templates are cached per exporter address, protocol version, source id or
+// observation domain and template id, as template ids are only unique within
+// that scope.
+type netflowDecoder struct {
+	templates map[string]*template
+	sync.Mutex
+}
+
+func (d *netflowDecoder) Init() error {
+	d.templates = make(map[string]*template)
+	return nil
+}
+
+func (d *netflowDecoder) Decode(src net.IP, payload []byte) ([]telegraf.Metric, error) {
+	if len(payload) < 2 {
+		return nil, errors.New("packet too short")
+	}
+
+	t := time.Now()
+	switch version := binary.BigEndian.Uint16(payload); version {
+	case 5:
+		return decodeNetflowV5(src, payload, t)
+	case 9:
+		return d.decodeNetflowV9(src, payload, t)
+	case 10:
+		return d.decodeIPFIX(src, payload, t)
+	default:
+		return nil, fmt.Errorf("unsupported netflow version %d", version)
+	}
+}
+
+func templateKey(src net.IP, version uint16, domain uint32, id uint16) string {
+	return src.String() + "/" + strconv.Itoa(int(version)) + "/" +
+		strconv.FormatUint(uint64(domain), 10) + "/" + strconv.Itoa(int(id))
+}
+
+func (d *netflowDecoder) addTemplate(key string, t *template) {
+	d.Lock()
+	d.templates[key] = t
+	d.Unlock()
+}
+
+func (d *netflowDecoder) removeTemplate(key string) {
+	d.Lock()
+	delete(d.templates, key)
+	d.Unlock()
+}
+
+func (d *netflowDecoder) lookupTemplate(key string) (*template, bool) {
+	d.Lock()
+	defer d.Unlock()
+	t, ok := d.templates[key]
+	return t, ok
+}
+
+// decodeNetflowV9 decodes a NetFlow v9 packet as described in RFC3954.
+func (d *netflowDecoder) decodeNetflowV9(src net.IP, payload []byte, t time.Time) ([]telegraf.Metric, error) {
+	if len(payload) < netflowV9HeaderLength {
+		return nil, fmt.Errorf("netflow v9 packet too short (%d bytes)", len(payload))
+	}
+	sourceID := binary.BigEndian.Uint32(payload[16:])
+
+	tags := map[string]string{
+		"source":  src.String(),
+		"version": "NetFlowV9",
+	}
+
+	var metrics []telegraf.Metric
+	err := forEachSet(payload[netflowV9HeaderLength:], func(id uint16, set []byte) error {
+		switch {
+		case id == 0:
+			return d.decodeNetflowV9Templates(src, sourceID, set)
+		case id == 1:
+			return d.decodeNetflowV9OptionsTemplates(src, sourceID, set)
+		case id >= 256:
+			m, err := d.decodeDataSet(templateKey(src, 9, sourceID, id), set, tags, t)
+			metrics = append(metrics, m...)
+			return err
+		}
+		return nil
+	})
+	return metrics, err
+}
+
+func (d *netflowDecoder) decodeNetflowV9Templates(src net.IP, sourceID uint32, set []byte) error {
+	for len(set) >= 4 {
+		id := binary.BigEndian.Uint16(set)
+		count := int(binary.BigEndian.Uint16(set[2:]))
+		set = set[4:]
+		if len(set) < count*4 {
+			return fmt.Errorf("template %d truncated", id)
+		}
+
+		t := &template{fields: make([]templateField, 0, count)}
+		for i := 0; i < count; i++ {
+			typ := binary.BigEndian.Uint16(set[i*4:])
+			t.fields = append(t.fields, templateField{
+				length:  binary.BigEndian.Uint16(set[i*4+2:]),
+				mapping: lookupField(typ, 0),
+			})
+		}
+		set = set[count*4:]
+		d.addTemplate(templateKey(src, 9, sourceID, id), t)
+	}
+	return nil
+}
+
+func (d *netflowDecoder) decodeNetflowV9OptionsTemplates(src net.IP, sourceID uint32, set []byte) error {
+	for len(set) >= 6 {
+		id := binary.BigEndian.Uint16(set)
+		scopeLength := int(binary.BigEndian.Uint16(set[2:]))
+		optionLength := int(binary.BigEndian.Uint16(set[4:]))
+		set = set[6:]
+		if len(set) < scopeLength+optionLength || scopeLength%4 != 0 || optionLength%4 != 0 {
+			return fmt.Errorf("options template %d truncated", id)
+		}
+
+		t := &template{fields: make([]templateField, 0, (scopeLength+optionLength)/4)}
+		for i := 0; i < scopeLength+optionLength; i += 4 {
+			typ := binary.BigEndian.Uint16(set[i:])
+			mapping, ok := fieldMappingsNetflowV9Scope[typ]
+			if !ok || i >= scopeLength {
+				mapping = lookupField(typ, 0)
+			}
+			t.fields = append(t.fields, templateField{
+				length:  binary.BigEndian.Uint16(set[i+2:]),
+				mapping: mapping,
+			})
+		}
+		set = set[scopeLength+optionLength:]
+		d.addTemplate(templateKey(src, 9, sourceID, id), t)
+	}
+	return nil
+}
+
+// decodeIPFIX decodes an IPFIX message as described in RFC7011.
+func (d *netflowDecoder) decodeIPFIX(src net.IP, payload []byte, t time.Time) ([]telegraf.Metric, error) {
+	if len(payload) < ipfixHeaderLength {
+		return nil, fmt.Errorf("ipfix message too short (%d bytes)", len(payload))
+	}
+	length := int(binary.BigEndian.Uint16(payload[2:]))
+	if length < ipfixHeaderLength || length > len(payload) {
+		return nil, fmt.Errorf("invalid ipfix message length %d", length)
+	}
+	domain := binary.BigEndian.Uint32(payload[12:])
+
+	tags := map[string]string{
+		"source":  src.String(),
+		"version": "IPFIX",
+	}
+
+	var metrics []telegraf.Metric
+	err := forEachSet(payload[ipfixHeaderLength:length], func(id uint16, set []byte) error {
+		switch {
+		case id == 2:
+			return d.decodeIPFIXTemplates(src, domain, set, false)
+		case id == 3:
+			return d.decodeIPFIXTemplates(src, domain, set, true)
+		case id >= 256:
+			m, err := d.decodeDataSet(templateKey(src, 10, domain, id), set, tags, t)
+			metrics = append(metrics, m...)
+			return err
+		}
+		return nil
+	})
+	return metrics, err
+}
+
+func (d *netflowDecoder) decodeIPFIXTemplates(src net.IP, domain uint32, set []byte, options bool) error {
+	headerLength := 4
+	if options {
+		headerLength = 6
+	}
+
+	for len(set) >= 4 {
+		id := binary.BigEndian.Uint16(set)
+		count := int(binary.BigEndian.Uint16(set[2:]))
+		if count == 0 {
+			// Template withdrawal
+			d.removeTemplate(templateKey(src, 10, domain, id))
+			set = set[4:]
+			continue
+		}
+		if len(set) < headerLength {
+			return fmt.Errorf("template %d truncated", id)
+		}
+		set = set[headerLength:]
+
+		t := &template{fields: make([]templateField, 0, count)}
+		for i := 0; i < count; i++ {
+			if len(set) < 4 {
+				return fmt.Errorf("template %d truncated", id)
+			}
+			typ := binary.BigEndian.Uint16(set)
+			length := binary.BigEndian.Uint16(set[2:])
+			set = set[4:]
+
+			var enterprise uint32
+			if typ&0x8000 != 0 {
+				if len(set) < 4 {
+					return fmt.Errorf("template %d truncated", id)
+				}
+				typ &= 0x7fff
+				enterprise = binary.BigEndian.Uint32(set)
+				set = set[4:]
+			}
+			t.fields = append(t.fields, templateField{
+				length:  length,
+				mapping: lookupField(typ, enterprise),
+			})
+		}
+		d.addTemplate(templateKey(src, 10, domain, id), t)
+	}
+	return nil
+}
+
+// decodeDataSet decodes the records of a data set using the cached template.
+// Data sets received before their template are skipped.
+func (d *netflowDecoder) decodeDataSet(key string, set []byte, tags map[string]string, t time.Time) ([]telegraf.Metric, error) {
+	tmpl, ok := d.lookupTemplate(key)
+	if !ok {
+		log.Printf("D! [inputs.netflow] skipping data set without template %s", key)
+		return nil, nil
+	}
+
+	minLength := tmpl.minLength()
+	if minLength == 0 {
+		return nil, nil
+	}
+
+	var metrics []telegraf.Metric
+	for len(set) >= minLength {
+		fields := make(map[string]interface{}, len(tmpl.fields))
+		for _, f := range tmpl.fields {
+			length := int(f.length)
+			if f.length == variableLength {
+				var err error
+				if length, set, err = readVariableLength(set); err != nil {
+					return metrics, err
+				}
+			}
+			if len(set) < length {
+				return metrics, errors.New("data record truncated")
+			}
+			if length > 0 {
+				fields[f.mapping.name] = f.mapping.decoder(set[:length])
+			}
+			set = set[length:]
+		}
+
+		m, err := metric.New("netflow", flowTags(tags, fields), fields, t)
+		if err != nil {
+			return metrics, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// readVariableLength reads the length prefix of a variable length field,
+// which is one byte or, if that byte is 255, the following two bytes.
+func readVariableLength(b []byte) (int, []byte, error) {
+	if len(b) < 1 {
+		return 0, nil, errors.New("data record truncated")
+	}
+	if b[0] < 255 {
+		return int(b[0]), b[1:], nil
+	}
+	if len(b) < 3 {
+		return 0, nil, errors.New("data record truncated")
+	}
+	return int(binary.BigEndian.Uint16(b[1:])), b[3:], nil
+}
+
+// forEachSet calls fn for each set (or flowset) of a packet with the set id and
+// its content without the set header.
+func forEachSet(b []byte, fn func(id uint16, set []byte) error) error {
+	for len(b) >= 4 {
+		id := binary.BigEndian.Uint16(b)
+		length := int(binary.BigEndian.Uint16(b[2:]))
+		if length < 4 || length > len(b) {
+			return fmt.Errorf("invalid length %d of set %d", length, id)
+		}
+		if err := fn(id, b[4:length]); err != nil {
+			return err
+		}
+		b = b[length:]
+	}
+	return nil
+}
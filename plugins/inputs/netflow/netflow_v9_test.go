@@ -0,0 +1,145 @@
+package netflow
+
+import (
+	"encoding/hex"
+	"net"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeHexPacket(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func decodeAll(t *testing.T, d protocolDecoder, src net.IP, packets ...string) []telegraf.Metric {
+	var metrics []telegraf.Metric
+	for _, p := range packets {
+		m, err := d.Decode(src, decodeHexPacket(t, p))
+		require.NoError(t, err)
+		metrics = append(metrics, m...)
+	}
+	return metrics
+}
+
+const (
+	v9Header = "00090001000001005bfe8f0000000001"
+	// template 256: src, dst, protocol, in_bytes
+	v9Template = "0000001801000004" + "00080004000c0004" + "0004000100010004"
+	v9Data     = "01000014" + "c0a80001c0a80002" + "11" + "00000400" + "000000"
+	// options template 257: scope interface, sampling_interval, sampling_algo
+	v9OptionsTemplate = "000100180101000400080002000400220004002300010000"
+	v9OptionsData     = "01010010" + "00000003" + "00000064" + "02" + "000000"
+)
+
+func TestDecodeNetflowV9(t *testing.T) {
+	d := &netflowDecoder{}
+	require.NoError(t, d.Init())
+	src := net.IPv4(127, 0, 0, 1)
+
+	// data received before the template is skipped
+	metrics := decodeAll(t, d, src, v9Header+"00000001"+v9Data)
+	require.Empty(t, metrics)
+
+	metrics = decodeAll(t, d, src,
+		v9Header+"00000001"+v9Template,
+		v9Header+"00000001"+v9Data+v9Data,
+	)
+	require.Len(t, metrics, 2)
+	for _, m := range metrics {
+		require.Equal(t, "netflow", m.Name())
+		require.Equal(t, map[string]string{
+			"source":   "127.0.0.1",
+			"version":  "NetFlowV9",
+			"src":      "192.168.0.1",
+			"dst":      "192.168.0.2",
+			"protocol": "udp",
+		}, m.Tags())
+		require.Equal(t, map[string]interface{}{
+			"in_bytes": uint64(1024),
+		}, m.Fields())
+	}
+
+	// templates are not shared between source ids and exporters
+	require.Empty(t, decodeAll(t, d, src, v9Header+"00000002"+v9Data))
+	require.Empty(t, decodeAll(t, d, net.IPv4(127, 0, 0, 2), v9Header+"00000001"+v9Data))
+}
+
+func TestDecodeNetflowV9_options(t *testing.T) {
+	d := &netflowDecoder{}
+	require.NoError(t, d.Init())
+
+	metrics := decodeAll(t, d, net.IPv4(127, 0, 0, 1), v9Header+"00000001"+v9OptionsTemplate+v9OptionsData)
+	require.Len(t, metrics, 1)
+	require.Equal(t, map[string]interface{}{
+		"scope_interface":   uint64(3),
+		"sampling_interval": uint64(100),
+		"sampling_algo":     uint64(2),
+	}, metrics[0].Fields())
+}
+
+func TestDecodeNetflowV9_malformed(t *testing.T) {
+	d := &netflowDecoder{}
+	require.NoError(t, d.Init())
+
+	_, err := d.Decode(net.IPv4(127, 0, 0, 1), decodeHexPacket(t, v9Header+"00000001"+"01000040"))
+	require.Error(t, err)
+	_, err = d.Decode(net.IPv4(127, 0, 0, 1), decodeHexPacket(t, "0009"))
+	require.Error(t, err)
+	_, err = d.Decode(net.IPv4(127, 0, 0, 1), decodeHexPacket(t, "0007"))
+	require.Error(t, err)
+}
+
+const (
+	// template 256: src, dst, variable length interface_name and an
+	// enterprise specific element
+	ipfixTemplate   = "0002001c01000004" + "00080004000c0004" + "0052ffff" + "8001000400007e28"
+	ipfixData       = "01000018" + "c0a80001c0a80002" + "0465746830" + "deadbeef" + "000000"
+	ipfixWithdrawal = "0002000801000000"
+)
+
+func ipfixMessage(sets ...string) string {
+	length := 16
+	for _, s := range sets {
+		length += len(s) / 2
+	}
+	msg := hex.EncodeToString([]byte{0, 10, byte(length >> 8), byte(length)}) + "5bfe8f000000000000000001"
+	for _, s := range sets {
+		msg += s
+	}
+	return msg
+}
+
+func TestDecodeIPFIX(t *testing.T) {
+	d := &netflowDecoder{}
+	require.NoError(t, d.Init())
+	src := net.IPv4(127, 0, 0, 1)
+
+	metrics := decodeAll(t, d, src, ipfixMessage(ipfixTemplate, ipfixData))
+	require.Len(t, metrics, 1)
+	require.Equal(t, map[string]string{
+		"source":  "127.0.0.1",
+		"version": "IPFIX",
+		"src":     "192.168.0.1",
+		"dst":     "192.168.0.2",
+	}, metrics[0].Tags())
+	require.Equal(t, map[string]interface{}{
+		"interface_name": "eth0",
+		"type_32296_1":   "deadbeef",
+	}, metrics[0].Fields())
+
+	// withdrawn templates are no longer used
+	metrics = decodeAll(t, d, src, ipfixMessage(ipfixWithdrawal), ipfixMessage(ipfixData))
+	require.Empty(t, metrics)
+}
+
+func TestDecodeIPFIX_invalidLength(t *testing.T) {
+	d := &netflowDecoder{}
+	require.NoError(t, d.Init())
+
+	_, err := d.Decode(net.IPv4(127, 0, 0, 1), decodeHexPacket(t, "000a00ff5bfe8f000000000000000001"))
+	require.Error(t, err)
+}
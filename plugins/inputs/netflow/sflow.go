@@ -0,0 +1,314 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// sFlow v5 sample and record formats, see https://sflow.org/sflow_version_5.txt
+const (
+	sflowFlowSample         = 1
+	sflowExpandedFlowSample = 3
+	sflowRawPacketHeader    = 1
+
+	sflowHeaderProtocolEthernet = 1
+	sflowHeaderProtocolIPv4     = 11
+	sflowHeaderProtocolIPv6     = 12
+)
+
+var errSflowTruncated = errors.New("sflow datagram truncated")
+
+// sflowReader reads the XDR encoded values of a sFlow datagram.
+type sflowReader struct {
+	b   []byte
+	err error
+}
+
+func (r *sflowReader) uint32() uint32 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.b) < 4 {
+		r.err = errSflowTruncated
+		return 0
+	}
+	v := binary.BigEndian.Uint32(r.b)
+	r.b = r.b[4:]
+	return v
+}
+
+// bytes reads n bytes followed by the padding to a multiple of four bytes.
+func (r *sflowReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	padded := (n + 3) &^ 3
+	if n < 0 || len(r.b) < padded {
+		r.err = errSflowTruncated
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[padded:]
+	return v
+}
+
+// sflowDecoder decodes the flow samples of sFlow v5 datagrams.  Counter
+// samples are skipped.
+type sflowDecoder struct{}
+
+func (d *sflowDecoder) Init() error {
+	return nil
+}
+
+func (d *sflowDecoder) Decode(_ net.IP, payload []byte) ([]telegraf.Metric, error) {
+	t := time.Now()
+	r := &sflowReader{b: payload}
+
+	if version := r.uint32(); r.err == nil && version != 5 {
+		return nil, fmt.Errorf("unsupported sflow version %d", version)
+	}
+	var agent net.IP
+	switch addrType := r.uint32(); addrType {
+	case 1:
+		agent = net.IP(r.bytes(net.IPv4len))
+	case 2:
+		agent = net.IP(r.bytes(net.IPv6len))
+	default:
+		if r.err == nil {
+			return nil, fmt.Errorf("unknown sflow agent address type %d", addrType)
+		}
+	}
+	r.uint32() // sub agent id
+	r.uint32() // sequence number
+	r.uint32() // uptime
+	count := int(r.uint32())
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var metrics []telegraf.Metric
+	for i := 0; i < count; i++ {
+		format := r.uint32()
+		data := r.bytes(int(r.uint32()))
+		if r.err != nil {
+			return metrics, r.err
+		}
+		if format>>12 != 0 {
+			// enterprise specific sample
+			continue
+		}
+
+		switch format & 0xfff {
+		case sflowFlowSample, sflowExpandedFlowSample:
+			m, err := decodeSflowFlowSample(agent, format&0xfff == sflowExpandedFlowSample, data, t)
+			metrics = append(metrics, m...)
+			if err != nil {
+				return metrics, err
+			}
+		}
+	}
+	return metrics, nil
+}
+
+func decodeSflowFlowSample(agent net.IP, expanded bool, data []byte, t time.Time) ([]telegraf.Metric, error) {
+	r := &sflowReader{b: data}
+
+	var sourceIDType, sourceIDIndex uint32
+	var input, output uint32
+	var inputFormat, outputFormat uint32
+
+	r.uint32() // sequence number
+	if expanded {
+		sourceIDType = r.uint32()
+		sourceIDIndex = r.uint32()
+	} else {
+		sourceID := r.uint32()
+		sourceIDType = sourceID >> 24
+		sourceIDIndex = sourceID & 0xffffff
+	}
+	samplingRate := uint64(r.uint32())
+	r.uint32() // sample pool
+	drops := uint64(r.uint32())
+	if expanded {
+		inputFormat, input = r.uint32(), r.uint32()
+		outputFormat, output = r.uint32(), r.uint32()
+	} else {
+		input, output = r.uint32(), r.uint32()
+		inputFormat, input = input>>30, input&0x3fffffff
+		outputFormat, output = output>>30, output&0x3fffffff
+	}
+	count := int(r.uint32())
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	tags := map[string]string{
+		"agent_address":   agent.String(),
+		"source_id_type":  strconv.FormatUint(uint64(sourceIDType), 10),
+		"source_id_index": strconv.FormatUint(uint64(sourceIDIndex), 10),
+	}
+	// A format other than zero denotes a discarded packet or multiple
+	// interfaces instead of an interface index.
+	if inputFormat == 0 {
+		tags["input_ifindex"] = strconv.FormatUint(uint64(input), 10)
+	}
+	if outputFormat == 0 {
+		tags["output_ifindex"] = strconv.FormatUint(uint64(output), 10)
+	}
+	if inputFormat == 0 && input == sourceIDIndex {
+		tags["sample_direction"] = "ingress"
+	} else {
+		tags["sample_direction"] = "egress"
+	}
+
+	var metrics []telegraf.Metric
+	for i := 0; i < count; i++ {
+		format := r.uint32()
+		record := r.bytes(int(r.uint32()))
+		if r.err != nil {
+			return metrics, r.err
+		}
+		if format != sflowRawPacketHeader {
+			continue
+		}
+
+		fields, err := decodeSflowRawPacketHeader(record)
+		if err != nil {
+			return metrics, err
+		}
+		fields["sampling_rate"] = samplingRate
+		fields["drops"] = drops
+		if frameLength, ok := fields["frame_length"].(uint64); ok {
+			fields["bytes"] = frameLength * samplingRate
+		}
+
+		m, err := metric.New("sflow", tags, fields, t)
+		if err != nil {
+			return metrics, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func decodeSflowRawPacketHeader(record []byte) (map[string]interface{}, error) {
+	r := &sflowReader{b: record}
+	protocol := r.uint32()
+	frameLength := r.uint32()
+	r.uint32() // stripped
+	header := r.bytes(int(r.uint32()))
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	fields := map[string]interface{}{
+		"frame_length": uint64(frameLength),
+	}
+	switch protocol {
+	case sflowHeaderProtocolEthernet:
+		fields["header_protocol"] = "ETHERNET-ISO8023"
+		decodeEthernetHeader(header, fields)
+	case sflowHeaderProtocolIPv4:
+		fields["header_protocol"] = "IPv4"
+		decodeIPv4Header(header, fields)
+	case sflowHeaderProtocolIPv6:
+		fields["header_protocol"] = "IPv6"
+		decodeIPv6Header(header, fields)
+	default:
+		fields["header_protocol"] = strconv.FormatUint(uint64(protocol), 10)
+	}
+	return fields, nil
+}
+
+// The decoders of the sampled packet header extract as much as the header
+// holds, as sampled headers are usually truncated.
+
+func decodeEthernetHeader(b []byte, fields map[string]interface{}) {
+	if len(b) < 14 {
+		return
+	}
+	fields["dst_mac"] = net.HardwareAddr(b[0:6]).String()
+	fields["src_mac"] = net.HardwareAddr(b[6:12]).String()
+	etherType := binary.BigEndian.Uint16(b[12:])
+	b = b[14:]
+	if etherType == 0x8100 && len(b) >= 4 {
+		fields["vlan"] = uint64(binary.BigEndian.Uint16(b) & 0x0fff)
+		etherType = binary.BigEndian.Uint16(b[2:])
+		b = b[4:]
+	}
+
+	switch etherType {
+	case 0x0800:
+		fields["ether_type"] = "IPv4"
+		decodeIPv4Header(b, fields)
+	case 0x86dd:
+		fields["ether_type"] = "IPv6"
+		decodeIPv6Header(b, fields)
+	case 0x0806:
+		fields["ether_type"] = "ARP"
+	default:
+		fields["ether_type"] = fmt.Sprintf("0x%04x", etherType)
+	}
+}
+
+func decodeIPv4Header(b []byte, fields map[string]interface{}) {
+	if len(b) < 20 {
+		return
+	}
+	headerLength := int(b[0]&0x0f) * 4
+	fields["ip_version"] = uint64(b[0] >> 4)
+	fields["ip_tos"] = uint64(b[1])
+	fields["ip_total_length"] = uint64(binary.BigEndian.Uint16(b[2:]))
+	fields["ip_flags"] = uint64(b[6] >> 5)
+	fields["ip_fragment_offset"] = uint64(binary.BigEndian.Uint16(b[6:]) & 0x1fff)
+	fields["ip_ttl"] = uint64(b[8])
+	fields["protocol"] = l4ProtoName(uint64(b[9]))
+	fields["src_ip"] = net.IP(b[12:16]).String()
+	fields["dst_ip"] = net.IP(b[16:20]).String()
+	if headerLength < 20 || len(b) < headerLength {
+		return
+	}
+	decodeL4Header(b[9], b[headerLength:], fields)
+}
+
+func decodeIPv6Header(b []byte, fields map[string]interface{}) {
+	if len(b) < 40 {
+		return
+	}
+	fields["ip_version"] = uint64(b[0] >> 4)
+	fields["ip_tos"] = uint64(binary.BigEndian.Uint16(b) >> 4 & 0xff)
+	fields["flow_label"] = uint64(binary.BigEndian.Uint32(b) & 0xfffff)
+	fields["ip_payload_length"] = uint64(binary.BigEndian.Uint16(b[4:]))
+	fields["ip_ttl"] = uint64(b[7])
+	fields["protocol"] = l4ProtoName(uint64(b[6]))
+	fields["src_ip"] = net.IP(b[8:24]).String()
+	fields["dst_ip"] = net.IP(b[24:40]).String()
+	decodeL4Header(b[6], b[40:], fields)
+}
+
+func decodeL4Header(proto byte, b []byte, fields map[string]interface{}) {
+	switch proto {
+	case 6:
+		if len(b) < 20 {
+			return
+		}
+		fields["src_port"] = uint64(binary.BigEndian.Uint16(b))
+		fields["dst_port"] = uint64(binary.BigEndian.Uint16(b[2:]))
+		fields["tcp_flags"] = tcpFlags(uint64(b[13]))
+		fields["tcp_window_size"] = uint64(binary.BigEndian.Uint16(b[14:]))
+	case 17:
+		if len(b) < 8 {
+			return
+		}
+		fields["src_port"] = uint64(binary.BigEndian.Uint16(b))
+		fields["dst_port"] = uint64(binary.BigEndian.Uint16(b[2:]))
+		fields["udp_length"] = uint64(binary.BigEndian.Uint16(b[4:]))
+	}
+}
@@ -0,0 +1,113 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// xdr builds sFlow test datagrams.
+type xdr []byte
+
+func (x xdr) uint32(v ...uint32) xdr {
+	for _, u := range v {
+		x = append(x, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(x[len(x)-4:], u)
+	}
+	return x
+}
+
+// opaque appends b prefixed with its length and padded to four bytes.
+func (x xdr) opaque(b []byte) xdr {
+	x = x.uint32(uint32(len(b)))
+	x = append(x, b...)
+	for len(x)%4 != 0 {
+		x = append(x, 0)
+	}
+	return x
+}
+
+func (x xdr) raw(b []byte) xdr {
+	return append(x, b...)
+}
+
+func sampledHeader(t *testing.T) []byte {
+	return decodeHexPacket(t,
+		"001122334455"+"66778899aabb"+"8100000a"+"0800"+ // ethernet with vlan 10
+			"450005dc0000400040060000"+"0a0000010a000002"+ // ipv4
+			"04d201bb"+"0000000000000000"+"5018100000000000") // tcp
+}
+
+func sflowDatagram(t *testing.T) []byte {
+	record := xdr{}.uint32(sflowHeaderProtocolEthernet, 1518, 4).opaque(sampledHeader(t))
+	flowSample := xdr{}.uint32(1, 3, 512, 1024, 0, 3, 4, 1).
+		uint32(sflowRawPacketHeader).opaque(record)
+	counterSample := xdr{}.uint32(1, 3, 0)
+
+	return xdr{}.uint32(5, 1).raw(net.IPv4(10, 0, 0, 1).To4()).uint32(0, 1, 1000, 2).
+		uint32(sflowFlowSample).opaque(flowSample).
+		uint32(2).opaque(counterSample)
+}
+
+func TestDecodeSflow(t *testing.T) {
+	d := &sflowDecoder{}
+	require.NoError(t, d.Init())
+
+	metrics, err := d.Decode(net.IPv4(127, 0, 0, 1), sflowDatagram(t))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+
+	m := metrics[0]
+	require.Equal(t, "sflow", m.Name())
+	require.Equal(t, map[string]string{
+		"agent_address":    "10.0.0.1",
+		"source_id_type":   "0",
+		"source_id_index":  "3",
+		"input_ifindex":    "3",
+		"output_ifindex":   "4",
+		"sample_direction": "ingress",
+	}, m.Tags())
+	require.Equal(t, map[string]interface{}{
+		"sampling_rate":      uint64(512),
+		"drops":              uint64(0),
+		"bytes":              uint64(1518 * 512),
+		"frame_length":       uint64(1518),
+		"header_protocol":    "ETHERNET-ISO8023",
+		"dst_mac":            "00:11:22:33:44:55",
+		"src_mac":            "66:77:88:99:aa:bb",
+		"vlan":               uint64(10),
+		"ether_type":         "IPv4",
+		"ip_version":         uint64(4),
+		"ip_tos":             uint64(0),
+		"ip_total_length":    uint64(1500),
+		"ip_flags":           uint64(2),
+		"ip_fragment_offset": uint64(0),
+		"ip_ttl":             uint64(64),
+		"protocol":           "tcp",
+		"src_ip":             "10.0.0.1",
+		"dst_ip":             "10.0.0.2",
+		"src_port":           uint64(1234),
+		"dst_port":           uint64(443),
+		"tcp_flags":          "...AP...",
+		"tcp_window_size":    uint64(4096),
+	}, m.Fields())
+}
+
+func TestDecodeSflow_truncated(t *testing.T) {
+	d := &sflowDecoder{}
+	require.NoError(t, d.Init())
+
+	datagram := sflowDatagram(t)
+	_, err := d.Decode(net.IPv4(127, 0, 0, 1), datagram[:len(datagram)-8])
+	require.Error(t, err)
+}
+
+func TestDecodeSflow_version(t *testing.T) {
+	d := &sflowDecoder{}
+	require.NoError(t, d.Init())
+
+	_, err := d.Decode(net.IPv4(127, 0, 0, 1), xdr{}.uint32(4, 1, 0))
+	require.Error(t, err)
+}
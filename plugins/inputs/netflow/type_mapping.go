@@ -0,0 +1,271 @@
+package netflow
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// decodeFunc converts the raw value of an information element to a field value.
+type decodeFunc func(b []byte) interface{}
+
+type fieldMapping struct {
+	name    string
+	decoder decodeFunc
+}
+
+// fieldMappingsNetflowCommon maps the information elements shared by
+// NetFlow v9 (RFC3954) and IPFIX (RFC7012) to field names and decoders.
+// Source and destination addresses use the same field names for IPv4 and
+// IPv6 as a record contains one or the other.
+var fieldMappingsNetflowCommon = map[uint16]fieldMapping{
+	1:   {"in_bytes", decodeUint},
+	2:   {"in_packets", decodeUint},
+	3:   {"flows", decodeUint},
+	4:   {"protocol", decodeL4Proto},
+	5:   {"src_tos", decodeUint},
+	6:   {"tcp_flags", decodeTCPFlags},
+	7:   {"src_port", decodeUint},
+	8:   {"src", decodeIP},
+	9:   {"src_mask", decodeUint},
+	10:  {"in_snmp", decodeUint},
+	11:  {"dst_port", decodeUint},
+	12:  {"dst", decodeIP},
+	13:  {"dst_mask", decodeUint},
+	14:  {"out_snmp", decodeUint},
+	15:  {"next_hop", decodeIP},
+	16:  {"bgp_src_as", decodeUint},
+	17:  {"bgp_dst_as", decodeUint},
+	18:  {"bgp_next_hop", decodeIP},
+	19:  {"out_mcast_packets", decodeUint},
+	20:  {"out_mcast_bytes", decodeUint},
+	21:  {"last_switched", decodeUint},
+	22:  {"first_switched", decodeUint},
+	23:  {"out_bytes", decodeUint},
+	24:  {"out_packets", decodeUint},
+	25:  {"min_packet_len", decodeUint},
+	26:  {"max_packet_len", decodeUint},
+	27:  {"src", decodeIP},
+	28:  {"dst", decodeIP},
+	29:  {"src_mask", decodeUint},
+	30:  {"dst_mask", decodeUint},
+	31:  {"flow_label", decodeUint},
+	32:  {"icmp_type", decodeICMP},
+	33:  {"igmp_type", decodeUint},
+	34:  {"sampling_interval", decodeUint},
+	35:  {"sampling_algo", decodeUint},
+	36:  {"flow_active_timeout", decodeUint},
+	37:  {"flow_inactive_timeout", decodeUint},
+	38:  {"engine_type", decodeUint},
+	39:  {"engine_id", decodeUint},
+	40:  {"total_bytes_exp", decodeUint},
+	41:  {"total_packets_exp", decodeUint},
+	42:  {"total_flows_exp", decodeUint},
+	46:  {"mpls_top_label_type", decodeUint},
+	47:  {"mpls_top_label_ip", decodeIP},
+	48:  {"sampler_id", decodeUint},
+	49:  {"sampler_mode", decodeUint},
+	50:  {"sampler_interval", decodeUint},
+	52:  {"min_ttl", decodeUint},
+	53:  {"max_ttl", decodeUint},
+	54:  {"fragment_id", decodeUint},
+	55:  {"dst_tos", decodeUint},
+	56:  {"in_src_mac", decodeMAC},
+	57:  {"out_dst_mac", decodeMAC},
+	58:  {"src_vlan", decodeUint},
+	59:  {"dst_vlan", decodeUint},
+	60:  {"ip_version", decodeUint},
+	61:  {"direction", decodeDirection},
+	62:  {"next_hop", decodeIP},
+	63:  {"bgp_next_hop", decodeIP},
+	64:  {"ipv6_extension_headers", decodeHex},
+	70:  {"mpls_label_1", decodeHex},
+	80:  {"in_dst_mac", decodeMAC},
+	81:  {"out_src_mac", decodeMAC},
+	82:  {"interface_name", decodeString},
+	83:  {"interface_desc", decodeString},
+	85:  {"in_total_bytes", decodeUint},
+	86:  {"in_total_packets", decodeUint},
+	88:  {"fragment_offset", decodeUint},
+	89:  {"forwarding_status", decodeUint},
+	130: {"exporter_address", decodeIP},
+	131: {"exporter_address", decodeIP},
+	136: {"flow_end_reason", decodeUint},
+	148: {"flow_id", decodeUint},
+	150: {"flow_start", decodeUint},
+	151: {"flow_end", decodeUint},
+	152: {"flow_start_ms", decodeUint},
+	153: {"flow_end_ms", decodeUint},
+	176: {"icmp_type", decodeUint},
+	177: {"icmp_code", decodeUint},
+	178: {"icmp_type", decodeUint},
+	179: {"icmp_code", decodeUint},
+	192: {"ttl", decodeUint},
+	225: {"post_nat_src", decodeIP},
+	226: {"post_nat_dst", decodeIP},
+	227: {"post_napt_src_port", decodeUint},
+	228: {"post_napt_dst_port", decodeUint},
+}
+
+// Scope field types of NetFlow v9 options templates (RFC3954 section 6.1).
+var fieldMappingsNetflowV9Scope = map[uint16]fieldMapping{
+	1: {"scope_system", decodeHex},
+	2: {"scope_interface", decodeUint},
+	3: {"scope_line_card", decodeUint},
+	4: {"scope_cache", decodeUint},
+	5: {"scope_template", decodeUint},
+}
+
+// flowKeyTags are the fields identifying a flow which are reported as tags,
+// counters and the other elements remain fields.
+var flowKeyTags = []string{
+	"src",
+	"dst",
+	"src_port",
+	"dst_port",
+	"protocol",
+	"in_snmp",
+	"out_snmp",
+	"direction",
+}
+
+// flowTags returns the tags of a flow record: the given tags along with the
+// flow keys, which are removed from fields.
+func flowTags(tags map[string]string, fields map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(tags)+len(flowKeyTags))
+	for k, v := range tags {
+		result[k] = v
+	}
+	for _, name := range flowKeyTags {
+		value, ok := fields[name]
+		if !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			result[name] = v
+		case uint64:
+			result[name] = strconv.FormatUint(v, 10)
+		default:
+			continue
+		}
+		delete(fields, name)
+	}
+	return result
+}
+
+// lookupField returns the field name and decoder of the given information
+// element.  Elements without a mapping are returned as hex strings named after
+// their type and, for enterprise specific elements, the enterprise number.
+func lookupField(id uint16, enterprise uint32) fieldMapping {
+	if enterprise == 0 {
+		if m, ok := fieldMappingsNetflowCommon[id]; ok {
+			return m
+		}
+		return fieldMapping{"type_" + strconv.Itoa(int(id)), decodeHex}
+	}
+	return fieldMapping{"type_" + strconv.FormatUint(uint64(enterprise), 10) + "_" + strconv.Itoa(int(id)), decodeHex}
+}
+
+var l4ProtoNames = map[uint64]string{
+	1:   "icmp",
+	2:   "igmp",
+	6:   "tcp",
+	17:  "udp",
+	47:  "gre",
+	50:  "esp",
+	51:  "ah",
+	58:  "ipv6-icmp",
+	89:  "ospf",
+	103: "pim",
+	112: "vrrp",
+	132: "sctp",
+}
+
+func l4ProtoName(proto uint64) string {
+	if name, ok := l4ProtoNames[proto]; ok {
+		return name
+	}
+	return strconv.FormatUint(proto, 10)
+}
+
+func decodeUint(b []byte) interface{} {
+	return readUint(b)
+}
+
+// readUint reads a big endian unsigned integer of up to 8 bytes.
+func readUint(b []byte) uint64 {
+	switch len(b) {
+	case 1:
+		return uint64(b[0])
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b))
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b))
+	case 8:
+		return binary.BigEndian.Uint64(b)
+	}
+	var v uint64
+	for i := 0; i < len(b) && i < 8; i++ {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
+
+func decodeIP(b []byte) interface{} {
+	if len(b) != net.IPv4len && len(b) != net.IPv6len {
+		return decodeHex(b)
+	}
+	return net.IP(b).String()
+}
+
+func decodeMAC(b []byte) interface{} {
+	return net.HardwareAddr(b).String()
+}
+
+func decodeString(b []byte) interface{} {
+	return strings.TrimRight(string(b), "\x00")
+}
+
+func decodeHex(b []byte) interface{} {
+	return hex.EncodeToString(b)
+}
+
+func decodeL4Proto(b []byte) interface{} {
+	return l4ProtoName(readUint(b))
+}
+
+// decodeICMP decodes the combined ICMP type and code as "type.code".
+func decodeICMP(b []byte) interface{} {
+	v := readUint(b)
+	return strconv.FormatUint(v>>8, 10) + "." + strconv.FormatUint(v&0xff, 10)
+}
+
+func decodeDirection(b []byte) interface{} {
+	switch readUint(b) {
+	case 0:
+		return "ingress"
+	case 1:
+		return "egress"
+	}
+	return "unknown"
+}
+
+// decodeTCPFlags decodes the TCP control bits as a string showing the letter
+// of each bit set, e.g. "...A..S." for a SYN-ACK.
+func decodeTCPFlags(b []byte) interface{} {
+	return tcpFlags(readUint(b))
+}
+
+func tcpFlags(v uint64) string {
+	const letters = "CEUAPRSF"
+	flags := []byte("........")
+	for i := range flags {
+		if v&(1<<uint(7-i)) != 0 {
+			flags[i] = letters[i]
+		}
+	}
+	return string(flags)
+}
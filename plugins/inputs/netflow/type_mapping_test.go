@@ -0,0 +1,43 @@
+package netflow
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlowTags(t *testing.T) {
+	common := map[string]string{"source": "127.0.0.1"}
+	fields := map[string]interface{}{
+		"src":        "10.0.0.1",
+		"dst":        "10.0.0.2",
+		"src_port":   uint64(1234),
+		"dst_port":   uint64(53),
+		"protocol":   "udp",
+		"in_snmp":    uint64(1),
+		"out_snmp":   uint64(2),
+		"direction":  "egress",
+		"in_bytes":   uint64(512),
+		"in_packets": uint64(4),
+	}
+
+	tags := flowTags(common, fields)
+	require.Equal(t, map[string]string{
+		"source":    "127.0.0.1",
+		"src":       "10.0.0.1",
+		"dst":       "10.0.0.2",
+		"src_port":  "1234",
+		"dst_port":  "53",
+		"protocol":  "udp",
+		"in_snmp":   "1",
+		"out_snmp":  "2",
+		"direction": "egress",
+	}, tags)
+	require.Equal(t, map[string]interface{}{
+		"in_bytes":   uint64(512),
+		"in_packets": uint64(4),
+	}, fields)
+
+	// the tags shared by the records of a packet are not modified
+	require.Equal(t, map[string]string{"source": "127.0.0.1"}, common)
+}
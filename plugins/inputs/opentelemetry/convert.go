@@ -0,0 +1,270 @@
+package opentelemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/otlp/common"
+	"github.com/influxdata/telegraf/internal/otlp/metrics"
+)
+
+// Tags of the instrumentation scope of the metrics.
+const (
+	scopeNameTag    = "otel.scope.name"
+	scopeVersionTag = "otel.scope.version"
+)
+
+// addResourceMetrics adds the data points of the metrics to the accumulator,
+// tagged with the attributes of the resource, the scope and the data point.
+func (o *OpenTelemetry) addResourceMetrics(resourceMetrics []*metrics.ResourceMetrics) {
+	for _, rm := range resourceMetrics {
+		resourceTags := addAttributes(nil, rm.GetResource().GetAttributes())
+
+		for _, sm := range rm.GetScopeMetrics() {
+			scopeTags := make(map[string]string, len(resourceTags)+2)
+			for k, v := range resourceTags {
+				scopeTags[k] = v
+			}
+			if name := sm.GetScope().GetName(); name != "" {
+				scopeTags[scopeNameTag] = name
+			}
+			if version := sm.GetScope().GetVersion(); version != "" {
+				scopeTags[scopeVersionTag] = version
+			}
+			scopeTags = addAttributes(scopeTags, sm.GetScope().GetAttributes())
+
+			for _, m := range sm.GetMetrics() {
+				o.addMetric(m, scopeTags)
+			}
+		}
+	}
+}
+
+// addMetric adds the data points of the metric with their typed value.
+func (o *OpenTelemetry) addMetric(m *metrics.Metric, scopeTags map[string]string) {
+	name := m.GetName()
+
+	switch data := m.GetData().(type) {
+	case *metrics.Metric_Gauge:
+		for _, dp := range data.Gauge.GetDataPoints() {
+			if noRecordedValue(dp.GetFlags()) {
+				continue
+			}
+			fields := map[string]interface{}{"gauge": numberValue(dp)}
+			o.acc.AddGauge(name, fields, tags(scopeTags, dp.GetAttributes()), timestamp(dp.GetTimeUnixNano()))
+		}
+	case *metrics.Metric_Sum:
+		for _, dp := range data.Sum.GetDataPoints() {
+			if noRecordedValue(dp.GetFlags()) {
+				continue
+			}
+			t := tags(scopeTags, dp.GetAttributes())
+			// Only monotonic sums are counters, others go up and down.
+			if data.Sum.GetIsMonotonic() {
+				fields := map[string]interface{}{"counter": numberValue(dp)}
+				o.acc.AddCounter(name, fields, t, timestamp(dp.GetTimeUnixNano()))
+			} else {
+				fields := map[string]interface{}{"gauge": numberValue(dp)}
+				o.acc.AddGauge(name, fields, t, timestamp(dp.GetTimeUnixNano()))
+			}
+		}
+	case *metrics.Metric_Histogram:
+		for _, dp := range data.Histogram.GetDataPoints() {
+			if noRecordedValue(dp.GetFlags()) {
+				continue
+			}
+			fields := map[string]interface{}{
+				"count": float64(dp.GetCount()),
+			}
+			if dp.GetXSum() != nil {
+				fields["sum"] = dp.GetSum()
+			}
+			if dp.GetXMin() != nil {
+				fields["min"] = dp.GetMin()
+			}
+			if dp.GetXMax() != nil {
+				fields["max"] = dp.GetMax()
+			}
+
+			// Buckets are cumulative like in Prometheus, the last bucket
+			// is the count.
+			var cumulative uint64
+			counts := dp.GetBucketCounts()
+			for i, bound := range dp.GetExplicitBounds() {
+				if i < len(counts) {
+					cumulative += counts[i]
+				}
+				fields[fmt.Sprint(bound)] = float64(cumulative)
+			}
+			fields[fmt.Sprint(math.Inf(1))] = float64(dp.GetCount())
+
+			o.acc.AddHistogram(name, fields, tags(scopeTags, dp.GetAttributes()), timestamp(dp.GetTimeUnixNano()))
+		}
+	case *metrics.Metric_ExponentialHistogram:
+		for _, dp := range data.ExponentialHistogram.GetDataPoints() {
+			if noRecordedValue(dp.GetFlags()) {
+				continue
+			}
+			fields := map[string]interface{}{
+				"count": float64(dp.GetCount()),
+			}
+			if dp.GetXSum() != nil {
+				fields["sum"] = dp.GetSum()
+			}
+			if dp.GetXMin() != nil {
+				fields["min"] = dp.GetMin()
+			}
+			if dp.GetXMax() != nil {
+				fields["max"] = dp.GetMax()
+			}
+			exponentialBuckets(dp, fields)
+			o.acc.AddHistogram(name, fields, tags(scopeTags, dp.GetAttributes()), timestamp(dp.GetTimeUnixNano()))
+		}
+	case *metrics.Metric_Summary:
+		for _, dp := range data.Summary.GetDataPoints() {
+			if noRecordedValue(dp.GetFlags()) {
+				continue
+			}
+			fields := map[string]interface{}{
+				"count": float64(dp.GetCount()),
+				"sum":   dp.GetSum(),
+			}
+			for _, q := range dp.GetQuantileValues() {
+				if !math.IsNaN(q.GetValue()) {
+					fields[fmt.Sprint(q.GetQuantile())] = q.GetValue()
+				}
+			}
+			o.acc.AddSummary(name, fields, tags(scopeTags, dp.GetAttributes()), timestamp(dp.GetTimeUnixNano()))
+		}
+	}
+}
+
+// exponentialBuckets adds the buckets of the exponential histogram to the
+// fields as cumulative buckets named after their upper bound, like the
+// buckets of the explicit histograms.  The bucket of index i covers the values
+// from base^i to base^(i+1), negated for the negative buckets, where base is
+// 2^(2^-scale).
+func exponentialBuckets(dp *metrics.ExponentialHistogramDataPoint, fields map[string]interface{}) {
+	base := math.Exp2(math.Exp2(-float64(dp.GetScale())))
+
+	var cumulative uint64
+	negative := dp.GetNegative()
+	counts := negative.GetBucketCounts()
+	for i := len(counts) - 1; i >= 0; i-- {
+		cumulative += counts[i]
+		index := int(negative.GetOffset()) + i
+		fields[fmt.Sprint(-math.Pow(base, float64(index)))] = float64(cumulative)
+	}
+
+	cumulative += dp.GetZeroCount()
+	fields[fmt.Sprint(0.0)] = float64(cumulative)
+
+	positive := dp.GetPositive()
+	for i, count := range positive.GetBucketCounts() {
+		cumulative += count
+		index := int(positive.GetOffset()) + i
+		fields[fmt.Sprint(math.Pow(base, float64(index+1)))] = float64(cumulative)
+	}
+	fields[fmt.Sprint(math.Inf(1))] = float64(dp.GetCount())
+}
+
+func noRecordedValue(flags uint32) bool {
+	return flags&uint32(metrics.DataPointFlags_FLAG_NO_RECORDED_VALUE) != 0
+}
+
+// numberValue returns the value of the data point as int64 or float64.
+func numberValue(dp *metrics.NumberDataPoint) interface{} {
+	if v, ok := dp.GetValue().(*metrics.NumberDataPoint_AsInt); ok {
+		return v.AsInt
+	}
+	return dp.GetAsDouble()
+}
+
+// timestamp returns the time of a data point, the time the metric is added
+// when it is not set.
+func timestamp(unixNano uint64) time.Time {
+	if unixNano == 0 {
+		return time.Now()
+	}
+	return time.Unix(0, int64(unixNano))
+}
+
+// tags returns the scope tags with the attributes of the data point.
+func tags(scopeTags map[string]string, attributes []*common.KeyValue) map[string]string {
+	t := make(map[string]string, len(scopeTags)+len(attributes))
+	for k, v := range scopeTags {
+		t[k] = v
+	}
+	return addAttributes(t, attributes)
+}
+
+// addAttributes adds the attributes to the tags, replacing the existing
+// tags with the same keys.
+func addAttributes(tags map[string]string, attributes []*common.KeyValue) map[string]string {
+	if tags == nil {
+		tags = make(map[string]string, len(attributes))
+	}
+	for _, kv := range attributes {
+		if kv.GetKey() == "" || kv.GetValue() == nil {
+			continue
+		}
+		tags[kv.GetKey()] = attributeString(kv.GetValue())
+	}
+	return tags
+}
+
+// attributeString formats an attribute value as a tag value, arrays and
+// key-value lists are formatted as JSON.
+func attributeString(v *common.AnyValue) string {
+	switch value := v.GetValue().(type) {
+	case *common.AnyValue_StringValue:
+		return value.StringValue
+	case *common.AnyValue_BoolValue:
+		return strconv.FormatBool(value.BoolValue)
+	case *common.AnyValue_IntValue:
+		return strconv.FormatInt(value.IntValue, 10)
+	case *common.AnyValue_DoubleValue:
+		return strconv.FormatFloat(value.DoubleValue, 'g', -1, 64)
+	case *common.AnyValue_BytesValue:
+		return fmt.Sprintf("%x", value.BytesValue)
+	default:
+		b, err := json.Marshal(anyValue(v))
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+}
+
+// anyValue converts an attribute value to a Go value.
+func anyValue(v *common.AnyValue) interface{} {
+	switch value := v.GetValue().(type) {
+	case *common.AnyValue_StringValue:
+		return value.StringValue
+	case *common.AnyValue_BoolValue:
+		return value.BoolValue
+	case *common.AnyValue_IntValue:
+		return value.IntValue
+	case *common.AnyValue_DoubleValue:
+		return value.DoubleValue
+	case *common.AnyValue_BytesValue:
+		return fmt.Sprintf("%x", value.BytesValue)
+	case *common.AnyValue_ArrayValue:
+		values := make([]interface{}, 0, len(value.ArrayValue.GetValues()))
+		for _, item := range value.ArrayValue.GetValues() {
+			values = append(values, anyValue(item))
+		}
+		return values
+	case *common.AnyValue_KvlistValue:
+		values := make(map[string]interface{}, len(value.KvlistValue.GetValues()))
+		for _, kv := range value.KvlistValue.GetValues() {
+			values[kv.GetKey()] = anyValue(kv.GetValue())
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,293 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/otlp/collector"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	// Register the gzip compressor, the default of the OTLP exporters.
+	_ "google.golang.org/grpc/encoding/gzip"
+)
+
+const (
+	// defaultMaxMsgSize is the maximum size of an export request, the
+	// default of the OpenTelemetry collector.
+	defaultMaxMsgSize = 4 * 1024 * 1024
+
+	// httpPath is the path of OTLP/HTTP metric exports.
+	httpPath = "/v1/metrics"
+
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+)
+
+type OpenTelemetry struct {
+	ServiceAddress     string            `toml:"service_address"`
+	HTTPServiceAddress string            `toml:"http_service_address"`
+	MaxMsgSize         internal.Size     `toml:"max_msg_size"`
+	ReadTimeout        internal.Duration `toml:"read_timeout"`
+	WriteTimeout       internal.Duration `toml:"write_timeout"`
+	tlsint.ServerConfig
+
+	acc telegraf.Accumulator
+	wg  sync.WaitGroup
+
+	grpcServer   *grpc.Server
+	grpcListener net.Listener
+	httpServer   *http.Server
+	httpListener net.Listener
+}
+
+const sampleConfig = `
+  ## Address and port to listen to for OTLP/gRPC exports, 4317 is the
+  ## standard port.  Set to an empty string to disable.
+  service_address = "0.0.0.0:4317"
+
+  ## Address and port to listen to for OTLP/HTTP exports, 4318 is the
+  ## standard port.  Exports are accepted on the "/v1/metrics" path, encoded
+  ## as protobuf or JSON.  Set to an empty string to disable.
+  # http_service_address = "0.0.0.0:4318"
+
+  ## Maximum size of an export request.
+  # max_msg_size = "4MB"
+
+  ## Maximum duration before timing out read of the HTTP request and write
+  ## of the response.
+  # read_timeout = "10s"
+  # write_timeout = "10s"
+
+  ## Set one or more allowed client CA certificate file names to
+  ## enable mutually authenticated TLS connections.
+  # tls_allowed_cacerts = ["/etc/telegraf/clientca.pem"]
+
+  ## Add service certificate and key.
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+`
+
+func (o *OpenTelemetry) SampleConfig() string {
+	return sampleConfig
+}
+
+func (o *OpenTelemetry) Description() string {
+	return "Receive OpenTelemetry metrics over OTLP/gRPC and OTLP/HTTP"
+}
+
+func (o *OpenTelemetry) Gather(_ telegraf.Accumulator) error {
+	return nil
+}
+
+// Start starts the gRPC and HTTP servers.
+func (o *OpenTelemetry) Start(acc telegraf.Accumulator) error {
+	if o.ServiceAddress == "" && o.HTTPServiceAddress == "" {
+		return errors.New("service_address or http_service_address is required")
+	}
+	if o.MaxMsgSize.Size == 0 {
+		o.MaxMsgSize.Size = defaultMaxMsgSize
+	}
+	if o.ReadTimeout.Duration < time.Second {
+		o.ReadTimeout.Duration = time.Second * 10
+	}
+	if o.WriteTimeout.Duration < time.Second {
+		o.WriteTimeout.Duration = time.Second * 10
+	}
+
+	o.acc = acc
+
+	tlsConf, err := o.ServerConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	if o.ServiceAddress != "" {
+		if err := o.startGRPC(tlsConf); err != nil {
+			return err
+		}
+	}
+
+	if o.HTTPServiceAddress != "" {
+		if err := o.startHTTP(tlsConf); err != nil {
+			o.Stop()
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o *OpenTelemetry) startGRPC(tlsConf *tls.Config) error {
+	listener, err := net.Listen("tcp", o.ServiceAddress)
+	if err != nil {
+		return err
+	}
+	o.grpcListener = listener
+
+	options := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(int(o.MaxMsgSize.Size)),
+	}
+	if tlsConf != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConf)))
+	}
+	o.grpcServer = grpc.NewServer(options...)
+	collector.RegisterMetricsServiceServer(o.grpcServer, &metricsService{o: o})
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		if err := o.grpcServer.Serve(listener); err != nil {
+			o.acc.AddError(fmt.Errorf("gRPC server: %v", err))
+		}
+	}()
+
+	log.Printf("I! [inputs.opentelemetry] Started OTLP/gRPC service on %s", listener.Addr())
+	return nil
+}
+
+func (o *OpenTelemetry) startHTTP(tlsConf *tls.Config) error {
+	var listener net.Listener
+	var err error
+	if tlsConf != nil {
+		listener, err = tls.Listen("tcp", o.HTTPServiceAddress, tlsConf)
+	} else {
+		listener, err = net.Listen("tcp", o.HTTPServiceAddress)
+	}
+	if err != nil {
+		return err
+	}
+	o.httpListener = listener
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(httpPath, o.serveHTTP)
+	o.httpServer = &http.Server{
+		Handler:      mux,
+		ReadTimeout:  o.ReadTimeout.Duration,
+		WriteTimeout: o.WriteTimeout.Duration,
+		TLSConfig:    tlsConf,
+	}
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		o.httpServer.Serve(listener)
+	}()
+
+	log.Printf("I! [inputs.opentelemetry] Started OTLP/HTTP service on %s", listener.Addr())
+	return nil
+}
+
+// Stop stops the servers, waiting for the running exports to end.
+func (o *OpenTelemetry) Stop() {
+	if o.httpServer != nil {
+		// The exports are bounded by the read and write timeouts.
+		o.httpServer.Shutdown(context.Background())
+	}
+	if o.grpcServer != nil {
+		o.grpcServer.GracefulStop()
+	}
+	o.wg.Wait()
+
+	log.Printf("I! [inputs.opentelemetry] Stopped OTLP services")
+}
+
+// metricsService is the OTLP/gRPC metrics service.
+type metricsService struct {
+	o *OpenTelemetry
+}
+
+func (s *metricsService) Export(ctx context.Context, req *collector.ExportMetricsServiceRequest) (*collector.ExportMetricsServiceResponse, error) {
+	s.o.addResourceMetrics(req.GetResourceMetrics())
+	return &collector.ExportMetricsServiceResponse{}, nil
+}
+
+// serveHTTP handles the OTLP/HTTP metric exports.
+func (o *OpenTelemetry) serveHTTP(res http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		res.Header().Set("Allow", "POST")
+		http.Error(res, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	contentType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil || (contentType != contentTypeProtobuf && contentType != contentTypeJSON) {
+		http.Error(res, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if req.ContentLength > o.MaxMsgSize.Size {
+		http.Error(res, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	body := req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		body, err = gzip.NewReader(req.Body)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer body.Close()
+	}
+
+	// Read one byte more than allowed to detect too large bodies, also
+	// after decompression.
+	b, err := ioutil.ReadAll(io.LimitReader(body, o.MaxMsgSize.Size+1))
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if int64(len(b)) > o.MaxMsgSize.Size {
+		http.Error(res, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var export collector.ExportMetricsServiceRequest
+	if contentType == contentTypeJSON {
+		unmarshaler := jsonpb.Unmarshaler{AllowUnknownFields: true}
+		err = unmarshaler.Unmarshal(bytes.NewReader(b), &export)
+	} else {
+		err = proto.Unmarshal(b, &export)
+	}
+	if err != nil {
+		log.Printf("D! [inputs.opentelemetry] Invalid export request: %v", err)
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	o.addResourceMetrics(export.GetResourceMetrics())
+
+	res.Header().Set("Content-Type", contentType)
+	if contentType == contentTypeJSON {
+		res.Write([]byte("{}"))
+		return
+	}
+	resp, _ := proto.Marshal(&collector.ExportMetricsServiceResponse{})
+	res.Write(resp)
+}
+
+func init() {
+	inputs.Add("opentelemetry", func() telegraf.Input {
+		return &OpenTelemetry{
+			ServiceAddress: "0.0.0.0:4317",
+		}
+	})
+}
@@ -0,0 +1,348 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/otlp/collector"
+	"github.com/influxdata/telegraf/internal/otlp/common"
+	"github.com/influxdata/telegraf/internal/otlp/metrics"
+	"github.com/influxdata/telegraf/internal/otlp/resource"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+var ts = time.Unix(1538395200, 0)
+
+func stringAttribute(key, value string) *common.KeyValue {
+	return &common.KeyValue{
+		Key:   key,
+		Value: &common.AnyValue{Value: &common.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+func exportRequest() *collector.ExportMetricsServiceRequest {
+	return &collector.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metrics.ResourceMetrics{{
+			Resource: &resource.Resource{
+				Attributes: []*common.KeyValue{stringAttribute("service.name", "checkout")},
+			},
+			ScopeMetrics: []*metrics.ScopeMetrics{{
+				Scope: &common.InstrumentationScope{Name: "io.opentelemetry.runtime", Version: "1.0"},
+				Metrics: []*metrics.Metric{
+					{
+						Name: "memory",
+						Data: &metrics.Metric_Gauge{Gauge: &metrics.Gauge{
+							DataPoints: []*metrics.NumberDataPoint{{
+								Attributes:   []*common.KeyValue{stringAttribute("pool", "heap")},
+								TimeUnixNano: uint64(ts.UnixNano()),
+								Value:        &metrics.NumberDataPoint_AsInt{AsInt: 1024},
+							}},
+						}},
+					},
+					{
+						Name: "requests",
+						Data: &metrics.Metric_Sum{Sum: &metrics.Sum{
+							IsMonotonic:            true,
+							AggregationTemporality: metrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+							DataPoints: []*metrics.NumberDataPoint{{
+								TimeUnixNano: uint64(ts.UnixNano()),
+								Value:        &metrics.NumberDataPoint_AsDouble{AsDouble: 42.5},
+							}},
+						}},
+					},
+					{
+						Name: "queue",
+						Data: &metrics.Metric_Sum{Sum: &metrics.Sum{
+							DataPoints: []*metrics.NumberDataPoint{
+								{
+									TimeUnixNano: uint64(ts.UnixNano()),
+									Value:        &metrics.NumberDataPoint_AsInt{AsInt: -3},
+								},
+								{
+									TimeUnixNano: uint64(ts.UnixNano()),
+									Flags:        uint32(metrics.DataPointFlags_FLAG_NO_RECORDED_VALUE),
+								},
+							},
+						}},
+					},
+					{
+						Name: "latency",
+						Data: &metrics.Metric_Histogram{Histogram: &metrics.Histogram{
+							DataPoints: []*metrics.HistogramDataPoint{{
+								TimeUnixNano:   uint64(ts.UnixNano()),
+								Count:          6,
+								XSum:           &metrics.HistogramDataPoint_Sum{Sum: 12.5},
+								ExplicitBounds: []float64{0.5, 1},
+								BucketCounts:   []uint64{1, 2, 3},
+							}},
+						}},
+					},
+					{
+						Name: "duration",
+						Data: &metrics.Metric_Summary{Summary: &metrics.Summary{
+							DataPoints: []*metrics.SummaryDataPoint{{
+								TimeUnixNano: uint64(ts.UnixNano()),
+								Count:        10,
+								Sum:          20,
+								QuantileValues: []*metrics.SummaryDataPoint_ValueAtQuantile{
+									{Quantile: 0.5, Value: 1.5},
+									{Quantile: 0.99, Value: 4},
+								},
+							}},
+						}},
+					},
+				},
+			}},
+		}},
+	}
+}
+
+func requireExported(t *testing.T, acc *testutil.Accumulator) {
+	scopeTags := map[string]string{
+		"service.name":       "checkout",
+		"otel.scope.name":    "io.opentelemetry.runtime",
+		"otel.scope.version": "1.0",
+	}
+	withTags := func(extra map[string]string) map[string]string {
+		tags := map[string]string{}
+		for k, v := range scopeTags {
+			tags[k] = v
+		}
+		for k, v := range extra {
+			tags[k] = v
+		}
+		return tags
+	}
+
+	require.Len(t, acc.Metrics, 5)
+	acc.AssertContainsTaggedFields(t, "memory",
+		map[string]interface{}{"gauge": int64(1024)},
+		withTags(map[string]string{"pool": "heap"}))
+	acc.AssertContainsTaggedFields(t, "requests",
+		map[string]interface{}{"counter": 42.5},
+		scopeTags)
+	acc.AssertContainsTaggedFields(t, "queue",
+		map[string]interface{}{"gauge": int64(-3)},
+		scopeTags)
+	acc.AssertContainsTaggedFields(t, "latency",
+		map[string]interface{}{
+			"count": 6.0,
+			"sum":   12.5,
+			"0.5":   1.0,
+			"1":     3.0,
+			"+Inf":  6.0,
+		},
+		scopeTags)
+	acc.AssertContainsTaggedFields(t, "duration",
+		map[string]interface{}{
+			"count": 10.0,
+			"sum":   20.0,
+			"0.5":   1.5,
+			"0.99":  4.0,
+		},
+		scopeTags)
+	require.True(t, acc.HasTimestamp("memory", ts))
+}
+
+func start(t *testing.T, o *OpenTelemetry) *testutil.Accumulator {
+	acc := &testutil.Accumulator{}
+	require.NoError(t, o.Start(acc))
+	return acc
+}
+
+func TestOpenTelemetry_GRPC(t *testing.T) {
+	o := &OpenTelemetry{ServiceAddress: "127.0.0.1:0"}
+	acc := start(t, o)
+	defer o.Stop()
+
+	conn, err := grpc.Dial(o.grpcListener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := collector.NewMetricsServiceClient(conn)
+	_, err = client.Export(context.Background(), exportRequest())
+	require.NoError(t, err)
+
+	requireExported(t, acc)
+}
+
+func TestOpenTelemetry_HTTPProtobuf(t *testing.T) {
+	o := &OpenTelemetry{HTTPServiceAddress: "127.0.0.1:0"}
+	acc := start(t, o)
+	defer o.Stop()
+
+	body, err := proto.Marshal(exportRequest())
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	req, err := http.NewRequest("POST", "http://"+o.httpListener.Addr().String()+"/v1/metrics", &buf)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "gzip")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/x-protobuf", resp.Header.Get("Content-Type"))
+
+	requireExported(t, acc)
+}
+
+func TestOpenTelemetry_HTTPJSON(t *testing.T) {
+	o := &OpenTelemetry{HTTPServiceAddress: "127.0.0.1:0"}
+	acc := start(t, o)
+	defer o.Stop()
+
+	body := `{
+  "resourceMetrics": [{
+    "resource": {"attributes": [{"key": "host.name", "value": {"stringValue": "web1"}}]},
+    "scopeMetrics": [{
+      "metrics": [
+        {
+          "name": "cpu",
+          "gauge": {"dataPoints": [{"timeUnixNano": "1538395200000000000", "asDouble": 0.5}]}
+        },
+        {
+          "name": "latency",
+          "histogram": {
+            "aggregationTemporality": 2,
+            "dataPoints": [{
+              "timeUnixNano": "1538395200000000000",
+              "count": "2",
+              "sum": 3,
+              "bucketCounts": ["1", "1"],
+              "explicitBounds": [1]
+            }]
+          }
+        }
+      ]
+    }]
+  }]
+}`
+	resp, err := http.Post("http://"+o.httpListener.Addr().String()+"/v1/metrics",
+		"application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{"gauge": 0.5},
+		map[string]string{"host.name": "web1"})
+	acc.AssertContainsTaggedFields(t, "latency",
+		map[string]interface{}{"count": 2.0, "sum": 3.0, "1": 1.0, "+Inf": 2.0},
+		map[string]string{"host.name": "web1"})
+	require.True(t, acc.HasTimestamp("cpu", ts))
+}
+
+func TestOpenTelemetry_HTTPErrors(t *testing.T) {
+	o := &OpenTelemetry{
+		HTTPServiceAddress: "127.0.0.1:0",
+		MaxMsgSize:         internal.Size{Size: 16},
+	}
+	acc := start(t, o)
+	defer o.Stop()
+
+	url := "http://" + o.httpListener.Addr().String() + "/v1/metrics"
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"method", "GET", "application/x-protobuf", "", http.StatusMethodNotAllowed},
+		{"content type", "POST", "text/plain", "", http.StatusUnsupportedMediaType},
+		{"too large", "POST", "application/json", strings.Repeat(" ", 17), http.StatusRequestEntityTooLarge},
+		{"invalid", "POST", "application/json", "{", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, url, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			require.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+	require.Empty(t, acc.Metrics)
+}
+
+func TestOpenTelemetry_NoAddress(t *testing.T) {
+	o := &OpenTelemetry{}
+	require.Error(t, o.Start(&testutil.Accumulator{}))
+}
+
+func TestAttributeString(t *testing.T) {
+	tests := []struct {
+		value    *common.AnyValue
+		expected string
+	}{
+		{&common.AnyValue{Value: &common.AnyValue_BoolValue{BoolValue: true}}, "true"},
+		{&common.AnyValue{Value: &common.AnyValue_IntValue{IntValue: -1}}, "-1"},
+		{&common.AnyValue{Value: &common.AnyValue_DoubleValue{DoubleValue: 0.25}}, "0.25"},
+		{&common.AnyValue{Value: &common.AnyValue_BytesValue{BytesValue: []byte{0xca, 0xfe}}}, "cafe"},
+		{
+			&common.AnyValue{Value: &common.AnyValue_ArrayValue{ArrayValue: &common.ArrayValue{
+				Values: []*common.AnyValue{
+					{Value: &common.AnyValue_StringValue{StringValue: "a"}},
+					{Value: &common.AnyValue_IntValue{IntValue: 1}},
+				},
+			}}},
+			`["a",1]`,
+		},
+		{
+			&common.AnyValue{Value: &common.AnyValue_KvlistValue{KvlistValue: &common.KeyValueList{
+				Values: []*common.KeyValue{stringAttribute("k", "v")},
+			}}},
+			`{"k":"v"}`,
+		},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, attributeString(tt.value))
+	}
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	dp := &metrics.ExponentialHistogramDataPoint{
+		Count:     7,
+		Scale:     0,
+		ZeroCount: 1,
+		Negative: &metrics.ExponentialHistogramDataPoint_Buckets{
+			BucketCounts: []uint64{1, 2},
+		},
+		Positive: &metrics.ExponentialHistogramDataPoint_Buckets{
+			Offset:       1,
+			BucketCounts: []uint64{3},
+		},
+	}
+
+	fields := map[string]interface{}{}
+	exponentialBuckets(dp, fields)
+	require.Equal(t, map[string]interface{}{
+		"-2":   2.0,
+		"-1":   3.0,
+		"0":    4.0,
+		"4":    7.0,
+		"+Inf": 7.0,
+	}, fields)
+}
@@ -52,6 +52,22 @@ type Ping struct {
 	// when `Arguments` is not empty, other options (ping_interval, timeout, etc) will be ignored
 	Arguments []string
 
+	// Method used to ping, either "exec" to run the ping binary or "native"
+	// to send ICMP echo requests directly
+	Method string
+
+	// Number of data bytes of the echo requests, native method only
+	Size int
+
+	// Use raw sockets instead of unprivileged datagram sockets, native method only
+	Privileged bool
+
+	// Resolve urls to IPv6 addresses, native method only
+	IPv6 bool `toml:"ipv6"`
+
+	// Percentiles of the response times to report, native method only
+	Percentiles []int
+
 	// host ping function
 	pingHost HostPinger
 }
@@ -87,6 +103,28 @@ const sampleConfig = `
   ## Arguments for ping command
   ## when arguments is not empty, other options (ping_interval, timeout, etc) will be ignored
   # arguments = ["-c", "3"]
+
+  ## Method used for sending pings, can be either "exec" or "native".  When set
+  ## to "exec" the system's ping binary is executed.  When set to "native"
+  ## ICMP echo requests are sent directly, without the ping binary.
+  # method = "exec"
+
+  ## The following options only apply to the native method.
+
+  ## Number of data bytes to be sent (ping -s <SIZE>)
+  # size = 56
+
+  ## Use raw sockets, requiring root or the CAP_NET_RAW capability.  By
+  ## default unprivileged datagram sockets are used, which on Linux require
+  ## the group of the telegraf process to be allowed by the
+  ## net.ipv4.ping_group_range sysctl.
+  # privileged = false
+
+  ## Resolve urls to IPv6 addresses (ping -6)
+  # ipv6 = false
+
+  ## Percentiles of the response times to report
+  # percentiles = [50, 95, 99]
 `
 
 func (_ *Ping) SampleConfig() string {
@@ -94,10 +132,19 @@ func (_ *Ping) SampleConfig() string {
 }
 
 func (p *Ping) Gather(acc telegraf.Accumulator) error {
+	pingToURL := p.pingToURL
+	switch p.Method {
+	case "", "exec":
+	case "native":
+		pingToURL = p.nativePingToURL
+	default:
+		return fmt.Errorf("invalid method %q", p.Method)
+	}
+
 	// Spin off a go routine for each url to ping
 	for _, url := range p.Urls {
 		p.wg.Add(1)
-		go p.pingToURL(url, acc)
+		go pingToURL(url, acc)
 	}
 
 	p.wg.Wait()
@@ -179,6 +226,23 @@ func (p *Ping) pingToURL(u string, acc telegraf.Accumulator) {
 	acc.AddFields("ping", fields, tags)
 }
 
+func (p *Ping) nativePingToURL(u string, acc telegraf.Accumulator) {
+	defer p.wg.Done()
+	tags := map[string]string{"url": u}
+
+	stats, err := p.nativePing(u)
+	if err != nil {
+		acc.AddError(fmt.Errorf("host %s: %s", u, err))
+		fields := map[string]interface{}{"result_code": 2}
+		if _, ok := err.(*net.DNSError); ok {
+			fields["result_code"] = 1
+		}
+		acc.AddFields("ping", fields, tags)
+		return
+	}
+	acc.AddFields("ping", stats.fields(p.Percentiles), tags)
+}
+
 func hostPinger(binary string, timeout float64, args ...string) (string, error) {
 	bin, err := exec.LookPath(binary)
 	if err != nil {
@@ -309,6 +373,8 @@ func init() {
 			Deadline:     10,
 			Binary:       "ping",
 			Arguments:    []string{},
+			Method:       "exec",
+			Size:         56,
 		}
 	})
 }
@@ -0,0 +1,377 @@
+// +build !windows
+
+package ping
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	protocolICMP     = 1
+	protocolIPv6ICMP = 58
+
+	defaultNativeTimeout = 5 * time.Second
+)
+
+// echoID is incremented for each native ping to tell the replies of
+// concurrent pings apart on raw sockets, which receive all ICMP replies.
+var echoID = uint32(os.Getpid())
+
+// pingStats holds the results of a native ping to a single host.
+type pingStats struct {
+	sent int
+	recv int
+	ttl  int
+	rtts []time.Duration // round trip times, ordered by sequence number
+}
+
+type echoReply struct {
+	seq int
+	at  time.Time
+	ttl int
+}
+
+// nativePinger sends ICMP echo requests to a single host.
+type nativePinger struct {
+	addr       *net.IPAddr
+	source     string
+	privileged bool
+	count      int
+	interval   time.Duration
+	timeout    time.Duration
+	deadline   time.Duration
+	size       int
+}
+
+func (n *nativePinger) ipv4() bool {
+	return n.addr.IP.To4() != nil
+}
+
+func (n *nativePinger) listen() (*icmp.PacketConn, error) {
+	network, source := "udp6", "::"
+	if n.ipv4() {
+		network, source = "udp4", "0.0.0.0"
+	}
+	if n.privileged {
+		network = "ip6:ipv6-icmp"
+		if n.ipv4() {
+			network = "ip4:icmp"
+		}
+	}
+	if n.source != "" {
+		source = n.source
+	}
+
+	conn, err := icmp.ListenPacket(network, source)
+	if err != nil {
+		return nil, err
+	}
+
+	// The TTL is reported when the platform supports the control message.
+	if n.ipv4() {
+		conn.IPv4PacketConn().SetControlMessage(ipv4.FlagTTL, true)
+	} else {
+		conn.IPv6PacketConn().SetControlMessage(ipv6.FlagHopLimit, true)
+	}
+	return conn, nil
+}
+
+func (n *nativePinger) dst() net.Addr {
+	if n.privileged {
+		return n.addr
+	}
+	return &net.UDPAddr{IP: n.addr.IP, Zone: n.addr.Zone}
+}
+
+func (n *nativePinger) ping() (*pingStats, error) {
+	conn, err := n.listen()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	id := int(atomic.AddUint32(&echoID, 1) & 0xffff)
+	sentAt := make([]time.Time, n.count)
+	rtts := make([]time.Duration, n.count)
+	received := make([]bool, n.count)
+
+	done := make(chan struct{})
+	defer close(done)
+	replies := make(chan echoReply)
+	go n.receive(conn, id, replies, done)
+
+	stats := &pingStats{ttl: -1}
+	start := time.Now()
+	var deadline <-chan time.Time
+	if n.deadline > 0 {
+		deadline = time.After(n.deadline)
+	}
+	var timeout <-chan time.Time
+	send := time.NewTimer(0)
+	defer send.Stop()
+
+loop:
+	for {
+		select {
+		case <-send.C:
+			seq := stats.sent
+			msg, err := n.echoRequest(id, seq)
+			if err != nil {
+				return nil, err
+			}
+			sentAt[seq] = time.Now()
+			if _, err := conn.WriteTo(msg, n.dst()); err != nil {
+				return nil, err
+			}
+			stats.sent++
+			if stats.sent < n.count {
+				send.Reset(start.Add(time.Duration(stats.sent) * n.interval).Sub(time.Now()))
+			} else {
+				timeout = time.After(n.timeout)
+			}
+		case r := <-replies:
+			if r.seq >= stats.sent || received[r.seq] {
+				// unexpected or duplicate reply
+				continue
+			}
+			received[r.seq] = true
+			rtts[r.seq] = r.at.Sub(sentAt[r.seq])
+			stats.recv++
+			if r.ttl >= 0 {
+				stats.ttl = r.ttl
+			}
+			if stats.recv == n.count {
+				break loop
+			}
+		case <-timeout:
+			break loop
+		case <-deadline:
+			break loop
+		}
+	}
+
+	for seq, ok := range received {
+		if ok {
+			stats.rtts = append(stats.rtts, rtts[seq])
+		}
+	}
+	return stats, nil
+}
+
+func (n *nativePinger) echoRequest(id, seq int) ([]byte, error) {
+	data := make([]byte, n.size)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	msg := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: data},
+	}
+	if !n.ipv4() {
+		msg.Type = ipv6.ICMPTypeEchoRequest
+	}
+	return msg.Marshal(nil)
+}
+
+// receive reads the echo replies from conn until it is closed.
+func (n *nativePinger) receive(conn *icmp.PacketConn, id int, replies chan<- echoReply, done <-chan struct{}) {
+	buf := make([]byte, 65536)
+	for {
+		var count, ttl int
+		var peer net.Addr
+		var err error
+		proto := protocolIPv6ICMP
+		if n.ipv4() {
+			proto = protocolICMP
+			var cm *ipv4.ControlMessage
+			count, cm, peer, err = conn.IPv4PacketConn().ReadFrom(buf)
+			ttl = -1
+			if cm != nil {
+				ttl = cm.TTL
+			}
+		} else {
+			var cm *ipv6.ControlMessage
+			count, cm, peer, err = conn.IPv6PacketConn().ReadFrom(buf)
+			ttl = -1
+			if cm != nil {
+				ttl = cm.HopLimit
+			}
+		}
+		if err != nil {
+			return
+		}
+		now := time.Now()
+
+		if !n.fromTarget(peer) {
+			continue
+		}
+		msg, err := icmp.ParseMessage(proto, buf[:count])
+		if err != nil || (msg.Type != ipv4.ICMPTypeEchoReply && msg.Type != ipv6.ICMPTypeEchoReply) {
+			continue
+		}
+		echo, ok := msg.Body.(*icmp.Echo)
+		// Datagram sockets get an id assigned by the kernel, which also
+		// delivers only the replies belonging to the socket.
+		if !ok || (n.privileged && echo.ID != id) || echo.Seq < 0 || echo.Seq >= n.count {
+			continue
+		}
+
+		select {
+		case replies <- echoReply{seq: echo.Seq, at: now, ttl: ttl}:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (n *nativePinger) fromTarget(peer net.Addr) bool {
+	switch peer := peer.(type) {
+	case *net.IPAddr:
+		return peer.IP.Equal(n.addr.IP)
+	case *net.UDPAddr:
+		return peer.IP.Equal(n.addr.IP)
+	}
+	return false
+}
+
+// sourceAddress returns the address to send pings to ip from.  The
+// interface may be given as an address or as the name of a network interface,
+// in which case its first address of the same family as ip is used.
+func sourceAddress(iface string, ip net.IP) (string, error) {
+	if iface == "" || net.ParseIP(iface) != nil {
+		return iface, nil
+	}
+
+	i, err := net.InterfaceByName(iface)
+	if err != nil {
+		return "", err
+	}
+	addrs, err := i.Addrs()
+	if err != nil {
+		return "", err
+	}
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok && (ipnet.IP.To4() != nil) == (ip.To4() != nil) {
+			return ipnet.IP.String(), nil
+		}
+	}
+	return "", fmt.Errorf("no suitable address found on interface %s", iface)
+}
+
+func (p *Ping) nativePing(host string) (*pingStats, error) {
+	network := "ip"
+	if p.IPv6 {
+		network = "ip6"
+	}
+	addr, err := net.ResolveIPAddr(network, host)
+	if err != nil {
+		return nil, err
+	}
+	source, err := sourceAddress(p.Interface, addr.IP)
+	if err != nil {
+		return nil, err
+	}
+	if p.Count < 1 {
+		return nil, errors.New("count must be at least 1")
+	}
+
+	pinger := &nativePinger{
+		addr:       addr,
+		source:     source,
+		privileged: p.Privileged,
+		count:      p.Count,
+		interval:   time.Duration(p.PingInterval * float64(time.Second)),
+		timeout:    time.Duration(p.Timeout * float64(time.Second)),
+		deadline:   time.Duration(p.Deadline) * time.Second,
+		size:       p.Size,
+	}
+	if pinger.interval <= 0 {
+		pinger.interval = time.Second
+	}
+	if pinger.timeout <= 0 {
+		pinger.timeout = defaultNativeTimeout
+	}
+	if pinger.size < 0 {
+		pinger.size = 0
+	}
+	return pinger.ping()
+}
+
+// fields returns the ping fields of the native ping statistics, in
+// milliseconds.
+func (s *pingStats) fields(percentiles []int) map[string]interface{} {
+	fields := map[string]interface{}{
+		"result_code":         0,
+		"packets_transmitted": s.sent,
+		"packets_received":    s.recv,
+		"percent_packet_loss": float64(s.sent-s.recv) / float64(s.sent) * 100.0,
+	}
+	if s.ttl >= 0 {
+		fields["ttl"] = s.ttl
+	}
+	if len(s.rtts) == 0 {
+		return fields
+	}
+
+	min, max, sum, sumSquares := s.rtts[0], s.rtts[0], 0.0, 0.0
+	var jitter float64
+	for i, rtt := range s.rtts {
+		if rtt < min {
+			min = rtt
+		}
+		if rtt > max {
+			max = rtt
+		}
+		sum += ms(rtt)
+		sumSquares += ms(rtt) * ms(rtt)
+		if i > 0 {
+			jitter += math.Abs(ms(rtt) - ms(s.rtts[i-1]))
+		}
+	}
+	count := float64(len(s.rtts))
+	avg := sum / count
+
+	fields["minimum_response_ms"] = ms(min)
+	fields["average_response_ms"] = avg
+	fields["maximum_response_ms"] = ms(max)
+	fields["standard_deviation_ms"] = math.Sqrt(math.Max(sumSquares/count-avg*avg, 0))
+	if len(s.rtts) > 1 {
+		fields["jitter_ms"] = jitter / (count - 1)
+	}
+
+	sorted := make([]time.Duration, len(s.rtts))
+	copy(sorted, s.rtts)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for _, perc := range percentiles {
+		if perc <= 0 || perc > 100 {
+			continue
+		}
+		fields[fmt.Sprintf("percentile%d_ms", perc)] = ms(percentile(sorted, perc))
+	}
+	return fields
+}
+
+// percentile returns the nearest-rank percentile of the sorted values.
+func percentile(sorted []time.Duration, perc int) time.Duration {
+	rank := int(math.Ceil(float64(perc) / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
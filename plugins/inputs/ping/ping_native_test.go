@@ -0,0 +1,112 @@
+// +build !windows
+
+package ping
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPingStatsFields(t *testing.T) {
+	stats := &pingStats{
+		sent: 5,
+		recv: 4,
+		ttl:  64,
+		rtts: []time.Duration{
+			10 * time.Millisecond,
+			30 * time.Millisecond,
+			20 * time.Millisecond,
+			20 * time.Millisecond,
+		},
+	}
+
+	fields := stats.fields([]int{50, 75, 100})
+	assert.Equal(t, 0, fields["result_code"])
+	assert.Equal(t, 5, fields["packets_transmitted"])
+	assert.Equal(t, 4, fields["packets_received"])
+	assert.Equal(t, 20.0, fields["percent_packet_loss"])
+	assert.Equal(t, 64, fields["ttl"])
+	assert.Equal(t, 10.0, fields["minimum_response_ms"])
+	assert.Equal(t, 20.0, fields["average_response_ms"])
+	assert.Equal(t, 30.0, fields["maximum_response_ms"])
+	assert.InDelta(t, 7.071, fields["standard_deviation_ms"], 0.001)
+	assert.InDelta(t, 10.0, fields["jitter_ms"], 0.001)
+	assert.Equal(t, 20.0, fields["percentile50_ms"])
+	assert.Equal(t, 20.0, fields["percentile75_ms"])
+	assert.Equal(t, 30.0, fields["percentile100_ms"])
+}
+
+func TestPingStatsFieldsNoReply(t *testing.T) {
+	stats := &pingStats{sent: 3, ttl: -1}
+
+	fields := stats.fields([]int{50})
+	assert.Equal(t, map[string]interface{}{
+		"result_code":         0,
+		"packets_transmitted": 3,
+		"packets_received":    0,
+		"percent_packet_loss": 100.0,
+	}, fields)
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, time.Duration(1), percentile(sorted, 1))
+	assert.Equal(t, time.Duration(5), percentile(sorted, 50))
+	assert.Equal(t, time.Duration(10), percentile(sorted, 95))
+	assert.Equal(t, time.Duration(10), percentile(sorted, 100))
+}
+
+func TestNativeMethodInvalid(t *testing.T) {
+	p := &Ping{Urls: []string{"localhost"}, Method: "foo"}
+	var acc testutil.Accumulator
+	require.Error(t, p.Gather(&acc))
+}
+
+func TestNativePingGather(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping network-dependent test in short mode.")
+	}
+
+	for _, privileged := range []bool{false, true} {
+		p := &Ping{
+			Urls:         []string{"127.0.0.1"},
+			Method:       "native",
+			Count:        3,
+			PingInterval: 0.01,
+			Timeout:      1,
+			Size:         56,
+			Privileged:   privileged,
+			Percentiles:  []int{50},
+		}
+		if _, err := p.nativePing("127.0.0.1"); err != nil {
+			t.Logf("unable to ping with privileged = %v: %s", privileged, err)
+			continue
+		}
+
+		var acc testutil.Accumulator
+		require.NoError(t, p.Gather(&acc))
+		require.Len(t, acc.Errors, 0)
+		assert.True(t, acc.HasTag("ping", "url"))
+		assert.Equal(t, 0, acc.Metrics[0].Fields["result_code"])
+		assert.Equal(t, 3, acc.Metrics[0].Fields["packets_transmitted"])
+		assert.Equal(t, 3, acc.Metrics[0].Fields["packets_received"])
+		assert.True(t, acc.HasFloatField("ping", "percentile50_ms"))
+	}
+}
+
+func TestNativePingNoSuchHost(t *testing.T) {
+	p := &Ping{
+		Urls:   []string{"host.invalid"},
+		Method: "native",
+		Count:  1,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	assert.Equal(t, 1, acc.Metrics[0].Fields["result_code"])
+}
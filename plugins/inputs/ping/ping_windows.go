@@ -42,6 +42,9 @@ type Ping struct {
 	// when `Arguments` is not empty, other options (ping_interval, timeout, etc) will be ignored
 	Arguments []string
 
+	// Method used to ping, only "exec" is supported on Windows
+	Method string
+
 	// host ping function
 	pingHost HostPinger
 }
@@ -66,6 +69,9 @@ const sampleConfig = `
 	## Arguments for ping command
 	## when arguments is not empty, other options (ping_interval, timeout, etc) will be ignored
 	# arguments = ["-c", "3"]
+
+	## Method used for sending pings, only "exec" is supported on Windows.
+	# method = "exec"
 `
 
 func (s *Ping) SampleConfig() string {
@@ -73,6 +79,12 @@ func (s *Ping) SampleConfig() string {
 }
 
 func (p *Ping) Gather(acc telegraf.Accumulator) error {
+	switch p.Method {
+	case "", "exec":
+	default:
+		return fmt.Errorf("method %q is not supported on Windows, only \"exec\" is", p.Method)
+	}
+
 	if p.Count < 1 {
 		p.Count = 1
 	}
@@ -254,6 +266,7 @@ func init() {
 			Count:     1,
 			Binary:    "ping",
 			Arguments: []string{},
+			Method:    "exec",
 		}
 	})
 }
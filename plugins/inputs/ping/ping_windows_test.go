@@ -360,3 +360,16 @@ func TestPingBinary(t *testing.T) {
 	}
 	acc.GatherError(p.Gather)
 }
+
+func TestPingMethodNotSupported(t *testing.T) {
+	var acc testutil.Accumulator
+	p := Ping{
+		Urls:   []string{"www.google.com"},
+		Method: "native",
+		pingHost: func(binary string, timeout float64, args ...string) (string, error) {
+			t.Fatal("ping should not be run")
+			return "", nil
+		},
+	}
+	require.Error(t, acc.GatherError(p.Gather))
+}
@@ -150,15 +150,35 @@ func (p *Prometheus) GetAllURLs() ([]URLAndAddress, error) {
 	return allURLs, nil
 }
 
+// Init creates the HTTP client and checks the URLs, failing if the TLS
+// settings or one of the URLs are invalid.
+func (p *Prometheus) Init() error {
+	for _, u := range p.URLs {
+		if _, err := url.Parse(u); err != nil {
+			return fmt.Errorf("invalid url %q: %s", u, err)
+		}
+	}
+	for _, service := range p.KubernetesServices {
+		if _, err := url.Parse(service); err != nil {
+			return fmt.Errorf("invalid kubernetes service %q: %s", service, err)
+		}
+	}
+
+	client, err := p.createHTTPClient()
+	if err != nil {
+		return err
+	}
+	p.client = client
+	return nil
+}
+
 // Reads stats from all configured servers accumulates stats.
 // Returns one of the errors encountered while gather stats (if any).
 func (p *Prometheus) Gather(acc telegraf.Accumulator) error {
 	if p.client == nil {
-		client, err := p.createHTTPClient()
-		if err != nil {
+		if err := p.Init(); err != nil {
 			return err
 		}
-		p.client = client
 	}
 
 	var wg sync.WaitGroup
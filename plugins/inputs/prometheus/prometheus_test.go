@@ -103,3 +103,21 @@ func TestPrometheusGeneratesMetricsAlthoughFirstDNSFails(t *testing.T) {
 	assert.True(t, acc.HasFloatField("test_metric", "value"))
 	assert.True(t, acc.HasTimestamp("test_metric", time.Unix(1490802350, 0)))
 }
+
+func TestPrometheusInvalidConfig(t *testing.T) {
+	p := &Prometheus{
+		URLs: []string{"http://localhost:9100/metrics"},
+	}
+	p.TLSCA = "/nonexistent/ca.pem"
+	require.Error(t, p.Init())
+
+	p = &Prometheus{
+		URLs: []string{"http://[::1/metrics"},
+	}
+	require.Error(t, p.Init())
+
+	p = &Prometheus{
+		URLs: []string{"http://localhost:9100/metrics"},
+	}
+	require.NoError(t, p.Init())
+}
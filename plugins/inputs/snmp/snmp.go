@@ -1,12 +1,10 @@
 package snmp
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"fmt"
 	"math"
 	"net"
-	"os/exec"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	snmpint "github.com/influxdata/telegraf/internal/snmp"
 	"github.com/influxdata/telegraf/plugins/inputs"
 
 	"github.com/soniah/gosnmp"
@@ -29,6 +28,10 @@ const sampleConfig = `
   ## SNMP version, values can be 1, 2, or 3
   version = 2
 
+  ## Paths to MIB files.  Defaults to the directories in the MIBDIRS
+  ## environment variable, or "/usr/share/snmp/mibs".
+  # path = ["/usr/share/snmp/mibs"]
+
   ## SNMP community string.
   community = "public"
 
@@ -78,25 +81,6 @@ const sampleConfig = `
     oid = "HOST-RESOURCES-MIB::hrNetworkTable"
 `
 
-// execCommand is so tests can mock out exec.Command usage.
-var execCommand = exec.Command
-
-// execCmd executes the specified command, returning the STDOUT content.
-// If command exits with error status, the output is captured into the returned error.
-func execCmd(arg0 string, args ...string) ([]byte, error) {
-	out, err := execCommand(arg0, args...).Output()
-	if err != nil {
-		if err, ok := err.(*exec.ExitError); ok {
-			return nil, NestedError{
-				Err:       err,
-				NestedErr: fmt.Errorf("%s", bytes.TrimRight(err.Stderr, "\r\n")),
-			}
-		}
-		return nil, err
-	}
-	return out, nil
-}
-
 // Snmp holds the configuration for the plugin.
 type Snmp struct {
 	// The SNMP agent to query. Format is ADDR[:PORT] (e.g. 1.2.3.4:161).
@@ -106,6 +90,8 @@ type Snmp struct {
 	Retries int
 	// Values: 1, 2, 3
 	Version uint8
+	// Path holds the directories to load MIBs from.
+	Path []string
 
 	// Parameters for Version 1 & 2
 	Community string
@@ -140,11 +126,25 @@ type Snmp struct {
 	initialized     bool
 }
 
+// Init loads the MIBs and resolves the configured OIDs so that errors are
+// reported when the configuration is loaded.
+func (s *Snmp) Init() error {
+	return s.init()
+}
+
 func (s *Snmp) init() error {
 	if s.initialized {
 		return nil
 	}
 
+	paths := s.Path
+	if len(paths) == 0 {
+		paths = snmpint.DefaultMibPaths()
+	}
+	if err := snmpint.LoadMibsFromPath(paths); err != nil {
+		return Errorf(err, "loading MIBs")
+	}
+
 	s.connectionCache = make([]snmpConnection, len(s.Agents))
 
 	for i := range s.Tables {
@@ -207,8 +207,7 @@ func (t *Table) init() error {
 }
 
 // initBuild initializes the table if it has an OID configured. If so, the
-// net-snmp tools will be used to look up the OID and auto-populate the table's
-// fields.
+// MIB will be used to look up the OID and auto-populate the table's fields.
 func (t *Table) initBuild() error {
 	if t.Oid == "" {
 		return nil
@@ -257,8 +256,12 @@ type Field struct {
 	//  "int" will conver the value into an integer.
 	//  "hwaddr" will convert a 6-byte string to a MAC address.
 	//  "ipaddr" will convert the value to an IPv4 or IPv6 address.
+	//  "enum" will convert an integer into its label from the MIB.
 	Conversion string
 
+	// enums holds the MIB labels used by the "enum" conversion.
+	enums map[int]string
+
 	initialized bool
 }
 
@@ -279,6 +282,12 @@ func (f *Field) init() error {
 	if f.Conversion == "" {
 		f.Conversion = conversion
 	}
+	if f.Conversion == "enum" {
+		f.enums = snmpint.TranslateEnums(f.Oid)
+		if f.enums == nil {
+			return fmt.Errorf("no enumeration found in MIB for %s", oidText)
+		}
+	}
 
 	//TODO use textual convention conversion from the MIB
 
@@ -353,6 +362,12 @@ func (s *Snmp) Description() string {
 // Any error encountered does not halt the process. The errors are accumulated
 // and returned at the end.
 func (s *Snmp) Gather(acc telegraf.Accumulator) error {
+	return s.GatherContext(context.Background(), acc)
+}
+
+// GatherContext is like Gather but aborts the outstanding requests when the
+// context is done.
+func (s *Snmp) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
 	if err := s.init(); err != nil {
 		return err
 	}
@@ -367,6 +382,7 @@ func (s *Snmp) Gather(acc telegraf.Accumulator) error {
 				acc.AddError(Errorf(err, "agent %s", agent))
 				return
 			}
+			gs = withContext(ctx, gs)
 
 			// First is the top-level fields. We treat the fields as table prefixes with an empty index.
 			t := Table{
@@ -380,6 +396,9 @@ func (s *Snmp) Gather(acc telegraf.Accumulator) error {
 
 			// Now is the real tables.
 			for _, t := range s.Tables {
+				if ctx.Err() != nil {
+					return
+				}
 				if err := s.gatherTable(acc, gs, t, topTags, true); err != nil {
 					acc.AddError(Errorf(err, "agent %s: gathering table %s", agent, t.Name))
 				}
@@ -454,7 +473,7 @@ func (t Table) Build(gs snmpConnection, walk bool) (*RTable, error) {
 				return nil, Errorf(err, "performing get on field %s", f.Name)
 			} else if pkt != nil && len(pkt.Variables) > 0 && pkt.Variables[0].Type != gosnmp.NoSuchObject && pkt.Variables[0].Type != gosnmp.NoSuchInstance {
 				ent := pkt.Variables[0]
-				fv, err := fieldConvert(f.Conversion, ent.Value)
+				fv, err := f.convert(ent.Value)
 				if err != nil {
 					return nil, Errorf(err, "converting %q (OID %s) for field %s", ent.Value, ent.Name, f.Name)
 				}
@@ -487,7 +506,7 @@ func (t Table) Build(gs snmpConnection, walk bool) (*RTable, error) {
 					}, idx)
 				}
 
-				fv, err := fieldConvert(f.Conversion, ent.Value)
+				fv, err := f.convert(ent.Value)
 				if err != nil {
 					return Errorf(err, "converting %q (OID %s) for field %s", ent.Value, ent.Name, f.Name)
 				}
@@ -553,6 +572,47 @@ type snmpConnection interface {
 // gosnmpWrapper wraps a *gosnmp.GoSNMP object so we can use it as a snmpConnection.
 type gosnmpWrapper struct {
 	*gosnmp.GoSNMP
+
+	// ctx aborts the requests when done, it is set for each gather.
+	ctx context.Context
+}
+
+// withContext returns the connection with its requests aborted when ctx is
+// done.
+func withContext(ctx context.Context, gs snmpConnection) snmpConnection {
+	if gsw, ok := gs.(gosnmpWrapper); ok {
+		gsw.ctx = ctx
+		return gsw
+	}
+	return gs
+}
+
+// watch closes the connection when the context is done, interrupting the
+// request in progress as gosnmp has no support for contexts.  The connection
+// is reopened by the next request.  The returned function stops watching.
+func (gsw gosnmpWrapper) watch() func() {
+	if gsw.ctx == nil || gsw.Conn == nil {
+		return func() {}
+	}
+
+	conn := gsw.Conn
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-gsw.ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// canceled returns the error of the context once done.
+func (gsw gosnmpWrapper) canceled() error {
+	if gsw.ctx == nil {
+		return nil
+	}
+	return gsw.ctx.Err()
 }
 
 // Host returns the value of GoSNMP.Target.
@@ -568,14 +628,19 @@ func (gsw gosnmpWrapper) Walk(oid string, fn gosnmp.WalkFunc) error {
 	// On error, retry once.
 	// Unfortunately we can't distinguish between an error returned by gosnmp, and one returned by the walk function.
 	for i := 0; i < 2; i++ {
+		stop := gsw.watch()
 		if gsw.Version == gosnmp.Version1 {
 			err = gsw.GoSNMP.Walk(oid, fn)
 		} else {
 			err = gsw.GoSNMP.BulkWalk(oid, fn)
 		}
+		stop()
 		if err == nil {
 			return nil
 		}
+		if err := gsw.canceled(); err != nil {
+			return err
+		}
 		if err := gsw.GoSNMP.Connect(); err != nil {
 			return Errorf(err, "reconnecting")
 		}
@@ -589,10 +654,15 @@ func (gsw gosnmpWrapper) Get(oids []string) (*gosnmp.SnmpPacket, error) {
 	var err error
 	var pkt *gosnmp.SnmpPacket
 	for i := 0; i < 2; i++ {
+		stop := gsw.watch()
 		pkt, err = gsw.GoSNMP.Get(oids)
+		stop()
 		if err == nil {
 			return pkt, nil
 		}
+		if err := gsw.canceled(); err != nil {
+			return nil, err
+		}
 		if err := gsw.GoSNMP.Connect(); err != nil {
 			return nil, Errorf(err, "reconnecting")
 		}
@@ -611,7 +681,7 @@ func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
 
 	agent := s.Agents[idx]
 
-	gs := gosnmpWrapper{&gosnmp.GoSNMP{}}
+	gs := gosnmpWrapper{GoSNMP: &gosnmp.GoSNMP{}}
 	s.connectionCache[idx] = gs
 
 	host, portStr, err := net.SplitHostPort(agent)
@@ -715,6 +785,41 @@ func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
 	return gs, nil
 }
 
+// convert converts the value according to the field's conversion.
+func (f *Field) convert(v interface{}) (interface{}, error) {
+	if f.Conversion == "enum" {
+		return enumConvert(f.enums, v), nil
+	}
+	return fieldConvert(f.Conversion, v)
+}
+
+// enumConvert converts an integer into its label. Values without a label are
+// returned unchanged.
+func enumConvert(enums map[int]string, v interface{}) interface{} {
+	var i int
+	switch vt := v.(type) {
+	case int:
+		i = vt
+	case int32:
+		i = int(vt)
+	case int64:
+		i = int(vt)
+	case uint:
+		i = int(vt)
+	case uint32:
+		i = int(vt)
+	case uint64:
+		i = int(vt)
+	default:
+		return v
+	}
+
+	if label, ok := enums[i]; ok {
+		return label
+	}
+	return v
+}
+
 // fieldConvert converts from any type according to the conv specification
 //  "float"/"float(0)" will convert the value into a float.
 //  "float(X)" will convert the value into a float, and then move the decimal before Xth right-most digit.
@@ -838,199 +943,23 @@ func fieldConvert(conv string, v interface{}) (interface{}, error) {
 	return nil, fmt.Errorf("invalid conversion type '%s'", conv)
 }
 
-type snmpTableCache struct {
-	mibName string
-	oidNum  string
-	oidText string
-	fields  []Field
-	err     error
-}
-
-var snmpTableCaches map[string]snmpTableCache
-var snmpTableCachesLock sync.Mutex
-
 // snmpTable resolves the given OID as a table, providing information about the
 // table and fields within.
 func snmpTable(oid string) (mibName string, oidNum string, oidText string, fields []Field, err error) {
-	snmpTableCachesLock.Lock()
-	if snmpTableCaches == nil {
-		snmpTableCaches = map[string]snmpTableCache{}
-	}
-
-	var stc snmpTableCache
-	var ok bool
-	if stc, ok = snmpTableCaches[oid]; !ok {
-		stc.mibName, stc.oidNum, stc.oidText, stc.fields, stc.err = snmpTableCall(oid)
-		snmpTableCaches[oid] = stc
-	}
-
-	snmpTableCachesLock.Unlock()
-	return stc.mibName, stc.oidNum, stc.oidText, stc.fields, stc.err
-}
-
-func snmpTableCall(oid string) (mibName string, oidNum string, oidText string, fields []Field, err error) {
-	mibName, oidNum, oidText, _, err = snmpTranslate(oid)
+	mibName, oidNum, oidText, columns, err := snmpint.TranslateTable(oid)
 	if err != nil {
-		return "", "", "", nil, Errorf(err, "translating")
-	}
-
-	mibPrefix := mibName + "::"
-	oidFullName := mibPrefix + oidText
-
-	// first attempt to get the table's tags
-	tagOids := map[string]struct{}{}
-	// We have to guess that the "entry" oid is `oid+".1"`. snmptable and snmptranslate don't seem to have a way to provide the info.
-	if out, err := execCmd("snmptranslate", "-Td", oidFullName+".1"); err == nil {
-		scanner := bufio.NewScanner(bytes.NewBuffer(out))
-		for scanner.Scan() {
-			line := scanner.Text()
-
-			if !strings.HasPrefix(line, "  INDEX") {
-				continue
-			}
-
-			i := strings.Index(line, "{ ")
-			if i == -1 { // parse error
-				continue
-			}
-			line = line[i+2:]
-			i = strings.Index(line, " }")
-			if i == -1 { // parse error
-				continue
-			}
-			line = line[:i]
-			for _, col := range strings.Split(line, ", ") {
-				tagOids[mibPrefix+col] = struct{}{}
-			}
-		}
+		return "", "", "", nil, err
 	}
 
-	// this won't actually try to run a query. The `-Ch` will just cause it to dump headers.
-	out, err := execCmd("snmptable", "-Ch", "-Cl", "-c", "public", "127.0.0.1", oidFullName)
-	if err != nil {
-		return "", "", "", nil, Errorf(err, "getting table columns")
+	// Use the symbolic name so that columns can be matched against the
+	// user-configured fields before they are translated.
+	for _, col := range columns {
+		fields = append(fields, Field{Name: col.Name, Oid: mibName + "::" + col.Name, IsTag: col.IsTag})
 	}
-	scanner := bufio.NewScanner(bytes.NewBuffer(out))
-	scanner.Scan()
-	cols := scanner.Text()
-	if len(cols) == 0 {
-		return "", "", "", nil, fmt.Errorf("could not find any columns in table")
-	}
-	for _, col := range strings.Split(cols, " ") {
-		if len(col) == 0 {
-			continue
-		}
-		_, isTag := tagOids[mibPrefix+col]
-		fields = append(fields, Field{Name: col, Oid: mibPrefix + col, IsTag: isTag})
-	}
-
-	return mibName, oidNum, oidText, fields, err
-}
-
-type snmpTranslateCache struct {
-	mibName    string
-	oidNum     string
-	oidText    string
-	conversion string
-	err        error
+	return mibName, oidNum, oidText, fields, nil
 }
 
-var snmpTranslateCachesLock sync.Mutex
-var snmpTranslateCaches map[string]snmpTranslateCache
-
 // snmpTranslate resolves the given OID.
 func snmpTranslate(oid string) (mibName string, oidNum string, oidText string, conversion string, err error) {
-	snmpTranslateCachesLock.Lock()
-	if snmpTranslateCaches == nil {
-		snmpTranslateCaches = map[string]snmpTranslateCache{}
-	}
-
-	var stc snmpTranslateCache
-	var ok bool
-	if stc, ok = snmpTranslateCaches[oid]; !ok {
-		// This will result in only one call to snmptranslate running at a time.
-		// We could speed it up by putting a lock in snmpTranslateCache and then
-		// returning it immediately, and multiple callers would then release the
-		// snmpTranslateCachesLock and instead wait on the individual
-		// snmpTranlsation.Lock to release. But I don't know that the extra complexity
-		// is worth it. Especially when it would slam the system pretty hard if lots
-		// of lookups are being perfomed.
-
-		stc.mibName, stc.oidNum, stc.oidText, stc.conversion, stc.err = snmpTranslateCall(oid)
-		snmpTranslateCaches[oid] = stc
-	}
-
-	snmpTranslateCachesLock.Unlock()
-
-	return stc.mibName, stc.oidNum, stc.oidText, stc.conversion, stc.err
-}
-
-func snmpTranslateCall(oid string) (mibName string, oidNum string, oidText string, conversion string, err error) {
-	var out []byte
-	if strings.ContainsAny(oid, ":abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") {
-		out, err = execCmd("snmptranslate", "-Td", "-Ob", oid)
-	} else {
-		out, err = execCmd("snmptranslate", "-Td", "-Ob", "-m", "all", oid)
-		if err, ok := err.(*exec.Error); ok && err.Err == exec.ErrNotFound {
-			// Silently discard error if snmptranslate not found and we have a numeric OID.
-			// Meaning we can get by without the lookup.
-			return "", oid, oid, "", nil
-		}
-	}
-	if err != nil {
-		return "", "", "", "", err
-	}
-
-	scanner := bufio.NewScanner(bytes.NewBuffer(out))
-	ok := scanner.Scan()
-	if !ok && scanner.Err() != nil {
-		return "", "", "", "", Errorf(scanner.Err(), "getting OID text")
-	}
-
-	oidText = scanner.Text()
-
-	i := strings.Index(oidText, "::")
-	if i == -1 {
-		// was not found in MIB.
-		if bytes.Contains(out, []byte("[TRUNCATED]")) {
-			return "", oid, oid, "", nil
-		}
-		// not truncated, but not fully found. We still need to parse out numeric OID, so keep going
-		oidText = oid
-	} else {
-		mibName = oidText[:i]
-		oidText = oidText[i+2:]
-	}
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if strings.HasPrefix(line, "  -- TEXTUAL CONVENTION ") {
-			tc := strings.TrimPrefix(line, "  -- TEXTUAL CONVENTION ")
-			switch tc {
-			case "MacAddress", "PhysAddress":
-				conversion = "hwaddr"
-			case "InetAddressIPv4", "InetAddressIPv6", "InetAddress", "IPSIpAddress":
-				conversion = "ipaddr"
-			}
-		} else if strings.HasPrefix(line, "::= { ") {
-			objs := strings.TrimPrefix(line, "::= { ")
-			objs = strings.TrimSuffix(objs, " }")
-
-			for _, obj := range strings.Split(objs, " ") {
-				if len(obj) == 0 {
-					continue
-				}
-				if i := strings.Index(obj, "("); i != -1 {
-					obj = obj[i+1:]
-					oidNum += "." + obj[:strings.Index(obj, ")")]
-				} else {
-					oidNum += "." + obj
-				}
-			}
-			break
-		}
-	}
-
-	return mibName, oidNum, oidText, conversion, nil
+	return snmpint.TranslateOid(oid)
 }
@@ -1,15 +1,15 @@
-//go:generate go run -tags generate snmp_mocks_generate.go
 package snmp
 
 import (
+	"context"
 	"fmt"
 	"net"
-	"os/exec"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
+	snmpint "github.com/influxdata/telegraf/internal/snmp"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/influxdata/toml"
 	"github.com/soniah/gosnmp"
@@ -17,6 +17,12 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func init() {
+	if err := snmpint.LoadMibsFromPath([]string{"testdata"}); err != nil {
+		panic(err)
+	}
+}
+
 type testSNMPConnection struct {
 	host   string
 	values map[string]interface{}
@@ -205,12 +211,7 @@ func TestSnmpInit(t *testing.T) {
 }
 
 func TestSnmpInit_noTranslate(t *testing.T) {
-	// override execCommand so it returns exec.ErrNotFound
-	defer func(ec func(string, ...string) *exec.Cmd) { execCommand = ec }(execCommand)
-	execCommand = func(_ string, _ ...string) *exec.Cmd {
-		return exec.Command("snmptranslateExecErrNotFound")
-	}
-
+	// none of these OIDs are defined in any loaded MIB
 	s := &Snmp{
 		Fields: []Field{
 			{Oid: ".1.1.1.1", Name: "one", IsTag: true},
@@ -377,7 +378,7 @@ func TestGosnmpWrapper_walk_retry(t *testing.T) {
 	require.NoError(t, err)
 	conn := gs.Conn
 
-	gsw := gosnmpWrapper{gs}
+	gsw := gosnmpWrapper{GoSNMP: gs}
 	err = gsw.Walk(".1.0.0", func(_ gosnmp.SnmpPDU) error { return nil })
 	srvr.Close()
 	wg.Wait()
@@ -425,7 +426,7 @@ func TestGosnmpWrapper_get_retry(t *testing.T) {
 	require.NoError(t, err)
 	conn := gs.Conn
 
-	gsw := gosnmpWrapper{gs}
+	gsw := gosnmpWrapper{GoSNMP: gs}
 	_, err = gsw.Get([]string{".1.0.0"})
 	srvr.Close()
 	wg.Wait()
@@ -434,6 +435,33 @@ func TestGosnmpWrapper_get_retry(t *testing.T) {
 	assert.Equal(t, (gs.Retries+1)*2, reqCount)
 }
 
+func TestGosnmpWrapper_get_cancel(t *testing.T) {
+	srvr, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	require.NoError(t, err)
+	defer srvr.Close()
+
+	// The server never answers, the request only ends with the context.
+	gs := &gosnmp.GoSNMP{
+		Target:    srvr.LocalAddr().(*net.UDPAddr).IP.String(),
+		Port:      uint16(srvr.LocalAddr().(*net.UDPAddr).Port),
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		Timeout:   time.Second * 10,
+		Retries:   1,
+	}
+	err = gs.Connect()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	gsw := withContext(ctx, gosnmpWrapper{GoSNMP: gs})
+	start := time.Now()
+	_, err = gsw.Get([]string{".1.0.0"})
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.True(t, time.Since(start) < time.Second*5)
+}
+
 func TestTableBuild_walk(t *testing.T) {
 	tbl := Table{
 		Name:       "mytable",
@@ -705,69 +733,28 @@ func TestFieldConvert(t *testing.T) {
 	}
 }
 
-func TestSnmpTranslateCache_miss(t *testing.T) {
-	snmpTranslateCaches = nil
-	oid := "IF-MIB::ifPhysAddress.1"
-	mibName, oidNum, oidText, conversion, err := snmpTranslate(oid)
-	assert.Len(t, snmpTranslateCaches, 1)
-	stc := snmpTranslateCaches[oid]
-	require.NotNil(t, stc)
-	assert.Equal(t, mibName, stc.mibName)
-	assert.Equal(t, oidNum, stc.oidNum)
-	assert.Equal(t, oidText, stc.oidText)
-	assert.Equal(t, conversion, stc.conversion)
-	assert.Equal(t, err, stc.err)
-}
+func TestFieldConvert_enum(t *testing.T) {
+	f := Field{Oid: "IF-MIB::ifAdminStatus", Conversion: "enum"}
+	require.NoError(t, f.init())
 
-func TestSnmpTranslateCache_hit(t *testing.T) {
-	snmpTranslateCaches = map[string]snmpTranslateCache{
-		"foo": {
-			mibName:    "a",
-			oidNum:     "b",
-			oidText:    "c",
-			conversion: "d",
-			err:        fmt.Errorf("e"),
-		},
+	testTable := []struct {
+		input    interface{}
+		expected interface{}
+	}{
+		{1, "up"},
+		{uint(2), "down"},
+		{int64(3), "testing"},
+		{9, 9},
+		{"foo", "foo"},
 	}
-	mibName, oidNum, oidText, conversion, err := snmpTranslate("foo")
-	assert.Equal(t, "a", mibName)
-	assert.Equal(t, "b", oidNum)
-	assert.Equal(t, "c", oidText)
-	assert.Equal(t, "d", conversion)
-	assert.Equal(t, fmt.Errorf("e"), err)
-	snmpTranslateCaches = nil
-}
-
-func TestSnmpTableCache_miss(t *testing.T) {
-	snmpTableCaches = nil
-	oid := ".1.0.0.0"
-	mibName, oidNum, oidText, fields, err := snmpTable(oid)
-	assert.Len(t, snmpTableCaches, 1)
-	stc := snmpTableCaches[oid]
-	require.NotNil(t, stc)
-	assert.Equal(t, mibName, stc.mibName)
-	assert.Equal(t, oidNum, stc.oidNum)
-	assert.Equal(t, oidText, stc.oidText)
-	assert.Equal(t, fields, stc.fields)
-	assert.Equal(t, err, stc.err)
-}
-
-func TestSnmpTableCache_hit(t *testing.T) {
-	snmpTableCaches = map[string]snmpTableCache{
-		"foo": {
-			mibName: "a",
-			oidNum:  "b",
-			oidText: "c",
-			fields:  []Field{{Name: "d"}},
-			err:     fmt.Errorf("e"),
-		},
+	for _, tc := range testTable {
+		act, err := f.convert(tc.input)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, act, "input=%T(%v)", tc.input, tc.input)
 	}
-	mibName, oidNum, oidText, fields, err := snmpTable("foo")
-	assert.Equal(t, "a", mibName)
-	assert.Equal(t, "b", oidNum)
-	assert.Equal(t, "c", oidText)
-	assert.Equal(t, []Field{{Name: "d"}}, fields)
-	assert.Equal(t, fmt.Errorf("e"), err)
+
+	f = Field{Oid: "IF-MIB::ifDescr", Conversion: "enum"}
+	require.Error(t, f.init())
 }
 
 func TestError(t *testing.T) {
@@ -0,0 +1,557 @@
+package snmp_trap
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	snmpint "github.com/influxdata/telegraf/internal/snmp"
+	"github.com/influxdata/telegraf/plugins/inputs"
+
+	"github.com/soniah/gosnmp"
+)
+
+const (
+	// sysUpTime.0, the first varbind of every v2c and v3 notification.
+	sysUpTimeOid = ".1.3.6.1.2.1.1.3.0"
+	// snmpTrapOID.0, the varbind holding the notification OID.
+	snmpTrapOid = ".1.3.6.1.6.3.1.1.4.1.0"
+	// snmpTraps, the prefix of the generic v1 trap OIDs.
+	snmpTrapsOid = ".1.3.6.1.6.3.1.1.5"
+)
+
+// errV3Inform is the error of the v3 informs, which can't be acknowledged.
+var errV3Inform = errors.New("SNMPv3 informs are not supported")
+
+const sampleConfig = `
+  ## Transport, local address, and port to listen on.  Transport must
+  ## be "udp", "udp4" or "udp6".  Omit local address to listen on all
+  ## interfaces.
+  ##   example: "udp://127.0.0.1:1234"
+  # service_address = "udp://:162"
+
+  ## Paths to MIB files.  Defaults to the directories in the MIBDIRS
+  ## environment variable, or "/usr/share/snmp/mibs".
+  # path = ["/usr/share/snmp/mibs"]
+
+  ## SNMPv3 authentication and encryption options.  When sec_name is
+  ## unset only v1 and v2c notifications are accepted.
+  # sec_name = "myuser"
+  ## Values: "noAuthNoPriv", "authNoPriv", "authPriv"
+  # sec_level = "authNoPriv"
+  ## Values: "MD5", "SHA", ""
+  # auth_protocol = "MD5"
+  # auth_password = "pass"
+  ## Values: "DES", "AES", ""
+  # priv_protocol = ""
+  # priv_password = ""
+`
+
+type translateFunc func(oid string) (mibName string, oidNum string, oidText string, conversion string, err error)
+
+type SnmpTrap struct {
+	ServiceAddress string   `toml:"service_address"`
+	Path           []string `toml:"path"`
+
+	// Parameters for Version 3
+	SecName string `toml:"sec_name"`
+	// Values: "noAuthNoPriv", "authNoPriv", "authPriv"
+	SecLevel string `toml:"sec_level"`
+	// Values: "MD5", "SHA", "". Default: ""
+	AuthProtocol string `toml:"auth_protocol"`
+	AuthPassword string `toml:"auth_password"`
+	// Values: "DES", "AES", "". Default: ""
+	PrivProtocol string `toml:"priv_protocol"`
+	PrivPassword string `toml:"priv_password"`
+
+	acc       telegraf.Accumulator
+	conn      *net.UDPConn
+	params    *gosnmp.GoSNMP
+	msgFlags  gosnmp.SnmpV3MsgFlags
+	translate translateFunc
+	wg        sync.WaitGroup
+}
+
+func (s *SnmpTrap) Description() string {
+	return "Receive SNMP traps and informs"
+}
+
+func (s *SnmpTrap) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *SnmpTrap) Gather(_ telegraf.Accumulator) error {
+	return nil
+}
+
+func (s *SnmpTrap) Start(acc telegraf.Accumulator) error {
+	s.acc = acc
+
+	params, err := s.newParams()
+	if err != nil {
+		return err
+	}
+	s.params = params
+
+	paths := s.Path
+	if len(paths) == 0 {
+		paths = snmpint.DefaultMibPaths()
+	}
+	if err := snmpint.LoadMibsFromPath(paths); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(s.ServiceAddress)
+	if err != nil {
+		return fmt.Errorf("invalid service address %q: %s", s.ServiceAddress, err)
+	}
+	switch u.Scheme {
+	case "udp", "udp4", "udp6":
+	default:
+		return fmt.Errorf("unsupported transport %q", u.Scheme)
+	}
+
+	addr, err := net.ResolveUDPAddr(u.Scheme, u.Host)
+	if err != nil {
+		return err
+	}
+	s.conn, err = net.ListenUDP(u.Scheme, addr)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("I! [inputs.snmp_trap] Listening on %s://%s", u.Scheme, s.conn.LocalAddr())
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.listen()
+	}()
+
+	return nil
+}
+
+func (s *SnmpTrap) Stop() {
+	s.conn.Close()
+	s.wg.Wait()
+}
+
+// newParams builds the gosnmp parameters used to decode incoming messages.
+//
+// Authentication of v3 messages is done in a second pass by unmarshal, since
+// the keys must be localized to the engine ID of the sender.
+func (s *SnmpTrap) newParams() (*gosnmp.GoSNMP, error) {
+	params := &gosnmp.GoSNMP{Version: gosnmp.Version2c}
+	if s.SecName == "" {
+		return params, nil
+	}
+
+	switch strings.ToLower(s.SecLevel) {
+	case "noauthnopriv", "":
+		s.msgFlags = gosnmp.NoAuthNoPriv
+	case "authnopriv":
+		s.msgFlags = gosnmp.AuthNoPriv
+	case "authpriv":
+		s.msgFlags = gosnmp.AuthPriv
+	default:
+		return nil, fmt.Errorf("invalid sec_level %q", s.SecLevel)
+	}
+
+	sp := &gosnmp.UsmSecurityParameters{
+		UserName:                 s.SecName,
+		AuthenticationPassphrase: s.AuthPassword,
+		PrivacyPassphrase:        s.PrivPassword,
+		Logger:                   log.New(ioutil.Discard, "", 0),
+	}
+
+	switch strings.ToLower(s.AuthProtocol) {
+	case "md5":
+		sp.AuthenticationProtocol = gosnmp.MD5
+	case "sha":
+		sp.AuthenticationProtocol = gosnmp.SHA
+	case "":
+		sp.AuthenticationProtocol = gosnmp.NoAuth
+	default:
+		return nil, fmt.Errorf("invalid auth_protocol %q", s.AuthProtocol)
+	}
+
+	switch strings.ToLower(s.PrivProtocol) {
+	case "des":
+		sp.PrivacyProtocol = gosnmp.DES
+	case "aes":
+		sp.PrivacyProtocol = gosnmp.AES
+	case "":
+		sp.PrivacyProtocol = gosnmp.NoPriv
+	default:
+		return nil, fmt.Errorf("invalid priv_protocol %q", s.PrivProtocol)
+	}
+
+	if s.msgFlags&gosnmp.AuthNoPriv != 0 && sp.AuthenticationProtocol == gosnmp.NoAuth {
+		return nil, fmt.Errorf("auth_protocol is required with sec_level %q", s.SecLevel)
+	}
+	if s.msgFlags&gosnmp.AuthPriv == gosnmp.AuthPriv && sp.PrivacyProtocol == gosnmp.NoPriv {
+		return nil, fmt.Errorf("priv_protocol is required with sec_level %q", s.SecLevel)
+	}
+
+	params.Version = gosnmp.Version3
+	params.SecurityModel = gosnmp.UserSecurityModel
+	params.MsgFlags = gosnmp.NoAuthNoPriv
+	params.SecurityParameters = sp
+	return params, nil
+}
+
+func (s *SnmpTrap) listen() {
+	buf := make([]byte, 64*1024) // 64kb - maximum size of IP packet
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
+				s.acc.AddError(err)
+			}
+			return
+		}
+
+		msg := make([]byte, n)
+		copy(msg, buf[:n])
+		s.handle(msg, addr)
+	}
+}
+
+// handle decodes a single notification, acknowledges it if it is an inform
+// and adds the resulting metric.
+func (s *SnmpTrap) handle(msg []byte, addr *net.UDPAddr) {
+	// gosnmp only decodes traps. A v1/v2c inform carries the same PDU as a
+	// v2 trap, and its acknowledgement is the same PDU sent back as a
+	// response, so only the PDU type needs to be rewritten.
+	//
+	// v3 informs are rejected by unmarshal.
+	var response []byte
+	if offset, version, ok := pduOffset(msg); ok && version != gosnmp.Version3 &&
+		gosnmp.PDUType(msg[offset]) == gosnmp.InformRequest {
+		response = make([]byte, len(msg))
+		copy(response, msg)
+		response[offset] = byte(gosnmp.GetResponse)
+		msg[offset] = byte(gosnmp.SNMPv2Trap)
+	}
+
+	packet, err := s.unmarshal(msg)
+	if err == errV3Inform {
+		s.acc.AddError(fmt.Errorf("unable to acknowledge inform from %s: %s", addr.IP, err))
+		return
+	}
+	if err != nil {
+		s.acc.AddError(fmt.Errorf("unable to decode notification from %s: %s", addr.IP, err))
+		return
+	}
+
+	if response != nil {
+		if _, err := s.conn.WriteToUDP(response, addr); err != nil {
+			s.acc.AddError(fmt.Errorf("unable to acknowledge inform from %s: %s", addr.IP, err))
+		}
+	}
+
+	tags, fields := s.decode(packet)
+	tags["source"] = addr.IP.String()
+	s.acc.AddFields("snmp_trap", fields, tags, time.Now())
+}
+
+// unmarshal decodes msg, verifying the user and authentication of v3
+// messages.  v3 informs and engine discoveries fail with errV3Inform.
+//
+// Acknowledging a v3 inform requires acting as the authoritative engine,
+// answering the engine discovery sent beforehand, which is not supported.
+func (s *SnmpTrap) unmarshal(msg []byte) (*gosnmp.SnmpPacket, error) {
+	// gosnmp decodes v3 messages in place, so keep the original around for
+	// the authentication pass.
+	buf := make([]byte, len(msg))
+	copy(buf, msg)
+
+	packet := s.params.UnmarshalTrap(buf)
+	if packet == nil {
+		// gosnmp only decodes traps, but the scoped PDU of an encrypted
+		// message has been decrypted in buf before failing.
+		if isV3Request(buf) {
+			return nil, errV3Inform
+		}
+		return nil, fmt.Errorf("malformed message or unsupported version")
+	}
+	if packet.Version != gosnmp.Version3 {
+		return packet, nil
+	}
+
+	sp, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if !ok {
+		return nil, fmt.Errorf("unsupported security model")
+	}
+	if sp.UserName != s.SecName {
+		return nil, fmt.Errorf("unknown user %q", sp.UserName)
+	}
+	if packet.MsgFlags&gosnmp.AuthPriv < s.msgFlags {
+		return nil, fmt.Errorf("security level below %q", s.SecLevel)
+	}
+	if s.msgFlags&gosnmp.AuthNoPriv == 0 {
+		return packet, nil
+	}
+
+	// The security parameters of the first pass hold keys localized to the
+	// sender, which are used to check the message digest.
+	verifier := &gosnmp.GoSNMP{
+		Version:            gosnmp.Version3,
+		SecurityModel:      gosnmp.UserSecurityModel,
+		MsgFlags:           s.msgFlags,
+		SecurityParameters: sp,
+	}
+	packet = verifier.UnmarshalTrap(msg)
+	if packet == nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+	if packet.PDUType == gosnmp.InformRequest || packet.PDUType == gosnmp.GetRequest {
+		return nil, errV3Inform
+	}
+	return packet, nil
+}
+
+// isV3Request reports if msg is a v3 inform, or the engine discovery sent
+// before it, with a plaintext or decrypted scoped PDU.
+func isV3Request(msg []byte) bool {
+	offset, version, ok := pduOffset(msg)
+	if !ok || version != gosnmp.Version3 {
+		return false
+	}
+	pduType := gosnmp.PDUType(msg[offset])
+	return pduType == gosnmp.InformRequest || pduType == gosnmp.GetRequest
+}
+
+// decode converts the notification into tags and fields. The trap OID is
+// added as the oid, name and mib tags, and each varbind becomes a field
+// named after its MIB object.
+func (s *SnmpTrap) decode(packet *gosnmp.SnmpPacket) (map[string]string, map[string]interface{}) {
+	tags := map[string]string{}
+	fields := map[string]interface{}{}
+
+	var trapOid string
+	switch packet.Version {
+	case gosnmp.Version1:
+		tags["version"] = "1"
+		tags["agent_address"] = packet.AgentAddress
+		trapOid = v1TrapOid(packet.Enterprise, packet.GenericTrap, packet.SpecificTrap)
+		_, _, name := s.lookup(sysUpTimeOid)
+		fields[name] = packet.Timestamp
+	case gosnmp.Version2c:
+		tags["version"] = "2c"
+		tags["community"] = packet.Community
+	case gosnmp.Version3:
+		tags["version"] = "3"
+		tags["context_name"] = packet.ContextName
+	}
+
+	for _, v := range packet.Variables {
+		if normalizeOid(v.Name) == snmpTrapOid {
+			if oid, ok := v.Value.(string); ok {
+				trapOid = oid
+			}
+			continue
+		}
+
+		value := s.convert(v)
+		if value == nil {
+			continue
+		}
+		_, _, name := s.lookup(v.Name)
+		fields[name] = value
+	}
+
+	if trapOid != "" {
+		mibName, oidNum, oidText := s.lookup(trapOid)
+		tags["oid"] = oidNum
+		tags["name"] = oidText
+		if mibName != "" {
+			tags["mib"] = mibName
+		}
+	}
+
+	return tags, fields
+}
+
+// convert returns the field value of a varbind, or nil if it has none.
+func (s *SnmpTrap) convert(v gosnmp.SnmpPDU) interface{} {
+	switch v.Type {
+	case gosnmp.Null, gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView:
+		return nil
+	case gosnmp.ObjectIdentifier:
+		oid, ok := v.Value.(string)
+		if !ok {
+			return nil
+		}
+		mibName, _, oidText := s.lookup(oid)
+		if mibName == "" {
+			return oidText
+		}
+		return mibName + "::" + oidText
+	case gosnmp.OctetString:
+		bs, ok := v.Value.([]byte)
+		if !ok {
+			return v.Value
+		}
+		_, _, _, conversion, _ := s.translate(v.Name)
+		if conversion == "hwaddr" {
+			return net.HardwareAddr(bs).String()
+		}
+		return string(bs)
+	}
+	return v.Value
+}
+
+// lookup resolves an OID to its MIB, numeric form and name. If the OID
+// cannot be resolved the numeric form is used as the name.
+func (s *SnmpTrap) lookup(oid string) (mibName string, oidNum string, oidText string) {
+	oid = normalizeOid(oid)
+	mibName, oidNum, oidText, _, err := s.translate(oid)
+	if err != nil || oidText == "" {
+		return "", oid, oid
+	}
+	if oidNum == "" {
+		oidNum = oid
+	}
+	return mibName, oidNum, oidText
+}
+
+// normalizeOid returns the OID with a leading dot.
+func normalizeOid(oid string) string {
+	if !strings.HasPrefix(oid, ".") {
+		return "." + oid
+	}
+	return oid
+}
+
+// v1TrapOid maps a SNMPv1 trap onto its SNMPv2 notification OID as described
+// in RFC 3584 section 3.1.
+func v1TrapOid(enterprise string, generic int, specific int) string {
+	if generic != 6 {
+		return snmpTrapsOid + "." + strconv.Itoa(generic+1)
+	}
+	return normalizeOid(enterprise) + ".0." + strconv.Itoa(specific)
+}
+
+// berHeader returns the length of the BER encoded value starting at b, and
+// the size of its type and length header.
+func berHeader(b []byte) (length int, size int, ok bool) {
+	if len(b) < 2 {
+		return 0, 0, false
+	}
+	if b[1] < 0x80 {
+		return int(b[1]), 2, true
+	}
+
+	n := int(b[1] & 0x7f)
+	if n == 0 || n > 4 || len(b) < 2+n {
+		return 0, 0, false
+	}
+	for _, c := range b[2 : 2+n] {
+		length = length<<8 | int(c)
+	}
+	return length, 2 + n, true
+}
+
+// berSkip returns the offset following the BER value of type tag starting at
+// cursor.
+func berSkip(msg []byte, cursor int, tag gosnmp.Asn1BER) (int, bool) {
+	if cursor >= len(msg) || msg[cursor] != byte(tag) {
+		return 0, false
+	}
+	length, size, ok := berHeader(msg[cursor:])
+	if !ok {
+		return 0, false
+	}
+	return cursor + size + length, true
+}
+
+// pduOffset returns the offset of the PDU type and the version of a v1 or v2c
+// message, or of a v3 message whose scoped PDU is not, or no longer,
+// encrypted.
+func pduOffset(msg []byte) (int, gosnmp.SnmpVersion, bool) {
+	if len(msg) == 0 || msg[0] != byte(gosnmp.Sequence) {
+		return 0, 0, false
+	}
+	_, cursor, ok := berHeader(msg)
+	if !ok {
+		return 0, 0, false
+	}
+
+	// version
+	length, size, ok := berHeader(msg[cursor:])
+	if !ok || msg[cursor] != byte(gosnmp.Integer) || length != 1 || cursor+size >= len(msg) {
+		return 0, 0, false
+	}
+	version := gosnmp.SnmpVersion(msg[cursor+size])
+	cursor += size + length
+
+	if version != gosnmp.Version3 {
+		// community
+		if cursor, ok = berSkip(msg, cursor, gosnmp.OctetString); !ok || cursor >= len(msg) {
+			return 0, 0, false
+		}
+		return cursor, version, true
+	}
+
+	// global data: message ID, maximum size, flags and security model
+	if cursor >= len(msg) || msg[cursor] != byte(gosnmp.Sequence) {
+		return 0, 0, false
+	}
+	if _, size, ok = berHeader(msg[cursor:]); !ok {
+		return 0, 0, false
+	}
+	cursor += size
+	for i := 0; i < 2; i++ {
+		if cursor, ok = berSkip(msg, cursor, gosnmp.Integer); !ok {
+			return 0, 0, false
+		}
+	}
+	if cursor, ok = berSkip(msg, cursor, gosnmp.OctetString); !ok {
+		return 0, 0, false
+	}
+	if cursor, ok = berSkip(msg, cursor, gosnmp.Integer); !ok {
+		return 0, 0, false
+	}
+
+	// security parameters
+	if cursor, ok = berSkip(msg, cursor, gosnmp.OctetString); !ok {
+		return 0, 0, false
+	}
+	// scoped PDU, an encrypted one is an octet string: context engine ID
+	// and context name
+	if cursor >= len(msg) || msg[cursor] != byte(gosnmp.Sequence) {
+		return 0, 0, false
+	}
+	if _, size, ok = berHeader(msg[cursor:]); !ok {
+		return 0, 0, false
+	}
+	cursor += size
+	for i := 0; i < 2; i++ {
+		if cursor, ok = berSkip(msg, cursor, gosnmp.OctetString); !ok {
+			return 0, 0, false
+		}
+	}
+
+	if cursor >= len(msg) {
+		return 0, 0, false
+	}
+	return cursor, version, true
+}
+
+func init() {
+	inputs.Add("snmp_trap", func() telegraf.Input {
+		return &SnmpTrap{
+			ServiceAddress: "udp://:162",
+			translate:      snmpint.TranslateOid,
+		}
+	})
+}
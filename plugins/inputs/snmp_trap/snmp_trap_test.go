@@ -0,0 +1,535 @@
+package snmp_trap
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/require"
+)
+
+var testMib = map[string][2]string{
+	".1.3.6.1.2.1.1.3.0":     {"DISMAN-EVENT-MIB", "sysUpTimeInstance"},
+	".1.3.6.1.6.3.1.1.5.3":   {"IF-MIB", "linkDown"},
+	".1.3.6.1.2.1.2.2.1.1.2": {"IF-MIB", "ifIndex.2"},
+	".1.3.6.1.2.1.2.2.1.6.2": {"IF-MIB", "ifPhysAddress.2"},
+	".1.3.6.1.2.1.2.2.1.7.2": {"IF-MIB", "ifAdminStatus.2"},
+}
+
+func testTranslate(oid string) (string, string, string, string, error) {
+	e, ok := testMib[oid]
+	if !ok {
+		return "", oid, oid, "", nil
+	}
+	var conversion string
+	if e[1] == "ifPhysAddress.2" {
+		conversion = "hwaddr"
+	}
+	return e[0], oid, e[1], conversion, nil
+}
+
+func newTestSnmpTrap() *SnmpTrap {
+	return &SnmpTrap{
+		ServiceAddress: "udp://127.0.0.1:0",
+		translate:      testTranslate,
+	}
+}
+
+func linkDownVariables() []gosnmp.SnmpPDU {
+	return []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(1234)},
+		{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.6.3.1.1.5.3"},
+		{Name: ".1.3.6.1.2.1.2.2.1.1.2", Type: gosnmp.Integer, Value: 2},
+		{Name: ".1.3.6.1.2.1.2.2.1.6.2", Type: gosnmp.OctetString, Value: []byte{0, 1, 2, 3, 4, 5}},
+		{Name: ".1.3.6.1.2.1.2.2.1.7.2", Type: gosnmp.Integer, Value: 2},
+	}
+}
+
+func start(t *testing.T, s *SnmpTrap) (*testutil.Accumulator, int) {
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Start(acc))
+	return acc, s.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func newSender(port int, version gosnmp.SnmpVersion) *gosnmp.GoSNMP {
+	return &gosnmp.GoSNMP{
+		Target:    "127.0.0.1",
+		Port:      uint16(port),
+		Version:   version,
+		Community: "public",
+		Timeout:   time.Second,
+	}
+}
+
+func linkDownFields() map[string]interface{} {
+	return map[string]interface{}{
+		"sysUpTimeInstance": uint(1234),
+		"ifIndex.2":         2,
+		"ifPhysAddress.2":   "00:01:02:03:04:05",
+		"ifAdminStatus.2":   2,
+	}
+}
+
+func TestReceiveV1Trap(t *testing.T) {
+	s := newTestSnmpTrap()
+	acc, port := start(t, s)
+	defer s.Stop()
+
+	sender := newSender(port, gosnmp.Version1)
+	require.NoError(t, sender.Connect())
+	defer sender.Conn.Close()
+
+	_, err := sender.SendTrap(gosnmp.SnmpTrap{
+		Variables: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.2.2.1.1.2", Type: gosnmp.Integer, Value: 2},
+		},
+		Enterprise:   ".1.3.6.1.4.1.8072",
+		AgentAddress: "10.0.0.1",
+		GenericTrap:  2,
+		Timestamp:    1234,
+	})
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "snmp_trap",
+		map[string]interface{}{
+			"sysUpTimeInstance": uint(1234),
+			"ifIndex.2":         2,
+		},
+		map[string]string{
+			"source":        "127.0.0.1",
+			"version":       "1",
+			"agent_address": "10.0.0.1",
+			"oid":           ".1.3.6.1.6.3.1.1.5.3",
+			"name":          "linkDown",
+			"mib":           "IF-MIB",
+		},
+	)
+}
+
+func TestReceiveV2cTrap(t *testing.T) {
+	s := newTestSnmpTrap()
+	acc, port := start(t, s)
+	defer s.Stop()
+
+	sender := newSender(port, gosnmp.Version2c)
+	require.NoError(t, sender.Connect())
+	defer sender.Conn.Close()
+
+	_, err := sender.SendTrap(gosnmp.SnmpTrap{Variables: linkDownVariables()})
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "snmp_trap",
+		linkDownFields(),
+		map[string]string{
+			"source":    "127.0.0.1",
+			"version":   "2c",
+			"community": "public",
+			"oid":       ".1.3.6.1.6.3.1.1.5.3",
+			"name":      "linkDown",
+			"mib":       "IF-MIB",
+		},
+	)
+}
+
+func TestReceiveV2cInform(t *testing.T) {
+	s := newTestSnmpTrap()
+	acc, port := start(t, s)
+	defer s.Stop()
+
+	packet := &gosnmp.SnmpPacket{
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		PDUType:   gosnmp.InformRequest,
+		RequestID: 42,
+		Variables: linkDownVariables()[:3],
+	}
+	msg, err := packet.MarshalMsg()
+	require.NoError(t, err)
+
+	conn, err := net.Dial("udp", "127.0.0.1:"+strconv.Itoa(port))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write(msg)
+	require.NoError(t, err)
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+
+	response := (&gosnmp.GoSNMP{}).UnmarshalTrap(buf[:n])
+	require.NotNil(t, response)
+	require.Equal(t, gosnmp.GetResponse, response.PDUType)
+	require.Equal(t, uint32(42), response.RequestID)
+	require.Len(t, response.Variables, 3)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "snmp_trap",
+		map[string]interface{}{
+			"sysUpTimeInstance": uint(1234),
+			"ifIndex.2":         2,
+		},
+		map[string]string{
+			"source":    "127.0.0.1",
+			"version":   "2c",
+			"community": "public",
+			"oid":       ".1.3.6.1.6.3.1.1.5.3",
+			"name":      "linkDown",
+			"mib":       "IF-MIB",
+		},
+	)
+}
+
+// localizedParams returns USM parameters with keys localized to engineID.
+//
+// gosnmp only localizes keys when it learns the engine ID from a received
+// message, so one is decoded to obtain them.
+func localizedParams(t *testing.T, engineID string, sp *gosnmp.UsmSecurityParameters) *gosnmp.UsmSecurityParameters {
+	sp.Logger = log.New(ioutil.Discard, "", 0)
+	packet := &gosnmp.SnmpPacket{
+		Version:       gosnmp.Version3,
+		MsgFlags:      gosnmp.NoAuthNoPriv,
+		SecurityModel: gosnmp.UserSecurityModel,
+		SecurityParameters: &gosnmp.UsmSecurityParameters{
+			AuthoritativeEngineID: engineID,
+			UserName:              sp.UserName,
+			Logger:                sp.Logger,
+		},
+		PDUType:   gosnmp.SNMPv2Trap,
+		Variables: linkDownVariables()[:2],
+	}
+	msg, err := packet.MarshalMsg()
+	require.NoError(t, err)
+
+	decoder := &gosnmp.GoSNMP{
+		Version:            gosnmp.Version3,
+		SecurityModel:      gosnmp.UserSecurityModel,
+		SecurityParameters: sp,
+	}
+	decoded := decoder.UnmarshalTrap(msg)
+	require.NotNil(t, decoded)
+	return decoded.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+}
+
+func sendV3(t *testing.T, port int, flags gosnmp.SnmpV3MsgFlags, sp *gosnmp.UsmSecurityParameters) {
+	sender := newSender(port, gosnmp.Version3)
+	sender.SecurityModel = gosnmp.UserSecurityModel
+	sender.MsgFlags = flags
+	sender.SecurityParameters = localizedParams(t, "\x80\x00\x1f\x88\x04test", sp)
+	require.NoError(t, sender.Connect())
+	defer sender.Conn.Close()
+
+	_, err := sender.SendTrap(gosnmp.SnmpTrap{Variables: linkDownVariables()})
+	require.NoError(t, err)
+}
+
+func TestReceiveV3Trap(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		flags    gosnmp.SnmpV3MsgFlags
+		auth     gosnmp.SnmpV3AuthProtocol
+		authName string
+		priv     gosnmp.SnmpV3PrivProtocol
+		privName string
+	}{
+		{
+			name:  "noAuthNoPriv",
+			level: "noAuthNoPriv",
+			flags: gosnmp.NoAuthNoPriv,
+		},
+		{
+			name:     "authNoPriv md5",
+			level:    "authNoPriv",
+			flags:    gosnmp.AuthNoPriv,
+			auth:     gosnmp.MD5,
+			authName: "MD5",
+		},
+		{
+			name:     "authPriv sha des",
+			level:    "authPriv",
+			flags:    gosnmp.AuthPriv,
+			auth:     gosnmp.SHA,
+			authName: "SHA",
+			priv:     gosnmp.DES,
+			privName: "DES",
+		},
+		{
+			name:     "authPriv sha aes",
+			level:    "authPriv",
+			flags:    gosnmp.AuthPriv,
+			auth:     gosnmp.SHA,
+			authName: "SHA",
+			priv:     gosnmp.AES,
+			privName: "AES",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSnmpTrap()
+			s.SecName = "user"
+			s.SecLevel = tt.level
+			s.AuthProtocol = tt.authName
+			s.AuthPassword = "authpassword"
+			s.PrivProtocol = tt.privName
+			s.PrivPassword = "privpassword"
+			acc, port := start(t, s)
+			defer s.Stop()
+
+			sendV3(t, port, tt.flags, &gosnmp.UsmSecurityParameters{
+				UserName:                 "user",
+				AuthenticationProtocol:   tt.auth,
+				AuthenticationPassphrase: "authpassword",
+				PrivacyProtocol:          tt.priv,
+				PrivacyPassphrase:        "privpassword",
+			})
+
+			acc.Wait(1)
+			acc.AssertContainsTaggedFields(t, "snmp_trap",
+				linkDownFields(),
+				map[string]string{
+					"source":       "127.0.0.1",
+					"version":      "3",
+					"context_name": "",
+					"oid":          ".1.3.6.1.6.3.1.1.5.3",
+					"name":         "linkDown",
+					"mib":          "IF-MIB",
+				},
+			)
+		})
+	}
+}
+
+func TestReceiveV3TrapRejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags gosnmp.SnmpV3MsgFlags
+		sp    *gosnmp.UsmSecurityParameters
+	}{
+		{
+			name:  "wrong password",
+			flags: gosnmp.AuthNoPriv,
+			sp: &gosnmp.UsmSecurityParameters{
+				UserName:                 "user",
+				AuthenticationProtocol:   gosnmp.MD5,
+				AuthenticationPassphrase: "wrongpassword",
+			},
+		},
+		{
+			name:  "unknown user",
+			flags: gosnmp.AuthNoPriv,
+			sp: &gosnmp.UsmSecurityParameters{
+				UserName:                 "other",
+				AuthenticationProtocol:   gosnmp.MD5,
+				AuthenticationPassphrase: "authpassword",
+			},
+		},
+		{
+			name:  "unauthenticated",
+			flags: gosnmp.NoAuthNoPriv,
+			sp: &gosnmp.UsmSecurityParameters{
+				UserName: "user",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSnmpTrap()
+			s.SecName = "user"
+			s.SecLevel = "authNoPriv"
+			s.AuthProtocol = "MD5"
+			s.AuthPassword = "authpassword"
+			acc, port := start(t, s)
+			defer s.Stop()
+
+			sendV3(t, port, tt.flags, tt.sp)
+
+			acc.WaitError(1)
+			require.Len(t, acc.Errors, 1)
+			require.Empty(t, acc.Metrics)
+		})
+	}
+}
+
+func TestV3Disabled(t *testing.T) {
+	s := newTestSnmpTrap()
+	acc, port := start(t, s)
+	defer s.Stop()
+
+	sendV3(t, port, gosnmp.NoAuthNoPriv, &gosnmp.UsmSecurityParameters{
+		UserName: "user",
+	})
+
+	acc.WaitError(1)
+	require.Empty(t, acc.Metrics)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		s    *SnmpTrap
+	}{
+		{
+			name: "transport",
+			s:    &SnmpTrap{ServiceAddress: "tcp://127.0.0.1:0"},
+		},
+		{
+			name: "sec_level",
+			s:    &SnmpTrap{ServiceAddress: "udp://127.0.0.1:0", SecName: "user", SecLevel: "foo"},
+		},
+		{
+			name: "missing auth_protocol",
+			s:    &SnmpTrap{ServiceAddress: "udp://127.0.0.1:0", SecName: "user", SecLevel: "authNoPriv"},
+		},
+		{
+			name: "missing priv_protocol",
+			s:    &SnmpTrap{ServiceAddress: "udp://127.0.0.1:0", SecName: "user", SecLevel: "authPriv", AuthProtocol: "SHA"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.s.Start(&testutil.Accumulator{})
+			require.Error(t, err, fmt.Sprintf("%+v", tt.s))
+		})
+	}
+}
+
+func TestPduOffset(t *testing.T) {
+	packet := &gosnmp.SnmpPacket{
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		PDUType:   gosnmp.InformRequest,
+		Variables: linkDownVariables()[:2],
+	}
+	msg, err := packet.MarshalMsg()
+	require.NoError(t, err)
+
+	offset, version, ok := pduOffset(msg)
+	require.True(t, ok)
+	require.Equal(t, gosnmp.Version2c, version)
+	require.Equal(t, byte(gosnmp.InformRequest), msg[offset])
+
+	_, _, ok = pduOffset(msg[:offset])
+	require.False(t, ok)
+	_, _, ok = pduOffset([]byte{0x30})
+	require.False(t, ok)
+}
+
+func TestPduOffsetV3(t *testing.T) {
+	packet := &gosnmp.SnmpPacket{
+		Version:       gosnmp.Version3,
+		MsgFlags:      gosnmp.Reportable | gosnmp.NoAuthNoPriv,
+		SecurityModel: gosnmp.UserSecurityModel,
+		SecurityParameters: &gosnmp.UsmSecurityParameters{
+			UserName: "user",
+			Logger:   log.New(ioutil.Discard, "", 0),
+		},
+		ContextName: "ctx",
+		PDUType:     gosnmp.InformRequest,
+		Variables:   linkDownVariables()[:2],
+	}
+	msg, err := packet.MarshalMsg()
+	require.NoError(t, err)
+
+	offset, version, ok := pduOffset(msg)
+	require.True(t, ok)
+	require.Equal(t, gosnmp.Version3, version)
+	require.Equal(t, byte(gosnmp.InformRequest), msg[offset])
+}
+
+func TestReceiveV3InformRejected(t *testing.T) {
+	s := newTestSnmpTrap()
+	s.SecName = "user"
+	acc, port := start(t, s)
+	defer s.Stop()
+
+	packet := &gosnmp.SnmpPacket{
+		Version:       gosnmp.Version3,
+		MsgFlags:      gosnmp.Reportable | gosnmp.NoAuthNoPriv,
+		SecurityModel: gosnmp.UserSecurityModel,
+		SecurityParameters: &gosnmp.UsmSecurityParameters{
+			UserName: "user",
+			Logger:   log.New(ioutil.Discard, "", 0),
+		},
+		PDUType:   gosnmp.InformRequest,
+		Variables: linkDownVariables()[:2],
+	}
+	msg, err := packet.MarshalMsg()
+	require.NoError(t, err)
+
+	conn, err := net.Dial("udp", "127.0.0.1:"+strconv.Itoa(port))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write(msg)
+	require.NoError(t, err)
+
+	acc.WaitError(1)
+	require.Contains(t, acc.Errors[0].Error(), "SNMPv3 informs are not supported")
+	require.Empty(t, acc.Metrics)
+}
+
+func TestReceiveV3EncryptedInformRejected(t *testing.T) {
+	for _, tt := range []struct {
+		priv     gosnmp.SnmpV3PrivProtocol
+		privName string
+	}{
+		{gosnmp.DES, "DES"},
+		{gosnmp.AES, "AES"},
+	} {
+		t.Run(tt.privName, func(t *testing.T) {
+			s := newTestSnmpTrap()
+			s.SecName = "user"
+			s.SecLevel = "authPriv"
+			s.AuthProtocol = "SHA"
+			s.AuthPassword = "authpassword"
+			s.PrivProtocol = tt.privName
+			s.PrivPassword = "privpassword"
+			acc, port := start(t, s)
+			defer s.Stop()
+
+			sp := localizedParams(t, "\x80\x00\x1f\x88\x04test", &gosnmp.UsmSecurityParameters{
+				UserName:                 "user",
+				AuthenticationProtocol:   gosnmp.SHA,
+				AuthenticationPassphrase: "authpassword",
+				PrivacyProtocol:          tt.priv,
+				PrivacyPassphrase:        "privpassword",
+			})
+			sp.PrivacyParameters = []byte("saltsalt")
+			packet := &gosnmp.SnmpPacket{
+				Version:            gosnmp.Version3,
+				MsgFlags:           gosnmp.Reportable | gosnmp.AuthPriv,
+				SecurityModel:      gosnmp.UserSecurityModel,
+				SecurityParameters: sp,
+				PDUType:            gosnmp.InformRequest,
+				Variables:          linkDownVariables(),
+			}
+			msg, err := packet.MarshalMsg()
+			require.NoError(t, err)
+			_, _, ok := pduOffset(msg)
+			require.False(t, ok)
+
+			conn, err := net.Dial("udp", "127.0.0.1:"+strconv.Itoa(port))
+			require.NoError(t, err)
+			defer conn.Close()
+			_, err = conn.Write(msg)
+			require.NoError(t, err)
+
+			acc.WaitError(1)
+			require.Contains(t, acc.Errors[0].Error(), "SNMPv3 informs are not supported")
+			require.Empty(t, acc.Metrics)
+		})
+	}
+}
+
+func TestV1TrapOid(t *testing.T) {
+	require.Equal(t, ".1.3.6.1.6.3.1.1.5.1", v1TrapOid(".1.3.6.1.4.1.8072", 0, 0))
+	require.Equal(t, ".1.3.6.1.4.1.8072.0.17", v1TrapOid("1.3.6.1.4.1.8072", 6, 17))
+}
@@ -0,0 +1,316 @@
+package sql
+
+import (
+	dbsql "database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+)
+
+const defaultMeasurement = "sql"
+
+// Query is a query and the mapping of its result columns to a metric.
+type Query struct {
+	Query             string            `toml:"query"`
+	Script            string            `toml:"query_script"`
+	Measurement       string            `toml:"measurement"`
+	MeasurementColumn string            `toml:"measurement_column"`
+	TimeColumn        string            `toml:"time_column"`
+	TimeFormat        string            `toml:"time_format"`
+	Interval          internal.Duration `toml:"interval"`
+
+	TagColumnsInclude   []string `toml:"tag_columns_include"`
+	TagColumnsExclude   []string `toml:"tag_columns_exclude"`
+	FieldColumnsInclude []string `toml:"field_columns_include"`
+	FieldColumnsExclude []string `toml:"field_columns_exclude"`
+
+	FieldColumnsFloat  []string `toml:"field_columns_float"`
+	FieldColumnsInt    []string `toml:"field_columns_int"`
+	FieldColumnsUint   []string `toml:"field_columns_uint"`
+	FieldColumnsBool   []string `toml:"field_columns_bool"`
+	FieldColumnsString []string `toml:"field_columns_string"`
+
+	tagFilter   filter.Filter
+	fieldFilter filter.Filter
+	conversions []conversion
+	lastRun     time.Time
+}
+
+// conversion converts the values of the columns matching the filter.
+type conversion struct {
+	filter  filter.Filter
+	convert func(interface{}) (interface{}, error)
+}
+
+func (q *Query) compile() error {
+	if strings.TrimSpace(q.Query) == "" {
+		return fmt.Errorf("missing query")
+	}
+	if q.Measurement == "" {
+		q.Measurement = defaultMeasurement
+	}
+
+	var err error
+	// Unlike fields, no column is a tag unless included.
+	if len(q.TagColumnsInclude) > 0 {
+		q.tagFilter, err = filter.NewIncludeExcludeFilter(q.TagColumnsInclude, q.TagColumnsExclude)
+		if err != nil {
+			return fmt.Errorf("tag columns: %v", err)
+		}
+	}
+	q.fieldFilter, err = filter.NewIncludeExcludeFilter(q.FieldColumnsInclude, q.FieldColumnsExclude)
+	if err != nil {
+		return fmt.Errorf("field columns: %v", err)
+	}
+
+	q.conversions = nil
+	for _, c := range []struct {
+		columns []string
+		convert func(interface{}) (interface{}, error)
+	}{
+		{q.FieldColumnsFloat, toFloat},
+		{q.FieldColumnsInt, toInt},
+		{q.FieldColumnsUint, toUint},
+		{q.FieldColumnsBool, toBool},
+		{q.FieldColumnsString, toString},
+	} {
+		if len(c.columns) == 0 {
+			continue
+		}
+		f, err := filter.Compile(c.columns)
+		if err != nil {
+			return fmt.Errorf("field column types: %v", err)
+		}
+		q.conversions = append(q.conversions, conversion{filter: f, convert: c.convert})
+	}
+
+	return nil
+}
+
+// due reports if the query should run at now.  The interval is shortened
+// slightly so that jitter in the gather times does not delay the query by a
+// whole interval of the input.
+func (q *Query) due(now time.Time) bool {
+	if q.Interval.Duration <= 0 || q.lastRun.IsZero() {
+		return true
+	}
+	return now.Sub(q.lastRun) >= q.Interval.Duration*9/10
+}
+
+// parse adds a metric for each of the rows.
+func (q *Query) parse(acc telegraf.Accumulator, rows *dbsql.Rows, now time.Time) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+
+		measurement := q.Measurement
+		timestamp := now
+		tags := make(map[string]string)
+		fields := make(map[string]interface{})
+
+		for i, name := range columns {
+			value := values[i]
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			if value == nil {
+				continue
+			}
+
+			switch {
+			case name == q.MeasurementColumn:
+				measurement, err = formatString(value)
+				if err != nil {
+					return fmt.Errorf("measurement column %q: %v", name, err)
+				}
+			case name == q.TimeColumn:
+				timestamp, err = q.parseTime(value)
+				if err != nil {
+					return fmt.Errorf("time column %q: %v", name, err)
+				}
+			case q.tagFilter != nil && q.tagFilter.Match(name):
+				tags[name], err = formatString(value)
+				if err != nil {
+					return fmt.Errorf("tag column %q: %v", name, err)
+				}
+			case q.fieldFilter.Match(name):
+				fields[name], err = q.convert(name, value)
+				if err != nil {
+					return fmt.Errorf("field column %q: %v", name, err)
+				}
+			}
+		}
+
+		if len(fields) > 0 {
+			acc.AddFields(measurement, fields, tags, timestamp)
+		}
+	}
+	return rows.Err()
+}
+
+// convert converts the value of a field column to the type configured for
+// the column, if any.
+func (q *Query) convert(name string, value interface{}) (interface{}, error) {
+	for _, c := range q.conversions {
+		if c.filter.Match(name) {
+			return c.convert(value)
+		}
+	}
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.RFC3339Nano), nil
+	}
+	return value, nil
+}
+
+func (q *Query) parseTime(value interface{}) (time.Time, error) {
+	if t, ok := value.(time.Time); ok {
+		return t, nil
+	}
+
+	switch q.TimeFormat {
+	case "", "unix", "unix_ms", "unix_us", "unix_ns":
+		if s, ok := value.(string); ok && q.TimeFormat == "" {
+			return time.Parse(time.RFC3339Nano, s)
+		}
+		v, err := toInt(value)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return parseUnix(v.(int64), q.TimeFormat), nil
+	default:
+		s, ok := value.(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("cannot parse %T with time_format %q", value, q.TimeFormat)
+		}
+		return time.Parse(q.TimeFormat, s)
+	}
+}
+
+func parseUnix(v int64, format string) time.Time {
+	switch format {
+	case "unix_ms":
+		return time.Unix(0, v*int64(time.Millisecond))
+	case "unix_us":
+		return time.Unix(0, v*int64(time.Microsecond))
+	case "unix_ns":
+		return time.Unix(0, v)
+	default:
+		return time.Unix(v, 0)
+	}
+}
+
+func formatString(value interface{}) (string, error) {
+	v, err := toString(value)
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+func toFloat(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case bool:
+		if v {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return nil, fmt.Errorf("cannot convert %T to float", value)
+}
+
+func toInt(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if v < math.MinInt64 || v > math.MaxInt64 {
+			return nil, fmt.Errorf("%v overflows int", v)
+		}
+		return int64(v), nil
+	case bool:
+		if v {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return nil, fmt.Errorf("cannot convert %T to int", value)
+}
+
+func toUint(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		if v < 0 {
+			return nil, fmt.Errorf("%d is negative", v)
+		}
+		return uint64(v), nil
+	case float64:
+		if v < 0 || v > math.MaxUint64 {
+			return nil, fmt.Errorf("%v overflows uint", v)
+		}
+		return uint64(v), nil
+	case bool:
+		if v {
+			return uint64(1), nil
+		}
+		return uint64(0), nil
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	}
+	return nil, fmt.Errorf("cannot convert %T to uint", value)
+}
+
+func toBool(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case int64:
+		return v != 0, nil
+	case float64:
+		return v != 0, nil
+	case string:
+		return strconv.ParseBool(v)
+	}
+	return nil, fmt.Errorf("cannot convert %T to bool", value)
+}
+
+func toString(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to string", value)
+}
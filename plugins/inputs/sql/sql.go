@@ -0,0 +1,214 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/sqldriver"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+var sampleConfig = `
+  ## Database driver
+  ## Valid options: mysql, postgres, sqlserver, sqlite
+  driver = "mysql"
+
+  ## Data source name for connecting
+  ## The syntax and supported options depend on the driver:
+  ##   mysql:     https://github.com/go-sql-driver/mysql#dsn-data-source-name
+  ##   postgres:  https://godoc.org/github.com/jackc/pgx#ParseConnectionString
+  ##   sqlserver: https://github.com/denisenkom/go-mssqldb#connection-parameters-and-dsn
+  ##   sqlite:    https://github.com/mattn/go-sqlite3#connection-string
+  dsn = "username:password@tcp(localhost:3306)/dbname"
+
+  ## Timeout for each query
+  # timeout = "5s"
+
+  ## Connection pool settings, by default the pool is unbounded and
+  ## connections are kept forever.
+  # connection_max_open = 0
+  # connection_max_idle = 2
+  # connection_max_lifetime = "0s"
+
+  ## Queries to perform, each query adds a metric per row of its result.
+  [[inputs.sql.query]]
+    ## Query to perform on the server
+    query = "SELECT user, state, latency, score FROM Scoreboard WHERE application > 0"
+    ## Alternatively, the query can be read from a file
+    # query_script = "/path/to/query.sql"
+
+    ## Name of the measurement
+    # measurement = "sql"
+    ## Column used as the measurement name, the measurement above is used for
+    ## rows where the column is NULL.
+    # measurement_column = ""
+
+    ## Column used as the time of the metric, the time of the gather is used
+    ## when empty.
+    # time_column = ""
+    ## Format of the time column, one of "unix", "unix_ms", "unix_us",
+    ## "unix_ns" or a Go time layout such as "2006-01-02 15:04:05".  By
+    ## default numbers are unix seconds and strings are RFC3339, columns
+    ## with a native time type need no format.
+    # time_format = ""
+
+    ## Minimum time between two runs of the query, by default the query is
+    ## run every interval of the input.
+    # interval = "0s"
+
+    ## Columns to use as tags.  Globs accepted.
+    ## By default no column is a tag.
+    # tag_columns_include = []
+    # tag_columns_exclude = []
+
+    ## Columns to use as fields.  Globs accepted.
+    ## By default all the columns that are not tags are fields.
+    # field_columns_include = []
+    # field_columns_exclude = []
+
+    ## Columns to convert to the given type.  Globs accepted.
+    ## Other columns keep the type returned by the driver, with binary values
+    ## converted to strings.
+    # field_columns_float = []
+    # field_columns_int = []
+    # field_columns_uint = []
+    # field_columns_bool = []
+    # field_columns_string = []
+`
+
+// SQL runs custom queries on any database with a database/sql driver.
+type SQL struct {
+	Driver             string            `toml:"driver"`
+	Dsn                string            `toml:"dsn"`
+	Timeout            internal.Duration `toml:"timeout"`
+	MaxOpenConnections int               `toml:"connection_max_open"`
+	MaxIdleConnections int               `toml:"connection_max_idle"`
+	MaxLifetime        internal.Duration `toml:"connection_max_lifetime"`
+	Queries            []Query           `toml:"query"`
+
+	driverName string
+	db         *dbsql.DB
+}
+
+func (s *SQL) Description() string {
+	return "Read metrics from SQL queries"
+}
+
+func (s *SQL) SampleConfig() string {
+	return sampleConfig
+}
+
+// Init validates the driver and compiles the queries.
+func (s *SQL) Init() error {
+	if s.Dsn == "" {
+		return errors.New("missing dsn")
+	}
+
+	driver, ok := sqldriver.Lookup(s.Driver)
+	if !ok {
+		return fmt.Errorf("unknown driver %q, valid drivers are: %s",
+			s.Driver, strings.Join(sqldriver.Names(), ", "))
+	}
+	s.driverName = driver
+
+	if len(s.Queries) == 0 {
+		return errors.New("no query configured")
+	}
+	for i := range s.Queries {
+		q := &s.Queries[i]
+		if q.Script != "" {
+			if q.Query != "" {
+				return fmt.Errorf("query %d: only one of query and query_script can be set", i+1)
+			}
+			script, err := ioutil.ReadFile(q.Script)
+			if err != nil {
+				return fmt.Errorf("query %d: %v", i+1, err)
+			}
+			q.Query = string(script)
+		}
+		if err := q.compile(); err != nil {
+			return fmt.Errorf("query %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s *SQL) Start(telegraf.Accumulator) error {
+	if s.driverName == "" {
+		if err := s.Init(); err != nil {
+			return err
+		}
+	}
+
+	db, err := dbsql.Open(s.driverName, s.Dsn)
+	if err != nil {
+		return err
+	}
+	db.SetMaxOpenConns(s.MaxOpenConnections)
+	db.SetMaxIdleConns(s.MaxIdleConnections)
+	db.SetConnMaxLifetime(s.MaxLifetime.Duration)
+	s.db = db
+	return nil
+}
+
+func (s *SQL) Stop() {
+	if s.db != nil {
+		s.db.Close()
+	}
+}
+
+func (s *SQL) Gather(acc telegraf.Accumulator) error {
+	return s.GatherContext(context.Background(), acc)
+}
+
+// GatherContext runs the queries that are due, one after the other.
+func (s *SQL) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
+	now := time.Now()
+	for i := range s.Queries {
+		q := &s.Queries[i]
+		if !q.due(now) {
+			continue
+		}
+		q.lastRun = now
+
+		if err := s.run(ctx, acc, q, now); err != nil {
+			acc.AddError(fmt.Errorf("query %d: %v", i+1, err))
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (s *SQL) run(ctx context.Context, acc telegraf.Accumulator, q *Query, now time.Time) error {
+	if s.Timeout.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout.Duration)
+		defer cancel()
+	}
+
+	rows, err := s.db.QueryContext(ctx, q.Query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return q.parse(acc, rows, now)
+}
+
+func init() {
+	inputs.Add("sql", func() telegraf.Input {
+		return &SQL{
+			Timeout:            internal.Duration{Duration: 5 * time.Second},
+			MaxIdleConnections: 2,
+		}
+	})
+}
@@ -0,0 +1,287 @@
+// +build cgo
+
+package sql
+
+import (
+	dbsql "database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const schema = `
+CREATE TABLE readings (
+	sensor TEXT,
+	kind TEXT,
+	value REAL,
+	count INTEGER,
+	active TEXT,
+	ts INTEGER
+);
+INSERT INTO readings VALUES ('a', 'temperature', 21.5, 3, 'true', 1538395200);
+INSERT INTO readings VALUES ('b', 'humidity', 40, 5, 'false', 1538395260);
+INSERT INTO readings VALUES ('c', NULL, NULL, 7, 'true', 1538395320);
+`
+
+// newDatabase creates a SQLite database with the test schema and returns its
+// DSN.
+func newDatabase(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "sql")
+	require.NoError(t, err)
+	dsn := filepath.Join(dir, "test.db")
+
+	db, err := dbsql.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(schema)
+	require.NoError(t, err)
+	return dsn
+}
+
+func newSQL(dsn string, queries ...Query) *SQL {
+	return &SQL{
+		Driver:  "sqlite",
+		Dsn:     dsn,
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+		Queries: queries,
+	}
+}
+
+func gather(t *testing.T, s *SQL) *testutil.Accumulator {
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Init())
+	require.NoError(t, s.Start(acc))
+	defer s.Stop()
+	require.NoError(t, acc.GatherError(s.Gather))
+	return acc
+}
+
+func TestSQL_Gather(t *testing.T) {
+	dsn := newDatabase(t)
+	defer os.RemoveAll(filepath.Dir(dsn))
+
+	s := newSQL(dsn, Query{
+		Query:             "SELECT sensor, kind, value, count, active, ts FROM readings ORDER BY sensor",
+		Measurement:       "readings",
+		TimeColumn:        "ts",
+		TagColumnsInclude: []string{"sensor"},
+		FieldColumnsBool:  []string{"active"},
+	})
+	acc := gather(t, s)
+
+	expected := []*testutil.Metric{
+		{
+			Measurement: "readings",
+			Tags:        map[string]string{"sensor": "a"},
+			Fields: map[string]interface{}{
+				"kind":   "temperature",
+				"value":  21.5,
+				"count":  int64(3),
+				"active": true,
+			},
+			Time: time.Unix(1538395200, 0),
+		},
+		{
+			Measurement: "readings",
+			Tags:        map[string]string{"sensor": "b"},
+			Fields: map[string]interface{}{
+				"kind":   "humidity",
+				"value":  40.0,
+				"count":  int64(5),
+				"active": false,
+			},
+			Time: time.Unix(1538395260, 0),
+		},
+		{
+			Measurement: "readings",
+			Tags:        map[string]string{"sensor": "c"},
+			Fields: map[string]interface{}{
+				"count":  int64(7),
+				"active": true,
+			},
+			Time: time.Unix(1538395320, 0),
+		},
+	}
+	require.Len(t, acc.Metrics, len(expected))
+	for i, m := range acc.Metrics {
+		require.Equal(t, expected[i].Measurement, m.Measurement)
+		require.Equal(t, expected[i].Tags, m.Tags)
+		require.Equal(t, expected[i].Fields, m.Fields)
+		require.True(t, expected[i].Time.Equal(m.Time), "time %v != %v", expected[i].Time, m.Time)
+	}
+}
+
+func TestSQL_MeasurementColumn(t *testing.T) {
+	dsn := newDatabase(t)
+	defer os.RemoveAll(filepath.Dir(dsn))
+
+	s := newSQL(dsn, Query{
+		Query:               "SELECT kind, sensor, value FROM readings",
+		MeasurementColumn:   "kind",
+		FieldColumnsExclude: []string{"sensor"},
+	})
+	acc := gather(t, s)
+
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsFields(t, "temperature", map[string]interface{}{"value": 21.5})
+	acc.AssertContainsFields(t, "humidity", map[string]interface{}{"value": 40.0})
+}
+
+func TestSQL_Conversions(t *testing.T) {
+	dsn := newDatabase(t)
+	defer os.RemoveAll(filepath.Dir(dsn))
+
+	s := newSQL(dsn, Query{
+		Query:              "SELECT sensor, value, count, ts FROM readings WHERE sensor = 'a'",
+		TagColumnsInclude:  []string{"sensor"},
+		FieldColumnsInt:    []string{"value"},
+		FieldColumnsUint:   []string{"count"},
+		FieldColumnsString: []string{"ts"},
+	})
+	acc := gather(t, s)
+
+	acc.AssertContainsTaggedFields(t, "sql",
+		map[string]interface{}{
+			"value": int64(21),
+			"count": uint64(3),
+			"ts":    "1538395200",
+		},
+		map[string]string{"sensor": "a"})
+}
+
+func TestSQL_ConversionError(t *testing.T) {
+	dsn := newDatabase(t)
+	defer os.RemoveAll(filepath.Dir(dsn))
+
+	s := newSQL(dsn, Query{
+		Query:           "SELECT kind FROM readings WHERE sensor = 'a'",
+		FieldColumnsInt: []string{"kind"},
+	})
+	var acc testutil.Accumulator
+	require.NoError(t, s.Init())
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+
+	err := acc.GatherError(s.Gather)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `field column "kind"`)
+	require.Empty(t, acc.Metrics)
+}
+
+func TestSQL_TimeFormat(t *testing.T) {
+	dsn := newDatabase(t)
+	defer os.RemoveAll(filepath.Dir(dsn))
+
+	s := newSQL(dsn,
+		Query{
+			Query:       "SELECT ts * 1000 AS ts, count FROM readings WHERE sensor = 'a'",
+			Measurement: "ms",
+			TimeColumn:  "ts",
+			TimeFormat:  "unix_ms",
+		},
+		Query{
+			Query:       "SELECT '2018-10-01 12:00:00' AS ts, count FROM readings WHERE sensor = 'a'",
+			Measurement: "layout",
+			TimeColumn:  "ts",
+			TimeFormat:  "2006-01-02 15:04:05",
+		},
+	)
+	acc := gather(t, s)
+
+	require.True(t, acc.HasTimestamp("ms", time.Unix(1538395200, 0)))
+	require.True(t, acc.HasTimestamp("layout", time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestSQL_QueryInterval(t *testing.T) {
+	dsn := newDatabase(t)
+	defer os.RemoveAll(filepath.Dir(dsn))
+
+	s := newSQL(dsn,
+		Query{
+			Query:       "SELECT count FROM readings WHERE sensor = 'a'",
+			Measurement: "always",
+		},
+		Query{
+			Query:       "SELECT count FROM readings WHERE sensor = 'a'",
+			Measurement: "hourly",
+			Interval:    internal.Duration{Duration: time.Hour},
+		},
+	)
+	acc := gather(t, s)
+	require.True(t, acc.HasMeasurement("always"))
+	require.True(t, acc.HasMeasurement("hourly"))
+
+	acc.ClearMetrics()
+	require.NoError(t, s.Start(acc))
+	defer s.Stop()
+	require.NoError(t, acc.GatherError(s.Gather))
+	require.True(t, acc.HasMeasurement("always"))
+	require.False(t, acc.HasMeasurement("hourly"))
+
+	s.Queries[1].lastRun = time.Now().Add(-time.Hour)
+	acc.ClearMetrics()
+	require.NoError(t, acc.GatherError(s.Gather))
+	require.True(t, acc.HasMeasurement("hourly"))
+}
+
+func TestSQL_QueryScript(t *testing.T) {
+	dsn := newDatabase(t)
+	defer os.RemoveAll(filepath.Dir(dsn))
+
+	script := filepath.Join(filepath.Dir(dsn), "query.sql")
+	err := ioutil.WriteFile(script, []byte("SELECT count FROM readings WHERE sensor = 'b'"), 0640)
+	require.NoError(t, err)
+
+	s := newSQL(dsn, Query{Script: script})
+	acc := gather(t, s)
+
+	acc.AssertContainsFields(t, "sql", map[string]interface{}{"count": int64(5)})
+}
+
+func TestSQL_InitErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  *SQL
+		err  string
+	}{
+		{
+			name: "unknown driver",
+			sql:  &SQL{Driver: "oracle", Dsn: "x", Queries: []Query{{Query: "SELECT 1"}}},
+			err:  `unknown driver "oracle"`,
+		},
+		{
+			name: "missing dsn",
+			sql:  &SQL{Driver: "sqlite", Queries: []Query{{Query: "SELECT 1"}}},
+			err:  "missing dsn",
+		},
+		{
+			name: "no query",
+			sql:  &SQL{Driver: "sqlite", Dsn: "x"},
+			err:  "no query configured",
+		},
+		{
+			name: "empty query",
+			sql:  &SQL{Driver: "sqlite", Dsn: "x", Queries: []Query{{Query: " "}}},
+			err:  "query 1: missing query",
+		},
+		{
+			name: "query and script",
+			sql:  &SQL{Driver: "sqlite", Dsn: "x", Queries: []Query{{Query: "SELECT 1", Script: "a.sql"}}},
+			err:  "query 1: only one of query and query_script can be set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.sql.Init()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package syslog
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/go-syslog/rfc5424"
+)
+
+// rfc3164Stamp is the timestamp layout of RFC3164, lacking year and timezone.
+const rfc3164Stamp = time.Stamp
+
+// rfc5424Stamp is the most precise timestamp layout allowed by RFC5424.
+const rfc5424Stamp = "2006-01-02T15:04:05.999999Z07:00"
+
+// parseRFC3164 parses a BSD syslog message as described in RFC3164:
+//
+//	<PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG
+//
+// Many senders use an RFC3339 timestamp instead, which is accepted as well.
+// As RFC3164 timestamps have no year, the year of now is used, or the
+// previous one for dates more than a month ahead of now.
+//
+// The message is returned as an RFC5424 message without version and structured
+// data, so both formats produce the same tags and fields.  In best effort mode
+// the message is returned together with the error as long as the priority
+// could be parsed.
+func parseRFC3164(input []byte, bestEffort bool, now time.Time) (*rfc5424.SyslogMessage, error) {
+	p := &rfc3164Parser{input: input}
+
+	prio, err := p.priority()
+	if err != nil {
+		return nil, err
+	}
+	msg := &rfc5424.SyslogMessage{}
+	msg.SetPriority(prio)
+
+	fail := func(err error) (*rfc5424.SyslogMessage, error) {
+		if bestEffort {
+			return msg, err
+		}
+		return nil, err
+	}
+
+	ts, err := p.timestamp(now)
+	if err != nil {
+		return fail(err)
+	}
+	msg.SetTimestamp(ts.Format(rfc5424Stamp))
+
+	if err := p.space(); err != nil {
+		return fail(err)
+	}
+	hostname := p.word()
+	if hostname == "" {
+		return fail(p.errorf("expecting a hostname"))
+	}
+	msg.SetHostname(hostname)
+
+	if err := p.space(); err != nil {
+		return fail(err)
+	}
+	if tag, procid, ok := p.tag(); ok {
+		msg.SetAppname(tag)
+		if procid != "" {
+			msg.SetProcID(procid)
+		}
+	}
+
+	if p.pos < len(p.input) {
+		msg.SetMessage(string(p.input[p.pos:]))
+	}
+	return msg, nil
+}
+
+type rfc3164Parser struct {
+	input []byte
+	pos   int
+}
+
+func (p *rfc3164Parser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf(format+" [col %d]", append(a, p.pos)...)
+}
+
+func (p *rfc3164Parser) priority() (uint8, error) {
+	if p.pos >= len(p.input) || p.input[p.pos] != '<' {
+		return 0, p.errorf("expecting a priority value within angle brackets")
+	}
+	p.pos++
+
+	start := p.pos
+	for p.pos < len(p.input) && p.pos-start < 3 && isDigit(p.input[p.pos]) {
+		p.pos++
+	}
+	if p.pos >= len(p.input) || p.input[p.pos] != '>' || p.pos == start {
+		return 0, p.errorf("expecting a priority value within angle brackets")
+	}
+
+	prio, err := strconv.ParseUint(string(p.input[start:p.pos]), 10, 8)
+	if err != nil || prio > 191 {
+		return 0, p.errorf("expecting a priority value in the range 0-191")
+	}
+	p.pos++
+	return uint8(prio), nil
+}
+
+func (p *rfc3164Parser) timestamp(now time.Time) (time.Time, error) {
+	rest := p.input[p.pos:]
+
+	if len(rest) >= len(rfc3164Stamp) {
+		if ts, err := time.Parse(rfc3164Stamp, string(rest[:len(rfc3164Stamp)])); err == nil {
+			p.pos += len(rfc3164Stamp)
+			year := now.UTC().Year()
+			t := time.Date(year, ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, time.UTC)
+			// A message sent in December and received in January belongs
+			// to the previous year.
+			if t.After(now.AddDate(0, 1, 0)) {
+				t = time.Date(year-1, ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, time.UTC)
+			}
+			return t, nil
+		}
+	}
+
+	word := rest
+	for i, c := range rest {
+		if c == ' ' {
+			word = rest[:i]
+			break
+		}
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, string(word)); err == nil {
+		p.pos += len(word)
+		return ts, nil
+	}
+
+	return time.Time{}, p.errorf("expecting a timestamp")
+}
+
+func (p *rfc3164Parser) space() error {
+	if p.pos >= len(p.input) || p.input[p.pos] != ' ' {
+		return p.errorf("expecting a space")
+	}
+	p.pos++
+	return nil
+}
+
+func (p *rfc3164Parser) word() string {
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] > ' ' && p.input[p.pos] < 0x7f {
+		p.pos++
+	}
+	return string(p.input[start:p.pos])
+}
+
+// tag reads the optional "TAG[PID]:" prefix of the message content.  If the
+// content does not start with a tag, the position is left unchanged.
+func (p *rfc3164Parser) tag() (tag string, procid string, ok bool) {
+	start := p.pos
+	pos := p.pos
+	for pos < len(p.input) && pos-start < 48 && isTagChar(p.input[pos]) {
+		pos++
+	}
+	if pos == start {
+		return "", "", false
+	}
+	tag = string(p.input[start:pos])
+
+	if pos < len(p.input) && p.input[pos] == '[' {
+		end := pos + 1
+		for end < len(p.input) && p.input[end] != ']' && p.input[end] > ' ' {
+			end++
+		}
+		if end >= len(p.input) || p.input[end] != ']' || end == pos+1 {
+			return "", "", false
+		}
+		procid = string(p.input[pos+1 : end])
+		pos = end + 1
+	}
+
+	if pos >= len(p.input) || p.input[pos] != ':' {
+		return "", "", false
+	}
+	pos++
+	if pos < len(p.input) && p.input[pos] == ' ' {
+		pos++
+	}
+
+	p.pos = pos
+	return tag, procid, true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isTagChar(c byte) bool {
+	return c > ' ' && c < 0x7f && c != '[' && c != ']' && c != ':'
+}
@@ -0,0 +1,185 @@
+package syslog
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRFC3164(t *testing.T) {
+	now := time.Date(2018, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		data           string
+		wantStrict     map[string]interface{}
+		wantBestEffort map[string]interface{}
+		werr           bool
+	}{
+		{
+			name: "complete",
+			data: "<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8",
+			wantStrict: map[string]interface{}{
+				"severity":  "crit",
+				"facility":  "auth",
+				"hostname":  "mymachine",
+				"appname":   "su",
+				"procid":    "123",
+				"message":   "'su root' failed for lonvick on /dev/pts/8",
+				"timestamp": time.Date(2018, time.October, 11, 22, 14, 15, 0, time.UTC).UnixNano(),
+			},
+		},
+		{
+			name: "padded day without procid",
+			data: "<13>Feb  5 17:32:18 10.0.0.99 myapp: Use the BFG!",
+			wantStrict: map[string]interface{}{
+				"severity":  "notice",
+				"facility":  "user",
+				"hostname":  "10.0.0.99",
+				"appname":   "myapp",
+				"message":   "Use the BFG!",
+				"timestamp": time.Date(2018, time.February, 5, 17, 32, 18, 0, time.UTC).UnixNano(),
+			},
+		},
+		{
+			name: "rfc3339 timestamp",
+			data: "<165>2003-10-11T22:14:15.003Z mymachine.example.com evntslog: An application event",
+			wantStrict: map[string]interface{}{
+				"severity":  "notice",
+				"facility":  "local4",
+				"hostname":  "mymachine.example.com",
+				"appname":   "evntslog",
+				"message":   "An application event",
+				"timestamp": time.Date(2003, time.October, 11, 22, 14, 15, 3000000, time.UTC).UnixNano(),
+			},
+		},
+		{
+			name: "without tag",
+			data: "<0>Oct 22 10:52:01 scapegoat.dmz.example.org sched [0]: that's all folks",
+			wantStrict: map[string]interface{}{
+				"severity":  "emerg",
+				"facility":  "kern",
+				"hostname":  "scapegoat.dmz.example.org",
+				"message":   "sched [0]: that's all folks",
+				"timestamp": time.Date(2018, time.October, 22, 10, 52, 1, 0, time.UTC).UnixNano(),
+			},
+		},
+		{
+			name: "missing hostname",
+			data: "<1>Oct 22 10:52:01 ",
+			wantBestEffort: map[string]interface{}{
+				"severity":  "alert",
+				"facility":  "kern",
+				"timestamp": time.Date(2018, time.October, 22, 10, 52, 1, 0, time.UTC).UnixNano(),
+			},
+			werr: true,
+		},
+		{
+			name: "invalid timestamp",
+			data: "<1>yesterday host app: msg",
+			wantBestEffort: map[string]interface{}{
+				"severity": "alert",
+				"facility": "kern",
+			},
+			werr: true,
+		},
+		{
+			name: "invalid priority",
+			data: "<192>Oct 22 10:52:01 host app: msg",
+			werr: true,
+		},
+		{
+			name: "missing priority",
+			data: "Oct 22 10:52:01 host app: msg",
+			werr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, bestEffort := range []bool{false, true} {
+				want := tt.wantStrict
+				if bestEffort && tt.wantBestEffort != nil {
+					want = tt.wantBestEffort
+				}
+
+				msg, err := parseRFC3164([]byte(tt.data), bestEffort, now)
+				if tt.werr {
+					require.Error(t, err)
+				} else {
+					require.NoError(t, err)
+				}
+				if want == nil {
+					require.Nil(t, msg, "best effort: %v", bestEffort)
+					continue
+				}
+				require.NotNil(t, msg, "best effort: %v", bestEffort)
+
+				got := map[string]interface{}{}
+				for k, v := range tags(*msg) {
+					got[k] = v
+				}
+				for k, v := range fields(*msg, &Syslog{}) {
+					got[k] = v
+				}
+				delete(got, "severity_code")
+				delete(got, "facility_code")
+				require.Equal(t, want, got, "best effort: %v", bestEffort)
+			}
+		})
+	}
+}
+
+func TestParseRFC3164YearRollover(t *testing.T) {
+	now := time.Date(2019, time.January, 1, 0, 10, 0, 0, time.UTC)
+
+	msg, err := parseRFC3164([]byte("<13>Dec 31 23:59:00 host app: last year"), false, now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2018, time.December, 31, 23, 59, 0, 0, time.UTC), *msg.Timestamp())
+
+	// A sender clock slightly ahead does not change the year.
+	msg, err = parseRFC3164([]byte("<13>Jan  1 00:15:00 host app: this year"), false, now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2019, time.January, 1, 0, 15, 0, 0, time.UTC), *msg.Timestamp())
+}
+
+func TestRFC3164_udp(t *testing.T) {
+	receiver := newUDPSyslogReceiver("udp://"+address, false)
+	receiver.SyslogStandard = syslogRFC3164
+	acc := &testutil.Accumulator{}
+	require.NoError(t, receiver.Start(acc))
+	defer receiver.Stop()
+
+	conn, err := net.Dial("udp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("<13>Jan  1 00:00:01 host app[42]: hello\n"))
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	want := &testutil.Metric{
+		Measurement: "syslog",
+		Fields: map[string]interface{}{
+			"severity_code": 5,
+			"facility_code": 1,
+			"timestamp":     time.Unix(1, 0).UnixNano(),
+			"procid":        "42",
+			"message":       "hello",
+		},
+		Tags: map[string]string{
+			"severity": "notice",
+			"facility": "user",
+			"hostname": "host",
+			"appname":  "app",
+		},
+		Time: defaultTime,
+	}
+	if !cmp.Equal(want, acc.Metrics[0]) {
+		t.Fatalf("Got (+) / Want (-)\n %s", cmp.Diff(want, acc.Metrics[0]))
+	}
+}
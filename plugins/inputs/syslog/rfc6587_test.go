@@ -0,0 +1,272 @@
+package syslog
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	framing "github.com/influxdata/telegraf/internal/syslog"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type testCase6587 struct {
+	name           string
+	data           []byte
+	trailer        framing.Trailer
+	standard       string
+	wantStrict     []testutil.Metric
+	wantBestEffort []testutil.Metric
+	werr           int // how many errors we expect in the strict mode?
+}
+
+func getTestCasesForNonTransparent() []testCase6587 {
+	testCases := []testCase6587{
+		{
+			name: "1st/min/ok//2nd/min/ok",
+			data: []byte("<1>2 - - - - - -\n<4>11 - - - - - -\n"),
+			wantStrict: []testutil.Metric{
+				{
+					Measurement: "syslog",
+					Fields: map[string]interface{}{
+						"version":       uint16(2),
+						"severity_code": 1,
+						"facility_code": 0,
+					},
+					Tags: map[string]string{
+						"severity": "alert",
+						"facility": "kern",
+					},
+					Time: defaultTime,
+				},
+				{
+					Measurement: "syslog",
+					Fields: map[string]interface{}{
+						"version":       uint16(11),
+						"severity_code": 4,
+						"facility_code": 0,
+					},
+					Tags: map[string]string{
+						"severity": "warning",
+						"facility": "kern",
+					},
+					Time: defaultTime.Add(time.Nanosecond),
+				},
+			},
+		},
+		{
+			name:    "1st/nul/ok", // newline within the message
+			data:    []byte("<1>3 - - - - - - hello\nworld\x00"),
+			trailer: framing.NUL,
+			wantStrict: []testutil.Metric{
+				{
+					Measurement: "syslog",
+					Fields: map[string]interface{}{
+						"version":       uint16(3),
+						"message":       "hello\nworld",
+						"severity_code": 1,
+						"facility_code": 0,
+					},
+					Tags: map[string]string{
+						"severity": "alert",
+						"facility": "kern",
+					},
+					Time: defaultTime,
+				},
+			},
+		},
+		{
+			name: "1st/notrailer/ok", // the last message may lack the trailer
+			data: []byte("<1>1 - - - - - - A"),
+			wantStrict: []testutil.Metric{
+				{
+					Measurement: "syslog",
+					Fields: map[string]interface{}{
+						"version":       uint16(1),
+						"message":       "A",
+						"severity_code": 1,
+						"facility_code": 0,
+					},
+					Tags: map[string]string{
+						"severity": "alert",
+						"facility": "kern",
+					},
+					Time: defaultTime,
+				},
+			},
+		},
+		{
+			name: "1st/ko//2nd/min/ok", // an invalid message stops strict parsing
+			data: []byte("<1>2\n<1>1 - - - - - -\n"),
+			wantBestEffort: []testutil.Metric{
+				{
+					Measurement: "syslog",
+					Fields: map[string]interface{}{
+						"version":       uint16(2),
+						"severity_code": 1,
+						"facility_code": 0,
+					},
+					Tags: map[string]string{
+						"severity": "alert",
+						"facility": "kern",
+					},
+					Time: defaultTime,
+				},
+				{
+					Measurement: "syslog",
+					Fields: map[string]interface{}{
+						"version":       uint16(1),
+						"severity_code": 1,
+						"facility_code": 0,
+					},
+					Tags: map[string]string{
+						"severity": "alert",
+						"facility": "kern",
+					},
+					Time: defaultTime.Add(time.Nanosecond),
+				},
+			},
+			werr: 1,
+		},
+		{
+			name:     "rfc3164/ok",
+			data:     []byte("<13>Jan  1 00:00:01 host app[42]: hello\n<14>Jan  1 00:00:02 host app[42]: world\n"),
+			standard: syslogRFC3164,
+			wantStrict: []testutil.Metric{
+				{
+					Measurement: "syslog",
+					Fields: map[string]interface{}{
+						"timestamp":     time.Unix(1, 0).UnixNano(),
+						"procid":        "42",
+						"message":       "hello",
+						"severity_code": 5,
+						"facility_code": 1,
+					},
+					Tags: map[string]string{
+						"severity": "notice",
+						"facility": "user",
+						"hostname": "host",
+						"appname":  "app",
+					},
+					Time: defaultTime,
+				},
+				{
+					Measurement: "syslog",
+					Fields: map[string]interface{}{
+						"timestamp":     time.Unix(2, 0).UnixNano(),
+						"procid":        "42",
+						"message":       "world",
+						"severity_code": 6,
+						"facility_code": 1,
+					},
+					Tags: map[string]string{
+						"severity": "info",
+						"facility": "user",
+						"hostname": "host",
+						"appname":  "app",
+					},
+					Time: defaultTime.Add(time.Nanosecond),
+				},
+			},
+		},
+	}
+
+	return testCases
+}
+
+func testNonTransparent(t *testing.T, protocol string, address string, bestEffort bool) {
+	for _, tc := range getTestCasesForNonTransparent() {
+		t.Run(tc.name, func(t *testing.T) {
+			// Creation of a receiver using non-transparent framing
+			receiver := newTCPSyslogReceiver(protocol+"://"+address, nil, 0, bestEffort)
+			receiver.Framing = framing.NonTransparent
+			receiver.Trailer = tc.trailer
+			receiver.SyslogStandard = tc.standard
+			acc := &testutil.Accumulator{}
+			require.NoError(t, receiver.Start(acc))
+			defer receiver.Stop()
+
+			// Connect
+			conn, err := net.Dial(protocol, address)
+			require.NoError(t, err)
+
+			// Write
+			_, err = conn.Write(tc.data)
+			conn.Close()
+			require.NoError(t, err)
+
+			want := tc.wantStrict
+			if bestEffort && tc.wantBestEffort != nil {
+				want = tc.wantBestEffort
+			}
+
+			// Wait that the the number of data points is accumulated
+			// Since the receiver is running concurrently
+			if want != nil {
+				acc.Wait(len(want))
+			}
+			if !bestEffort {
+				acc.WaitError(tc.werr)
+				if len(acc.Errors) != tc.werr {
+					t.Fatalf("Got unexpected errors. want error = %v, errors = %v\n", tc.werr, acc.Errors)
+				}
+			}
+
+			// Verify
+			var got []testutil.Metric
+			for _, metric := range acc.Metrics {
+				got = append(got, *metric)
+			}
+			if !cmp.Equal(want, got) {
+				t.Fatalf("Got (+) / Want (-)\n %s", cmp.Diff(want, got))
+			}
+		})
+	}
+}
+
+func TestNonTransparentStrict_tcp(t *testing.T) {
+	testNonTransparent(t, "tcp", address, false)
+}
+
+func TestNonTransparentBestEffort_tcp(t *testing.T) {
+	testNonTransparent(t, "tcp", address, true)
+}
+
+func TestNonTransparentStrict_unix(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	sock := filepath.Join(tmpdir, "syslog.TestNonTransparentStrict_unix.sock")
+	testNonTransparent(t, "unix", sock, false)
+}
+
+func TestNonTransparentBestEffort_unix(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	sock := filepath.Join(tmpdir, "syslog.TestNonTransparentBestEffort_unix.sock")
+	testNonTransparent(t, "unix", sock, true)
+}
+
+func TestReadNonTransparentIncomplete(t *testing.T) {
+	// A message lacking the trailer is accepted at the end of the stream.
+	r := bufio.NewReader(strings.NewReader("<13>first\n<13>last"))
+	frame, err := readNonTransparent(r, '\n')
+	require.NoError(t, err)
+	require.Equal(t, "<13>first", string(frame))
+	frame, err = readNonTransparent(r, '\n')
+	require.NoError(t, err)
+	require.Equal(t, "<13>last", string(frame))
+
+	// But not when the read is interrupted.
+	r = bufio.NewReader(iotest.TimeoutReader(strings.NewReader("<13>partial")))
+	_, err = readNonTransparent(r, '\n')
+	require.Error(t, err)
+}
@@ -1,21 +1,23 @@
 package syslog
 
 import (
+	"bufio"
 	"crypto/tls"
 	"fmt"
 	"io"
 	"net"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 	"unicode"
 
 	"github.com/influxdata/go-syslog/rfc5424"
-	"github.com/influxdata/go-syslog/rfc5425"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	framing "github.com/influxdata/telegraf/internal/syslog"
 	tlsConfig "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -23,6 +25,11 @@ import (
 const defaultReadTimeout = time.Second * 5
 const ipMaxPacketSize = 64 * 1024
 
+const (
+	syslogRFC5424 = "RFC5424"
+	syslogRFC3164 = "RFC3164"
+)
+
 // Syslog is a syslog plugin
 type Syslog struct {
 	tlsConfig.ServerConfig
@@ -30,6 +37,10 @@ type Syslog struct {
 	KeepAlivePeriod *internal.Duration
 	ReadTimeout     *internal.Duration
 	MaxConnections  int
+	SocketMode      string
+	SyslogStandard  string          `toml:"syslog_standard"`
+	Framing         framing.Framing `toml:"framing"`
+	Trailer         framing.Trailer `toml:"trailer"`
 	BestEffort      bool
 	Separator       string `toml:"sdparam_separator"`
 
@@ -54,8 +65,13 @@ var sampleConfig = `
   ## Protocol, address and port to host the syslog receiver.
   ## If no host is specified, then localhost is used.
   ## If no port is specified, 6514 is used (RFC5425#section-4.1).
+  ## Unix sockets are specified by path - eg., unix:///var/run/telegraf-syslog.sock,
+  ## unixgram:///var/run/telegraf-syslog.sock
   server = "tcp://:6514"
 
+  ## Permission for unix sockets (only available for unix sockets).
+  # socket_mode = "0666"
+
   ## TLS Config
   # tls_allowed_cacerts = ["/etc/telegraf/ca.pem"]
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -76,6 +92,21 @@ var sampleConfig = `
   ## 0 means unlimited.
   # read_timeout = "5s"
 
+  ## Format of the syslog messages, either "RFC5424" or "RFC3164" (BSD syslog).
+  # syslog_standard = "RFC5424"
+
+  ## The framing technique with which it is expected that messages are
+  ## transported (default = "octet-counting").  Messages are either prefixed by
+  ## their length (RFC5425#section-4.3), or terminated by a trailer character
+  ## (RFC6587#section-3.4.2), as sent by default by rsyslog and syslog-ng.
+  ## Must be one of "octet-counting", "non-transparent".
+  ## Only applies to stream sockets (e.g. TCP).
+  # framing = "octet-counting"
+
+  ## The trailer to be expected in case of non-transparent framing (default = "LF").
+  ## Must be one of "LF", or "NUL".
+  # trailer = "LF"
+
   ## Whether to parse in best effort mode or not (default = false).
   ## By default best effort parsing is off.
   # best_effort = false
@@ -95,7 +126,7 @@ func (s *Syslog) SampleConfig() string {
 
 // Description returns the plugin description
 func (s *Syslog) Description() string {
-	return "Accepts syslog messages per RFC5425"
+	return "Accepts syslog messages following RFC5424 or RFC3164 formats"
 }
 
 // Gather ...
@@ -123,6 +154,14 @@ func (s *Syslog) Start(acc telegraf.Accumulator) error {
 		return fmt.Errorf("unknown protocol '%s' in '%s'", scheme, s.Address)
 	}
 
+	switch s.SyslogStandard {
+	case "":
+		s.SyslogStandard = syslogRFC5424
+	case syslogRFC5424, syslogRFC3164:
+	default:
+		return fmt.Errorf("unknown syslog standard '%s'", s.SyslogStandard)
+	}
+
 	if scheme == "unix" || scheme == "unixpacket" || scheme == "unixgram" {
 		os.Remove(s.Address)
 	}
@@ -155,6 +194,18 @@ func (s *Syslog) Start(acc telegraf.Accumulator) error {
 
 	if scheme == "unix" || scheme == "unixpacket" || scheme == "unixgram" {
 		s.Closer = unixCloser{path: s.Address, closer: s.Closer}
+
+		if s.SocketMode != "" {
+			mode, err := strconv.ParseUint(s.SocketMode, 8, 32)
+			if err == nil {
+				err = os.Chmod(s.Address, os.FileMode(mode))
+			}
+			if err != nil {
+				s.Close()
+				s.wg.Wait()
+				return fmt.Errorf("unable to set socket mode '%s': %s", s.SocketMode, err)
+			}
+		}
 	}
 
 	return nil
@@ -203,7 +254,7 @@ func getAddressParts(a string) (string, string, error) {
 func (s *Syslog) listenPacket(acc telegraf.Accumulator) {
 	defer s.wg.Done()
 	b := make([]byte, ipMaxPacketSize)
-	p := rfc5424.NewParser()
+	parse := s.newParser()
 	for {
 		n, _, err := s.udpListener.ReadFrom(b)
 		if err != nil {
@@ -213,13 +264,8 @@ func (s *Syslog) listenPacket(acc telegraf.Accumulator) {
 			break
 		}
 
-		message, err := p.Parse(b[:n], &s.BestEffort)
-		if message != nil {
-			acc.AddFields("syslog", fields(*message, s), tags(*message), s.time())
-		}
-		if err != nil {
-			acc.AddError(err)
-		}
+		msg, err := parse(b[:n])
+		s.store(msg, err, acc)
 	}
 }
 
@@ -276,24 +322,114 @@ func (s *Syslog) handle(conn net.Conn, acc telegraf.Accumulator) {
 		conn.Close()
 	}()
 
-	var p *rfc5425.Parser
+	parse := s.newParser()
+	r := bufio.NewReaderSize(conn, ipMaxPacketSize)
 
-	if s.BestEffort {
-		p = rfc5425.NewParser(conn, rfc5425.WithBestEffort())
-	} else {
-		p = rfc5425.NewParser(conn)
+	for {
+		if s.ReadTimeout != nil && s.ReadTimeout.Duration > 0 {
+			conn.SetReadDeadline(time.Now().Add(s.ReadTimeout.Duration))
+		}
+
+		var frame []byte
+		var err error
+		if s.Framing == framing.OctetCounting {
+			frame, err = readOctetCounted(r)
+		} else {
+			frame, err = readNonTransparent(r, s.Trailer.Byte())
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			// Though the frame is incomplete, try to salvage its content
+			if s.BestEffort && len(frame) > 0 {
+				if msg, _ := parse(frame); msg != nil {
+					s.store(msg, nil, acc)
+				}
+			}
+			acc.AddError(err)
+			return
+		}
+
+		msg, err := parse(frame)
+		s.store(msg, err, acc)
+		if err != nil && !s.BestEffort {
+			return
+		}
 	}
+}
 
-	if s.ReadTimeout != nil && s.ReadTimeout.Duration > 0 {
-		conn.SetReadDeadline(time.Now().Add(s.ReadTimeout.Duration))
+// readOctetCounted reads the next frame of a stream using octet-counting
+// framing (RFC5425#section-4.3): the message length, a space, and the message.
+// io.EOF is returned when the stream ends between two frames.
+func readOctetCounted(r *bufio.Reader) ([]byte, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return nil, io.EOF
 	}
 
-	p.ParseExecuting(func(r *rfc5425.Result) {
-		s.store(*r, acc)
-		if s.ReadTimeout != nil && s.ReadTimeout.Duration > 0 {
-			conn.SetReadDeadline(time.Now().Add(s.ReadTimeout.Duration))
+	// MSGLEN = NONZERO-DIGIT 0*DIGIT
+	if c < '1' || c > '9' {
+		return nil, fmt.Errorf("found %q, expecting a message length", c)
+	}
+	msglen := int(c - '0')
+	for {
+		c, err = r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("unexpected end of stream, expecting a message length")
 		}
-	})
+		if c < '0' || c > '9' {
+			break
+		}
+		msglen = msglen*10 + int(c-'0')
+		if msglen > ipMaxPacketSize {
+			return nil, fmt.Errorf("message length exceeds %d octets", ipMaxPacketSize)
+		}
+	}
+	if c != ' ' {
+		return nil, fmt.Errorf("found %q after message length, expecting a space", c)
+	}
+
+	frame := make([]byte, msglen)
+	n, err := io.ReadFull(r, frame)
+	if err != nil {
+		return frame[:n], fmt.Errorf("found %d octets, expecting a message containing %d octets", n, msglen)
+	}
+	return frame, nil
+}
+
+// readNonTransparent reads the next frame of a stream using non-transparent
+// framing (RFC6587#section-3.4.2), where each message ends with the trailer.
+// A message at the end of the stream lacking the trailer is accepted as well,
+// but not one interrupted by another error such as a read timeout.
+// io.EOF is returned when the stream ends between two frames.
+func readNonTransparent(r *bufio.Reader, trailer byte) ([]byte, error) {
+	frame, err := r.ReadBytes(trailer)
+	if err != nil {
+		if len(frame) == 0 {
+			return nil, io.EOF
+		}
+		if err != io.EOF {
+			return frame, fmt.Errorf("found %d octets, expecting a message ending with the trailer: %s", len(frame), err)
+		}
+		return frame, nil
+	}
+	return frame[:len(frame)-1], nil
+}
+
+// newParser returns a function parsing a single message according to the
+// configured syslog standard.  It must not be used concurrently.
+func (s *Syslog) newParser() func([]byte) (*rfc5424.SyslogMessage, error) {
+	if s.SyslogStandard == syslogRFC3164 {
+		return func(b []byte) (*rfc5424.SyslogMessage, error) {
+			return parseRFC3164(b, s.BestEffort, s.now())
+		}
+	}
+
+	p := rfc5424.NewParser()
+	return func(b []byte) (*rfc5424.SyslogMessage, error) {
+		return p.Parse(b, &s.BestEffort)
+	}
 }
 
 func (s *Syslog) setKeepAlive(c *net.TCPConn) error {
@@ -310,16 +446,12 @@ func (s *Syslog) setKeepAlive(c *net.TCPConn) error {
 	return c.SetKeepAlivePeriod(s.KeepAlivePeriod.Duration)
 }
 
-func (s *Syslog) store(res rfc5425.Result, acc telegraf.Accumulator) {
-	if res.Error != nil {
-		acc.AddError(res.Error)
-	}
-	if res.MessageError != nil {
-		acc.AddError(res.MessageError)
+func (s *Syslog) store(msg *rfc5424.SyslogMessage, err error, acc telegraf.Accumulator) {
+	if msg != nil {
+		acc.AddFields("syslog", fields(*msg, s), tags(*msg), s.time())
 	}
-	if res.Message != nil {
-		msg := *res.Message
-		acc.AddFields("syslog", fields(msg, s), tags(msg), s.time())
+	if err != nil {
+		acc.AddError(err)
 	}
 }
 
@@ -343,8 +475,10 @@ func tags(msg rfc5424.SyslogMessage) map[string]string {
 
 func fields(msg rfc5424.SyslogMessage, s *Syslog) map[string]interface{} {
 	// Not checking assuming a minimally valid message
-	flds := map[string]interface{}{
-		"version": msg.Version(),
+	flds := map[string]interface{}{}
+	// RFC3164 messages have no version
+	if msg.Version() != 0 {
+		flds["version"] = msg.Version()
 	}
 	flds["severity_code"] = int(*msg.Severity())
 	flds["facility_code"] = int(*msg.Facility())
@@ -415,7 +549,10 @@ func init() {
 		ReadTimeout: &internal.Duration{
 			Duration: defaultReadTimeout,
 		},
-		Separator: "_",
+		Separator:      "_",
+		SyslogStandard: syslogRFC5424,
+		Framing:        framing.OctetCounting,
+		Trailer:        framing.LF,
 	}
 
 	inputs.Add("syslog", func() telegraf.Input { return receiver })
@@ -66,3 +66,31 @@ func TestAddress(t *testing.T) {
 	require.Equal(t, "localhost:6514", rec.Address)
 	rec.Stop()
 }
+
+func TestSocketMode(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+	sock := filepath.Join(tmpdir, "syslog.TestSocketMode.sock")
+
+	rec := &Syslog{
+		Address:    "unix://" + sock,
+		SocketMode: "0600",
+	}
+	err = rec.Start(&testutil.Accumulator{})
+	require.NoError(t, err)
+	defer rec.Stop()
+
+	info, err := os.Stat(sock)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestSyslogStandard(t *testing.T) {
+	rec := &Syslog{
+		Address:        "tcp://localhost",
+		SyslogStandard: "RFC1234",
+	}
+	err := rec.Start(&testutil.Accumulator{})
+	require.EqualError(t, err, "unknown syslog standard 'RFC1234'")
+}
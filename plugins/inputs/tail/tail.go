@@ -5,13 +5,17 @@ package tail
 import (
 	"fmt"
 	"log"
+	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/influxdata/tail"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/internal/multiline"
+	"github.com/influxdata/telegraf/internal/tailstate"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 )
@@ -25,12 +29,20 @@ type Tail struct {
 	FromBeginning bool
 	Pipe          bool
 	WatchMethod   string
+	StateFile     string
+	Multiline     *multiline.Config
 
 	tailers    map[string]*tail.Tail
+	state      *tailstate.State
 	parserFunc parsers.ParserFunc
 	wg         sync.WaitGroup
 	acc        telegraf.Accumulator
 
+	// offsets holds the position following the last record emitted from
+	// each file, it is updated by the receivers
+	offsets   map[string]int64
+	offsetsMu sync.Mutex
+
 	sync.Mutex
 }
 
@@ -59,11 +71,38 @@ const sampleConfig = `
   ## Method used to watch for file updates.  Can be either "inotify" or "poll".
   # watch_method = "inotify"
 
+  ## File used to save the position in each tailed file, allowing files to be
+  ## resumed after a restart.  Files that were truncated or rotated while
+  ## telegraf was not running are read from the beginning.  Each plugin
+  ## instance requires its own state file.
+  # state_file = ""
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
   data_format = "influx"
+
+  ## Group lines belonging to the same event, such as stack traces, into a
+  ## single record before parsing.
+  # [inputs.tail.multiline]
+    ## Regular expression lines are matched against.
+    # pattern = '^\s'
+
+    ## Either "previous" or "next".  A matching line is joined to the lines
+    ## before it with "previous", or to the lines after it with "next".
+    # match_which_line = "previous"
+
+    ## Apply match_which_line to lines not matching the pattern instead, for
+    ## example to join all lines to the last one matching a start pattern.
+    # invert_match = false
+
+    ## Maximum number of lines in a record, 0 is unlimited.
+    # max_lines = 0
+
+    ## Time after which a pending record is parsed even if no new line
+    ## completed it.
+    # timeout = "5s"
 `
 
 func (t *Tail) SampleConfig() string {
@@ -78,6 +117,8 @@ func (t *Tail) Gather(acc telegraf.Accumulator) error {
 	t.Lock()
 	defer t.Unlock()
 
+	t.saveState()
+
 	return t.tailNewFiles(true)
 }
 
@@ -85,8 +126,23 @@ func (t *Tail) Start(acc telegraf.Accumulator) error {
 	t.Lock()
 	defer t.Unlock()
 
+	if t.Multiline != nil {
+		if _, err := t.Multiline.NewMultiline(); err != nil {
+			return err
+		}
+	}
+
+	if t.StateFile != "" && !t.Pipe {
+		state, err := tailstate.Load(t.StateFile)
+		if err != nil {
+			return fmt.Errorf("error loading state file %s: %s", t.StateFile, err)
+		}
+		t.state = state
+	}
+
 	t.acc = acc
 	t.tailers = make(map[string]*tail.Tail)
+	t.offsets = make(map[string]int64)
 
 	return t.tailNewFiles(t.FromBeginning)
 }
@@ -117,15 +173,41 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 				continue
 			}
 
+			location := seek
+			var offset int64
+			if location != nil {
+				// seek to the current size so that the position of the
+				// lines read is known
+				if stat, err := os.Stat(file); err == nil {
+					offset = stat.Size()
+					location = &tail.SeekInfo{
+						Whence: 0,
+						Offset: offset,
+					}
+				}
+			}
+			if t.state != nil {
+				resume, status := t.state.Resume(file)
+				if status != tailstate.NotFound {
+					log.Printf("D! [inputs.tail] resuming file %s at offset %d, file %s", file, resume, status)
+					offset = resume
+					location = &tail.SeekInfo{
+						Whence: 0,
+						Offset: offset,
+					}
+				}
+			}
+
+			logger := tailstate.NewReopenLogger()
 			tailer, err := tail.TailFile(file,
 				tail.Config{
 					ReOpen:    true,
 					Follow:    true,
-					Location:  seek,
+					Location:  location,
 					MustExist: true,
 					Poll:      poll,
 					Pipe:      t.Pipe,
-					Logger:    tail.DiscardingLogger,
+					Logger:    logger,
 				})
 			if err != nil {
 				t.acc.AddError(err)
@@ -141,7 +223,7 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 
 			// create a goroutine for each "tailer"
 			t.wg.Add(1)
-			go t.receiver(parser, tailer)
+			go t.receiver(parser, tailer, offset, logger.Reopened)
 			t.tailers[tailer.Filename] = tailer
 		}
 	}
@@ -149,48 +231,96 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 }
 
 // this is launched as a goroutine to continuously watch a tailed logfile
-// for changes, parse any incoming msgs, and add to the accumulator.
-func (t *Tail) receiver(parser parsers.Parser, tailer *tail.Tail) {
+// for changes, parse any incoming msgs, and add to the accumulator.  offset is
+// the position in the file of the first line read, it is reset when reopened
+// receives a value.
+func (t *Tail) receiver(parser parsers.Parser, tailer *tail.Tail, offset int64, reopened <-chan struct{}) {
 	defer t.wg.Done()
 
+	var mline *multiline.Multiline
+	var timer *time.Timer
+	var timeout <-chan time.Time
+	if t.Multiline != nil {
+		// the config was validated on start
+		mline, _ = t.Multiline.NewMultiline()
+		timer = time.NewTimer(mline.Timeout())
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
 	var firstLine = true
-	var metrics []telegraf.Metric
-	var m telegraf.Metric
-	var err error
-	var line *tail.Line
-	for line = range tailer.Lines {
+	for {
+		var line *tail.Line
+		var ok bool
+		select {
+		case line, ok = <-tailer.Lines:
+		case <-timeout:
+			if text, ok := mline.Flush(); ok {
+				t.parse(parser, tailer, text, firstLine)
+				t.setOffset(tailer.Filename, offset)
+				firstLine = false
+			}
+			continue
+		case <-reopened:
+			// The file was rotated or truncated, the pending record belongs
+			// to the previous file and the next lines start at the beginning.
+			if mline != nil {
+				if text, ok := mline.Flush(); ok {
+					t.parse(parser, tailer, text, firstLine)
+					firstLine = false
+				}
+			}
+			offset = 0
+			t.setOffset(tailer.Filename, offset)
+			continue
+		}
+		if !ok {
+			break
+		}
+
 		if line.Err != nil {
 			t.acc.AddError(fmt.Errorf("E! Error tailing file %s, Error: %s\n",
-				tailer.Filename, err))
+				tailer.Filename, line.Err))
 			continue
 		}
+
+		// The line ending is stripped by the tailer.
+		start := offset
+		offset += int64(len(line.Text)) + 1
+		emitted := offset
+
 		// Fix up files with Windows line endings.
 		text := strings.TrimRight(line.Text, "\r")
 
-		if firstLine {
-			metrics, err = parser.Parse([]byte(text))
-			if err == nil {
-				if len(metrics) == 0 {
-					firstLine = false
-					continue
-				} else {
-					m = metrics[0]
+		if mline != nil {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
 				}
 			}
-			firstLine = false
-		} else {
-			m, err = parser.ParseLine(text)
-		}
+			timer.Reset(mline.Timeout())
 
-		if err == nil {
-			if m != nil {
-				tags := m.Tags()
-				tags["path"] = tailer.Filename
-				t.acc.AddFields(m.Name(), m.Fields(), tags, m.Time())
+			var complete bool
+			text, complete = mline.ProcessLine(text)
+			if !complete {
+				continue
+			}
+			// the line starts the next group
+			if mline.Pending() {
+				emitted = start
 			}
-		} else {
-			t.acc.AddError(fmt.Errorf("E! Malformed log line in %s: [%s], Error: %s\n",
-				tailer.Filename, line.Text, err))
+		}
+
+		t.parse(parser, tailer, text, firstLine)
+		t.setOffset(tailer.Filename, emitted)
+		firstLine = false
+	}
+
+	if mline != nil {
+		if text, ok := mline.Flush(); ok {
+			t.parse(parser, tailer, text, firstLine)
+			t.setOffset(tailer.Filename, offset)
 		}
 	}
 
@@ -202,6 +332,71 @@ func (t *Tail) receiver(parser parsers.Parser, tailer *tail.Tail) {
 	}
 }
 
+// parse parses the text and adds the resulting metric to the accumulator.
+// The first record of a file is parsed with Parse, allowing parsers to read a
+// header.
+func (t *Tail) parse(parser parsers.Parser, tailer *tail.Tail, text string, firstLine bool) {
+	var metrics []telegraf.Metric
+	var m telegraf.Metric
+	var err error
+
+	if firstLine {
+		metrics, err = parser.Parse([]byte(text))
+		if err == nil {
+			if len(metrics) == 0 {
+				return
+			}
+			m = metrics[0]
+		}
+	} else {
+		m, err = parser.ParseLine(text)
+	}
+
+	if err == nil {
+		if m != nil {
+			tags := m.Tags()
+			tags["path"] = tailer.Filename
+			t.acc.AddFields(m.Name(), m.Fields(), tags, m.Time())
+		}
+	} else {
+		t.acc.AddError(fmt.Errorf("E! Malformed log line in %s: [%s], Error: %s\n",
+			tailer.Filename, text, err))
+	}
+}
+
+// setOffset records the position following the last record emitted from the
+// file.
+func (t *Tail) setOffset(filename string, offset int64) {
+	t.offsetsMu.Lock()
+	t.offsets[filename] = offset
+	t.offsetsMu.Unlock()
+}
+
+// saveState records the position following the last record emitted from
+// each tailed file in the state file.
+func (t *Tail) saveState() {
+	if t.state == nil {
+		return
+	}
+
+	t.offsetsMu.Lock()
+	defer t.offsetsMu.Unlock()
+
+	for filename := range t.tailers {
+		offset, ok := t.offsets[filename]
+		if !ok {
+			continue
+		}
+		if err := t.state.Set(filename, offset); err != nil {
+			log.Printf("D! [inputs.tail] unable to record position of %s: %s", filename, err)
+		}
+	}
+
+	if err := t.state.Save(); err != nil {
+		t.acc.AddError(fmt.Errorf("E! Error saving state file %s: %s", t.StateFile, err))
+	}
+}
+
 func (t *Tail) Stop() {
 	t.Lock()
 	defer t.Unlock()
@@ -217,6 +412,10 @@ func (t *Tail) Stop() {
 		tailer.Cleanup()
 	}
 	t.wg.Wait()
+
+	// the receivers are done, the offsets include the records flushed on
+	// stop
+	t.saveState()
 }
 
 func (t *Tail) SetParserFunc(fn parsers.ParserFunc) {
@@ -3,9 +3,14 @@ package tail
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/multiline"
+	"github.com/influxdata/telegraf/internal/tailstate"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	"github.com/influxdata/telegraf/testutil"
 
@@ -139,3 +144,235 @@ func TestTailDosLineendings(t *testing.T) {
 			"usage_idle": float64(200),
 		})
 }
+
+func TestTailMultiline(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString("Exception in thread main\n  at Foo(Foo.java:1)\n  at Bar(Bar.java:2)\nnext event\n")
+	require.NoError(t, err)
+
+	tt := NewTail()
+	tt.FromBeginning = true
+	tt.Files = []string{tmpfile.Name()}
+	tt.Multiline = &multiline.Config{
+		Pattern: `^\s`,
+		Timeout: &internal.Duration{Duration: 100 * time.Millisecond},
+	}
+	tt.SetParserFunc(func() (parsers.Parser, error) {
+		return parsers.NewValueParser("log", "string", nil)
+	})
+	defer tt.Stop()
+	defer tmpfile.Close()
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	require.NoError(t, acc.GatherError(tt.Gather))
+
+	acc.Wait(2)
+	tags := map[string]string{"path": tmpfile.Name()}
+	assert.True(t, acc.HasPoint("log", tags, "value",
+		"Exception in thread main\n  at Foo(Foo.java:1)\n  at Bar(Bar.java:2)"))
+	assert.True(t, acc.HasPoint("log", tags, "value", "next event"))
+}
+
+func TestTailMultilineInvalidPattern(t *testing.T) {
+	tt := NewTail()
+	tt.Multiline = &multiline.Config{Pattern: "("}
+
+	acc := testutil.Accumulator{}
+	require.Error(t, tt.Start(&acc))
+}
+
+func TestTailStateFile(t *testing.T) {
+	for _, method := range []string{"inotify", "poll"} {
+		t.Run(method, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			logPath := filepath.Join(dir, "test.log")
+			statePath := filepath.Join(dir, "state")
+			require.NoError(t, ioutil.WriteFile(logPath, []byte("cpu usage_idle=1\n"), 0644))
+
+			tt := NewTail()
+			tt.FromBeginning = true
+			tt.WatchMethod = method
+			tt.StateFile = statePath
+			tt.Files = []string{logPath}
+			tt.SetParserFunc(parsers.NewInfluxParser)
+
+			acc := testutil.Accumulator{}
+			require.NoError(t, tt.Start(&acc))
+			acc.Wait(1)
+			tt.Stop()
+
+			f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+			require.NoError(t, err)
+			_, err = f.WriteString("cpu usage_idle=2\n")
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			tt = NewTail()
+			tt.FromBeginning = true
+			tt.WatchMethod = method
+			tt.StateFile = statePath
+			tt.Files = []string{logPath}
+			tt.SetParserFunc(parsers.NewInfluxParser)
+
+			acc = testutil.Accumulator{}
+			require.NoError(t, tt.Start(&acc))
+			acc.Wait(1)
+			tt.Stop()
+
+			require.Len(t, acc.Metrics, 1)
+			acc.AssertContainsFields(t, "cpu",
+				map[string]interface{}{
+					"usage_idle": float64(2),
+				})
+		})
+	}
+}
+
+func TestTailStateFileMultiline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "test.log")
+	statePath := filepath.Join(dir, "state")
+	require.NoError(t, ioutil.WriteFile(logPath, []byte("event 1\n  detail\nevent 2\n"), 0644))
+
+	tt := NewTail()
+	tt.FromBeginning = true
+	tt.StateFile = statePath
+	tt.Files = []string{logPath}
+	tt.Multiline = &multiline.Config{
+		Pattern: `^\s`,
+		Timeout: &internal.Duration{Duration: time.Hour},
+	}
+	tt.SetParserFunc(func() (parsers.Parser, error) {
+		return parsers.NewValueParser("log", "string", nil)
+	})
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	defer tt.Stop()
+	acc.Wait(1)
+
+	// The last line was read but is still pending, the saved position is
+	// the start of that line.
+	var offset int64
+	for i := 0; i < 100 && offset == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+		tt.offsetsMu.Lock()
+		offset = tt.offsets[logPath]
+		tt.offsetsMu.Unlock()
+	}
+	require.Equal(t, int64(len("event 1\n  detail\n")), offset)
+
+	require.NoError(t, tt.Gather(&acc))
+	state, err := tailstate.Load(statePath)
+	require.NoError(t, err)
+	resume, status := state.Resume(logPath)
+	require.Equal(t, tailstate.Unchanged, status)
+	require.Equal(t, offset, resume)
+}
+
+func TestTailStateFileTruncated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "test.log")
+	statePath := filepath.Join(dir, "state")
+	require.NoError(t, ioutil.WriteFile(logPath, []byte("cpu usage_idle=1\ncpu usage_idle=2\n"), 0644))
+
+	tt := NewTail()
+	tt.FromBeginning = true
+	tt.StateFile = statePath
+	tt.Files = []string{logPath}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(2)
+	tt.Stop()
+
+	require.NoError(t, ioutil.WriteFile(logPath, []byte("cpu usage_idle=3\n"), 0644))
+
+	tt = NewTail()
+	tt.StateFile = statePath
+	tt.Files = []string{logPath}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+
+	acc = testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(1)
+	tt.Stop()
+
+	acc.AssertContainsFields(t, "cpu",
+		map[string]interface{}{
+			"usage_idle": float64(3),
+		})
+}
+
+func TestTailStateFileRotated(t *testing.T) {
+	for _, method := range []string{"inotify", "poll"} {
+		t.Run(method, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			logPath := filepath.Join(dir, "test.log")
+			statePath := filepath.Join(dir, "state")
+			require.NoError(t, ioutil.WriteFile(logPath, []byte("cpu usage_idle=1\ncpu usage_idle=2\n"), 0644))
+
+			tt := NewTail()
+			tt.FromBeginning = true
+			tt.WatchMethod = method
+			tt.StateFile = statePath
+			tt.Files = []string{logPath}
+			tt.SetParserFunc(parsers.NewInfluxParser)
+
+			acc := testutil.Accumulator{}
+			require.NoError(t, tt.Start(&acc))
+			acc.Wait(2)
+
+			// The lines of the new file are counted from its beginning.
+			require.NoError(t, os.Rename(logPath, logPath+".1"))
+			require.NoError(t, ioutil.WriteFile(logPath, []byte("cpu usage_idle=3\n"), 0644))
+			acc.Wait(3)
+			tt.Stop()
+
+			state, err := tailstate.Load(statePath)
+			require.NoError(t, err)
+			offset, status := state.Resume(logPath)
+			require.Equal(t, tailstate.Unchanged, status)
+			require.Equal(t, int64(len("cpu usage_idle=3\n")), offset)
+
+			f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+			require.NoError(t, err)
+			_, err = f.WriteString("cpu usage_idle=4\n")
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			tt = NewTail()
+			tt.WatchMethod = method
+			tt.StateFile = statePath
+			tt.Files = []string{logPath}
+			tt.SetParserFunc(parsers.NewInfluxParser)
+
+			acc = testutil.Accumulator{}
+			require.NoError(t, tt.Start(&acc))
+			acc.Wait(1)
+			tt.Stop()
+
+			require.Len(t, acc.Metrics, 1)
+			acc.AssertContainsFields(t, "cpu",
+				map[string]interface{}{
+					"usage_idle": float64(4),
+				})
+		})
+	}
+}
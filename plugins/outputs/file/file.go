@@ -4,23 +4,47 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/csv"
 )
 
+// measurementPlaceholder is replaced in file names by the metric name.
+const measurementPlaceholder = "{measurement}"
+
+// layoutSerializer is implemented by serializers grouping the metrics into
+// layouts with their own header, such as csv.  The header is returned with
+// each row so that it is written once to each file.
+type layoutSerializer interface {
+	SetLayout(layout string) error
+	SerializeRow(metric telegraf.Metric) ([]byte, []byte, error)
+}
+
 type File struct {
 	Files []string
 
 	writers []io.Writer
 	closers []io.Closer
 
+	// files named after the measurement are opened when first written to
+	measurementFiles   []string
+	measurementWriters map[string]io.Writer
+
+	// headers written to each writer, files which were not empty when opened
+	// are assumed to have their headers already
+	headers  map[io.Writer]map[string]bool
+	appended map[io.Writer]bool
+
 	serializer serializers.Serializer
 }
 
 var sampleConfig = `
-  ## Files to write to, "stdout" is a specially handled file.
+  ## Files to write to, "stdout" is a specially handled file.  The string
+  ## "{measurement}" in a file name is replaced by the metric name, writing
+  ## each measurement to its own file.
   files = ["stdout", "/tmp/metrics.out"]
 
   ## Data format to output.
@@ -39,28 +63,77 @@ func (f *File) Connect() error {
 		f.Files = []string{"stdout"}
 	}
 
+	f.measurementWriters = make(map[string]io.Writer)
+	f.headers = make(map[io.Writer]map[string]bool)
+	f.appended = make(map[io.Writer]bool)
+
 	for _, file := range f.Files {
 		if file == "stdout" {
 			f.writers = append(f.writers, os.Stdout)
+		} else if strings.Contains(file, measurementPlaceholder) {
+			f.measurementFiles = append(f.measurementFiles, file)
 		} else {
-			var of *os.File
-			var err error
-			if _, err := os.Stat(file); os.IsNotExist(err) {
-				of, err = os.Create(file)
-			} else {
-				of, err = os.OpenFile(file, os.O_APPEND|os.O_WRONLY, os.ModeAppend)
-			}
-
+			of, appended, err := openFile(file)
 			if err != nil {
 				return err
 			}
 			f.writers = append(f.writers, of)
 			f.closers = append(f.closers, of)
+			f.appended[of] = appended
+		}
+	}
+
+	// Each measurement file needs the header of its measurement.
+	if len(f.measurementFiles) > 0 {
+		if ls, ok := f.serializer.(layoutSerializer); ok {
+			if err := ls.SetLayout(csv.LayoutMeasurement); err != nil {
+				return fmt.Errorf("files named after the measurement: %s", err)
+			}
 		}
 	}
 	return nil
 }
 
+// openFile opens the file for appending and reports if it was not empty.
+func openFile(file string) (*os.File, bool, error) {
+	info, err := os.Stat(file)
+	if os.IsNotExist(err) {
+		of, err := os.Create(file)
+		return of, false, err
+	}
+	of, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, os.ModeAppend)
+	return of, info != nil && info.Size() > 0, err
+}
+
+// metricWriters returns the writers the metric should be written to.  When a
+// file can not be opened, the other writers are returned with the error.
+func (f *File) metricWriters(metric telegraf.Metric) ([]io.Writer, error) {
+	if len(f.measurementFiles) == 0 {
+		return f.writers, nil
+	}
+
+	name := strings.Replace(metric.Name(), string(os.PathSeparator), "_", -1)
+	writers := append([]io.Writer(nil), f.writers...)
+	var openErr error
+	for _, pattern := range f.measurementFiles {
+		file := strings.Replace(pattern, measurementPlaceholder, name, -1)
+		w, ok := f.measurementWriters[file]
+		if !ok {
+			of, appended, err := openFile(file)
+			if err != nil {
+				openErr = err
+				continue
+			}
+			f.measurementWriters[file] = of
+			f.closers = append(f.closers, of)
+			f.appended[of] = appended
+			w = of
+		}
+		writers = append(writers, w)
+	}
+	return writers, openErr
+}
+
 func (f *File) Close() error {
 	var errS string
 	for _, c := range f.closers {
@@ -85,21 +158,60 @@ func (f *File) Description() string {
 func (f *File) Write(metrics []telegraf.Metric) error {
 	var writeErr error = nil
 	for _, metric := range metrics {
-		b, err := f.serializer.Serialize(metric)
+		header, b, err := f.serialize(metric)
 		if err != nil {
 			return fmt.Errorf("failed to serialize message: %s", err)
 		}
 
-		for _, writer := range f.writers {
-			_, err = writer.Write(b)
+		// The metric is still written to the files which could be opened,
+		// like when a write fails.
+		writers, err := f.metricWriters(metric)
+		if err != nil {
+			writeErr = fmt.Errorf("E! failed to open file: %s", err)
+		}
+
+		for _, writer := range writers {
+			data := b
+			withHeader := header != nil && f.needsHeader(writer, header)
+			if withHeader {
+				data = append(append([]byte(nil), header...), b...)
+			}
+			_, err = writer.Write(data)
 			if err != nil && writer != os.Stdout {
 				writeErr = fmt.Errorf("E! failed to write message: %s, %s", b, err)
+				continue
+			}
+			if withHeader {
+				f.headers[writer][string(header)] = true
 			}
 		}
 	}
 	return writeErr
 }
 
+// serialize returns the metric and, for layout serializers, the header to
+// write before it to the files which do not have it yet.
+func (f *File) serialize(metric telegraf.Metric) ([]byte, []byte, error) {
+	if ls, ok := f.serializer.(layoutSerializer); ok {
+		return ls.SerializeRow(metric)
+	}
+	b, err := f.serializer.Serialize(metric)
+	return nil, b, err
+}
+
+// needsHeader reports if the header has to be written to the writer.
+func (f *File) needsHeader(writer io.Writer, header []byte) bool {
+	if f.appended[writer] {
+		return false
+	}
+	written, ok := f.headers[writer]
+	if !ok {
+		written = make(map[string]bool)
+		f.headers[writer] = written
+	}
+	return !written[string(header)]
+}
+
 func init() {
 	outputs.Add("file", func() telegraf.Output {
 		return &File{}
@@ -5,12 +5,17 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/csv"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -142,6 +147,155 @@ func TestFileBoth(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestFileMeasurement(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files:      []string{filepath.Join(dir, "{measurement}.out")},
+		serializer: s,
+	}
+
+	err = f.Connect()
+	assert.NoError(t, err)
+
+	err = f.Write(testutil.MockMetrics())
+	assert.NoError(t, err)
+
+	validateFile(filepath.Join(dir, "test1.out"), expNewFile, t)
+
+	err = f.Close()
+	assert.NoError(t, err)
+}
+
+func TestFileMeasurementCSVHeader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := csv.NewSerializer(true, "", nil, "", "")
+	assert.NoError(t, err)
+	f := File{
+		Files:      []string{filepath.Join(dir, "{measurement}.csv")},
+		serializer: s,
+	}
+	assert.NoError(t, f.Connect())
+
+	ts := time.Unix(1525478795, 0)
+	cpu, _ := metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": 1.5}, ts)
+	mem, _ := metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"free": int64(2)}, ts)
+	assert.NoError(t, f.Write([]telegraf.Metric{cpu, mem, cpu}))
+	assert.NoError(t, f.Close())
+
+	validateFile(filepath.Join(dir, "cpu.csv"),
+		"timestamp,measurement,host,idle\n"+
+			"1525478795,cpu,a,1.5\n"+
+			"1525478795,cpu,a,1.5\n", t)
+	validateFile(filepath.Join(dir, "mem.csv"),
+		"timestamp,measurement,host,free\n"+
+			"1525478795,mem,a,2\n", t)
+}
+
+func TestFileMeasurementCSVLayoutConflict(t *testing.T) {
+	s, err := csv.NewSerializer(true, "", nil, "", csv.LayoutUnion)
+	assert.NoError(t, err)
+	f := File{
+		Files:      []string{"{measurement}.csv"},
+		serializer: s,
+	}
+	assert.Error(t, f.Connect())
+}
+
+func TestFileMeasurementCSVHeaderLateOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := csv.NewSerializer(true, "", nil, "", "")
+	assert.NoError(t, err)
+	f := File{
+		Files: []string{
+			filepath.Join(dir, "{measurement}.csv"),
+			filepath.Join(dir, "late", "{measurement}.csv"),
+		},
+		serializer: s,
+	}
+	assert.NoError(t, f.Connect())
+
+	ts := time.Unix(1525478795, 0)
+	cpu, _ := metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": 1.5}, ts)
+	assert.Error(t, f.Write([]telegraf.Metric{cpu}))
+
+	// The file opened on the second write gets the header too.
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "late"), 0755))
+	assert.NoError(t, f.Write([]telegraf.Metric{cpu}))
+	assert.NoError(t, f.Close())
+
+	validateFile(filepath.Join(dir, "cpu.csv"),
+		"timestamp,measurement,host,idle\n"+
+			"1525478795,cpu,a,1.5\n"+
+			"1525478795,cpu,a,1.5\n", t)
+	validateFile(filepath.Join(dir, "late", "cpu.csv"),
+		"timestamp,measurement,host,idle\n"+
+			"1525478795,cpu,a,1.5\n", t)
+}
+
+func TestFileCSVHeaderRestart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ts := time.Unix(1525478795, 0)
+	cpu, _ := metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": 1.5}, ts)
+
+	// The files are appended to on restart without repeating the header.
+	for i := 0; i < 2; i++ {
+		s, err := csv.NewSerializer(true, "", nil, "", "")
+		assert.NoError(t, err)
+		f := File{
+			Files: []string{
+				filepath.Join(dir, "metrics.csv"),
+				filepath.Join(dir, "{measurement}.csv"),
+			},
+			serializer: s,
+		}
+		assert.NoError(t, f.Connect())
+		assert.NoError(t, f.Write([]telegraf.Metric{cpu}))
+		assert.NoError(t, f.Close())
+	}
+
+	expected := "timestamp,measurement,host,idle\n" +
+		"1525478795,cpu,a,1.5\n" +
+		"1525478795,cpu,a,1.5\n"
+	validateFile(filepath.Join(dir, "metrics.csv"), expected, t)
+	validateFile(filepath.Join(dir, "cpu.csv"), expected, t)
+}
+
+func TestFileMeasurementOpenError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files: []string{
+			filepath.Join(dir, "{measurement}.out"),
+			filepath.Join(dir, "missing", "{measurement}.out"),
+		},
+		serializer: s,
+	}
+	assert.NoError(t, f.Connect())
+
+	// The metric is written to the file which can be opened.
+	err = f.Write(testutil.MockMetrics())
+	assert.Error(t, err)
+	assert.NoError(t, f.Close())
+
+	validateFile(filepath.Join(dir, "test1.out"), expNewFile, t)
+}
+
 func TestFileStdout(t *testing.T) {
 	// keep backup of the real stdout
 	old := os.Stdout
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +18,7 @@ import (
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/template"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 )
@@ -23,6 +27,11 @@ var sampleConfig = `
   ## URL is the address to send metrics to
   url = "http://127.0.0.1:8080/metric"
 
+  ## The url, method and header values can be Go templates rendered with
+  ## each metric, the metrics are then sent in a request per distinct
+  ## rendering.  The functions of the template data format are available.
+  # url = 'http://127.0.0.1:8080/{{ .Tag "tenant" }}/metric'
+
   ## Timeout for HTTP message
   # timeout = "5s"
 
@@ -60,12 +69,32 @@ var sampleConfig = `
   ## HTTP Content-Encoding for write request body, can be set to "gzip" to
   ## compress body or "identity" to apply no encoding.
   # content_encoding = "identity"
+
+  ## Number of times a request is retried when it fails with a retryable
+  ## error, a connection failure or a 429 or 5xx status, before the metrics
+  ## are kept for the next flush.  The delay doubles from retry_delay after
+  ## each retry, unless set by the Retry-After header of the response, and
+  ## is limited to retry_max_delay.
+  # max_retries = 0
+  # retry_delay = "1s"
+  # retry_max_delay = "30s"
+
+  ## Drop the metrics of requests rejected with a 4xx status other than 429
+  ## instead of keeping them for the next flush, where they would most likely
+  ## be rejected again.
+  # drop_rejected = false
+
+  ## Split the metrics of requests rejected with a 413 status in two halves
+  ## sent in separate requests, until the requests are small enough.
+  # split_too_large = false
 `
 
 const (
 	defaultClientTimeout = 5 * time.Second
 	defaultContentType   = "text/plain; charset=utf-8"
 	defaultMethod        = http.MethodPost
+	defaultRetryDelay    = time.Second
+	defaultRetryMaxDelay = 30 * time.Second
 )
 
 type HTTP struct {
@@ -82,8 +111,62 @@ type HTTP struct {
 	ContentEncoding string            `toml:"content_encoding"`
 	tls.ClientConfig
 
+	MaxRetries    int               `toml:"max_retries"`
+	RetryDelay    internal.Duration `toml:"retry_delay"`
+	RetryMaxDelay internal.Duration `toml:"retry_max_delay"`
+	DropRejected  bool              `toml:"drop_rejected"`
+	SplitTooLarge bool              `toml:"split_too_large"`
+
 	client     *http.Client
 	serializer serializers.Serializer
+
+	// Templates of the url, method and headers, nil when they are static.
+	urlTemplate     *template.Template
+	methodTemplate  *template.Template
+	headerTemplates map[string]*template.Template
+}
+
+// request holds the parameters of a request, rendered from the templates.
+type request struct {
+	url     string
+	method  string
+	headers map[string]string
+}
+
+// batch is the metrics sent in a request.
+type batch struct {
+	request request
+	metrics []telegraf.Metric
+}
+
+// statusError is the error of a request answered with a non-2xx status.
+type statusError struct {
+	url        string
+	statusCode int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("when writing to [%s] received status code: %d", e.url, e.statusCode)
+}
+
+// retryable returns true if the same request can succeed later.
+func retryable(err error) bool {
+	if e, ok := err.(*statusError); ok {
+		return e.statusCode == http.StatusTooManyRequests || e.statusCode >= 500
+	}
+	// Errors of the transport, such as connection failures and timeouts.
+	return true
+}
+
+// rejected returns true if the request was rejected by the server and would
+// be rejected again.
+func rejected(err error) bool {
+	if e, ok := err.(*statusError); ok {
+		return e.statusCode >= 400 && e.statusCode < 500 &&
+			e.statusCode != http.StatusTooManyRequests
+	}
+	return false
 }
 
 func (h *HTTP) SetSerializer(serializer serializers.Serializer) {
@@ -122,14 +205,48 @@ func (h *HTTP) Connect() error {
 	if h.Method == "" {
 		h.Method = http.MethodPost
 	}
-	h.Method = strings.ToUpper(h.Method)
-	if h.Method != http.MethodPost && h.Method != http.MethodPut {
-		return fmt.Errorf("invalid method [%s] %s", h.URL, h.Method)
+	if isTemplate(h.Method) {
+		tmpl, err := template.NewTemplate("method", h.Method)
+		if err != nil {
+			return err
+		}
+		h.methodTemplate = tmpl
+	} else {
+		h.Method = strings.ToUpper(h.Method)
+		if !validMethod(h.Method) {
+			return fmt.Errorf("invalid method [%s] %s", h.URL, h.Method)
+		}
+	}
+
+	if isTemplate(h.URL) {
+		tmpl, err := template.NewTemplate("url", h.URL)
+		if err != nil {
+			return err
+		}
+		h.urlTemplate = tmpl
+	}
+
+	h.headerTemplates = make(map[string]*template.Template)
+	for k, v := range h.Headers {
+		if !isTemplate(v) {
+			continue
+		}
+		tmpl, err := template.NewTemplate("header "+k, v)
+		if err != nil {
+			return err
+		}
+		h.headerTemplates[k] = tmpl
 	}
 
 	if h.Timeout.Duration == 0 {
 		h.Timeout.Duration = defaultClientTimeout
 	}
+	if h.RetryDelay.Duration == 0 {
+		h.RetryDelay.Duration = defaultRetryDelay
+	}
+	if h.RetryMaxDelay.Duration == 0 {
+		h.RetryMaxDelay.Duration = defaultRetryMaxDelay
+	}
 
 	ctx := context.Background()
 	client, err := h.createClient(ctx)
@@ -155,19 +272,179 @@ func (h *HTTP) SampleConfig() string {
 }
 
 func (h *HTTP) Write(metrics []telegraf.Metric) error {
+	return h.WriteContext(context.Background(), metrics)
+}
+
+// WriteContext sends the metrics in a request per distinct rendering of the
+// url, method and header templates.
+func (h *HTTP) WriteContext(ctx context.Context, metrics []telegraf.Metric) error {
+	// All the batches are attempted, when one fails the whole write is kept
+	// for the next flush and the batches which succeeded are sent again.
+	var errs []error
+	for _, b := range h.batches(metrics) {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		if err := h.writeBatch(ctx, b.request, b.metrics); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs)
+}
+
+// batches groups the metrics by request, in the order of their first
+// metric.
+func (h *HTTP) batches(metrics []telegraf.Metric) []*batch {
+	if h.urlTemplate == nil && h.methodTemplate == nil && len(h.headerTemplates) == 0 {
+		req := request{url: h.URL, method: h.Method, headers: h.Headers}
+		return []*batch{{request: req, metrics: metrics}}
+	}
+
+	var batches []*batch
+	index := make(map[string]*batch)
+	for _, m := range metrics {
+		req, err := h.request(m)
+		if err != nil {
+			log.Printf("E! [outputs.http] Dropping metric %q: %v", m.Name(), err)
+			continue
+		}
+
+		key := req.key()
+		b, ok := index[key]
+		if !ok {
+			b = &batch{request: req}
+			index[key] = b
+			batches = append(batches, b)
+		}
+		b.metrics = append(b.metrics, m)
+	}
+	return batches
+}
+
+// request renders the parameters of the request of the metric.
+func (h *HTTP) request(m telegraf.Metric) (request, error) {
+	req := request{
+		url:     h.URL,
+		method:  h.Method,
+		headers: make(map[string]string, len(h.Headers)),
+	}
+
+	var err error
+	if h.urlTemplate != nil {
+		req.url, err = h.urlTemplate.Execute(m)
+		if err != nil {
+			return req, err
+		}
+	}
+
+	if h.methodTemplate != nil {
+		req.method, err = h.methodTemplate.Execute(m)
+		if err != nil {
+			return req, err
+		}
+		req.method = strings.ToUpper(req.method)
+		if !validMethod(req.method) {
+			return req, fmt.Errorf("invalid method %q", req.method)
+		}
+	}
+
+	for k, v := range h.Headers {
+		if tmpl, ok := h.headerTemplates[k]; ok {
+			v, err = tmpl.Execute(m)
+			if err != nil {
+				return req, err
+			}
+		}
+		req.headers[k] = v
+	}
+	return req, nil
+}
+
+// key identifies the requests with the same parameters.
+func (r request) key() string {
+	keys := make([]string, 0, len(r.headers))
+	for k := range r.headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString(r.method)
+	buf.WriteByte(' ')
+	buf.WriteString(r.url)
+	for _, k := range keys {
+		buf.WriteByte('\n')
+		buf.WriteString(k)
+		buf.WriteByte(':')
+		buf.WriteString(r.headers[k])
+	}
+	return buf.String()
+}
+
+// writeBatch sends the metrics, retrying and splitting the request as
+// configured.
+func (h *HTTP) writeBatch(ctx context.Context, req request, metrics []telegraf.Metric) error {
 	reqBody, err := h.serializer.SerializeBatch(metrics)
 	if err != nil {
 		return err
 	}
 
-	if err := h.write(reqBody); err != nil {
-		return err
+	err = h.writeRetry(ctx, req, reqBody)
+	if err == nil {
+		return nil
 	}
 
-	return nil
+	if e, ok := err.(*statusError); ok && e.statusCode == http.StatusRequestEntityTooLarge &&
+		h.SplitTooLarge && len(metrics) > 1 {
+		log.Printf("D! [outputs.http] Request to [%s] too large, splitting %d metrics",
+			req.url, len(metrics))
+		half := len(metrics) / 2
+		var errs []error
+		if err := h.writeBatch(ctx, req, metrics[:half]); err != nil {
+			errs = append(errs, err)
+		}
+		if err := h.writeBatch(ctx, req, metrics[half:]); err != nil {
+			errs = append(errs, err)
+		}
+		return joinErrors(errs)
+	}
+
+	if h.DropRejected && rejected(err) {
+		log.Printf("E! [outputs.http] %v; dropping %d metrics", err, len(metrics))
+		return nil
+	}
+	return err
 }
 
-func (h *HTTP) write(reqBody []byte) error {
+// writeRetry sends the request, retrying it on retryable errors.
+func (h *HTTP) writeRetry(ctx context.Context, req request, reqBody []byte) error {
+	delay := h.RetryDelay.Duration
+	for retry := 0; ; retry++ {
+		err := h.write(ctx, req, reqBody)
+		if err == nil || !retryable(err) || retry >= h.MaxRetries {
+			return err
+		}
+
+		wait := delay
+		if e, ok := err.(*statusError); ok && e.retryAfter > 0 {
+			wait = e.retryAfter
+		}
+		if wait > h.RetryMaxDelay.Duration {
+			wait = h.RetryMaxDelay.Duration
+		}
+		log.Printf("D! [outputs.http] %v; retrying in %s", err, wait)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+func (h *HTTP) write(ctx context.Context, r request, reqBody []byte) error {
 	var reqBodyBuffer io.Reader = bytes.NewBuffer(reqBody)
 
 	var err error
@@ -178,10 +455,11 @@ func (h *HTTP) write(reqBody []byte) error {
 		}
 	}
 
-	req, err := http.NewRequest(h.Method, h.URL, reqBodyBuffer)
+	req, err := http.NewRequest(r.method, r.url, reqBodyBuffer)
 	if err != nil {
 		return err
 	}
+	req = req.WithContext(ctx)
 
 	if h.Username != "" || h.Password != "" {
 		req.SetBasicAuth(h.Username, h.Password)
@@ -192,7 +470,7 @@ func (h *HTTP) write(reqBody []byte) error {
 	if h.ContentEncoding == "gzip" {
 		req.Header.Set("Content-Encoding", "gzip")
 	}
-	for k, v := range h.Headers {
+	for k, v := range r.headers {
 		req.Header.Set(k, v)
 	}
 
@@ -204,17 +482,61 @@ func (h *HTTP) write(reqBody []byte) error {
 	_, err = ioutil.ReadAll(resp.Body)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("when writing to [%s] received status code: %d", h.URL, resp.StatusCode)
+		return &statusError{
+			url:        r.url,
+			statusCode: resp.StatusCode,
+			retryAfter: retryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
 
 	return nil
 }
 
+// retryAfter parses the Retry-After header, a number of seconds or a date.
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// joinErrors returns an error with the messages of errs, nil if empty.
+func joinErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Errorf("%d requests failed: %s", len(errs), strings.Join(msgs, "; "))
+}
+
+func isTemplate(s string) bool {
+	return strings.Contains(s, "{{")
+}
+
+func validMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut
+}
+
 func init() {
 	outputs.Add("http", func() telegraf.Output {
 		return &HTTP{
-			Timeout: internal.Duration{Duration: defaultClientTimeout},
-			Method:  defaultMethod,
+			Timeout:       internal.Duration{Duration: defaultClientTimeout},
+			Method:        defaultMethod,
+			RetryDelay:    internal.Duration{Duration: defaultRetryDelay},
+			RetryMaxDelay: internal.Duration{Duration: defaultRetryMaxDelay},
 		}
 	})
 }
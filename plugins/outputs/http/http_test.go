@@ -2,11 +2,14 @@ package http
 
 import (
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -453,3 +456,227 @@ func TestDefaultUserAgent(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func getTaggedMetrics(tenants ...string) []telegraf.Metric {
+	var metrics []telegraf.Metric
+	for i, tenant := range tenants {
+		m, err := metric.New(
+			"cpu",
+			map[string]string{"tenant": tenant},
+			map[string]interface{}{
+				"value": float64(i),
+			},
+			time.Unix(0, 0),
+		)
+		if err != nil {
+			panic(err)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics
+}
+
+func TestTemplates(t *testing.T) {
+	var mu sync.Mutex
+	bodies := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.MethodPut, r.Method)
+		require.Equal(t, "static", r.Header.Get("X-Static"))
+
+		mu.Lock()
+		defer mu.Unlock()
+		bodies[r.URL.Path+" "+r.Header.Get("X-Tenant")] += string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	plugin := &HTTP{
+		URL:    ts.URL + `/{{ .Tag "tenant" }}/write`,
+		Method: `{{ if .Tag "tenant" }}put{{ end }}`,
+		Headers: map[string]string{
+			"X-Tenant": `{{ .Tag "tenant" | upper }}`,
+			"X-Static": "static",
+		},
+	}
+	plugin.SetSerializer(influx.NewSerializer())
+	require.NoError(t, plugin.Connect())
+
+	err := plugin.Write(getTaggedMetrics("a", "b", "a", ""))
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{
+		"/a/write A": "cpu,tenant=a value=0 0\ncpu,tenant=a value=2 0\n",
+		"/b/write B": "cpu,tenant=b value=1 0\n",
+	}, bodies)
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	plugin := &HTTP{URL: "http://127.0.0.1:8080/{{ .Tag "}
+	require.Error(t, plugin.Connect())
+
+	plugin = &HTTP{URL: "http://127.0.0.1:8080/", Headers: map[string]string{"X-Tenant": "{{ "}}
+	require.Error(t, plugin.Connect())
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		plugin     *HTTP
+		responses  []int
+		retryAfter string
+		requests   int
+		err        bool
+	}{
+		{
+			name:      "retries 5xx",
+			plugin:    &HTTP{MaxRetries: 2},
+			responses: []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			requests:  3,
+		},
+		{
+			name:       "retries 429 after Retry-After",
+			plugin:     &HTTP{MaxRetries: 1},
+			responses:  []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter: "0",
+			requests:   2,
+		},
+		{
+			name:      "gives up after max retries",
+			plugin:    &HTTP{MaxRetries: 1},
+			responses: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK},
+			requests:  2,
+			err:       true,
+		},
+		{
+			name:      "does not retry 4xx",
+			plugin:    &HTTP{MaxRetries: 2},
+			responses: []int{http.StatusBadRequest, http.StatusOK},
+			requests:  1,
+			err:       true,
+		},
+		{
+			name:      "drops rejected metrics",
+			plugin:    &HTTP{MaxRetries: 2, DropRejected: true},
+			responses: []int{http.StatusBadRequest, http.StatusOK},
+			requests:  1,
+		},
+		{
+			name:      "keeps metrics of 429",
+			plugin:    &HTTP{DropRejected: true},
+			responses: []int{http.StatusTooManyRequests, http.StatusOK},
+			requests:  1,
+			err:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.responses[requests])
+				requests++
+			}))
+			defer ts.Close()
+
+			tt.plugin.URL = ts.URL
+			tt.plugin.RetryDelay = internal.Duration{Duration: time.Millisecond}
+			tt.plugin.SetSerializer(influx.NewSerializer())
+			require.NoError(t, tt.plugin.Connect())
+
+			err := tt.plugin.Write([]telegraf.Metric{getMetric()})
+			if tt.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.requests, requests)
+		})
+	}
+}
+
+func TestRetryCancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	plugin := &HTTP{URL: ts.URL, MaxRetries: 1, RetryMaxDelay: internal.Duration{Duration: time.Minute}}
+	plugin.SetSerializer(influx.NewSerializer())
+	require.NoError(t, plugin.Connect())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := plugin.WriteContext(ctx, []telegraf.Metric{getMetric()})
+	require.Error(t, err)
+}
+
+func TestSplitTooLarge(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		if strings.Count(string(body), "\n") > 1 {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	plugin := &HTTP{URL: ts.URL, SplitTooLarge: true}
+	plugin.SetSerializer(influx.NewSerializer())
+	require.NoError(t, plugin.Connect())
+
+	err := plugin.Write(getTaggedMetrics("a", "b", "c"))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"cpu,tenant=a value=0 0\n",
+		"cpu,tenant=b value=1 0\n",
+		"cpu,tenant=c value=2 0\n",
+	}, bodies)
+
+	plugin.SplitTooLarge = false
+	require.Error(t, plugin.Write(getTaggedMetrics("a", "b")))
+}
+
+func TestWriteAllBatches(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path != "/b/write" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	plugin := &HTTP{URL: ts.URL + `/{{ .Tag "tenant" }}/write`}
+	plugin.SetSerializer(influx.NewSerializer())
+	require.NoError(t, plugin.Connect())
+
+	// The failure of a batch doesn't prevent sending the others, and the
+	// error reports all the failures.
+	err := plugin.Write(getTaggedMetrics("a", "b", "c"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "2 requests failed")
+	require.Equal(t, []string{"/a/write", "/b/write", "/c/write"}, paths)
+}
+
+func TestRetryAfter(t *testing.T) {
+	require.Equal(t, time.Duration(0), retryAfter(""))
+	require.Equal(t, 2*time.Second, retryAfter("2"))
+	require.Equal(t, time.Duration(0), retryAfter("soon"))
+	d := retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	require.True(t, d > 59*time.Minute && d <= time.Hour)
+}
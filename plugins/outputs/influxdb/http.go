@@ -100,6 +100,15 @@ type HTTPConfig struct {
 	RetentionPolicy string
 	Consistency     string
 
+	// DatabaseTag and RetentionPolicyTag are the tags the database and
+	// retention policy of each metric are read from, Database and
+	// RetentionPolicy are used for the metrics without them.
+	DatabaseTag               string
+	ExcludeDatabaseTag        bool
+	RetentionPolicyTag        string
+	ExcludeRetentionPolicyTag bool
+	SkipDatabaseCreation      bool
+
 	InfluxUintSupport bool `toml:"influx_uint_support"`
 	Serializer        *influx.Serializer
 }
@@ -107,6 +116,7 @@ type HTTPConfig struct {
 type httpClient struct {
 	WriteURL        string
 	QueryURL        string
+	PingURL         string
 	ContentEncoding string
 	Timeout         time.Duration
 	Username        string
@@ -117,6 +127,23 @@ type httpClient struct {
 	serializer *influx.Serializer
 	url        *url.URL
 	database   string
+
+	retentionPolicy           string
+	consistency               string
+	databaseTag               string
+	excludeDatabaseTag        bool
+	retentionPolicyTag        string
+	excludeRetentionPolicyTag bool
+	skipDatabaseCreation      bool
+
+	// createdDatabases are the databases routed to by tag which exist.
+	createdDatabases map[string]bool
+}
+
+// dbrp is the destination of a batch of metrics.
+type dbrp struct {
+	Database        string
+	RetentionPolicy string
 }
 
 func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
@@ -169,6 +196,10 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 	if err != nil {
 		return nil, err
 	}
+	pingURL, err := makePingURL(config.URL)
+	if err != nil {
+		return nil, err
+	}
 
 	var transport *http.Transport
 	switch config.URL.Scheme {
@@ -201,11 +232,21 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 		url:             config.URL,
 		WriteURL:        writeURL,
 		QueryURL:        queryURL,
+		PingURL:         pingURL,
 		ContentEncoding: config.ContentEncoding,
 		Timeout:         timeout,
 		Username:        config.Username,
 		Password:        config.Password,
 		Headers:         headers,
+
+		retentionPolicy:           config.RetentionPolicy,
+		consistency:               config.Consistency,
+		databaseTag:               config.DatabaseTag,
+		excludeDatabaseTag:        config.ExcludeDatabaseTag,
+		retentionPolicyTag:        config.RetentionPolicyTag,
+		excludeRetentionPolicyTag: config.ExcludeRetentionPolicyTag,
+		skipDatabaseCreation:      config.SkipDatabaseCreation,
+		createdDatabases:          make(map[string]bool),
 	}
 	return client, nil
 }
@@ -224,8 +265,16 @@ func (c *httpClient) Database() string {
 // Note that some names are not allowed by the server, notably those with
 // non-printable characters or slashes.
 func (c *httpClient) CreateDatabase(ctx context.Context) error {
+	err := c.createDatabase(ctx, c.database)
+	if err == nil {
+		c.createdDatabases[c.database] = true
+	}
+	return err
+}
+
+func (c *httpClient) createDatabase(ctx context.Context, database string) error {
 	query := fmt.Sprintf(`CREATE DATABASE "%s"`,
-		escapeIdentifier.Replace(c.database))
+		escapeIdentifier.Replace(database))
 
 	req, err := c.makeQueryRequest(query)
 
@@ -264,10 +313,73 @@ func (c *httpClient) CreateDatabase(ctx context.Context) error {
 
 // Write sends the metrics to InfluxDB
 func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error {
+	if c.databaseTag == "" && c.retentionPolicyTag == "" {
+		return c.writeBatch(ctx, c.WriteURL, metrics)
+	}
+
+	batches := make(map[dbrp][]telegraf.Metric)
+	for _, metric := range metrics {
+		db, ok := metric.GetTag(c.databaseTag)
+		if !ok || db == "" {
+			db = c.database
+		}
+
+		rp, ok := metric.GetTag(c.retentionPolicyTag)
+		if !ok || rp == "" {
+			rp = c.retentionPolicy
+		}
+
+		if (c.excludeDatabaseTag && c.databaseTag != "") ||
+			(c.excludeRetentionPolicyTag && c.retentionPolicyTag != "") {
+			// Copy the metric as it is written again by retries.
+			metric = metric.Copy()
+			metric.Accept()
+			if c.excludeDatabaseTag {
+				metric.RemoveTag(c.databaseTag)
+			}
+			if c.excludeRetentionPolicyTag {
+				metric.RemoveTag(c.retentionPolicyTag)
+			}
+		}
+
+		key := dbrp{Database: db, RetentionPolicy: rp}
+		batches[key] = append(batches[key], metric)
+	}
+
+	for key, batch := range batches {
+		if !c.skipDatabaseCreation && !c.createdDatabases[key.Database] {
+			err := c.createDatabase(ctx, key.Database)
+			if err != nil {
+				log.Printf("W! [outputs.influxdb] when writing to [%s]: database %q creation failed: %v",
+					c.URL(), key.Database, err)
+			} else {
+				c.createdDatabases[key.Database] = true
+			}
+		}
+
+		writeURL, err := makeWriteURL(c.url, key.Database, key.RetentionPolicy, c.consistency)
+		if err != nil {
+			return err
+		}
+
+		err = c.writeBatch(ctx, writeURL, batch)
+		if err != nil {
+			if apiError, ok := err.(*APIError); ok && apiError.Type == DatabaseNotFound {
+				// Created again on the next write.
+				delete(c.createdDatabases, key.Database)
+			}
+			return fmt.Errorf("database %q: %v", key.Database, err)
+		}
+	}
+	return nil
+}
+
+// writeBatch writes the metrics to the write url of a database.
+func (c *httpClient) writeBatch(ctx context.Context, writeURL string, metrics []telegraf.Metric) error {
 	var err error
 
 	reader := influx.NewReader(metrics, c.serializer)
-	req, err := c.makeWriteRequest(reader)
+	req, err := c.makeWriteRequest(writeURL, reader)
 	if err != nil {
 		return err
 	}
@@ -339,6 +451,29 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 	}
 }
 
+// Health checks that the InfluxDB server is up with a ping.
+func (c *httpClient) Health(ctx context.Context) error {
+	req, err := http.NewRequest("GET", c.PingURL, nil)
+	if err != nil {
+		return err
+	}
+	c.addHeaders(req)
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			Title:      resp.Status,
+		}
+	}
+	return nil
+}
+
 func (c *httpClient) makeQueryRequest(query string) (*http.Request, error) {
 	params := url.Values{}
 	params.Set("q", query)
@@ -355,7 +490,7 @@ func (c *httpClient) makeQueryRequest(query string) (*http.Request, error) {
 	return req, nil
 }
 
-func (c *httpClient) makeWriteRequest(body io.Reader) (*http.Request, error) {
+func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request, error) {
 	var err error
 	if c.ContentEncoding == "gzip" {
 		body, err = internal.CompressWithGzip(body)
@@ -364,7 +499,7 @@ func (c *httpClient) makeWriteRequest(body io.Reader) (*http.Request, error) {
 		}
 	}
 
-	req, err := http.NewRequest("POST", c.WriteURL, body)
+	req, err := http.NewRequest("POST", url, body)
 	if err != nil {
 		return nil, err
 	}
@@ -430,3 +565,18 @@ func makeQueryURL(loc *url.URL) (string, error) {
 	}
 	return u.String(), nil
 }
+
+func makePingURL(loc *url.URL) (string, error) {
+	u := *loc
+	switch u.Scheme {
+	case "unix":
+		u.Scheme = "http"
+		u.Host = "127.0.0.1"
+		u.Path = "/ping"
+	case "http", "https":
+		u.Path = path.Join(u.Path, "ping")
+	default:
+		return "", fmt.Errorf("unsupported scheme: %q", loc.Scheme)
+	}
+	return u.String(), nil
+}
@@ -14,6 +14,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/outputs/influxdb"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -585,6 +587,141 @@ func TestHTTP_WriteContentEncodingGzip(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestHTTP_WriteDatabaseTag(t *testing.T) {
+	var mu sync.Mutex
+	created := make(map[string]bool)
+	written := make(map[string]string)
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch r.URL.Path {
+			case "/query":
+				created[strings.TrimPrefix(r.FormValue("q"), "CREATE DATABASE ")] = true
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`{"results": [{"statement_id": 0}]}`))
+			case "/write":
+				body, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				key := r.FormValue("db") + "/" + r.FormValue("rp")
+				written[key] += string(body)
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}),
+	)
+	defer ts.Close()
+
+	u, err := url.Parse(fmt.Sprintf("http://%s", ts.Listener.Addr().String()))
+	require.NoError(t, err)
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"tenant": "foo", "rp": "short"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{"tenant": "bar"},
+			map[string]interface{}{"value": 2.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": 3.0},
+			time.Unix(0, 0)),
+	}
+
+	config := &influxdb.HTTPConfig{
+		URL:                       u,
+		Database:                  "telegraf",
+		RetentionPolicy:           "autogen",
+		DatabaseTag:               "tenant",
+		ExcludeDatabaseTag:        true,
+		RetentionPolicyTag:        "rp",
+		ExcludeRetentionPolicyTag: true,
+	}
+	client, err := influxdb.NewHTTPClient(config)
+	require.NoError(t, err)
+
+	err = client.Write(context.Background(), metrics)
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{
+		"foo/short":        "cpu value=1 0\n",
+		"bar/autogen":      "cpu value=2 0\n",
+		"telegraf/autogen": "cpu value=3 0\n",
+	}, written)
+	require.Equal(t, map[string]bool{`"foo"`: true, `"bar"`: true, `"telegraf"`: true}, created)
+
+	// The tags are kept in the metrics for retries.
+	require.True(t, metrics[0].HasTag("tenant"))
+}
+
+func TestHTTP_WriteDatabaseTagSkipCreation(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/write":
+				require.Equal(t, "foo", r.FormValue("db"))
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error": "database not found: \"foo\""}`))
+			default:
+				t.Errorf("unexpected request to %s", r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}),
+	)
+	defer ts.Close()
+
+	u, err := url.Parse(fmt.Sprintf("http://%s", ts.Listener.Addr().String()))
+	require.NoError(t, err)
+
+	config := &influxdb.HTTPConfig{
+		URL:                  u,
+		DatabaseTag:          "tenant",
+		SkipDatabaseCreation: true,
+	}
+	client, err := influxdb.NewHTTPClient(config)
+	require.NoError(t, err)
+
+	err = client.Write(context.Background(), []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"tenant": "foo"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(0, 0)),
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `database "foo"`)
+}
+
+func TestHTTP_Health(t *testing.T) {
+	status := http.StatusNoContent
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/ping":
+				require.Equal(t, "GET", r.Method)
+				w.WriteHeader(status)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}),
+	)
+	defer ts.Close()
+
+	u, err := url.Parse(fmt.Sprintf("http://%s", ts.Listener.Addr().String()))
+	require.NoError(t, err)
+
+	client, err := influxdb.NewHTTPClient(&influxdb.HTTPConfig{URL: u})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, client.Health(ctx))
+
+	status = http.StatusServiceUnavailable
+	require.Error(t, client.Health(ctx))
+}
+
 func TestHTTP_UnixSocket(t *testing.T) {
 	tmpdir, err := ioutil.TempDir("", "telegraf-test")
 	if err != nil {
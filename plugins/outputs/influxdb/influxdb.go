@@ -5,12 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"math/rand"
 	"net/url"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/router"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
@@ -47,11 +47,23 @@ type InfluxDB struct {
 	ContentEncoding      string            `toml:"content_encoding"`
 	SkipDatabaseCreation bool              `toml:"skip_database_creation"`
 	InfluxUintSupport    bool              `toml:"influx_uint_support"`
+	Routing              string            `toml:"routing"`
+	RoutingTag           string            `toml:"routing_tag"`
+	WriteQuorum          int               `toml:"write_quorum"`
+	UnhealthyThreshold   int               `toml:"unhealthy_threshold"`
+	UnhealthyTimeout     internal.Duration `toml:"unhealthy_timeout"`
+
+	DatabaseTag               string `toml:"database_tag"`
+	ExcludeDatabaseTag        bool   `toml:"exclude_database_tag"`
+	RetentionPolicyTag        string `toml:"retention_policy_tag"`
+	ExcludeRetentionPolicyTag bool   `toml:"exclude_retention_policy_tag"`
+
 	tls.ClientConfig
 
 	Precision string // precision deprecated in 1.0; value is ignored
 
 	clients []Client
+	router  *router.Router
 
 	CreateHTTPClientF func(config *HTTPConfig) (Client, error)
 	CreateUDPClientF  func(config *UDPConfig) (Client, error)
@@ -63,14 +75,41 @@ var sampleConfig = `
   ## The full HTTP or UDP URL for your InfluxDB instance.
   ##
   ## Multiple URLs can be specified for a single cluster, only ONE of the
-  ## urls will be written to each interval.
+  ## urls will be written to each interval unless routing is "hash" or "all".
   # urls = ["unix:///var/run/influxdb.sock"]
   # urls = ["udp://127.0.0.1:8089"]
   # urls = ["http://127.0.0.1:8086"]
 
+  ## Routing of the writes between the urls, can be:
+  ##   "random":      write to a random url, trying the others on errors.
+  ##   "failover":    write to the first healthy url in the list.
+  ##   "round_robin": write to the next healthy url each interval.
+  ##   "hash":        split the metrics between the healthy urls by
+  ##                  consistent hashing of the value of routing_tag.
+  ##   "all":         write to all the healthy urls, the write succeeds if
+  ##                  write_quorum urls succeed, by default a majority.
+  # routing = "random"
+  # routing_tag = "host"
+  # write_quorum = 0
+
+  ## A url is unhealthy after unhealthy_threshold consecutive failed writes
+  ## and is not written to for unhealthy_timeout, it is then pinged before
+  ## being used again.  By default unhealthy_threshold is 0 and all urls are
+  ## always tried.
+  # unhealthy_threshold = 0
+  # unhealthy_timeout = "30s"
+
   ## The target database for metrics; will be created as needed.
   # database = "telegraf"
 
+  ## The value of this tag will be used to determine the database.  If this
+  ## tag is not set the 'database' option is used as the default.  Only takes
+  ## effect when using HTTP.
+  # database_tag = ""
+
+  ## If true, the 'database_tag' will not be included in the written metric.
+  # exclude_database_tag = false
+
   ## If true, no CREATE DATABASE queries will be sent.  Set to true when using
   ## Telegraf with a user without permissions to create databases or when the
   ## database already exists.
@@ -80,6 +119,15 @@ var sampleConfig = `
   ## the default retention policy.  Only takes effect when using HTTP.
   # retention_policy = ""
 
+  ## The value of this tag will be used to determine the retention policy.  If
+  ## this tag is not set the 'retention_policy' option is used as the default.
+  ## Only takes effect when using HTTP.
+  # retention_policy_tag = ""
+
+  ## If true, the 'retention_policy_tag' will not be included in the written
+  ## metric.
+  # exclude_retention_policy_tag = false
+
   ## Write consistency (clusters only), can be: "any", "one", "quorum", "all".
   ## Only takes effect when using HTTP.
   # write_consistency = "any"
@@ -174,6 +222,27 @@ func (i *InfluxDB) Connect() error {
 		}
 	}
 
+	endpoints := make([]router.Endpoint, 0, len(i.clients))
+	for n, c := range i.clients {
+		endpoints = append(endpoints, &endpoint{
+			Client:               c,
+			url:                  urls[n],
+			skipDatabaseCreation: i.SkipDatabaseCreation,
+		})
+	}
+
+	r, err := router.New("influxdb", router.Config{
+		Mode:               i.Routing,
+		Tag:                i.RoutingTag,
+		Quorum:             i.WriteQuorum,
+		UnhealthyThreshold: i.UnhealthyThreshold,
+		UnhealthyTimeout:   i.UnhealthyTimeout.Duration,
+	}, endpoints)
+	if err != nil {
+		return err
+	}
+	i.router = r
+
 	return nil
 }
 
@@ -189,37 +258,43 @@ func (i *InfluxDB) SampleConfig() string {
 	return sampleConfig
 }
 
-// Write sends metrics to one of the configured servers, logging each
-// unsuccessful. If all servers fail, return an error.
+// Write sends metrics to the servers chosen by the routing, logging each
+// unsuccessful write.
 func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
-	ctx := context.Background()
+	return i.router.Write(context.Background(), metrics)
+}
 
-	var err error
-	p := rand.Perm(len(i.clients))
-	for _, n := range p {
-		client := i.clients[n]
-		err = client.Write(ctx, metrics)
-		if err == nil {
-			return nil
-		}
+// endpoint is a Client written to by the router.
+type endpoint struct {
+	Client
+	url                  string
+	skipDatabaseCreation bool
+}
 
-		switch apiError := err.(type) {
-		case *APIError:
-			if !i.SkipDatabaseCreation {
-				if apiError.Type == DatabaseNotFound {
-					err := client.CreateDatabase(ctx)
-					if err != nil {
-						log.Printf("E! [outputs.influxdb] when writing to [%s]: database %q not found and failed to recreate",
-							client.URL(), client.Database())
-					}
-				}
-			}
-		}
+// URL returns the configured url of the client.
+func (e *endpoint) URL() string {
+	return e.url
+}
 
-		log.Printf("E! [outputs.influxdb] when writing to [%s]: %v", client.URL(), err)
+// Write writes to the client and recreates the database when it is not
+// found, the metrics are then written on the next try.
+func (e *endpoint) Write(ctx context.Context, metrics []telegraf.Metric) error {
+	err := e.Client.Write(ctx, metrics)
+	if apiError, ok := err.(*APIError); ok && apiError.Type == DatabaseNotFound && !e.skipDatabaseCreation {
+		if err := e.CreateDatabase(ctx); err != nil {
+			log.Printf("E! [outputs.influxdb] when writing to [%s]: database %q not found and failed to recreate",
+				e.URL(), e.Database())
+		}
 	}
+	return err
+}
 
-	return errors.New("could not write any address")
+// Health pings the server when the client supports it.
+func (e *endpoint) Health(ctx context.Context) error {
+	if checker, ok := e.Client.(router.HealthChecker); ok {
+		return checker.Health(ctx)
+	}
+	return nil
 }
 
 func (i *InfluxDB) udpClient(url *url.URL) (Client, error) {
@@ -257,6 +332,12 @@ func (i *InfluxDB) httpClient(ctx context.Context, url *url.URL, proxy *url.URL)
 		RetentionPolicy: i.RetentionPolicy,
 		Consistency:     i.WriteConsistency,
 		Serializer:      i.serializer,
+
+		DatabaseTag:               i.DatabaseTag,
+		ExcludeDatabaseTag:        i.ExcludeDatabaseTag,
+		RetentionPolicyTag:        i.RetentionPolicyTag,
+		ExcludeRetentionPolicyTag: i.ExcludeRetentionPolicyTag,
+		SkipDatabaseCreation:      i.SkipDatabaseCreation,
 	}
 
 	c, err := i.CreateHTTPClientF(config)
@@ -278,7 +359,8 @@ func (i *InfluxDB) httpClient(ctx context.Context, url *url.URL, proxy *url.URL)
 func init() {
 	outputs.Add("influxdb", func() telegraf.Output {
 		return &InfluxDB{
-			Timeout: internal.Duration{Duration: time.Second * 5},
+			Timeout:          internal.Duration{Duration: time.Second * 5},
+			UnhealthyTimeout: internal.Duration{Duration: time.Second * 30},
 			CreateHTTPClientF: func(config *HTTPConfig) (Client, error) {
 				return NewHTTPClient(config)
 			},
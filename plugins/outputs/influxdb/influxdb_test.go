@@ -2,6 +2,7 @@ package influxdb_test
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"testing"
 	"time"
@@ -182,3 +183,62 @@ func TestWriteRecreateDatabaseIfDatabaseNotFound(t *testing.T) {
 	// We only have one URL, so we expect an error
 	require.Error(t, err)
 }
+
+func TestWriteFailoverRouting(t *testing.T) {
+	var writes []string
+	output := influxdb.InfluxDB{
+		URLs:                 []string{"http://a:8086", "http://b:8086"},
+		Routing:              "failover",
+		SkipDatabaseCreation: true,
+		UnhealthyThreshold:   1,
+		UnhealthyTimeout:     internal.Duration{Duration: time.Hour},
+
+		CreateHTTPClientF: func(config *influxdb.HTTPConfig) (influxdb.Client, error) {
+			u := config.URL.String()
+			return &MockClient{
+				WriteF: func(ctx context.Context, metrics []telegraf.Metric) error {
+					writes = append(writes, u)
+					if u == "http://a:8086" {
+						return errors.New("connection refused")
+					}
+					return nil
+				},
+			}, nil
+		},
+	}
+
+	err := output.Connect()
+	require.NoError(t, err)
+
+	m, err := metric.New(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{
+			"value": 42.0,
+		},
+		time.Unix(0, 0),
+	)
+	require.NoError(t, err)
+	metrics := []telegraf.Metric{m}
+
+	require.NoError(t, output.Write(metrics))
+	require.NoError(t, output.Write(metrics))
+	// The first url is unhealthy after its first failure.
+	require.Equal(t, []string{"http://a:8086", "http://b:8086", "http://b:8086"}, writes)
+}
+
+func TestConnectRoutingErrors(t *testing.T) {
+	output := influxdb.InfluxDB{
+		URLs:    []string{"http://localhost:8086"},
+		Routing: "hash",
+
+		SkipDatabaseCreation: true,
+		CreateHTTPClientF: func(config *influxdb.HTTPConfig) (influxdb.Client, error) {
+			return &MockClient{}, nil
+		},
+	}
+
+	err := output.Connect()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "routing_tag is required")
+}
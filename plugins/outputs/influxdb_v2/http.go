@@ -54,11 +54,17 @@ type HTTPConfig struct {
 	ContentEncoding string
 	TLSConfig       *tls.Config
 
+	// BucketTag is the tag the bucket of each metric is read from, Bucket
+	// is used for the metrics without it.
+	BucketTag        string
+	ExcludeBucketTag bool
+
 	Serializer *influx.Serializer
 }
 
 type httpClient struct {
 	WriteURL        string
+	HealthURL       string
 	ContentEncoding string
 	Timeout         time.Duration
 	Headers         map[string]string
@@ -67,6 +73,11 @@ type httpClient struct {
 	serializer *influx.Serializer
 	url        *url.URL
 	retryTime  time.Time
+
+	organization     string
+	bucket           string
+	bucketTag        string
+	excludeBucketTag bool
 }
 
 func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
@@ -111,6 +122,11 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 		return nil, err
 	}
 
+	healthURL, err := makeHealthURL(*config.URL)
+	if err != nil {
+		return nil, err
+	}
+
 	var transport *http.Transport
 	switch config.URL.Scheme {
 	case "http", "https":
@@ -140,9 +156,15 @@ func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
 		},
 		url:             config.URL,
 		WriteURL:        writeURL,
+		HealthURL:       healthURL,
 		ContentEncoding: config.ContentEncoding,
 		Timeout:         timeout,
 		Headers:         headers,
+
+		organization:     config.Organization,
+		bucket:           config.Bucket,
+		bucketTag:        config.BucketTag,
+		excludeBucketTag: config.ExcludeBucketTag,
 	}
 	return client, nil
 }
@@ -173,8 +195,46 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 	if c.retryTime.After(time.Now()) {
 		return errors.New("Retry time has not elapsed")
 	}
+
+	if c.bucketTag == "" {
+		return c.writeBatch(ctx, c.WriteURL, metrics)
+	}
+
+	batches := make(map[string][]telegraf.Metric)
+	for _, metric := range metrics {
+		bucket, ok := metric.GetTag(c.bucketTag)
+		if !ok || bucket == "" {
+			bucket = c.bucket
+		}
+
+		if c.excludeBucketTag {
+			// Copy the metric as it is written again by retries.
+			metric = metric.Copy()
+			metric.Accept()
+			metric.RemoveTag(c.bucketTag)
+		}
+
+		batches[bucket] = append(batches[bucket], metric)
+	}
+
+	for bucket, batch := range batches {
+		writeURL, err := makeWriteURL(*c.url, c.organization, bucket)
+		if err != nil {
+			return err
+		}
+
+		err = c.writeBatch(ctx, writeURL, batch)
+		if err != nil {
+			return fmt.Errorf("bucket %q: %v", bucket, err)
+		}
+	}
+	return nil
+}
+
+// writeBatch writes the metrics to the write url of a bucket.
+func (c *httpClient) writeBatch(ctx context.Context, writeURL string, metrics []telegraf.Metric) error {
 	reader := influx.NewReader(metrics, c.serializer)
-	req, err := c.makeWriteRequest(reader)
+	req, err := c.makeWriteRequest(writeURL, reader)
 	if err != nil {
 		return err
 	}
@@ -227,7 +287,30 @@ func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error
 	}
 }
 
-func (c *httpClient) makeWriteRequest(body io.Reader) (*http.Request, error) {
+// Health checks that the InfluxDB server is ready to accept writes.
+func (c *httpClient) Health(ctx context.Context) error {
+	req, err := http.NewRequest("GET", c.HealthURL, nil)
+	if err != nil {
+		return err
+	}
+	c.addHeaders(req)
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			Title:      resp.Status,
+		}
+	}
+	return nil
+}
+
+func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request, error) {
 	var err error
 	if c.ContentEncoding == "gzip" {
 		body, err = internal.CompressWithGzip(body)
@@ -236,7 +319,7 @@ func (c *httpClient) makeWriteRequest(body io.Reader) (*http.Request, error) {
 		}
 	}
 
-	req, err := http.NewRequest("POST", c.WriteURL, body)
+	req, err := http.NewRequest("POST", url, body)
 	if err != nil {
 		return nil, err
 	}
@@ -275,3 +358,17 @@ func makeWriteURL(loc url.URL, org, bucket string) (string, error) {
 	loc.RawQuery = params.Encode()
 	return loc.String(), nil
 }
+
+func makeHealthURL(loc url.URL) (string, error) {
+	switch loc.Scheme {
+	case "unix":
+		loc.Scheme = "http"
+		loc.Host = "127.0.0.1"
+		loc.Path = "/health"
+	case "http", "https":
+		loc.Path = path.Join(loc.Path, "/health")
+	default:
+		return "", fmt.Errorf("unsupported scheme: %q", loc.Scheme)
+	}
+	return loc.String(), nil
+}
@@ -54,6 +54,6 @@ func TestMakeWriteRequest(t *testing.T) {
 		ContentEncoding: "gzip",
 		Headers:         map[string]string{"x": "y"},
 	}
-	_, err := cli.makeWriteRequest(reader)
+	_, err := cli.makeWriteRequest(cli.WriteURL, reader)
 	require.NoError(t, err)
 }
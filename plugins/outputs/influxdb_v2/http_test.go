@@ -1,10 +1,19 @@
 package influxdb_v2_test
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
 	influxdb "github.com/influxdata/telegraf/plugins/outputs/influxdb_v2"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -47,3 +56,78 @@ func TestNewHTTPClient(t *testing.T) {
 		}
 	}
 }
+
+func TestHealth(t *testing.T) {
+	status := http.StatusOK
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/health":
+				w.WriteHeader(status)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}),
+	)
+	defer ts.Close()
+
+	client, err := influxdb.NewHTTPClient(&influxdb.HTTPConfig{
+		URL: genURL(fmt.Sprintf("http://%s", ts.Listener.Addr().String())),
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, client.Health(ctx))
+
+	status = http.StatusServiceUnavailable
+	require.Error(t, client.Health(ctx))
+}
+
+func TestWriteBucketTag(t *testing.T) {
+	var mu sync.Mutex
+	written := make(map[string]string)
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch r.URL.Path {
+			case "/api/v2/write":
+				require.Equal(t, "influx", r.FormValue("org"))
+				body, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				written[r.FormValue("bucket")] += string(body)
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}),
+	)
+	defer ts.Close()
+
+	client, err := influxdb.NewHTTPClient(&influxdb.HTTPConfig{
+		URL:              genURL(fmt.Sprintf("http://%s", ts.Listener.Addr().String())),
+		Organization:     "influx",
+		Bucket:           "telegraf",
+		BucketTag:        "bucket",
+		ExcludeBucketTag: true,
+	})
+	require.NoError(t, err)
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"bucket": "foo"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": 2.0},
+			time.Unix(0, 0)),
+	}
+	require.NoError(t, client.Write(context.Background(), metrics))
+
+	require.Equal(t, map[string]string{
+		"foo":      "cpu value=1 0\n",
+		"telegraf": "cpu value=2 0\n",
+	}, written)
+	require.True(t, metrics[0].HasTag("bucket"))
+}
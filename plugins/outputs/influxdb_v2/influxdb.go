@@ -4,13 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
-	"math/rand"
 	"net/url"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/router"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
@@ -26,9 +25,28 @@ var sampleConfig = `
   ## The URLs of the InfluxDB cluster nodes.
   ##
   ## Multiple URLs can be specified for a single cluster, only ONE of the
-  ## urls will be written to each interval.
+  ## urls will be written to each interval unless routing is "hash" or "all".
   urls = ["http://127.0.0.1:9999"]
 
+  ## Routing of the writes between the urls, can be:
+  ##   "random":      write to a random url, trying the others on errors.
+  ##   "failover":    write to the first healthy url in the list.
+  ##   "round_robin": write to the next healthy url each interval.
+  ##   "hash":        split the metrics between the healthy urls by
+  ##                  consistent hashing of the value of routing_tag.
+  ##   "all":         write to all the healthy urls, the write succeeds if
+  ##                  write_quorum urls succeed, by default a majority.
+  # routing = "random"
+  # routing_tag = "host"
+  # write_quorum = 0
+
+  ## A url is unhealthy after unhealthy_threshold consecutive failed writes
+  ## and is not written to for unhealthy_timeout, its health endpoint is then
+  ## checked before it is used again.  By default unhealthy_threshold is 0 and
+  ## all urls are always tried.
+  # unhealthy_threshold = 0
+  # unhealthy_timeout = "30s"
+
   ## Token for authentication.
   token = ""
 
@@ -38,6 +56,13 @@ var sampleConfig = `
   ## Destination bucket to write into.
   bucket = ""
 
+  ## The value of this tag will be used to determine the bucket.  If this
+  ## tag is not set the 'bucket' option is used as the default.
+  # bucket_tag = ""
+
+  ## If true, the bucket tag will not be added to the metric.
+  # exclude_bucket_tag = false
+
   ## Timeout for HTTP messages.
   # timeout = "5s"
 
@@ -73,19 +98,29 @@ type Client interface {
 }
 
 type InfluxDB struct {
-	URLs            []string          `toml:"urls"`
-	Token           string            `toml:"token"`
-	Organization    string            `toml:"organization"`
-	Bucket          string            `toml:"bucket"`
-	Timeout         internal.Duration `toml:"timeout"`
-	HTTPHeaders     map[string]string `toml:"http_headers"`
-	HTTPProxy       string            `toml:"http_proxy"`
-	UserAgent       string            `toml:"user_agent"`
-	ContentEncoding string            `toml:"content_encoding"`
-	UintSupport     bool              `toml:"influx_uint_support"`
+	URLs               []string          `toml:"urls"`
+	Token              string            `toml:"token"`
+	Organization       string            `toml:"organization"`
+	Bucket             string            `toml:"bucket"`
+	Timeout            internal.Duration `toml:"timeout"`
+	HTTPHeaders        map[string]string `toml:"http_headers"`
+	HTTPProxy          string            `toml:"http_proxy"`
+	UserAgent          string            `toml:"user_agent"`
+	ContentEncoding    string            `toml:"content_encoding"`
+	UintSupport        bool              `toml:"influx_uint_support"`
+	Routing            string            `toml:"routing"`
+	RoutingTag         string            `toml:"routing_tag"`
+	WriteQuorum        int               `toml:"write_quorum"`
+	UnhealthyThreshold int               `toml:"unhealthy_threshold"`
+	UnhealthyTimeout   internal.Duration `toml:"unhealthy_timeout"`
+
+	BucketTag        string `toml:"bucket_tag"`
+	ExcludeBucketTag bool   `toml:"exclude_bucket_tag"`
+
 	tls.ClientConfig
 
 	clients    []Client
+	router     *router.Router
 	serializer *influx.Serializer
 }
 
@@ -128,6 +163,23 @@ func (i *InfluxDB) Connect() error {
 		}
 	}
 
+	endpoints := make([]router.Endpoint, 0, len(i.clients))
+	for _, c := range i.clients {
+		endpoints = append(endpoints, c)
+	}
+
+	r, err := router.New("influxdb_v2", router.Config{
+		Mode:               i.Routing,
+		Tag:                i.RoutingTag,
+		Quorum:             i.WriteQuorum,
+		UnhealthyThreshold: i.UnhealthyThreshold,
+		UnhealthyTimeout:   i.UnhealthyTimeout.Duration,
+	}, endpoints)
+	if err != nil {
+		return err
+	}
+	i.router = r
+
 	return nil
 }
 
@@ -143,24 +195,10 @@ func (i *InfluxDB) SampleConfig() string {
 	return sampleConfig
 }
 
-// Write sends metrics to one of the configured servers, logging each
-// unsuccessful. If all servers fail, return an error.
+// Write sends metrics to the servers chosen by the routing, logging each
+// unsuccessful write.
 func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
-	ctx := context.Background()
-
-	var err error
-	p := rand.Perm(len(i.clients))
-	for _, n := range p {
-		client := i.clients[n]
-		err = client.Write(ctx, metrics)
-		if err == nil {
-			return nil
-		}
-
-		log.Printf("E! [outputs.influxdb] when writing to [%s]: %v", client.URL(), err)
-	}
-
-	return errors.New("could not write any address")
+	return i.router.Write(context.Background(), metrics)
 }
 
 func (i *InfluxDB) getHTTPClient(ctx context.Context, url *url.URL, proxy *url.URL) (Client, error) {
@@ -181,6 +219,9 @@ func (i *InfluxDB) getHTTPClient(ctx context.Context, url *url.URL, proxy *url.U
 		ContentEncoding: i.ContentEncoding,
 		TLSConfig:       tlsConfig,
 		Serializer:      i.serializer,
+
+		BucketTag:        i.BucketTag,
+		ExcludeBucketTag: i.ExcludeBucketTag,
 	}
 
 	c, err := NewHTTPClient(config)
@@ -194,8 +235,9 @@ func (i *InfluxDB) getHTTPClient(ctx context.Context, url *url.URL, proxy *url.U
 func init() {
 	outputs.Add("influxdb_v2", func() telegraf.Output {
 		return &InfluxDB{
-			Timeout:         internal.Duration{Duration: time.Second * 5},
-			ContentEncoding: "gzip",
+			Timeout:          internal.Duration{Duration: time.Second * 5},
+			ContentEncoding:  "gzip",
+			UnhealthyTimeout: internal.Duration{Duration: time.Second * 30},
 		}
 	})
 }
@@ -1,15 +1,18 @@
 package kafka
 
 import (
+	"bytes"
 	"crypto/tls"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 
 	"github.com/influxdata/telegraf"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/template"
 	uuid "github.com/satori/go.uuid"
 
 	"github.com/Shopify/sarama"
@@ -51,10 +54,16 @@ type (
 		// SASL Password
 		SASLPassword string `toml:"sasl_password"`
 
+		HeaderTags       []string `toml:"header_tags"`
+		CompressionLevel int      `toml:"compression_level"`
+		IdempotentWrites bool     `toml:"idempotent_writes"`
+		UseBatchFormat   bool     `toml:"use_batch_format"`
+
 		tlsConfig tls.Config
 		producer  sarama.SyncProducer
 
-		serializer serializers.Serializer
+		serializer    serializers.Serializer
+		topicTemplate *template.Template
 	}
 	TopicSuffix struct {
 		Method    string   `toml:"method"`
@@ -68,6 +77,9 @@ var sampleConfig = `
   brokers = ["localhost:9092"]
   ## Kafka topic for producer messages
   topic = "telegraf"
+  ## The topic can be a Go template rendered with each metric, using the
+  ## functions of the template data format.
+  # topic = 'telegraf_{{ .Tag "env" }}'
 
   ## Optional Client id
   # client_id = "Telegraf"
@@ -116,14 +128,23 @@ var sampleConfig = `
   ##       routing_key = "telegraf"
   # routing_key = ""
 
+  ## Tags added as headers to the messages, with the tag key as header key.
+  ## Headers require version 0.11.0.0 or later.
+  # header_tags = []
+
   ## CompressionCodec represents the various compression codecs recognized by
   ## Kafka in messages.
   ##  0 : No compression
   ##  1 : Gzip compression
   ##  2 : Snappy compression
   ##  3 : LZ4 compression
+  ##  4 : ZSTD compression, requires version 2.1.0 or later
   # compression_codec = 0
 
+  ## Compression level of the gzip and zstd codecs, 0 uses the default level
+  ## of the codec.
+  # compression_level = 0
+
   ##  RequiredAcks is used in Produce Requests to tell the broker how many
   ##  replica acknowledgements it must see before responding
   ##   0 : the producer never waits for an acknowledgement from the broker.
@@ -144,6 +165,11 @@ var sampleConfig = `
   ## until the next flush.
   # max_retry = 3
 
+  ## Enable the idempotent producer, which ensures that retries of the
+  ## producer do not write duplicate messages.  Requires version 0.11.0.0 or
+  ## later, required_acks = -1 and max_retry of 1 or more.
+  # idempotent_writes = false
+
   ## The maximum permitted size of a message. Should be set equal to or
   ## smaller than the broker's 'message.max.bytes'.
   # max_message_bytes = 1000000
@@ -164,6 +190,10 @@ var sampleConfig = `
   ## more about them here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
   # data_format = "influx"
+
+  ## Serialize the metrics with the same topic, routing key and headers as a
+  ## batch in a single message, instead of a message per metric.
+  # use_batch_format = false
 `
 
 func ValidateTopicSuffixMethod(method string) error {
@@ -176,13 +206,32 @@ func ValidateTopicSuffixMethod(method string) error {
 }
 
 func (k *Kafka) GetTopicName(metric telegraf.Metric) string {
+	topicName, err := k.topicName(metric)
+	if err != nil {
+		log.Printf("E! [outputs.kafka] Could not render topic: %v", err)
+	}
+	return topicName
+}
+
+// topicName returns the topic of the metric, the topic template rendered
+// with the metric followed by the topic suffix.
+func (k *Kafka) topicName(metric telegraf.Metric) (string, error) {
+	topic := k.Topic
+	if k.topicTemplate != nil {
+		var err error
+		topic, err = k.topicTemplate.Execute(metric)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	var topicName string
 	switch k.TopicSuffix.Method {
 	case "measurement":
-		topicName = k.Topic + k.TopicSuffix.Separator + metric.Name()
+		topicName = topic + k.TopicSuffix.Separator + metric.Name()
 	case "tags":
 		var topicNameComponents []string
-		topicNameComponents = append(topicNameComponents, k.Topic)
+		topicNameComponents = append(topicNameComponents, topic)
 		for _, tag := range k.TopicSuffix.Keys {
 			tagValue := metric.Tags()[tag]
 			if tagValue != "" {
@@ -191,9 +240,9 @@ func (k *Kafka) GetTopicName(metric telegraf.Metric) string {
 		}
 		topicName = strings.Join(topicNameComponents, k.TopicSuffix.Separator)
 	default:
-		topicName = k.Topic
+		topicName = topic
 	}
-	return topicName
+	return topicName, nil
 }
 
 func (k *Kafka) SetSerializer(serializer serializers.Serializer) {
@@ -215,6 +264,17 @@ func (k *Kafka) Connect() error {
 		config.Version = version
 	}
 
+	if len(k.HeaderTags) > 0 && !config.Version.IsAtLeast(sarama.V0_11_0_0) {
+		return fmt.Errorf("header_tags requires version 0.11.0.0 or later")
+	}
+
+	if strings.Contains(k.Topic, "{{") {
+		k.topicTemplate, err = template.NewTemplate("topic", k.Topic)
+		if err != nil {
+			return err
+		}
+	}
+
 	if k.ClientID != "" {
 		config.ClientID = k.ClientID
 	} else {
@@ -226,6 +286,17 @@ func (k *Kafka) Connect() error {
 	config.Producer.Retry.Max = k.MaxRetry
 	config.Producer.Return.Successes = true
 
+	if k.CompressionLevel != 0 {
+		config.Producer.CompressionLevel = k.CompressionLevel
+	}
+
+	if k.IdempotentWrites {
+		// Requests are not reordered on retries with a single request in
+		// flight per broker.
+		config.Producer.Idempotent = true
+		config.Net.MaxOpenRequests = 1
+	}
+
 	if k.MaxMessageBytes > 0 {
 		config.Producer.MaxMessageBytes = k.MaxMessageBytes
 	}
@@ -274,51 +345,144 @@ func (k *Kafka) Description() string {
 }
 
 func (k *Kafka) routingKey(metric telegraf.Metric) string {
+	key, random := k.recordKey(metric)
+	if random {
+		return uuid.NewV4().String()
+	}
+	return key
+}
+
+// recordKey returns the routing key of the metric, or true if the key is
+// random.
+func (k *Kafka) recordKey(metric telegraf.Metric) (string, bool) {
 	if k.RoutingTag != "" {
 		key, ok := metric.GetTag(k.RoutingTag)
 		if ok {
-			return key
+			return key, false
 		}
 	}
 
 	if k.RoutingKey == "random" {
-		u := uuid.NewV4()
-		return u.String()
+		return "", true
 	}
 
-	return k.RoutingKey
+	return k.RoutingKey, false
 }
 
-func (k *Kafka) Write(metrics []telegraf.Metric) error {
-	msgs := make([]*sarama.ProducerMessage, 0, len(metrics))
+func (k *Kafka) headers(metric telegraf.Metric) []sarama.RecordHeader {
+	var headers []sarama.RecordHeader
+	for _, key := range k.HeaderTags {
+		if value, ok := metric.GetTag(key); ok {
+			headers = append(headers, sarama.RecordHeader{
+				Key:   []byte(key),
+				Value: []byte(value),
+			})
+		}
+	}
+	return headers
+}
+
+// record is the metrics of a message.
+type record struct {
+	topic   string
+	key     string
+	random  bool
+	headers []sarama.RecordHeader
+	metrics []telegraf.Metric
+}
+
+// records returns the records of the metrics, a record per metric or, with
+// the batch format, per distinct topic, routing key and headers.
+func (k *Kafka) records(metrics []telegraf.Metric) []*record {
+	var records []*record
+	index := make(map[string]*record)
 	for _, metric := range metrics {
-		buf, err := k.serializer.Serialize(metric)
+		topic, err := k.topicName(metric)
+		if err != nil || topic == "" {
+			log.Printf("E! [outputs.kafka] Dropping metric %q without topic: %v", metric.Name(), err)
+			continue
+		}
+
+		key, random := k.recordKey(metric)
+		r := &record{
+			topic:   topic,
+			key:     key,
+			random:  random,
+			headers: k.headers(metric),
+			metrics: []telegraf.Metric{metric},
+		}
+		if !k.UseBatchFormat {
+			records = append(records, r)
+			continue
+		}
+
+		id := r.id()
+		if batch, ok := index[id]; ok {
+			batch.metrics = append(batch.metrics, metric)
+			continue
+		}
+		index[id] = r
+		records = append(records, r)
+	}
+	return records
+}
+
+// id identifies the records with the same topic, routing key and headers.
+func (r *record) id() string {
+	var buf bytes.Buffer
+	buf.WriteString(r.topic)
+	buf.WriteByte(0)
+	if r.random {
+		buf.WriteByte(1)
+	}
+	buf.WriteString(r.key)
+	for _, h := range r.headers {
+		buf.WriteByte(0)
+		buf.Write(h.Key)
+		buf.WriteByte('=')
+		buf.Write(h.Value)
+	}
+	return buf.String()
+}
+
+func (k *Kafka) Write(metrics []telegraf.Metric) error {
+	records := k.records(metrics)
+	msgs := make([]*sarama.ProducerMessage, 0, len(records))
+	for _, r := range records {
+		var buf []byte
+		var err error
+		if k.UseBatchFormat {
+			buf, err = k.serializer.SerializeBatch(r.metrics)
+		} else {
+			buf, err = k.serializer.Serialize(r.metrics[0])
+		}
 		if err != nil {
 			return err
 		}
 
 		m := &sarama.ProducerMessage{
-			Topic: k.GetTopicName(metric),
-			Value: sarama.ByteEncoder(buf),
+			Topic:   r.topic,
+			Value:   sarama.ByteEncoder(buf),
+			Headers: r.headers,
+		}
+		key := r.key
+		if r.random {
+			key = uuid.NewV4().String()
 		}
-		key := k.routingKey(metric)
 		if key != "" {
 			m.Key = sarama.StringEncoder(key)
 		}
 		msgs = append(msgs, m)
 	}
 
+	if len(msgs) == 0 {
+		return nil
+	}
+
 	err := k.producer.SendMessages(msgs)
 	if err != nil {
-		// We could have many errors, return only the first encountered.
 		if errs, ok := err.(sarama.ProducerErrors); ok {
-			for _, prodErr := range errs {
-				if prodErr.Err == sarama.ErrMessageSizeTooLarge {
-					log.Printf("E! Error writing to output [kafka]: Message too large, consider increasing `max_message_bytes`; dropping batch")
-					return nil
-				}
-				return prodErr
-			}
+			return producerErrors(errs, len(msgs))
 		}
 		return err
 	}
@@ -326,6 +490,50 @@ func (k *Kafka) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
+// partition is a partition of a topic.
+type partition struct {
+	topic     string
+	partition int32
+}
+
+// producerErrors logs the errors of each partition, and returns an error
+// unless the failed messages would fail again.
+func producerErrors(errs sarama.ProducerErrors, total int) error {
+	var partitions []partition
+	partitionErrs := make(map[partition][]*sarama.ProducerError)
+	retryable := 0
+	for _, prodErr := range errs {
+		p := partition{topic: prodErr.Msg.Topic, partition: prodErr.Msg.Partition}
+		if _, ok := partitionErrs[p]; !ok {
+			partitions = append(partitions, p)
+		}
+		partitionErrs[p] = append(partitionErrs[p], prodErr)
+		if prodErr.Err != sarama.ErrMessageSizeTooLarge {
+			retryable++
+		}
+	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].topic != partitions[j].topic {
+			return partitions[i].topic < partitions[j].topic
+		}
+		return partitions[i].partition < partitions[j].partition
+	})
+	for _, p := range partitions {
+		pErrs := partitionErrs[p]
+		log.Printf("E! [outputs.kafka] Failed to deliver %d messages to topic %q partition %d: %v",
+			len(pErrs), p.topic, p.partition, pErrs[0].Err)
+	}
+
+	if retryable == 0 {
+		log.Printf("E! Error writing to output [kafka]: Message too large, consider increasing `max_message_bytes`; dropping batch")
+		return nil
+	}
+
+	return fmt.Errorf("failed to deliver %d of %d messages to %d partitions: %v",
+		len(errs), total, len(partitions), errs[0].Err)
+}
+
 func init() {
 	outputs.Add("kafka", func() telegraf.Output {
 		return &Kafka{
@@ -4,9 +4,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Shopify/sarama"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/plugins/serializers/template"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -155,3 +158,134 @@ func TestRoutingKey(t *testing.T) {
 		})
 	}
 }
+
+// fakeProducer records the messages, failing those of the partitions in
+// errs.
+type fakeProducer struct {
+	msgs []*sarama.ProducerMessage
+	errs map[int32]error
+}
+
+func (p *fakeProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	return 0, 0, p.SendMessages([]*sarama.ProducerMessage{msg})
+}
+
+func (p *fakeProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	var errs sarama.ProducerErrors
+	for i, msg := range msgs {
+		msg.Partition = int32(i % 2)
+		if err, ok := p.errs[msg.Partition]; ok {
+			errs = append(errs, &sarama.ProducerError{Msg: msg, Err: err})
+			continue
+		}
+		p.msgs = append(p.msgs, msg)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (p *fakeProducer) Close() error {
+	return nil
+}
+
+func newMetric(name string, tags map[string]string) telegraf.Metric {
+	m, err := metric.New(name, tags, map[string]interface{}{"value": 42.0}, time.Unix(0, 0))
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func messageValue(t *testing.T, msg *sarama.ProducerMessage) string {
+	b, err := msg.Value.Encode()
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestWriteTopicTemplateAndHeaders(t *testing.T) {
+	producer := &fakeProducer{}
+	k := &Kafka{
+		Topic:       `telegraf_{{ .Tag "env" }}`,
+		TopicSuffix: TopicSuffix{Method: "measurement", Separator: "."},
+		HeaderTags:  []string{"host", "missing"},
+		producer:    producer,
+		serializer:  influx.NewSerializer(),
+	}
+	var err error
+	k.topicTemplate, err = template.NewTemplate("topic", k.Topic)
+	require.NoError(t, err)
+
+	err = k.Write([]telegraf.Metric{
+		newMetric("cpu", map[string]string{"env": "prod", "host": "a"}),
+		newMetric("mem", map[string]string{"env": "dev"}),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, producer.msgs, 2)
+	require.Equal(t, "telegraf_prod.cpu", producer.msgs[0].Topic)
+	require.Equal(t, []sarama.RecordHeader{{Key: []byte("host"), Value: []byte("a")}},
+		producer.msgs[0].Headers)
+	require.Equal(t, "telegraf_dev.mem", producer.msgs[1].Topic)
+	require.Empty(t, producer.msgs[1].Headers)
+}
+
+func TestWriteBatchFormat(t *testing.T) {
+	producer := &fakeProducer{}
+	k := &Kafka{
+		Topic:          "telegraf",
+		RoutingTag:     "host",
+		UseBatchFormat: true,
+		producer:       producer,
+		serializer:     influx.NewSerializer(),
+	}
+
+	err := k.Write([]telegraf.Metric{
+		newMetric("cpu", map[string]string{"host": "a"}),
+		newMetric("cpu", map[string]string{"host": "b"}),
+		newMetric("mem", map[string]string{"host": "a"}),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, producer.msgs, 2)
+	key, err := producer.msgs[0].Key.Encode()
+	require.NoError(t, err)
+	require.Equal(t, "a", string(key))
+	require.Equal(t, "cpu,host=a value=42 0\nmem,host=a value=42 0\n", messageValue(t, producer.msgs[0]))
+	require.Equal(t, "cpu,host=b value=42 0\n", messageValue(t, producer.msgs[1]))
+}
+
+func TestWritePartitionErrors(t *testing.T) {
+	producer := &fakeProducer{errs: map[int32]error{1: sarama.ErrNotLeaderForPartition}}
+	k := &Kafka{
+		Topic:      "telegraf",
+		producer:   producer,
+		serializer: influx.NewSerializer(),
+	}
+
+	err := k.Write([]telegraf.Metric{
+		newMetric("cpu", nil),
+		newMetric("mem", nil),
+		newMetric("disk", nil),
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to deliver 1 of 3 messages to 1 partitions")
+
+	producer.errs = map[int32]error{1: sarama.ErrMessageSizeTooLarge}
+	err = k.Write([]telegraf.Metric{
+		newMetric("cpu", nil),
+		newMetric("mem", nil),
+	})
+	require.NoError(t, err)
+}
+
+func TestConnectHeaderTagsVersion(t *testing.T) {
+	k := &Kafka{
+		Brokers:    []string{"localhost:9092"},
+		Topic:      "telegraf",
+		HeaderTags: []string{"host"},
+		Version:    "0.10.2.0",
+	}
+	require.Error(t, k.Connect())
+}
@@ -0,0 +1,178 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: logproto.proto
+
+package logproto
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import timestamp "github.com/golang/protobuf/ptypes/timestamp"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type PushRequest struct {
+	Streams              []*StreamAdapter `protobuf:"bytes,1,rep,name=streams" json:"streams,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *PushRequest) Reset()         { *m = PushRequest{} }
+func (m *PushRequest) String() string { return proto.CompactTextString(m) }
+func (*PushRequest) ProtoMessage()    {}
+func (*PushRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_logproto_836329ac4dd63005, []int{0}
+}
+func (m *PushRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushRequest.Unmarshal(m, b)
+}
+func (m *PushRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushRequest.Marshal(b, m, deterministic)
+}
+func (dst *PushRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushRequest.Merge(dst, src)
+}
+func (m *PushRequest) XXX_Size() int {
+	return xxx_messageInfo_PushRequest.Size(m)
+}
+func (m *PushRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushRequest proto.InternalMessageInfo
+
+func (m *PushRequest) GetStreams() []*StreamAdapter {
+	if m != nil {
+		return m.Streams
+	}
+	return nil
+}
+
+type StreamAdapter struct {
+	// Labels of the stream in the Prometheus text format, such as
+	// {host="a", job="syslog"}.
+	Labels               string          `protobuf:"bytes,1,opt,name=labels" json:"labels,omitempty"`
+	Entries              []*EntryAdapter `protobuf:"bytes,2,rep,name=entries" json:"entries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *StreamAdapter) Reset()         { *m = StreamAdapter{} }
+func (m *StreamAdapter) String() string { return proto.CompactTextString(m) }
+func (*StreamAdapter) ProtoMessage()    {}
+func (*StreamAdapter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_logproto_836329ac4dd63005, []int{1}
+}
+func (m *StreamAdapter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StreamAdapter.Unmarshal(m, b)
+}
+func (m *StreamAdapter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StreamAdapter.Marshal(b, m, deterministic)
+}
+func (dst *StreamAdapter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamAdapter.Merge(dst, src)
+}
+func (m *StreamAdapter) XXX_Size() int {
+	return xxx_messageInfo_StreamAdapter.Size(m)
+}
+func (m *StreamAdapter) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamAdapter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamAdapter proto.InternalMessageInfo
+
+func (m *StreamAdapter) GetLabels() string {
+	if m != nil {
+		return m.Labels
+	}
+	return ""
+}
+
+func (m *StreamAdapter) GetEntries() []*EntryAdapter {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+type EntryAdapter struct {
+	Timestamp            *timestamp.Timestamp `protobuf:"bytes,1,opt,name=timestamp" json:"timestamp,omitempty"`
+	Line                 string               `protobuf:"bytes,2,opt,name=line" json:"line,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *EntryAdapter) Reset()         { *m = EntryAdapter{} }
+func (m *EntryAdapter) String() string { return proto.CompactTextString(m) }
+func (*EntryAdapter) ProtoMessage()    {}
+func (*EntryAdapter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_logproto_836329ac4dd63005, []int{2}
+}
+func (m *EntryAdapter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EntryAdapter.Unmarshal(m, b)
+}
+func (m *EntryAdapter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EntryAdapter.Marshal(b, m, deterministic)
+}
+func (dst *EntryAdapter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EntryAdapter.Merge(dst, src)
+}
+func (m *EntryAdapter) XXX_Size() int {
+	return xxx_messageInfo_EntryAdapter.Size(m)
+}
+func (m *EntryAdapter) XXX_DiscardUnknown() {
+	xxx_messageInfo_EntryAdapter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EntryAdapter proto.InternalMessageInfo
+
+func (m *EntryAdapter) GetTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.Timestamp
+	}
+	return nil
+}
+
+func (m *EntryAdapter) GetLine() string {
+	if m != nil {
+		return m.Line
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*PushRequest)(nil), "logproto.PushRequest")
+	proto.RegisterType((*StreamAdapter)(nil), "logproto.StreamAdapter")
+	proto.RegisterType((*EntryAdapter)(nil), "logproto.EntryAdapter")
+}
+
+func init() { proto.RegisterFile("logproto.proto", fileDescriptor_logproto_836329ac4dd63005) }
+
+var fileDescriptor_logproto_836329ac4dd63005 = []byte{
+	// 218 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x90, 0xb1, 0x4f, 0x84, 0x30,
+	0x14, 0xc6, 0x73, 0xa7, 0xb9, 0xf3, 0x1e, 0xea, 0xd0, 0xe1, 0x24, 0x2c, 0x1a, 0x26, 0xa6, 0xa2,
+	0xb8, 0x38, 0xaa, 0x89, 0xbb, 0xa9, 0x2e, 0x1a, 0x97, 0x12, 0x9f, 0x48, 0x52, 0x28, 0xb6, 0x8f,
+	0xc1, 0xff, 0xde, 0xf8, 0xa0, 0x45, 0x97, 0xb6, 0x5f, 0xbe, 0x5f, 0x7e, 0x79, 0xaf, 0x70, 0x6a,
+	0x6c, 0x33, 0x38, 0x4b, 0x56, 0xf2, 0x29, 0x8e, 0x42, 0xce, 0xce, 0x1b, 0x6b, 0x1b, 0x83, 0x25,
+	0xa7, 0x7a, 0xfc, 0x28, 0xa9, 0xed, 0xd0, 0x93, 0xee, 0x86, 0x09, 0xcd, 0x6f, 0x21, 0x79, 0x1c,
+	0xfd, 0xa7, 0xc2, 0xaf, 0x11, 0x3d, 0x89, 0x2b, 0xd8, 0x7a, 0x72, 0xa8, 0x3b, 0x9f, 0xae, 0x2e,
+	0x0e, 0x8a, 0xa4, 0x3a, 0x93, 0xd1, 0xfd, 0xc4, 0xc5, 0xdd, 0xbb, 0x1e, 0x08, 0x9d, 0x0a, 0x5c,
+	0xfe, 0x02, 0x27, 0xff, 0x1a, 0xb1, 0x87, 0x8d, 0xd1, 0x35, 0x9a, 0x5f, 0xc5, 0xaa, 0xd8, 0xa9,
+	0x39, 0x89, 0x4b, 0xd8, 0x62, 0x4f, 0xae, 0x45, 0x9f, 0xae, 0xd9, 0xbd, 0x5f, 0xdc, 0x0f, 0x3d,
+	0xb9, 0xef, 0xa8, 0x9e, 0xb1, 0xfc, 0x0d, 0x8e, 0xff, 0x16, 0xe2, 0x06, 0x76, 0x71, 0x7e, 0x96,
+	0x27, 0x55, 0x26, 0xa7, 0x0d, 0x65, 0xd8, 0x50, 0x3e, 0x07, 0x42, 0x2d, 0xb0, 0x10, 0x70, 0x68,
+	0xda, 0x1e, 0xd3, 0x35, 0x4f, 0xc4, 0xef, 0x7b, 0x78, 0x8d, 0xff, 0x54, 0x6f, 0xf8, 0xba, 0xfe,
+	0x19, 0x00, 0x70, 0xff, 0x61, 0xe3, 0x4a, 0x01, 0x00, 0x00,
+}
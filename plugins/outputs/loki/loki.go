@@ -0,0 +1,433 @@
+package loki
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/golang/snappy"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/outputs/loki/logproto"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const (
+	defaultURL              = "http://localhost:3100/loki/api/v1/push"
+	defaultTimeout          = 5 * time.Second
+	defaultMeasurementLabel = "measurement"
+
+	// tenantHeader is the header of the tenant of multi-tenant Loki
+	// deployments.
+	tenantHeader = "X-Scope-OrgID"
+)
+
+var sampleConfig = `
+  ## URL of the Loki push API.
+  # url = "http://localhost:3100/loki/api/v1/push"
+
+  ## Timeout for HTTP message
+  # timeout = "5s"
+
+  ## Encoding of the push requests, "json" or "protobuf".  Protobuf
+  ## requests are compressed with snappy as required by Loki.
+  # encoding = "json"
+
+  ## HTTP Content-Encoding for write request body, can be set to "gzip" to
+  ## compress body or "identity" to apply no encoding.
+  # content_encoding = "identity"
+
+  ## HTTP Basic Auth credentials
+  # username = "username"
+  # password = "pa$$word"
+
+  ## Tenant of the logs in multi-tenant Loki deployments, sent in the
+  ## X-Scope-OrgID header.
+  # tenant_id = ""
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Tags used as the labels of the log streams, metrics with the same
+  ## labels are pushed in the same stream.  Keep the number of distinct
+  ## label values low, the other tags are not sent unless the line is the
+  ## serialized metric.
+  # label_tags = ["host"]
+
+  ## Label set to the measurement name, set to an empty string to not add
+  ## the measurement name to the labels.  Loki requires at least one label,
+  ## it can only be empty with label_tags and the metrics without any of
+  ## these tags are still labeled with the measurement name.
+  # measurement_label = "measurement"
+
+  ## String field used as the log line, metrics without the field are
+  ## dropped.  When empty, the log line is the metric serialized with the
+  ## data format.
+  # line_field = "message"
+
+  ## Data format used to serialize the metrics when line_field is empty.
+  ## Each data format has it's own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  # data_format = "influx"
+
+  ## Additional HTTP headers
+  # [outputs.loki.headers]
+  #   key1 = "value1"
+`
+
+type Loki struct {
+	URL              string            `toml:"url"`
+	Timeout          internal.Duration `toml:"timeout"`
+	Encoding         string            `toml:"encoding"`
+	ContentEncoding  string            `toml:"content_encoding"`
+	Username         string            `toml:"username"`
+	Password         string            `toml:"password"`
+	TenantID         string            `toml:"tenant_id"`
+	Headers          map[string]string `toml:"headers"`
+	LabelTags        []string          `toml:"label_tags"`
+	MeasurementLabel string            `toml:"measurement_label"`
+	LineField        string            `toml:"line_field"`
+	tls.ClientConfig
+
+	client     *http.Client
+	serializer serializers.Serializer
+}
+
+// label is a label of a stream, with its name sanitized.
+type label struct {
+	name  string
+	value string
+}
+
+type entry struct {
+	time time.Time
+	line string
+}
+
+// stream is a log stream, the entries of the metrics with the same labels.
+type stream struct {
+	labels  []label
+	entries []entry
+}
+
+func (l *Loki) SetSerializer(serializer serializers.Serializer) {
+	l.serializer = serializer
+}
+
+func (l *Loki) SampleConfig() string {
+	return sampleConfig
+}
+
+func (l *Loki) Description() string {
+	return "Send log-like metrics to Loki"
+}
+
+func (l *Loki) Connect() error {
+	if l.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	switch l.Encoding {
+	case "":
+		l.Encoding = "json"
+	case "json", "protobuf":
+	default:
+		return fmt.Errorf("invalid encoding %q", l.Encoding)
+	}
+
+	if l.LineField == "" && l.serializer == nil {
+		return fmt.Errorf("line_field or a data format is required")
+	}
+
+	if l.MeasurementLabel == "" && len(l.LabelTags) == 0 {
+		return fmt.Errorf("measurement_label or label_tags is required")
+	}
+
+	if l.Timeout.Duration == 0 {
+		l.Timeout.Duration = defaultTimeout
+	}
+
+	tlsCfg, err := l.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	l.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+		Timeout: l.Timeout.Duration,
+	}
+	return nil
+}
+
+func (l *Loki) Close() error {
+	return nil
+}
+
+// Write pushes the metrics in a single request, grouped by stream.
+func (l *Loki) Write(metrics []telegraf.Metric) error {
+	streams := l.streams(metrics)
+	if len(streams) == 0 {
+		return nil
+	}
+
+	var body []byte
+	var contentType string
+	var err error
+	if l.Encoding == "protobuf" {
+		body, err = encodeProtobuf(streams)
+		contentType = "application/x-protobuf"
+	} else {
+		body, err = encodeJSON(streams)
+		contentType = "application/json"
+	}
+	if err != nil {
+		return err
+	}
+
+	return l.push(body, contentType)
+}
+
+// streams groups the log entries of the metrics by labels, in the order of
+// the first entry of the streams.  The entries of the streams are sorted by
+// time, as expected by Loki.
+func (l *Loki) streams(metrics []telegraf.Metric) []*stream {
+	var streams []*stream
+	index := make(map[string]*stream)
+
+	for _, m := range metrics {
+		line, ok := l.line(m)
+		if !ok {
+			continue
+		}
+
+		labels := l.labels(m)
+		key := labelsString(labels)
+		s, ok := index[key]
+		if !ok {
+			s = &stream{labels: labels}
+			index[key] = s
+			streams = append(streams, s)
+		}
+		s.entries = append(s.entries, entry{time: m.Time(), line: line})
+	}
+
+	for _, s := range streams {
+		entries := s.entries
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].time.Before(entries[j].time)
+		})
+	}
+	return streams
+}
+
+// line returns the log line of the metric, false if the metric has no line.
+func (l *Loki) line(m telegraf.Metric) (string, bool) {
+	if l.LineField != "" {
+		v, ok := m.GetField(l.LineField)
+		if !ok {
+			log.Printf("D! [outputs.loki] Dropping metric %q without field %q", m.Name(), l.LineField)
+			return "", false
+		}
+		line, ok := v.(string)
+		if !ok {
+			log.Printf("D! [outputs.loki] Dropping metric %q, field %q is not a string", m.Name(), l.LineField)
+			return "", false
+		}
+		return line, true
+	}
+
+	b, err := l.serializer.Serialize(m)
+	if err != nil {
+		log.Printf("D! [outputs.loki] Could not serialize metric: %v", err)
+		return "", false
+	}
+	return strings.TrimRight(string(b), "\n"), true
+}
+
+// labels returns the labels of the stream of the metric, sorted by name.
+// Metrics without any label are labeled with the measurement name, as Loki
+// rejects streams without labels.
+func (l *Loki) labels(m telegraf.Metric) []label {
+	labels := make([]label, 0, len(l.LabelTags)+1)
+	if l.MeasurementLabel != "" {
+		labels = append(labels, label{name: sanitize(l.MeasurementLabel), value: m.Name()})
+	}
+	for _, key := range l.LabelTags {
+		if value, ok := m.GetTag(key); ok {
+			labels = append(labels, label{name: sanitize(key), value: value})
+		}
+	}
+	if len(labels) == 0 {
+		labels = append(labels, label{name: defaultMeasurementLabel, value: m.Name()})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].name < labels[j].name
+	})
+	return labels
+}
+
+func (l *Loki) push(body []byte, contentType string) error {
+	var reqBody io.Reader = bytes.NewBuffer(body)
+
+	var err error
+	if l.ContentEncoding == "gzip" {
+		reqBody, err = internal.CompressWithGzip(reqBody)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest("POST", l.URL, reqBody)
+	if err != nil {
+		return err
+	}
+
+	if l.Username != "" || l.Password != "" {
+		req.SetBasicAuth(l.Username, l.Password)
+	}
+
+	req.Header.Set("User-Agent", "Telegraf/"+internal.Version())
+	req.Header.Set("Content-Type", contentType)
+	if l.ContentEncoding == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if l.TenantID != "" {
+		req.Header.Set(tenantHeader, l.TenantID)
+	}
+	for k, v := range l.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("when writing to [%s] received status code %d: %s",
+			l.URL, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	return nil
+}
+
+type jsonPushRequest struct {
+	Streams []jsonStream `json:"streams"`
+}
+
+type jsonStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// encodeJSON encodes the streams as a push request of the JSON API, with
+// the times of the entries as strings of Unix nanoseconds.
+func encodeJSON(streams []*stream) ([]byte, error) {
+	req := jsonPushRequest{Streams: make([]jsonStream, 0, len(streams))}
+	for _, s := range streams {
+		js := jsonStream{
+			Stream: make(map[string]string, len(s.labels)),
+			Values: make([][2]string, 0, len(s.entries)),
+		}
+		for _, l := range s.labels {
+			js.Stream[l.name] = l.value
+		}
+		for _, e := range s.entries {
+			js.Values = append(js.Values, [2]string{strconv.FormatInt(e.time.UnixNano(), 10), e.line})
+		}
+		req.Streams = append(req.Streams, js)
+	}
+	return json.Marshal(req)
+}
+
+// encodeProtobuf encodes the streams as a snappy compressed push request.
+func encodeProtobuf(streams []*stream) ([]byte, error) {
+	req := &logproto.PushRequest{Streams: make([]*logproto.StreamAdapter, 0, len(streams))}
+	for _, s := range streams {
+		ps := &logproto.StreamAdapter{
+			Labels:  labelsString(s.labels),
+			Entries: make([]*logproto.EntryAdapter, 0, len(s.entries)),
+		}
+		for _, e := range s.entries {
+			ps.Entries = append(ps.Entries, &logproto.EntryAdapter{
+				Timestamp: &timestamp.Timestamp{
+					Seconds: e.time.Unix(),
+					Nanos:   int32(e.time.Nanosecond()),
+				},
+				Line: e.line,
+			})
+		}
+		req.Streams = append(req.Streams, ps)
+	}
+
+	b, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, b), nil
+}
+
+// labelsString formats the labels like Prometheus, such as
+// {host="a", measurement="syslog"}.
+func labelsString(labels []label) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(l.name)
+		buf.WriteByte('=')
+		buf.WriteString(strconv.Quote(l.value))
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// sanitize replaces the characters not allowed in label names with
+// underscores.
+func sanitize(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+func init() {
+	outputs.Add("loki", func() telegraf.Output {
+		return &Loki{
+			URL:              defaultURL,
+			Timeout:          internal.Duration{Duration: defaultTimeout},
+			Encoding:         "json",
+			MeasurementLabel: defaultMeasurementLabel,
+		}
+	})
+}
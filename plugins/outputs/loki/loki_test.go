@@ -0,0 +1,174 @@
+package loki
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/outputs/loki/logproto"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/stretchr/testify/require"
+)
+
+func getMetrics() []telegraf.Metric {
+	newMetric := func(tags map[string]string, message string, t int64) telegraf.Metric {
+		m, err := metric.New("syslog", tags,
+			map[string]interface{}{"message": message, "severity_code": int64(6)},
+			time.Unix(t, 0))
+		if err != nil {
+			panic(err)
+		}
+		return m
+	}
+	return []telegraf.Metric{
+		newMetric(map[string]string{"host": "a", "appname": "sshd"}, "second", 2),
+		newMetric(map[string]string{"host": "b", "appname": "cron"}, "other", 1),
+		newMetric(map[string]string{"host": "a", "appname": "cron"}, "first", 1),
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var body []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		require.Equal(t, "team-a", r.Header.Get("X-Scope-OrgID"))
+		username, password, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user", username)
+		require.Equal(t, "secret", password)
+
+		gz, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, err = ioutil.ReadAll(gz)
+		require.NoError(t, err)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	plugin := &Loki{
+		URL:              ts.URL + "/loki/api/v1/push",
+		ContentEncoding:  "gzip",
+		Username:         "user",
+		Password:         "secret",
+		TenantID:         "team-a",
+		LabelTags:        []string{"host"},
+		MeasurementLabel: "measurement",
+		LineField:        "message",
+	}
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write(getMetrics()))
+
+	var req jsonPushRequest
+	require.NoError(t, json.Unmarshal(body, &req))
+	require.Equal(t, jsonPushRequest{Streams: []jsonStream{
+		{
+			Stream: map[string]string{"host": "a", "measurement": "syslog"},
+			Values: [][2]string{
+				{"1000000000", "first"},
+				{"2000000000", "second"},
+			},
+		},
+		{
+			Stream: map[string]string{"host": "b", "measurement": "syslog"},
+			Values: [][2]string{{"1000000000", "other"}},
+		},
+	}}, req)
+}
+
+func TestWriteProtobuf(t *testing.T) {
+	var req logproto.PushRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		b, err := snappy.Decode(nil, body)
+		require.NoError(t, err)
+		require.NoError(t, proto.Unmarshal(b, &req))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	plugin := &Loki{
+		URL:       ts.URL,
+		Encoding:  "protobuf",
+		LabelTags: []string{"appname", "host"},
+	}
+	serializer := influx.NewSerializer()
+	plugin.SetSerializer(serializer)
+	require.NoError(t, plugin.Connect())
+	metrics := getMetrics()[:2]
+	require.NoError(t, plugin.Write(metrics))
+
+	// The order of the fields depends on the creation of the metric.
+	line, err := serializer.Serialize(metrics[0])
+	require.NoError(t, err)
+
+	require.Len(t, req.Streams, 2)
+	require.Equal(t, `{appname="sshd", host="a"}`, req.Streams[0].Labels)
+	require.Len(t, req.Streams[0].Entries, 1)
+	require.Equal(t, int64(2), req.Streams[0].Entries[0].Timestamp.Seconds)
+	require.Equal(t, strings.TrimRight(string(line), "\n"), req.Streams[0].Entries[0].Line)
+	require.Contains(t, req.Streams[0].Entries[0].Line, `message="second"`)
+	require.Equal(t, `{appname="cron", host="b"}`, req.Streams[1].Labels)
+}
+
+func TestWriteError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "entry out of order", http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	plugin := &Loki{URL: ts.URL, LineField: "message", MeasurementLabel: "measurement"}
+	require.NoError(t, plugin.Connect())
+	err := plugin.Write(getMetrics())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "entry out of order")
+}
+
+func TestWriteNoLine(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	}))
+	defer ts.Close()
+
+	plugin := &Loki{URL: ts.URL, LineField: "severity_code", MeasurementLabel: "measurement"}
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write(getMetrics()))
+}
+
+func TestConnectErrors(t *testing.T) {
+	plugin := &Loki{LineField: "message"}
+	require.Error(t, plugin.Connect())
+
+	plugin = &Loki{URL: defaultURL, Encoding: "xml", LineField: "message"}
+	require.Error(t, plugin.Connect())
+
+	plugin = &Loki{URL: defaultURL}
+	require.Error(t, plugin.Connect())
+
+	plugin = &Loki{URL: defaultURL, LineField: "message"}
+	require.Error(t, plugin.Connect())
+}
+
+func TestLabelsFallback(t *testing.T) {
+	plugin := &Loki{LabelTags: []string{"facility"}}
+	require.Equal(t, []label{{name: "measurement", value: "syslog"}}, plugin.labels(getMetrics()[0]))
+}
+
+func TestSanitize(t *testing.T) {
+	require.Equal(t, "host", sanitize("host"))
+	require.Equal(t, "service_name", sanitize("service.name"))
+	require.Equal(t, "_xx", sanitize("0xx"))
+	require.Equal(t, "a1", sanitize("a1"))
+}
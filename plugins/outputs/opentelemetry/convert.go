@@ -0,0 +1,319 @@
+package opentelemetry
+
+import (
+	"log"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/otlp/collector"
+	"github.com/influxdata/telegraf/internal/otlp/common"
+	"github.com/influxdata/telegraf/internal/otlp/metrics"
+	"github.com/influxdata/telegraf/internal/otlp/resource"
+)
+
+// Tags of the instrumentation scope of the metrics, as set by the
+// opentelemetry input.
+const (
+	scopeNameTag    = "otel.scope.name"
+	scopeVersionTag = "otel.scope.version"
+)
+
+type scopeKey struct {
+	name    string
+	version string
+}
+
+type metricKey struct {
+	name      string
+	valueType telegraf.ValueType
+}
+
+// scope collects the OTLP metrics of an instrumentation scope, merging the
+// data points of the metrics with the same name and type.
+type scope struct {
+	metrics *metrics.ScopeMetrics
+	index   map[metricKey]*metrics.Metric
+}
+
+// exportRequest converts the metrics to an OTLP export request.  The fields
+// are converted like the prometheus_client output: each numeric field of
+// counters, gauges and untyped metrics is a metric named after the
+// measurement and the field, histograms and summaries have a field per
+// bucket or quantile.
+func (o *OpenTelemetry) exportRequest(ms []telegraf.Metric) *collector.ExportMetricsServiceRequest {
+	var scopes []*scope
+	scopeIndex := make(map[scopeKey]*scope)
+
+	for _, m := range ms {
+		name, _ := m.GetTag(scopeNameTag)
+		version, _ := m.GetTag(scopeVersionTag)
+		key := scopeKey{name: name, version: version}
+		s, ok := scopeIndex[key]
+		if !ok {
+			s = &scope{
+				metrics: &metrics.ScopeMetrics{
+					Scope: &common.InstrumentationScope{Name: name, Version: version},
+				},
+				index: make(map[metricKey]*metrics.Metric),
+			}
+			scopeIndex[key] = s
+			scopes = append(scopes, s)
+		}
+
+		o.addMetric(s, m)
+	}
+
+	rm := &metrics.ResourceMetrics{
+		Resource: &resource.Resource{Attributes: o.resourceAttributes()},
+	}
+	for _, s := range scopes {
+		if len(s.metrics.Metrics) > 0 {
+			rm.ScopeMetrics = append(rm.ScopeMetrics, s.metrics)
+		}
+	}
+
+	req := &collector.ExportMetricsServiceRequest{}
+	if len(rm.ScopeMetrics) > 0 {
+		req.ResourceMetrics = []*metrics.ResourceMetrics{rm}
+	}
+	return req
+}
+
+func (o *OpenTelemetry) resourceAttributes() []*common.KeyValue {
+	keys := make([]string, 0, len(o.Attributes))
+	for k := range o.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]*common.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attributes = append(attributes, stringAttribute(k, o.Attributes[k]))
+	}
+	return attributes
+}
+
+// addMetric adds the data points of the metric to the scope.
+func (o *OpenTelemetry) addMetric(s *scope, m telegraf.Metric) {
+	attributes := make([]*common.KeyValue, 0, len(m.TagList()))
+	for _, tag := range m.TagList() {
+		if tag.Key == scopeNameTag || tag.Key == scopeVersionTag {
+			continue
+		}
+		attributes = append(attributes, stringAttribute(tag.Key, tag.Value))
+	}
+	timeUnixNano := uint64(m.Time().UnixNano())
+	startTimeUnixNano := uint64(o.startTime.UnixNano())
+
+	switch m.Type() {
+	case telegraf.Histogram:
+		dp := histogramDataPoint(m)
+		if dp == nil {
+			return
+		}
+		dp.Attributes = attributes
+		dp.StartTimeUnixNano = startTimeUnixNano
+		dp.TimeUnixNano = timeUnixNano
+
+		metric := s.metric(m.Name(), telegraf.Histogram, func() *metrics.Metric {
+			return &metrics.Metric{Data: &metrics.Metric_Histogram{Histogram: &metrics.Histogram{
+				AggregationTemporality: metrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			}}}
+		})
+		h := metric.GetHistogram()
+		h.DataPoints = append(h.DataPoints, dp)
+	case telegraf.Summary:
+		dp := summaryDataPoint(m)
+		if dp == nil {
+			return
+		}
+		dp.Attributes = attributes
+		dp.StartTimeUnixNano = startTimeUnixNano
+		dp.TimeUnixNano = timeUnixNano
+
+		metric := s.metric(m.Name(), telegraf.Summary, func() *metrics.Metric {
+			return &metrics.Metric{Data: &metrics.Metric_Summary{Summary: &metrics.Summary{}}}
+		})
+		summary := metric.GetSummary()
+		summary.DataPoints = append(summary.DataPoints, dp)
+	default:
+		for _, field := range m.FieldList() {
+			dp := &metrics.NumberDataPoint{
+				Attributes:   attributes,
+				TimeUnixNano: timeUnixNano,
+			}
+			switch v := field.Value.(type) {
+			case int64:
+				dp.Value = &metrics.NumberDataPoint_AsInt{AsInt: v}
+			case uint64:
+				if v <= math.MaxInt64 {
+					dp.Value = &metrics.NumberDataPoint_AsInt{AsInt: int64(v)}
+				} else {
+					dp.Value = &metrics.NumberDataPoint_AsDouble{AsDouble: float64(v)}
+				}
+			case float64:
+				dp.Value = &metrics.NumberDataPoint_AsDouble{AsDouble: v}
+			default:
+				// Strings and booleans have no OTLP representation.
+				continue
+			}
+
+			if m.Type() == telegraf.Counter {
+				dp.StartTimeUnixNano = startTimeUnixNano
+				name := metricName(m.Name(), field.Key, "counter")
+				metric := s.metric(name, telegraf.Counter, func() *metrics.Metric {
+					return &metrics.Metric{Data: &metrics.Metric_Sum{Sum: &metrics.Sum{
+						AggregationTemporality: metrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+						IsMonotonic:            true,
+					}}}
+				})
+				sum := metric.GetSum()
+				sum.DataPoints = append(sum.DataPoints, dp)
+			} else {
+				name := metricName(m.Name(), field.Key, "gauge")
+				metric := s.metric(name, telegraf.Gauge, func() *metrics.Metric {
+					return &metrics.Metric{Data: &metrics.Metric_Gauge{Gauge: &metrics.Gauge{}}}
+				})
+				gauge := metric.GetGauge()
+				gauge.DataPoints = append(gauge.DataPoints, dp)
+			}
+		}
+	}
+}
+
+// metric returns the metric of the scope with the name and type, created
+// by newMetric if needed.
+func (s *scope) metric(name string, valueType telegraf.ValueType, newMetric func() *metrics.Metric) *metrics.Metric {
+	key := metricKey{name: name, valueType: valueType}
+	if metric, ok := s.index[key]; ok {
+		return metric
+	}
+	metric := newMetric()
+	metric.Name = name
+	s.index[key] = metric
+	s.metrics.Metrics = append(s.metrics.Metrics, metric)
+	return metric
+}
+
+// metricName returns the name of the metric of a field, the measurement
+// name for the "value" field and the field named after the type.
+func metricName(measurement, field, typeField string) string {
+	if field == "value" || field == typeField {
+		return measurement
+	}
+	return measurement + "_" + field
+}
+
+// histogramDataPoint converts the count, sum, min, max and cumulative bucket
+// fields of a histogram.  Histograms whose bucket counts decrease, or exceed
+// the count, are dropped.
+func histogramDataPoint(m telegraf.Metric) *metrics.HistogramDataPoint {
+	dp := &metrics.HistogramDataPoint{}
+	cumulative := make(map[float64]uint64)
+	var hasCount bool
+	for _, field := range m.FieldList() {
+		v, ok := toFloat(field.Value)
+		if !ok {
+			continue
+		}
+		switch field.Key {
+		case "count":
+			dp.Count = uint64(v)
+			hasCount = true
+		case "sum":
+			dp.XSum = &metrics.HistogramDataPoint_Sum{Sum: v}
+		case "min":
+			dp.XMin = &metrics.HistogramDataPoint_Min{Min: v}
+		case "max":
+			dp.XMax = &metrics.HistogramDataPoint_Max{Max: v}
+		default:
+			bound, err := strconv.ParseFloat(field.Key, 64)
+			if err != nil || math.IsInf(bound, 1) {
+				continue
+			}
+			cumulative[bound] = uint64(v)
+		}
+	}
+	if !hasCount {
+		return nil
+	}
+
+	for bound := range cumulative {
+		dp.ExplicitBounds = append(dp.ExplicitBounds, bound)
+	}
+	sort.Float64s(dp.ExplicitBounds)
+
+	var previous uint64
+	for _, bound := range dp.ExplicitBounds {
+		if cumulative[bound] < previous {
+			log.Printf("W! [outputs.opentelemetry] Dropping histogram %q, its buckets are not cumulative", m.Name())
+			return nil
+		}
+		dp.BucketCounts = append(dp.BucketCounts, cumulative[bound]-previous)
+		previous = cumulative[bound]
+	}
+	if dp.Count < previous {
+		log.Printf("W! [outputs.opentelemetry] Dropping histogram %q, its count is lower than its buckets", m.Name())
+		return nil
+	}
+	dp.BucketCounts = append(dp.BucketCounts, dp.Count-previous)
+	return dp
+}
+
+// summaryDataPoint converts the count, sum and quantile fields of a summary.
+func summaryDataPoint(m telegraf.Metric) *metrics.SummaryDataPoint {
+	dp := &metrics.SummaryDataPoint{}
+	var hasCount bool
+	for _, field := range m.FieldList() {
+		v, ok := toFloat(field.Value)
+		if !ok {
+			continue
+		}
+		switch field.Key {
+		case "count":
+			dp.Count = uint64(v)
+			hasCount = true
+		case "sum":
+			dp.Sum = v
+		default:
+			quantile, err := strconv.ParseFloat(field.Key, 64)
+			if err != nil {
+				continue
+			}
+			dp.QuantileValues = append(dp.QuantileValues, &metrics.SummaryDataPoint_ValueAtQuantile{
+				Quantile: quantile,
+				Value:    v,
+			})
+		}
+	}
+	if !hasCount {
+		return nil
+	}
+
+	sort.Slice(dp.QuantileValues, func(i, j int) bool {
+		return dp.QuantileValues[i].Quantile < dp.QuantileValues[j].Quantile
+	})
+	return dp
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func stringAttribute(key, value string) *common.KeyValue {
+	return &common.KeyValue{
+		Key:   key,
+		Value: &common.AnyValue{Value: &common.AnyValue_StringValue{StringValue: value}},
+	}
+}
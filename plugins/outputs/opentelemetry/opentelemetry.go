@@ -0,0 +1,143 @@
+package opentelemetry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/otlp/collector"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+)
+
+type OpenTelemetry struct {
+	ServiceAddress string            `toml:"service_address"`
+	Timeout        internal.Duration `toml:"timeout"`
+	Compression    string            `toml:"compression"`
+	Headers        map[string]string `toml:"headers"`
+	Attributes     map[string]string `toml:"attributes"`
+	tls.ClientConfig
+
+	conn      *grpc.ClientConn
+	client    collector.MetricsServiceClient
+	callOpts  []grpc.CallOption
+	startTime time.Time
+}
+
+var sampleConfig = `
+  ## OTLP/gRPC address of the OpenTelemetry collector.
+  # service_address = "localhost:4317"
+
+  ## Timeout of an export.
+  # timeout = "5s"
+
+  ## Compression of the exports, can be "gzip" or "none".
+  # compression = "gzip"
+
+  ## Optional TLS Config.
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Additional gRPC request metadata, such as authentication headers.
+  # [outputs.opentelemetry.headers]
+  #   key1 = "value1"
+
+  ## Attributes of the resource the metrics are exported for.
+  # [outputs.opentelemetry.attributes]
+  #   "service.name" = "telegraf"
+`
+
+func (o *OpenTelemetry) SampleConfig() string {
+	return sampleConfig
+}
+
+func (o *OpenTelemetry) Description() string {
+	return "Send metrics to an OpenTelemetry collector over OTLP/gRPC"
+}
+
+func (o *OpenTelemetry) Connect() error {
+	if o.ServiceAddress == "" {
+		return errors.New("service_address is required")
+	}
+
+	switch o.Compression {
+	case "", "none":
+	case "gzip":
+		o.callOpts = append(o.callOpts, grpc.UseCompressor(gzip.Name))
+	default:
+		return fmt.Errorf("unsupported compression %q", o.Compression)
+	}
+
+	tlsConfig, err := o.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	var dialOpt grpc.DialOption
+	if tlsConfig != nil {
+		dialOpt = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	} else {
+		dialOpt = grpc.WithInsecure()
+	}
+
+	// The connection is established in the background and retried when
+	// lost, exports fail meanwhile.
+	conn, err := grpc.Dial(o.ServiceAddress, dialOpt)
+	if err != nil {
+		return err
+	}
+
+	o.conn = conn
+	o.client = collector.NewMetricsServiceClient(conn)
+	o.startTime = time.Now()
+	return nil
+}
+
+func (o *OpenTelemetry) Close() error {
+	if o.conn == nil {
+		return nil
+	}
+	err := o.conn.Close()
+	o.conn = nil
+	return err
+}
+
+// Write exports the metrics in a single request.
+func (o *OpenTelemetry) Write(metrics []telegraf.Metric) error {
+	req := o.exportRequest(metrics)
+	if len(req.GetResourceMetrics()) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.Timeout.Duration)
+	defer cancel()
+	if len(o.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(o.Headers))
+	}
+
+	_, err := o.client.Export(ctx, req, o.callOpts...)
+	if err != nil {
+		return fmt.Errorf("export to %s failed: %v", o.ServiceAddress, err)
+	}
+	return nil
+}
+
+func init() {
+	outputs.Add("opentelemetry", func() telegraf.Output {
+		return &OpenTelemetry{
+			ServiceAddress: "localhost:4317",
+			Timeout:        internal.Duration{Duration: 5 * time.Second},
+			Compression:    "gzip",
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package opentelemetry
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/otlp/collector"
+	"github.com/influxdata/telegraf/internal/otlp/metrics"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var ts = time.Unix(1538395200, 0)
+
+type fakeCollector struct {
+	requests chan *collector.ExportMetricsServiceRequest
+	headers  chan metadata.MD
+}
+
+func (c *fakeCollector) Export(ctx context.Context, req *collector.ExportMetricsServiceRequest) (*collector.ExportMetricsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.headers <- md
+	c.requests <- req
+	return &collector.ExportMetricsServiceResponse{}, nil
+}
+
+func startCollector(t *testing.T) (*fakeCollector, string, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	c := &fakeCollector{
+		requests: make(chan *collector.ExportMetricsServiceRequest, 1),
+		headers:  make(chan metadata.MD, 1),
+	}
+	server := grpc.NewServer()
+	collector.RegisterMetricsServiceServer(server, c)
+	go server.Serve(listener)
+	return c, listener.Addr().String(), server.Stop
+}
+
+func newMetric(name string, tags map[string]string, fields map[string]interface{}, tp telegraf.ValueType) telegraf.Metric {
+	m, err := metric.New(name, tags, fields, ts, tp)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestWrite(t *testing.T) {
+	c, addr, stop := startCollector(t)
+	defer stop()
+
+	o := &OpenTelemetry{
+		ServiceAddress: addr,
+		Timeout:        internal.Duration{Duration: 5 * time.Second},
+		Compression:    "gzip",
+		Headers:        map[string]string{"authorization": "Bearer token"},
+		Attributes:     map[string]string{"service.name": "telegraf"},
+	}
+	require.NoError(t, o.Connect())
+	defer o.Close()
+
+	err := o.Write([]telegraf.Metric{
+		newMetric("cpu", map[string]string{"host": "a"},
+			map[string]interface{}{"usage_idle": 90.5}, telegraf.Untyped),
+	})
+	require.NoError(t, err)
+
+	md := <-c.headers
+	require.Equal(t, []string{"Bearer token"}, md.Get("authorization"))
+
+	req := <-c.requests
+	require.Len(t, req.ResourceMetrics, 1)
+	rm := req.ResourceMetrics[0]
+	require.Equal(t, "service.name", rm.Resource.Attributes[0].Key)
+	require.Equal(t, "telegraf", rm.Resource.Attributes[0].Value.GetStringValue())
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+
+	m := rm.ScopeMetrics[0].Metrics[0]
+	require.Equal(t, "cpu_usage_idle", m.Name)
+	dp := m.GetGauge().DataPoints[0]
+	require.Equal(t, 90.5, dp.GetAsDouble())
+	require.Equal(t, uint64(ts.UnixNano()), dp.TimeUnixNano)
+	require.Equal(t, "host", dp.Attributes[0].Key)
+	require.Equal(t, "a", dp.Attributes[0].Value.GetStringValue())
+}
+
+func TestWriteUnavailable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	o := &OpenTelemetry{
+		ServiceAddress: addr,
+		Timeout:        internal.Duration{Duration: 100 * time.Millisecond},
+	}
+	require.NoError(t, o.Connect())
+	defer o.Close()
+
+	err = o.Write([]telegraf.Metric{
+		newMetric("cpu", nil, map[string]interface{}{"value": 1.0}, telegraf.Gauge),
+	})
+	require.Error(t, err)
+}
+
+func TestConnectErrors(t *testing.T) {
+	o := &OpenTelemetry{}
+	require.Error(t, o.Connect())
+
+	o = &OpenTelemetry{ServiceAddress: "localhost:4317", Compression: "lz4"}
+	require.Error(t, o.Connect())
+}
+
+func TestExportRequest(t *testing.T) {
+	o := &OpenTelemetry{startTime: ts.Add(-time.Minute)}
+	scopeTags := map[string]string{
+		"otel.scope.name":    "io.opentelemetry.runtime",
+		"otel.scope.version": "1.0",
+		"pool":               "heap",
+	}
+
+	req := o.exportRequest([]telegraf.Metric{
+		newMetric("memory", scopeTags, map[string]interface{}{"gauge": int64(1024)}, telegraf.Gauge),
+		newMetric("requests", nil, map[string]interface{}{"counter": uint64(42)}, telegraf.Counter),
+		newMetric("requests", map[string]string{"code": "500"},
+			map[string]interface{}{"counter": uint64(3)}, telegraf.Counter),
+		newMetric("latency", nil, map[string]interface{}{
+			"count": 6.0,
+			"sum":   12.5,
+			"1":     3.0,
+			"0.5":   1.0,
+			"+Inf":  6.0,
+		}, telegraf.Histogram),
+		newMetric("duration", nil, map[string]interface{}{
+			"count": 10.0,
+			"sum":   20.0,
+			"0.99":  4.0,
+			"0.5":   1.5,
+		}, telegraf.Summary),
+		newMetric("status", nil, map[string]interface{}{"state": "ok", "up": true}, telegraf.Untyped),
+	})
+
+	require.Len(t, req.ResourceMetrics, 1)
+	scopes := req.ResourceMetrics[0].ScopeMetrics
+	require.Len(t, scopes, 2)
+
+	require.Equal(t, "io.opentelemetry.runtime", scopes[0].Scope.Name)
+	require.Equal(t, "1.0", scopes[0].Scope.Version)
+	require.Len(t, scopes[0].Metrics, 1)
+	memory := scopes[0].Metrics[0]
+	require.Equal(t, "memory", memory.Name)
+	require.Len(t, memory.GetGauge().DataPoints, 1)
+	require.Equal(t, int64(1024), memory.GetGauge().DataPoints[0].GetAsInt())
+	require.Len(t, memory.GetGauge().DataPoints[0].Attributes, 1)
+	require.Equal(t, "pool", memory.GetGauge().DataPoints[0].Attributes[0].Key)
+
+	require.Equal(t, "", scopes[1].Scope.Name)
+	require.Len(t, scopes[1].Metrics, 3)
+
+	requests := scopes[1].Metrics[0]
+	require.Equal(t, "requests", requests.Name)
+	sum := requests.GetSum()
+	require.True(t, sum.IsMonotonic)
+	require.Equal(t, metrics.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE, sum.AggregationTemporality)
+	require.Len(t, sum.DataPoints, 2)
+	require.Equal(t, int64(42), sum.DataPoints[0].GetAsInt())
+	require.Equal(t, uint64(ts.Add(-time.Minute).UnixNano()), sum.DataPoints[0].StartTimeUnixNano)
+
+	latency := scopes[1].Metrics[1]
+	require.Equal(t, "latency", latency.Name)
+	hdp := latency.GetHistogram().DataPoints[0]
+	require.Equal(t, uint64(6), hdp.Count)
+	require.Equal(t, 12.5, hdp.GetSum())
+	require.Equal(t, []float64{0.5, 1}, hdp.ExplicitBounds)
+	require.Equal(t, []uint64{1, 2, 3}, hdp.BucketCounts)
+
+	duration := scopes[1].Metrics[2]
+	require.Equal(t, "duration", duration.Name)
+	sdp := duration.GetSummary().DataPoints[0]
+	require.Equal(t, uint64(10), sdp.Count)
+	require.Equal(t, 20.0, sdp.Sum)
+	require.Len(t, sdp.QuantileValues, 2)
+	require.Equal(t, 0.5, sdp.QuantileValues[0].Quantile)
+	require.Equal(t, 1.5, sdp.QuantileValues[0].Value)
+	require.Equal(t, 0.99, sdp.QuantileValues[1].Quantile)
+}
+
+func TestExportRequestHistogramNotCumulative(t *testing.T) {
+	o := &OpenTelemetry{}
+	req := o.exportRequest([]telegraf.Metric{
+		newMetric("latency", nil, map[string]interface{}{
+			"count": 2.0,
+			"0.5":   1.0,
+			"1":     3.0,
+		}, telegraf.Histogram),
+		newMetric("latency", nil, map[string]interface{}{
+			"count": 2.0,
+			"0.5":   3.0,
+			"1":     1.0,
+		}, telegraf.Histogram),
+	})
+	require.Empty(t, req.ResourceMetrics)
+}
+
+func TestExportRequestEmpty(t *testing.T) {
+	o := &OpenTelemetry{}
+	req := o.exportRequest([]telegraf.Metric{
+		newMetric("status", nil, map[string]interface{}{"state": "ok"}, telegraf.Untyped),
+	})
+	require.Empty(t, req.ResourceMetrics)
+}
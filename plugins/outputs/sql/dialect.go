@@ -0,0 +1,106 @@
+package sql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ColumnTypes are the SQL types of the columns created for each kind of
+// value.
+type ColumnTypes struct {
+	Integer   string `toml:"integer"`
+	Unsigned  string `toml:"unsigned"`
+	Real      string `toml:"real"`
+	Text      string `toml:"text"`
+	Bool      string `toml:"bool"`
+	Timestamp string `toml:"timestamp"`
+}
+
+// dialect holds the differences in the SQL accepted by the databases.
+type dialect struct {
+	// quote and unquote are the characters around identifiers.
+	quote   string
+	unquote string
+
+	// placeholder returns the placeholder of the n-th argument, starting at 1.
+	placeholder func(n int) string
+
+	addColumnTemplate string
+	types             ColumnTypes
+
+	// unsigned is set when the driver accepts uint64 values out of the
+	// int64 range, for the unsigned column types of the database.
+	unsigned bool
+}
+
+func questionMark(int) string { return "?" }
+
+func dollar(n int) string { return "$" + strconv.Itoa(n) }
+
+func atP(n int) string { return "@p" + strconv.Itoa(n) }
+
+// dialects are indexed by the name the drivers are registered with.
+var dialects = map[string]dialect{
+	"pgx": {
+		quote:             `"`,
+		unquote:           `"`,
+		placeholder:       dollar,
+		addColumnTemplate: "ALTER TABLE {TABLE} ADD COLUMN {COLUMN}",
+		types: ColumnTypes{
+			Integer:   "BIGINT",
+			Unsigned:  "BIGINT",
+			Real:      "DOUBLE PRECISION",
+			Text:      "TEXT",
+			Bool:      "BOOLEAN",
+			Timestamp: "TIMESTAMP",
+		},
+	},
+	"mysql": {
+		quote:             "`",
+		unquote:           "`",
+		placeholder:       questionMark,
+		addColumnTemplate: "ALTER TABLE {TABLE} ADD COLUMN {COLUMN}",
+		types: ColumnTypes{
+			Integer:   "BIGINT",
+			Unsigned:  "BIGINT UNSIGNED",
+			Real:      "DOUBLE",
+			Text:      "TEXT",
+			Bool:      "BOOLEAN",
+			Timestamp: "DATETIME(6)",
+		},
+		unsigned: true,
+	},
+	"sqlserver": {
+		quote:             "[",
+		unquote:           "]",
+		placeholder:       atP,
+		addColumnTemplate: "ALTER TABLE {TABLE} ADD {COLUMN}",
+		types: ColumnTypes{
+			Integer:   "BIGINT",
+			Unsigned:  "BIGINT",
+			Real:      "FLOAT",
+			Text:      "NVARCHAR(MAX)",
+			Bool:      "BIT",
+			Timestamp: "DATETIME2",
+		},
+	},
+	"sqlite3": {
+		quote:             `"`,
+		unquote:           `"`,
+		placeholder:       questionMark,
+		addColumnTemplate: "ALTER TABLE {TABLE} ADD COLUMN {COLUMN}",
+		types: ColumnTypes{
+			Integer:   "INTEGER",
+			Unsigned:  "INTEGER",
+			Real:      "REAL",
+			Text:      "TEXT",
+			Bool:      "BOOLEAN",
+			Timestamp: "TIMESTAMP",
+		},
+	},
+}
+
+// quoteIdent quotes a table or column name.
+func (d *dialect) quoteIdent(name string) string {
+	return d.quote + strings.Replace(name, d.unquote, d.unquote+d.unquote, -1) + d.unquote
+}
@@ -0,0 +1,422 @@
+// +build cgo
+
+package sql
+
+import (
+	dbsql "database/sql"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var ts = time.Unix(1538395200, 0)
+
+func newSQL(t *testing.T, layout string) (*SQL, func()) {
+	dir, err := ioutil.TempDir("", "sql")
+	require.NoError(t, err)
+
+	s := &SQL{
+		Driver:              "sqlite",
+		Dsn:                 filepath.Join(dir, "test.db"),
+		Timeout:             internal.Duration{Duration: 5 * time.Second},
+		TableLayout:         layout,
+		Table:               "metrics",
+		TimestampColumn:     "timestamp",
+		MeasurementColumn:   "measurement",
+		TableCreateTemplate: "CREATE TABLE {TABLE}({COLUMNS})",
+	}
+	return s, func() { os.RemoveAll(dir) }
+}
+
+func connect(t *testing.T, s *SQL) {
+	require.NoError(t, s.Init())
+	require.NoError(t, s.Connect())
+}
+
+// query returns the rows of the query as maps of column names to values.
+func query(t *testing.T, s *SQL, query string) []map[string]interface{} {
+	db, err := dbsql.Open("sqlite3", s.Dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Query(query)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+
+	var result []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		require.NoError(t, rows.Scan(pointers...))
+
+		r := make(map[string]interface{})
+		for i, name := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			if tm, ok := values[i].(time.Time); ok {
+				values[i] = tm.Unix()
+			}
+			r[name] = values[i]
+		}
+		result = append(result, r)
+	}
+	require.NoError(t, rows.Err())
+	return result
+}
+
+func TestSQL_MeasurementLayout(t *testing.T) {
+	s, cleanup := newSQL(t, layoutMeasurement)
+	defer cleanup()
+	connect(t, s)
+	defer s.Close()
+
+	err := s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 42.5, "count": int64(3)},
+			ts),
+		testutil.MustMetric("mem",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"free": uint64(1024), "ok": true, "state": "fine"},
+			ts),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []map[string]interface{}{
+		{"timestamp": ts.Unix(), "host": "a", "count": int64(3), "usage": 42.5},
+	}, query(t, s, `SELECT * FROM cpu`))
+	require.Equal(t, []map[string]interface{}{
+		{"timestamp": ts.Unix(), "host": "a", "free": int64(1024), "ok": true, "state": "fine"},
+	}, query(t, s, `SELECT * FROM mem`))
+}
+
+func TestSQL_AddColumns(t *testing.T) {
+	s, cleanup := newSQL(t, layoutMeasurement)
+	defer cleanup()
+	connect(t, s)
+	defer s.Close()
+
+	err := s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 42.5},
+			ts),
+	})
+	require.NoError(t, err)
+
+	err = s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "b", "cpu": "cpu0"},
+			map[string]interface{}{"idle": 10.0},
+			ts.Add(time.Minute)),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []map[string]interface{}{
+		{"timestamp": ts.Unix(), "host": "a", "usage": 42.5, "cpu": nil, "idle": nil},
+		{"timestamp": ts.Add(time.Minute).Unix(), "host": "b", "usage": nil, "cpu": "cpu0", "idle": 10.0},
+	}, query(t, s, `SELECT * FROM cpu ORDER BY timestamp`))
+
+	// A new writer discovers the columns of the existing table.
+	s2 := *s
+	connect(t, &s2)
+	defer s2.Close()
+	err = s2.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "c"},
+			map[string]interface{}{"usage": 1.0},
+			ts.Add(2*time.Minute)),
+	})
+	require.NoError(t, err)
+	require.Len(t, query(t, s, `SELECT * FROM cpu`), 3)
+}
+
+func TestSQL_WideLayout(t *testing.T) {
+	s, cleanup := newSQL(t, layoutWide)
+	defer cleanup()
+	connect(t, s)
+	defer s.Close()
+
+	err := s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 42.5},
+			ts),
+		testutil.MustMetric("mem",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"free": int64(1024)},
+			ts),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []map[string]interface{}{
+		{"timestamp": ts.Unix(), "measurement": "cpu", "host": "a", "usage": 42.5, "free": nil},
+		{"timestamp": ts.Unix(), "measurement": "mem", "host": "a", "usage": nil, "free": int64(1024)},
+	}, query(t, s, `SELECT * FROM metrics ORDER BY measurement`))
+}
+
+func TestSQL_NarrowLayout(t *testing.T) {
+	s, cleanup := newSQL(t, layoutNarrow)
+	defer cleanup()
+	connect(t, s)
+	defer s.Close()
+
+	err := s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 42.5, "count": int64(3), "state": "ok"},
+			ts),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []map[string]interface{}{
+		{"timestamp": ts.Unix(), "measurement": "cpu", "host": "a", "field": "count", "value": 3.0, "value_text": nil},
+		{"timestamp": ts.Unix(), "measurement": "cpu", "host": "a", "field": "state", "value": nil, "value_text": "ok"},
+		{"timestamp": ts.Unix(), "measurement": "cpu", "host": "a", "field": "usage", "value": 42.5, "value_text": nil},
+	}, query(t, s, `SELECT * FROM metrics ORDER BY field`))
+}
+
+func TestSQL_Templates(t *testing.T) {
+	s, cleanup := newSQL(t, layoutWide)
+	defer cleanup()
+	s.TableCreateTemplate = "CREATE TABLE {TABLE}({COLUMNS}, PRIMARY KEY(timestamp, measurement))"
+	s.Convert.Real = "NUMERIC"
+	connect(t, s)
+	defer s.Close()
+
+	err := s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"usage": 42.5},
+			ts),
+	})
+	require.NoError(t, err)
+
+	rows := query(t, s, `SELECT type FROM pragma_table_info('metrics') WHERE name = 'usage'`)
+	require.Equal(t, []map[string]interface{}{{"type": "NUMERIC"}}, rows)
+	rows = query(t, s, `SELECT name FROM pragma_table_info('metrics') WHERE pk > 0 ORDER BY pk`)
+	require.Equal(t, []map[string]interface{}{{"name": "timestamp"}, {"name": "measurement"}}, rows)
+}
+
+func TestSQL_WriteIsTransactional(t *testing.T) {
+	s, cleanup := newSQL(t, layoutWide)
+	defer cleanup()
+	s.TableCreateTemplate = "CREATE TABLE {TABLE}({COLUMNS}, CHECK (value >= 0))"
+	connect(t, s)
+	defer s.Close()
+
+	err := s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": 1.0},
+			ts),
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": -1.0},
+			ts),
+	})
+	require.Error(t, err)
+	require.Empty(t, query(t, s, `SELECT * FROM metrics`))
+}
+
+func TestSQL_QuoteIdent(t *testing.T) {
+	tests := []struct {
+		driver   string
+		expected string
+	}{
+		{"pgx", `"my ""table"""`},
+		{"mysql", "`my \"table\"`"},
+		{"sqlserver", `[my "table"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			d := dialects[tt.driver]
+			require.Equal(t, tt.expected, d.quoteIdent(`my "table"`))
+		})
+	}
+
+	d := dialects["sqlserver"]
+	require.Equal(t, "[a]]b]", d.quoteIdent("a]b"))
+}
+
+func TestSQL_InsertSQL(t *testing.T) {
+	columns := []column{{name: "timestamp"}, {name: "host"}}
+	tests := []struct {
+		driver   string
+		expected string
+	}{
+		{"pgx", `INSERT INTO "cpu"("timestamp","host") VALUES($1,$2)`},
+		{"mysql", "INSERT INTO `cpu`(`timestamp`,`host`) VALUES(?,?)"},
+		{"sqlserver", `INSERT INTO [cpu]([timestamp],[host]) VALUES(@p1,@p2)`},
+		{"sqlite3", `INSERT INTO "cpu"("timestamp","host") VALUES(?,?)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			s := &SQL{dialect: dialects[tt.driver]}
+			require.Equal(t, tt.expected, s.insertSQL("cpu", columns))
+		})
+	}
+}
+
+func TestSQL_InitErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s *SQL)
+		err    string
+	}{
+		{
+			name:   "unknown driver",
+			modify: func(s *SQL) { s.Driver = "oracle" },
+			err:    `unknown driver "oracle"`,
+		},
+		{
+			name:   "unknown layout",
+			modify: func(s *SQL) { s.TableLayout = "tall" },
+			err:    `unknown table_layout "tall"`,
+		},
+		{
+			name:   "missing table",
+			modify: func(s *SQL) { s.TableLayout = layoutWide; s.Table = "" },
+			err:    "table is required with the wide layout",
+		},
+		{
+			name:   "bad create template",
+			modify: func(s *SQL) { s.TableCreateTemplate = "CREATE TABLE {TABLE}" },
+			err:    "table_create_template must contain {TABLE} and {COLUMNS}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, cleanup := newSQL(t, layoutMeasurement)
+			defer cleanup()
+			tt.modify(s)
+			err := s.Init()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestSQL_TypeChange(t *testing.T) {
+	s, cleanup := newSQL(t, layoutMeasurement)
+	defer cleanup()
+	connect(t, s)
+	defer s.Close()
+
+	err := s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"count": int64(1), "state": "ok"},
+			ts),
+	})
+	require.NoError(t, err)
+
+	// The values are converted to the types of the columns, rows which can't
+	// be converted are dropped without failing the others.
+	err = s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"count": 2.0, "state": int64(3)},
+			ts.Add(time.Minute)),
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"count": "many"},
+			ts.Add(2*time.Minute)),
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"count": 2.5},
+			ts.Add(3*time.Minute)),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []map[string]interface{}{
+		{"timestamp": ts.Unix(), "count": int64(1), "state": "ok"},
+		{"timestamp": ts.Add(time.Minute).Unix(), "count": int64(2), "state": "3"},
+	}, query(t, s, `SELECT * FROM cpu ORDER BY timestamp`))
+}
+
+func TestSQL_ConvertValue(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		kind     columnKind
+		expected interface{}
+		err      bool
+	}{
+		{int64(3), kindUnknown, int64(3), false},
+		{2.0, kindInteger, int64(2), false},
+		{2.5, kindInteger, nil, true},
+		{math.NaN(), kindInteger, nil, true},
+		{uint64(3), kindInteger, uint64(3), false},
+		{true, kindInteger, int64(1), false},
+		{"42", kindInteger, int64(42), false},
+		{"many", kindInteger, nil, true},
+		{1e30, kindInteger, nil, true},
+		{int64(3), kindReal, 3.0, false},
+		{"1.5", kindReal, 1.5, false},
+		{int64(0), kindBool, false, false},
+		{"true", kindBool, true, false},
+		{2.5, kindText, "2.5", false},
+		{uint64(3), kindText, "3", false},
+		{false, kindText, "false", false},
+		{ts, kindText, ts, false},
+	}
+	for _, tt := range tests {
+		value, err := convertValue(tt.value, tt.kind)
+		if tt.err {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, value)
+	}
+}
+
+func TestSQL_Unsigned(t *testing.T) {
+	m := testutil.MustMetric("mem",
+		map[string]string{},
+		map[string]interface{}{"free": uint64(math.MaxUint64)},
+		ts)
+
+	// The values are clamped unless the driver and the column type support
+	// them.
+	tests := []struct {
+		driver   string
+		unsigned string
+		expected interface{}
+	}{
+		{"mysql", "", uint64(math.MaxUint64)},
+		{"mysql", "BIGINT", int64(math.MaxInt64)},
+		{"pgx", "", int64(math.MaxInt64)},
+		{"sqlite3", "", int64(math.MaxInt64)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.driver+" "+tt.unsigned, func(t *testing.T) {
+			s := &SQL{
+				Driver:              tt.driver,
+				Dsn:                 "dsn",
+				TableLayout:         layoutMeasurement,
+				TableCreateTemplate: "CREATE TABLE {TABLE}({COLUMNS})",
+				Convert:             ColumnTypes{Unsigned: tt.unsigned},
+			}
+			require.NoError(t, s.Init())
+			columns := s.fieldColumns(m)
+			require.Len(t, columns, 1)
+			require.Equal(t, tt.expected, columns[0].value)
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package syslog
+
+import (
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/go-syslog/rfc5424"
+	"github.com/influxdata/telegraf"
+)
+
+// The go-syslog builder keeps the structured data being set in package level
+// variables, so messages must not be built concurrently.
+var builderMu sync.Mutex
+
+// Codes of the severity and facility keywords used by the syslog input.
+var severityCodes = map[string]uint8{
+	"emerg":   0,
+	"alert":   1,
+	"crit":    2,
+	"err":     3,
+	"warning": 4,
+	"notice":  5,
+	"info":    6,
+	"debug":   7,
+}
+
+var facilityCodes = map[string]uint8{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"authpriv": 10,
+	"ftp":      11,
+	"cron":     15,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// Tags and fields mapped to the message header and content, which are
+// therefore not part of the structured data.
+var reservedKeys = map[string]bool{
+	"severity":      true,
+	"severity_code": true,
+	"facility":      true,
+	"facility_code": true,
+	"version":       true,
+	"timestamp":     true,
+	"hostname":      true,
+	"source":        true,
+	"host":          true,
+	"appname":       true,
+	"procid":        true,
+	"msgid":         true,
+	"message":       true,
+}
+
+const rfc5424Stamp = "2006-01-02T15:04:05.999999Z07:00"
+
+type syslogMapper struct {
+	DefaultSdid         string
+	DefaultSeverityCode uint8
+	DefaultFacilityCode uint8
+	DefaultAppname      string
+	Sdids               []string
+	Separator           string
+}
+
+// MapMetricToSyslogMessage maps a metric to a RFC5424 syslog message.
+func (sm *syslogMapper) MapMetricToSyslogMessage(metric telegraf.Metric) (*rfc5424.SyslogMessage, error) {
+	builderMu.Lock()
+	defer builderMu.Unlock()
+
+	msg := &rfc5424.SyslogMessage{}
+
+	severity, facility, err := sm.mapPriority(metric)
+	if err != nil {
+		return nil, err
+	}
+	msg.SetPriority(facility*8 + severity)
+
+	version := uint16(1)
+	if v, ok := getFieldCode(metric, "version"); ok {
+		if v < 1 || v > 999 {
+			return nil, errors.New("version must be in the range 1-999")
+		}
+		version = uint16(v)
+	}
+	msg.SetVersion(version)
+
+	msg.SetTimestamp(sm.mapTimestamp(metric).Format(rfc5424Stamp))
+	msg.SetHostname(mapHostname(metric))
+
+	if appname, ok := metric.GetTag("appname"); ok {
+		msg.SetAppname(appname)
+	} else if sm.DefaultAppname != "" {
+		msg.SetAppname(sm.DefaultAppname)
+	}
+
+	if v, ok := metric.GetField("procid"); ok {
+		msg.SetProcID(formatValue(v))
+	}
+
+	if v, ok := metric.GetField("msgid"); ok {
+		msg.SetMsgID(formatValue(v))
+	} else {
+		msg.SetMsgID(metric.Name())
+	}
+
+	if v, ok := metric.GetField("message"); ok {
+		msg.SetMessage(formatValue(v))
+	}
+
+	for _, tag := range metric.TagList() {
+		sm.mapStructuredDataItem(tag.Key, tag.Value, msg)
+	}
+	for _, field := range metric.FieldList() {
+		if b, ok := field.Value.(bool); ok && b && sm.isSdid(field.Key) {
+			// The syslog input flags structured data elements without
+			// parameters with a true field named after the element.
+			msg.SetElementID(field.Key)
+			continue
+		}
+		sm.mapStructuredDataItem(field.Key, formatValue(field.Value), msg)
+	}
+
+	if !msg.Valid() {
+		return nil, errors.New("invalid syslog message")
+	}
+	return msg, nil
+}
+
+func (sm *syslogMapper) mapPriority(metric telegraf.Metric) (severity uint8, facility uint8, err error) {
+	severity = sm.DefaultSeverityCode
+	if v, ok := getFieldCode(metric, "severity_code"); ok {
+		if v < 0 || v > 7 {
+			return 0, 0, errors.New("severity_code must be in the range 0-7")
+		}
+		severity = uint8(v)
+	} else if name, ok := metric.GetTag("severity"); ok {
+		if code, ok := severityCodes[name]; ok {
+			severity = code
+		}
+	}
+
+	facility = sm.DefaultFacilityCode
+	if v, ok := getFieldCode(metric, "facility_code"); ok {
+		if v < 0 || v > 23 {
+			return 0, 0, errors.New("facility_code must be in the range 0-23")
+		}
+		facility = uint8(v)
+	} else if name, ok := metric.GetTag("facility"); ok {
+		if code, ok := facilityCodes[name]; ok {
+			facility = code
+		}
+	}
+
+	return severity, facility, nil
+}
+
+func (sm *syslogMapper) mapTimestamp(metric telegraf.Metric) time.Time {
+	if v, ok := metric.GetField("timestamp"); ok {
+		switch v := v.(type) {
+		case int64:
+			return time.Unix(0, v).UTC()
+		case uint64:
+			return time.Unix(0, int64(v)).UTC()
+		}
+	}
+	return metric.Time().UTC()
+}
+
+func mapHostname(metric telegraf.Metric) string {
+	for _, key := range []string{"hostname", "source", "host"} {
+		if value, ok := metric.GetTag(key); ok && value != "" {
+			return value
+		}
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		return hostname
+	}
+	return ""
+}
+
+func (sm *syslogMapper) isSdid(key string) bool {
+	for _, sdid := range sm.Sdids {
+		if key == sdid {
+			return true
+		}
+	}
+	return false
+}
+
+func (sm *syslogMapper) mapStructuredDataItem(key string, value string, msg *rfc5424.SyslogMessage) {
+	if reservedKeys[key] {
+		return
+	}
+	for _, sdid := range sm.Sdids {
+		prefix := sdid + sm.Separator
+		if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+			msg.SetParameter(sdid, strings.TrimPrefix(key, prefix), value)
+			return
+		}
+	}
+	if sm.DefaultSdid != "" {
+		msg.SetParameter(sm.DefaultSdid, key, value)
+	}
+}
+
+func getFieldCode(metric telegraf.Metric, key string) (int64, bool) {
+	v, ok := metric.GetField(key)
+	if !ok {
+		return 0, false
+	}
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case uint64:
+		if v > math.MaxInt64 {
+			return math.MaxInt64, true
+		}
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
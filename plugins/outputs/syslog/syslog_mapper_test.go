@@ -0,0 +1,278 @@
+package syslog
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/influxdata/go-syslog/rfc5424"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogMapperWithDefaults(t *testing.T) {
+	s := newSyslog()
+	s.initializeSyslogMapper()
+
+	m1, _ := metric.New(
+		"testmetric",
+		map[string]string{},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	assert.Equal(t, "<13>1 2010-11-10T23:00:00Z "+hostname+" Telegraf - testmetric -", str, "Wrong syslog message")
+}
+
+func TestSyslogMapperWithHostname(t *testing.T) {
+	s := newSyslog()
+	s.initializeSyslogMapper()
+
+	m1, _ := metric.New(
+		"testmetric",
+		map[string]string{
+			"hostname": "testhost",
+			"source":   "sourcevalue",
+			"host":     "hostvalue",
+		},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	assert.Equal(t, "<13>1 2010-11-10T23:00:00Z testhost Telegraf - testmetric -", str, "Wrong syslog message")
+}
+
+func TestSyslogMapperWithHostnameSourceFallback(t *testing.T) {
+	s := newSyslog()
+	s.initializeSyslogMapper()
+
+	m1, _ := metric.New(
+		"testmetric",
+		map[string]string{
+			"source": "sourcevalue",
+			"host":   "hostvalue",
+		},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	assert.Equal(t, "<13>1 2010-11-10T23:00:00Z sourcevalue Telegraf - testmetric -", str, "Wrong syslog message")
+}
+
+func TestSyslogMapperWithHostnameHostFallback(t *testing.T) {
+	s := newSyslog()
+	s.initializeSyslogMapper()
+
+	m1, _ := metric.New(
+		"testmetric",
+		map[string]string{
+			"host": "hostvalue",
+		},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	assert.Equal(t, "<13>1 2010-11-10T23:00:00Z hostvalue Telegraf - testmetric -", str, "Wrong syslog message")
+}
+
+func TestSyslogMapperWithDefaultSdid(t *testing.T) {
+	s := newSyslog()
+	s.DefaultSdid = "default@32473"
+	s.initializeSyslogMapper()
+
+	m1, _ := metric.New(
+		"testmetric",
+		map[string]string{
+			"appname":            "testapp",
+			"hostname":           "testhost",
+			"tag1":               "bar",
+			"default@32473_tag2": "foobar",
+		},
+		map[string]interface{}{
+			"severity_code":        uint64(3),
+			"facility_code":        uint64(3),
+			"msg":                  "Test message",
+			"procid":               uint64(25),
+			"version":              uint16(2),
+			"msgid":                int64(555),
+			"timestamp":            time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC).UnixNano(),
+			"value1":               int64(2),
+			"default@32473_value2": "foo",
+			"value3":               float64(1.2),
+		},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	assert.Equal(t, `<27>2 2010-11-10T23:30:00Z testhost testapp 25 555 [default@32473 default@32473_tag2="foobar" default@32473_value2="foo" msg="Test message" tag1="bar" value1="2" value3="1.2"]`, str, "Wrong syslog message")
+}
+
+func TestSyslogMapperWithDefaultSdidAndOtherSdids(t *testing.T) {
+	s := newSyslog()
+	s.DefaultSdid = "default@32473"
+	s.Sdids = []string{"bar@123", "foo@456"}
+	s.initializeSyslogMapper()
+
+	m1, _ := metric.New(
+		"testmetric",
+		map[string]string{
+			"appname":            "testapp",
+			"hostname":           "testhost",
+			"tag1":               "bar",
+			"default@32473_tag2": "foobar",
+			"bar@123_tag3":       "barfoobar",
+		},
+		map[string]interface{}{
+			"severity_code":        uint64(1),
+			"facility_code":        uint64(3),
+			"message":              "Test message",
+			"procid":               uint64(25),
+			"version":              uint16(2),
+			"msgid":                int64(555),
+			"timestamp":            time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC).UnixNano(),
+			"value1":               int64(2),
+			"default@32473_value2": "default",
+			"bar@123_value3":       int64(2),
+			"foo@456_value4":       "foo",
+		},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	assert.Equal(t, `<25>2 2010-11-10T23:30:00Z testhost testapp 25 555 [bar@123 tag3="barfoobar" value3="2"][default@32473 default@32473_tag2="foobar" default@32473_value2="default" tag1="bar" value1="2"][foo@456 value4="foo"] Test message`, str, "Wrong syslog message")
+}
+
+func TestSyslogMapperWithNoSdids(t *testing.T) {
+	s := newSyslog()
+	s.initializeSyslogMapper()
+
+	m1, _ := metric.New(
+		"testmetric",
+		map[string]string{
+			"appname":            "testapp",
+			"hostname":           "testhost",
+			"tag1":               "bar",
+			"default@32473_tag2": "foobar",
+			"bar@123_tag3":       "barfoobar",
+			"foo@456_tag4":       "foobarfoo",
+		},
+		map[string]interface{}{
+			"severity_code":        uint64(2),
+			"facility_code":        uint64(3),
+			"msg":                  "Test message",
+			"procid":               uint64(25),
+			"version":              uint16(2),
+			"msgid":                int64(555),
+			"timestamp":            time.Date(2010, time.November, 10, 23, 30, 0, 0, time.UTC).UnixNano(),
+			"value1":               int64(2),
+			"default@32473_value2": "default",
+			"bar@123_value3":       int64(2),
+			"foo@456_value4":       "foo",
+		},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	assert.Equal(t, "<26>2 2010-11-10T23:30:00Z testhost testapp 25 555 -", str, "Wrong syslog message")
+}
+
+// TestSyslogMapperRoundTrip checks a metric as produced by the syslog input
+// is mapped back to the original message.
+func TestSyslogMapperRoundTrip(t *testing.T) {
+	s := newSyslog()
+	s.Sdids = []string{"meta", "origin", "exampleSDID@32473"}
+	s.initializeSyslogMapper()
+
+	m1, _ := metric.New(
+		"syslog",
+		map[string]string{
+			"severity": "crit",
+			"facility": "auth",
+			"hostname": "mymachine.example.com",
+			"appname":  "evntslog",
+			"host":     "telegraf-host",
+		},
+		map[string]interface{}{
+			"version":                   uint64(1),
+			"severity_code":             int64(2),
+			"facility_code":             int64(4),
+			"timestamp":                 time.Date(2003, time.October, 11, 22, 14, 15, 3000, time.UTC).UnixNano(),
+			"procid":                    "1234",
+			"msgid":                     "ID47",
+			"exampleSDID@32473_iut":     "3",
+			"exampleSDID@32473_eventID": "1011",
+			"meta_sequence":             "14125553",
+			"origin":                    true,
+			"message":                   "An application event log entry",
+		},
+		time.Now(),
+	)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	want := `<34>1 2003-10-11T22:14:15.000003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 eventID="1011" iut="3"][meta sequence="14125553"][origin] An application event log entry`
+	assert.Equal(t, want, str)
+
+	parsed, err := rfc5424.NewParser().Parse([]byte(str), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "crit", *parsed.SeverityShortLevel())
+	assert.Equal(t, "auth", *parsed.FacilityLevel())
+}
+
+func TestSyslogMapperWithPriorityTags(t *testing.T) {
+	s := newSyslog()
+	s.initializeSyslogMapper()
+
+	m1, _ := metric.New(
+		"testmetric",
+		map[string]string{
+			"hostname": "testhost",
+			"severity": "err",
+			"facility": "local0",
+		},
+		map[string]interface{}{},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(m1)
+	require.NoError(t, err)
+	str, _ := syslogMessage.String()
+	assert.Equal(t, "<131>1 2010-11-10T23:00:00Z testhost Telegraf - testmetric -", str, "Wrong syslog message")
+}
+
+func TestSyslogMapperInvalidCodes(t *testing.T) {
+	s := newSyslog()
+	s.initializeSyslogMapper()
+
+	for _, fields := range []map[string]interface{}{
+		{"severity_code": int64(8)},
+		{"facility_code": int64(24)},
+		{"version": int64(0)},
+	} {
+		m1, _ := metric.New("testmetric", map[string]string{}, fields, time.Now())
+		_, err := s.mapper.MapMetricToSyslogMessage(m1)
+		require.Error(t, err, "fields: %v", fields)
+	}
+}
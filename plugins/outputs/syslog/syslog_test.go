@@ -0,0 +1,146 @@
+package syslog
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	framing "github.com/influxdata/telegraf/internal/syslog"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMetrics(t *testing.T) []telegraf.Metric {
+	m1, err := metric.New(
+		"testmetric",
+		map[string]string{"hostname": "testhost"},
+		map[string]interface{}{"message": "hello"},
+		time.Date(2010, time.November, 10, 23, 0, 0, 0, time.UTC),
+	)
+	require.NoError(t, err)
+	m2, err := metric.New(
+		"testmetric",
+		map[string]string{"hostname": "testhost"},
+		map[string]interface{}{"message": "hello\nworld"},
+		time.Date(2010, time.November, 10, 23, 0, 1, 0, time.UTC),
+	)
+	require.NoError(t, err)
+	return []telegraf.Metric{m1, m2}
+}
+
+func TestGetSyslogMessageWithFramingOctectCounting(t *testing.T) {
+	s := newSyslog()
+	s.Address = "tcp://127.0.0.1:6514"
+	s.initializeSyslogMapper()
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(testMetrics(t)[0])
+	require.NoError(t, err)
+	messageBytesWithFraming, err := s.getSyslogMessageBytesWithFraming(syslogMessage)
+	require.NoError(t, err)
+
+	assert.Equal(t, "65 <13>1 2010-11-10T23:00:00Z testhost Telegraf - testmetric - hello", string(messageBytesWithFraming), "Incorrect Octect counting framing")
+}
+
+func TestGetSyslogMessageWithFramingNonTransparent(t *testing.T) {
+	s := newSyslog()
+	s.Address = "tcp://127.0.0.1:6514"
+	s.Framing = framing.NonTransparent
+	s.Trailer = framing.NUL
+	s.initializeSyslogMapper()
+
+	syslogMessage, err := s.mapper.MapMetricToSyslogMessage(testMetrics(t)[0])
+	require.NoError(t, err)
+	messageBytesWithFraming, err := s.getSyslogMessageBytesWithFraming(syslogMessage)
+	require.NoError(t, err)
+
+	assert.Equal(t, "<13>1 2010-11-10T23:00:00Z testhost Telegraf - testmetric - hello\x00", string(messageBytesWithFraming), "Incorrect non-transparent framing")
+}
+
+func TestSyslogWriteWithTcp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := newSyslog()
+	s.Address = "tcp://" + listener.Addr().String()
+
+	err = s.Connect()
+	require.NoError(t, err)
+	defer s.Close()
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+	defer lconn.Close()
+
+	require.NoError(t, s.Write(testMetrics(t)))
+
+	want := "65 <13>1 2010-11-10T23:00:00Z testhost Telegraf - testmetric - hello" +
+		"71 <13>1 2010-11-10T23:00:01Z testhost Telegraf - testmetric - hello\nworld"
+	buf := make([]byte, len(want))
+	r := bufio.NewReader(lconn)
+	_, err = io.ReadFull(r, buf)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(buf))
+}
+
+func TestSyslogWriteWithUdp(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := newSyslog()
+	s.Address = "udp://" + listener.LocalAddr().String()
+
+	err = s.Connect()
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Write(testMetrics(t)[:1]))
+
+	buf := make([]byte, 256)
+	n, _, err := listener.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "<13>1 2010-11-10T23:00:00Z testhost Telegraf - testmetric - hello", string(buf[:n]))
+}
+
+func TestSyslogWriteReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	s := newSyslog()
+	s.Address = "tcp://" + listener.Addr().String()
+
+	err = s.Connect()
+	require.NoError(t, err)
+	defer s.Close()
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+	lconn.(*net.TCPConn).SetLinger(0)
+	lconn.Close()
+
+	// The first write may succeed before the reset is noticed.
+	err = s.Write(testMetrics(t)[:1])
+	if err == nil {
+		time.Sleep(100 * time.Millisecond)
+		err = s.Write(testMetrics(t)[:1])
+	}
+	require.Error(t, err)
+	assert.Nil(t, s.Conn)
+
+	require.NoError(t, s.Write(testMetrics(t)[:1]))
+	lconn, err = listener.Accept()
+	require.NoError(t, err)
+	defer lconn.Close()
+
+	want := "65 <13>1 2010-11-10T23:00:00Z testhost Telegraf - testmetric - hello"
+	buf := make([]byte, len(want))
+	_, err = io.ReadFull(lconn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(buf))
+}
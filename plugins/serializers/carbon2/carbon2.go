@@ -0,0 +1,112 @@
+package carbon2
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// FormatFieldSeparate writes the field name as the "field" intrinsic
+	// tag.
+	FormatFieldSeparate = "field_separate"
+
+	// FormatMetricIncludesField appends the field name to the "metric"
+	// intrinsic tag.
+	FormatMetricIncludesField = "metric_includes_field"
+)
+
+var sanitizer = strings.NewReplacer(" ", "_", "=", "_", "\t", "_", "\n", "_")
+
+type serializer struct {
+	Format string
+}
+
+func NewSerializer(format string) (*serializer, error) {
+	switch format {
+	case "":
+		format = FormatFieldSeparate
+	case FormatFieldSeparate, FormatMetricIncludesField:
+	default:
+		return nil, fmt.Errorf("unknown carbon2 format: %s", format)
+	}
+
+	return &serializer{
+		Format: format,
+	}, nil
+}
+
+func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	s.write(&buf, metric)
+	return buf.Bytes(), nil
+}
+
+func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, metric := range metrics {
+		s.write(&buf, metric)
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *serializer) write(buf *bytes.Buffer, metric telegraf.Metric) {
+	timestamp := strconv.FormatInt(metric.Time().Unix(), 10)
+
+	for _, field := range metric.FieldList() {
+		value, ok := formatValue(field.Value)
+		if !ok {
+			continue
+		}
+
+		switch s.Format {
+		case FormatMetricIncludesField:
+			buf.WriteString("metric=")
+			buf.WriteString(sanitize(metric.Name() + "_" + field.Key))
+		default:
+			buf.WriteString("metric=")
+			buf.WriteString(sanitize(metric.Name()))
+			buf.WriteString(" field=")
+			buf.WriteString(sanitize(field.Key))
+		}
+
+		for _, tag := range metric.TagList() {
+			buf.WriteString(" ")
+			buf.WriteString(sanitize(tag.Key))
+			buf.WriteString("=")
+			buf.WriteString(sanitize(tag.Value))
+		}
+
+		// Intrinsic and meta tags are separated by two spaces; all tags are
+		// written as intrinsic tags so the meta tags are empty.
+		buf.WriteString("  ")
+		buf.WriteString(value)
+		buf.WriteString(" ")
+		buf.WriteString(timestamp)
+		buf.WriteString("\n")
+	}
+}
+
+func sanitize(value string) string {
+	return sanitizer.Replace(value)
+}
+
+func formatValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "0", true
+	}
+	return "", false
+}
@@ -0,0 +1,142 @@
+package carbon2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func MustMetric(v telegraf.Metric, err error) telegraf.Metric {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  telegraf.Metric
+		output string
+	}{
+		{
+			name:   "field separate",
+			format: FormatFieldSeparate,
+			input: MustMetric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"cpu": "cpu0",
+					},
+					map[string]interface{}{
+						"usage_idle": 91.5,
+					},
+					time.Unix(1525478795, 0),
+				),
+			),
+			output: "metric=cpu field=usage_idle cpu=cpu0  91.5 1525478795\n",
+		},
+		{
+			name:   "metric includes field",
+			format: FormatMetricIncludesField,
+			input: MustMetric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"cpu": "cpu0",
+					},
+					map[string]interface{}{
+						"usage_idle": 91.5,
+					},
+					time.Unix(1525478795, 0),
+				),
+			),
+			output: "metric=cpu_usage_idle cpu=cpu0  91.5 1525478795\n",
+		},
+		{
+			name: "default format without tags",
+			input: MustMetric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"usage_idle": int64(91),
+					},
+					time.Unix(1525478795, 0),
+				),
+			),
+			output: "metric=cpu field=usage_idle  91 1525478795\n",
+		},
+		{
+			name:   "string fields are skipped and bools converted",
+			format: FormatFieldSeparate,
+			input: MustMetric(
+				metric.New(
+					"system",
+					map[string]string{},
+					map[string]interface{}{
+						"uptime_format": "1 day",
+						"up":            true,
+					},
+					time.Unix(1525478795, 0),
+				),
+			),
+			output: "metric=system field=up  1 1525478795\n",
+		},
+		{
+			name:   "invalid characters are replaced",
+			format: FormatFieldSeparate,
+			input: MustMetric(
+				metric.New(
+					"disk usage",
+					map[string]string{
+						"path": "C:=x y",
+					},
+					map[string]interface{}{
+						"used": uint64(42),
+					},
+					time.Unix(1525478795, 0),
+				),
+			),
+			output: "metric=disk_usage field=used path=C:_x_y  42 1525478795\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.format)
+			require.NoError(t, err)
+			buf, err := s.Serialize(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.output, string(buf))
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{},
+			map[string]interface{}{
+				"value": 42.0,
+			},
+			time.Unix(0, 0),
+		),
+	)
+
+	s, err := NewSerializer(FormatMetricIncludesField)
+	require.NoError(t, err)
+	buf, err := s.SerializeBatch([]telegraf.Metric{m, m})
+	require.NoError(t, err)
+	require.Equal(t, "metric=cpu_value  42 0\nmetric=cpu_value  42 0\n", string(buf))
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := NewSerializer("wide")
+	require.Error(t, err)
+}
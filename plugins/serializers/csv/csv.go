@@ -0,0 +1,321 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// LayoutUnion writes all metrics using a single set of columns, new tags
+	// and fields are appended as new columns when they are first seen.  With
+	// the header enabled, the columns are fixed by the first metric.
+	LayoutUnion = "union"
+
+	// LayoutMeasurement keeps a separate set of columns for each
+	// measurement.
+	LayoutMeasurement = "measurement"
+
+	timestampColumn   = "timestamp"
+	measurementColumn = "measurement"
+)
+
+type serializer struct {
+	Header          bool
+	Delimiter       rune
+	Columns         []string
+	TimestampFormat string
+	Layout          string
+
+	sync.Mutex
+	layouts map[string]*layout
+}
+
+// layout is the ordered list of columns used for a group of metrics.
+type layout struct {
+	columns []string
+	known   map[string]bool
+}
+
+func newLayout(columns []string) *layout {
+	l := &layout{known: make(map[string]bool)}
+	for _, column := range columns {
+		l.add(column)
+	}
+	return l
+}
+
+// add appends the column if not yet known.
+func (l *layout) add(column string) {
+	if l.known[column] {
+		return
+	}
+	l.known[column] = true
+	l.columns = append(l.columns, column)
+}
+
+// extend adds the tags and fields of the metric missing from the layout, in
+// sorted order so that the result does not depend on map iteration.
+func (l *layout) extend(metric telegraf.Metric) {
+	keys := make([]string, 0, len(metric.TagList()))
+	for _, tag := range metric.TagList() {
+		keys = append(keys, tag.Key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		l.add(key)
+	}
+
+	keys = keys[:0]
+	for _, field := range metric.FieldList() {
+		keys = append(keys, field.Key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		l.add(key)
+	}
+}
+
+// NewSerializer creates a csv serializer.  When columns is empty the columns
+// are discovered from the metrics and grouped according to layoutMode.
+func NewSerializer(header bool, delimiter string, columns []string, timestampFormat string, layoutMode string) (*serializer, error) {
+	s := &serializer{
+		Header:          header,
+		Delimiter:       ',',
+		Columns:         columns,
+		TimestampFormat: timestampFormat,
+		Layout:          layoutMode,
+		layouts:         make(map[string]*layout),
+	}
+
+	if delimiter != "" {
+		r, size := utf8.DecodeRuneInString(delimiter)
+		if size != len(delimiter) || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+			return nil, fmt.Errorf("invalid csv_delimiter: %q", delimiter)
+		}
+		s.Delimiter = r
+	}
+
+	if s.TimestampFormat == "" {
+		s.TimestampFormat = "unix"
+	}
+
+	// The layout is left empty when not configured, grouping the columns as
+	// in LayoutUnion, so that outputs can still change it.
+	switch s.Layout {
+	case "", LayoutUnion, LayoutMeasurement:
+	default:
+		return nil, fmt.Errorf("invalid csv_layout: %q", layoutMode)
+	}
+
+	return s, nil
+}
+
+// SetLayout sets the grouping of the columns, outputs writing each
+// measurement to its own destination use LayoutMeasurement so that the
+// header of each measurement is written to its destination.  It fails when
+// a different layout is configured.
+func (s *serializer) SetLayout(layoutMode string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	switch layoutMode {
+	case LayoutUnion, LayoutMeasurement:
+	default:
+		return fmt.Errorf("invalid csv_layout: %q", layoutMode)
+	}
+	if s.Layout != "" && s.Layout != layoutMode {
+		return fmt.Errorf("csv_layout %q can not be used, the output requires %q", s.Layout, layoutMode)
+	}
+	s.Layout = layoutMode
+	s.layouts = make(map[string]*layout)
+	return nil
+}
+
+// Serialize writes the metric as a row.  With the header enabled, the header
+// is written before the first row of each layout and the columns of the
+// layout are fixed from then on, so that all the rows match the header.
+func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var buf bytes.Buffer
+	w := s.newWriter(&buf)
+
+	l, created := s.layoutFor(s.layouts, metric, !s.Header)
+	if created && s.Header {
+		w.Write(l.columns)
+	}
+	w.Write(s.row(l, metric))
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SerializeRow writes the metric as a row like Serialize, but returns the
+// header of its layout separately instead of writing it before the first row,
+// so that the caller can write it once to each of its destinations.  The
+// header is nil when disabled.
+func (s *serializer) SerializeRow(metric telegraf.Metric) ([]byte, []byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	l, _ := s.layoutFor(s.layouts, metric, !s.Header)
+
+	var header []byte
+	if s.Header {
+		var buf bytes.Buffer
+		w := s.newWriter(&buf)
+		w.Write(l.columns)
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, nil, err
+		}
+		header = buf.Bytes()
+	}
+
+	var buf bytes.Buffer
+	w := s.newWriter(&buf)
+	w.Write(s.row(l, metric))
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, nil, err
+	}
+	return header, buf.Bytes(), nil
+}
+
+// SerializeBatch writes the metrics as a standalone document, with the
+// header repeated so that each batch can be read on its own.  In the
+// measurement layout the rows are grouped by measurement.
+func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var order []string
+	groups := make(map[string][]telegraf.Metric)
+	layouts := make(map[string]*layout)
+	for _, metric := range metrics {
+		key := s.layoutKey(metric)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], metric)
+		s.layoutFor(layouts, metric, true)
+	}
+
+	var buf bytes.Buffer
+	w := s.newWriter(&buf)
+	for _, key := range order {
+		l := layouts[key]
+		if s.Header {
+			w.Write(l.columns)
+		}
+		for _, metric := range groups[key] {
+			w.Write(s.row(l, metric))
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *serializer) newWriter(buf *bytes.Buffer) *csv.Writer {
+	w := csv.NewWriter(buf)
+	w.Comma = s.Delimiter
+	return w
+}
+
+func (s *serializer) layoutKey(metric telegraf.Metric) string {
+	if s.Layout == LayoutMeasurement {
+		return metric.Name()
+	}
+	return ""
+}
+
+// layoutFor returns the layout of the metric in layouts and reports if it
+// was created.  When extend is set, the tags and fields of the metric missing
+// from an existing layout are appended to it.
+func (s *serializer) layoutFor(layouts map[string]*layout, metric telegraf.Metric, extend bool) (*layout, bool) {
+	key := s.layoutKey(metric)
+	l, ok := layouts[key]
+	if !ok {
+		if len(s.Columns) > 0 {
+			l = newLayout(s.Columns)
+		} else {
+			l = newLayout([]string{timestampColumn, measurementColumn})
+			l.extend(metric)
+		}
+		layouts[key] = l
+		return l, true
+	}
+
+	if extend && len(s.Columns) == 0 {
+		l.extend(metric)
+	}
+	return l, false
+}
+
+func (s *serializer) row(l *layout, metric telegraf.Metric) []string {
+	row := make([]string, len(l.columns))
+	for i, column := range l.columns {
+		if value, ok := metric.GetTag(column); ok {
+			row[i] = value
+			continue
+		}
+		if value, ok := metric.GetField(column); ok {
+			row[i] = formatValue(value)
+			continue
+		}
+
+		switch column {
+		case timestampColumn:
+			row[i] = s.formatTimestamp(metric.Time())
+		case measurementColumn:
+			row[i] = metric.Name()
+		}
+	}
+	return row
+}
+
+func (s *serializer) formatTimestamp(t time.Time) string {
+	switch s.TimestampFormat {
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unix_ms":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	case "unix_us":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Microsecond), 10)
+	case "unix_ns":
+		return strconv.FormatInt(t.UnixNano(), 10)
+	}
+	return t.UTC().Format(s.TimestampFormat)
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
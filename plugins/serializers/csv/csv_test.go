@@ -0,0 +1,198 @@
+package csv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func MustMetric(v telegraf.Metric, err error) telegraf.Metric {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+var cpu = MustMetric(
+	metric.New(
+		"cpu",
+		map[string]string{
+			"host": "localhost",
+			"cpu":  "cpu0",
+		},
+		map[string]interface{}{
+			"usage_idle": 91.5,
+			"usage_user": int64(2),
+		},
+		time.Unix(1525478795, 0),
+	),
+)
+
+var mem = MustMetric(
+	metric.New(
+		"mem",
+		map[string]string{
+			"host": "localhost",
+		},
+		map[string]interface{}{
+			"free": uint64(42),
+		},
+		time.Unix(1525478795, 0),
+	),
+)
+
+func TestSerializeUnion(t *testing.T) {
+	s, err := NewSerializer(true, "", nil, "", "")
+	require.NoError(t, err)
+
+	buf, err := s.Serialize(cpu)
+	require.NoError(t, err)
+	require.Equal(t,
+		"timestamp,measurement,cpu,host,usage_idle,usage_user\n"+
+			"1525478795,cpu,cpu0,localhost,91.5,2\n", string(buf))
+
+	buf, err = s.Serialize(cpu)
+	require.NoError(t, err)
+	require.Equal(t, "1525478795,cpu,cpu0,localhost,91.5,2\n", string(buf))
+
+	// The columns are fixed once the header is written.
+	buf, err = s.Serialize(mem)
+	require.NoError(t, err)
+	require.Equal(t, "1525478795,mem,,localhost,,\n", string(buf))
+}
+
+func TestSerializeUnionNoHeader(t *testing.T) {
+	s, err := NewSerializer(false, "", nil, "", "")
+	require.NoError(t, err)
+
+	buf, err := s.Serialize(cpu)
+	require.NoError(t, err)
+	require.Equal(t, "1525478795,cpu,cpu0,localhost,91.5,2\n", string(buf))
+
+	buf, err = s.Serialize(mem)
+	require.NoError(t, err)
+	require.Equal(t, "1525478795,mem,,localhost,,,42\n", string(buf))
+}
+
+func TestSerializeBatchUnion(t *testing.T) {
+	s, err := NewSerializer(true, "", nil, "", "")
+	require.NoError(t, err)
+
+	buf, err := s.SerializeBatch([]telegraf.Metric{cpu, mem})
+	require.NoError(t, err)
+	require.Equal(t,
+		"timestamp,measurement,cpu,host,usage_idle,usage_user,free\n"+
+			"1525478795,cpu,cpu0,localhost,91.5,2,\n"+
+			"1525478795,mem,,localhost,,,42\n", string(buf))
+}
+
+func TestSerializeMeasurementLayout(t *testing.T) {
+	s, err := NewSerializer(true, ";", nil, "2006-01-02T15:04:05Z07:00", LayoutMeasurement)
+	require.NoError(t, err)
+
+	buf, err := s.SerializeBatch([]telegraf.Metric{cpu, mem, cpu})
+	require.NoError(t, err)
+	require.Equal(t,
+		"timestamp;measurement;cpu;host;usage_idle;usage_user\n"+
+			"2018-05-05T00:06:35Z;cpu;cpu0;localhost;91.5;2\n"+
+			"2018-05-05T00:06:35Z;cpu;cpu0;localhost;91.5;2\n"+
+			"timestamp;measurement;host;free\n"+
+			"2018-05-05T00:06:35Z;mem;localhost;42\n", string(buf))
+
+	// Batches do not share the layouts of the rows serialized one by one.
+	buf, err = s.Serialize(mem)
+	require.NoError(t, err)
+	require.Equal(t,
+		"timestamp;measurement;host;free\n"+
+			"2018-05-05T00:06:35Z;mem;localhost;42\n", string(buf))
+
+	buf, err = s.Serialize(mem)
+	require.NoError(t, err)
+	require.Equal(t, "2018-05-05T00:06:35Z;mem;localhost;42\n", string(buf))
+}
+
+func TestSetLayout(t *testing.T) {
+	s, err := NewSerializer(true, "", nil, "", "")
+	require.NoError(t, err)
+	require.NoError(t, s.SetLayout(LayoutMeasurement))
+	require.Error(t, s.SetLayout("bogus"))
+	require.Error(t, s.SetLayout(LayoutUnion))
+
+	_, err = s.Serialize(cpu)
+	require.NoError(t, err)
+	buf, err := s.Serialize(mem)
+	require.NoError(t, err)
+	require.Equal(t,
+		"timestamp,measurement,host,free\n"+
+			"1525478795,mem,localhost,42\n", string(buf))
+}
+
+func TestSerializeColumns(t *testing.T) {
+	s, err := NewSerializer(false, "", []string{"host", "timestamp", "usage_idle", "free"}, "unix_ms", "")
+	require.NoError(t, err)
+
+	buf, err := s.SerializeBatch([]telegraf.Metric{cpu, mem})
+	require.NoError(t, err)
+	require.Equal(t,
+		"localhost,1525478795000,91.5,\n"+
+			"localhost,1525478795000,,42\n", string(buf))
+}
+
+func TestSerializeQuoting(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"log",
+			map[string]string{},
+			map[string]interface{}{
+				"message": `a "quoted", message`,
+			},
+			time.Unix(0, 0),
+		),
+	)
+
+	s, err := NewSerializer(false, "", nil, "", "")
+	require.NoError(t, err)
+
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, `0,log,"a ""quoted"", message"`+"\n", string(buf))
+}
+
+func TestInvalidConfig(t *testing.T) {
+	_, err := NewSerializer(false, "ab", nil, "", "")
+	require.Error(t, err)
+
+	_, err = NewSerializer(false, "", nil, "", "columns")
+	require.Error(t, err)
+}
+
+func TestSetLayoutConfigured(t *testing.T) {
+	s, err := NewSerializer(true, "", nil, "", LayoutUnion)
+	require.NoError(t, err)
+	require.NoError(t, s.SetLayout(LayoutUnion))
+	require.Error(t, s.SetLayout(LayoutMeasurement))
+}
+
+func TestSerializeRow(t *testing.T) {
+	s, err := NewSerializer(true, "", nil, "", LayoutMeasurement)
+	require.NoError(t, err)
+
+	// The header is returned with every row instead of the first one only.
+	for i := 0; i < 2; i++ {
+		header, row, err := s.SerializeRow(mem)
+		require.NoError(t, err)
+		require.Equal(t, "timestamp,measurement,host,free\n", string(header))
+		require.Equal(t, "1525478795,mem,localhost,42\n", string(row))
+	}
+
+	s, err = NewSerializer(false, "", nil, "", "")
+	require.NoError(t, err)
+	header, row, err := s.SerializeRow(mem)
+	require.NoError(t, err)
+	require.Nil(t, header)
+	require.Equal(t, "1525478795,mem,localhost,42\n", string(row))
+}
@@ -0,0 +1,317 @@
+package openmetrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// FormatMetricIncludesField appends the field name to the metric family
+	// name.
+	FormatMetricIncludesField = "metric_includes_field"
+
+	// FormatFieldSeparate uses the measurement as metric family name and
+	// adds the field name as the "field" label.
+	FormatFieldSeparate = "field_separate"
+)
+
+var invalidNameCharRE = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type serializer struct {
+	Format string
+}
+
+func NewSerializer(format string) (*serializer, error) {
+	switch format {
+	case "":
+		format = FormatMetricIncludesField
+	case FormatMetricIncludesField, FormatFieldSeparate:
+	default:
+		return nil, fmt.Errorf("unknown openmetrics format: %s", format)
+	}
+
+	return &serializer{
+		Format: format,
+	}, nil
+}
+
+// family is a group of samples sharing a name and type.
+type family struct {
+	name    string
+	typ     string
+	samples []sample
+}
+
+type sample struct {
+	suffix    string
+	labels    []label
+	value     float64
+	timestamp time.Time
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+// Serialize writes the metric family descriptors and samples of a single
+// metric without the terminating "# EOF" line, so that the result can be used
+// with line oriented outputs.
+func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, fam := range s.families([]telegraf.Metric{metric}) {
+		writeFamily(&buf, fam)
+	}
+	return buf.Bytes(), nil
+}
+
+// SerializeBatch writes a complete exposition, with the samples grouped by
+// metric family and terminated by "# EOF".
+func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, fam := range s.families(metrics) {
+		writeFamily(&buf, fam)
+	}
+	buf.WriteString("# EOF\n")
+	return buf.Bytes(), nil
+}
+
+func (s *serializer) families(metrics []telegraf.Metric) []*family {
+	var order []*family
+	byName := make(map[string]*family)
+
+	add := func(name, typ string, smpl sample) {
+		fam, ok := byName[name]
+		if !ok {
+			fam = &family{name: name, typ: typ}
+			byName[name] = fam
+			order = append(order, fam)
+		}
+		fam.samples = append(fam.samples, smpl)
+	}
+
+	for _, metric := range metrics {
+		labels := make([]label, 0, len(metric.TagList())+1)
+		for _, tag := range metric.TagList() {
+			labels = append(labels, label{sanitize(tag.Key), tag.Value})
+		}
+
+		switch metric.Type() {
+		case telegraf.Histogram, telegraf.Summary:
+			name := sanitize(metric.Name())
+			for _, smpl := range distributionSamples(metric, labels) {
+				smpl.timestamp = metric.Time()
+				add(name, typeName(metric.Type()), smpl)
+			}
+		default:
+			for _, field := range metric.FieldList() {
+				value, ok := toFloat(field.Value)
+				if !ok {
+					continue
+				}
+
+				name, smplLabels := s.sampleName(metric, field.Key, labels)
+				smpl := sample{
+					labels:    smplLabels,
+					value:     value,
+					timestamp: metric.Time(),
+				}
+				if metric.Type() == telegraf.Counter {
+					// Counters from the prometheus input are already named
+					// with the suffix, the family name is without it.
+					name = strings.TrimSuffix(name, "_total")
+					smpl.suffix = "_total"
+				}
+				add(name, typeName(metric.Type()), smpl)
+			}
+		}
+	}
+
+	return order
+}
+
+// sampleName returns the metric family name and the labels of the sample for
+// the field.
+func (s *serializer) sampleName(metric telegraf.Metric, field string, labels []label) (string, []label) {
+	if s.Format == FormatFieldSeparate {
+		smplLabels := append(append([]label(nil), labels...), label{"field", field})
+		return sanitize(metric.Name()), smplLabels
+	}
+
+	// Special handling of value fields; supports passthrough from the
+	// prometheus input.
+	switch {
+	case field == "value",
+		metric.Type() == telegraf.Counter && field == "counter",
+		metric.Type() == telegraf.Gauge && field == "gauge":
+		return sanitize(metric.Name()), labels
+	}
+	return sanitize(metric.Name() + "_" + field), labels
+}
+
+// distributionSamples converts the fields of a histogram or summary, as
+// created by the prometheus input, into samples.
+func distributionSamples(metric telegraf.Metric, labels []label) []sample {
+	var bounds []float64
+	values := make(map[float64]float64)
+
+	for _, field := range metric.FieldList() {
+		value, ok := toFloat(field.Value)
+		if !ok {
+			continue
+		}
+
+		switch field.Key {
+		case "sum", "count":
+		default:
+			bound, err := strconv.ParseFloat(field.Key, 64)
+			if err != nil {
+				continue
+			}
+			bounds = append(bounds, bound)
+			values[bound] = value
+		}
+	}
+	sort.Float64s(bounds)
+
+	var buckets []sample
+	for _, bound := range bounds {
+		smpl := sample{value: values[bound]}
+		switch metric.Type() {
+		case telegraf.Histogram:
+			smpl.suffix = "_bucket"
+			smpl.labels = append(append([]label(nil), labels...), label{"le", formatFloat(bound)})
+		default:
+			smpl.labels = append(append([]label(nil), labels...), label{"quantile", formatFloat(bound)})
+		}
+		buckets = append(buckets, smpl)
+	}
+
+	// The +Inf bucket is the count, added unless the metric has the bucket
+	// like the histograms of the prometheus input.
+	hasInf := len(bounds) > 0 && math.IsInf(bounds[len(bounds)-1], 1)
+	if metric.Type() == telegraf.Histogram && !hasInf {
+		count, _ := metric.GetField("count")
+		if value, ok := toFloat(count); ok {
+			buckets = append(buckets, sample{
+				suffix: "_bucket",
+				labels: append(append([]label(nil), labels...), label{"le", "+Inf"}),
+				value:  value,
+			})
+		}
+	}
+
+	for _, key := range []string{"sum", "count"} {
+		field, _ := metric.GetField(key)
+		if value, ok := toFloat(field); ok {
+			buckets = append(buckets, sample{
+				suffix: "_" + key,
+				labels: labels,
+				value:  value,
+			})
+		}
+	}
+	return buckets
+}
+
+func writeFamily(buf *bytes.Buffer, fam *family) {
+	buf.WriteString("# TYPE ")
+	buf.WriteString(fam.name)
+	buf.WriteString(" ")
+	buf.WriteString(fam.typ)
+	buf.WriteString("\n")
+
+	for _, smpl := range fam.samples {
+		buf.WriteString(fam.name)
+		buf.WriteString(smpl.suffix)
+		if len(smpl.labels) > 0 {
+			buf.WriteString("{")
+			for i, l := range smpl.labels {
+				if i > 0 {
+					buf.WriteString(",")
+				}
+				buf.WriteString(l.name)
+				buf.WriteString(`="`)
+				buf.WriteString(labelValueEscaper.Replace(l.value))
+				buf.WriteString(`"`)
+			}
+			buf.WriteString("}")
+		}
+		buf.WriteString(" ")
+		buf.WriteString(formatFloat(smpl.value))
+		buf.WriteString(" ")
+		buf.WriteString(formatTimestamp(smpl.timestamp))
+		buf.WriteString("\n")
+	}
+}
+
+func typeName(tt telegraf.ValueType) string {
+	switch tt {
+	case telegraf.Counter:
+		return "counter"
+	case telegraf.Gauge:
+		return "gauge"
+	case telegraf.Histogram:
+		return "histogram"
+	case telegraf.Summary:
+		return "summary"
+	}
+	return "unknown"
+}
+
+func sanitize(value string) string {
+	name := invalidNameCharRE.ReplaceAllString(value, "_")
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// formatTimestamp returns the time in seconds since the epoch, with
+// millisecond precision.
+func formatTimestamp(t time.Time) string {
+	ms := t.UnixNano() / int64(time.Millisecond)
+	if ms%1000 == 0 {
+		return strconv.FormatInt(ms/1000, 10)
+	}
+	return fmt.Sprintf("%d.%03d", ms/1000, ms%1000)
+}
@@ -0,0 +1,243 @@
+package openmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func MustMetric(v telegraf.Metric, err error) telegraf.Metric {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  telegraf.Metric
+		output string
+	}{
+		{
+			name: "untyped metric includes field",
+			input: MustMetric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"host": "localhost",
+						"cpu":  "cpu0",
+					},
+					map[string]interface{}{
+						"usage_idle": 91.5,
+					},
+					time.Unix(1525478795, 0),
+				),
+			),
+			output: "# TYPE cpu_usage_idle unknown\n" +
+				"cpu_usage_idle{cpu=\"cpu0\",host=\"localhost\"} 91.5 1525478795\n",
+		},
+		{
+			name:   "field separate",
+			format: FormatFieldSeparate,
+			input: MustMetric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"cpu": "cpu0",
+					},
+					map[string]interface{}{
+						"usage_idle": 91.5,
+					},
+					time.Unix(1525478795, 0),
+				),
+			),
+			output: "# TYPE cpu unknown\n" +
+				"cpu{cpu=\"cpu0\",field=\"usage_idle\"} 91.5 1525478795\n",
+		},
+		{
+			name: "counter",
+			input: MustMetric(
+				metric.New(
+					"http_requests",
+					map[string]string{},
+					map[string]interface{}{
+						"counter": int64(42),
+					},
+					time.Unix(1525478795, 123000000),
+					telegraf.Counter,
+				),
+			),
+			output: "# TYPE http_requests counter\n" +
+				"http_requests_total 42 1525478795.123\n",
+		},
+		{
+			name: "gauge with escaped label",
+			input: MustMetric(
+				metric.New(
+					"disk.usage",
+					map[string]string{
+						"path": `C:\ "x"`,
+					},
+					map[string]interface{}{
+						"gauge":  42.0,
+						"string": "dropped",
+					},
+					time.Unix(0, 0),
+					telegraf.Gauge,
+				),
+			),
+			output: "# TYPE disk_usage gauge\n" +
+				`disk_usage{path="C:\\ \"x\""} 42 0` + "\n",
+		},
+		{
+			name: "histogram",
+			input: MustMetric(
+				metric.New(
+					"latency",
+					map[string]string{},
+					map[string]interface{}{
+						"0.5":   int64(2),
+						"0.1":   int64(1),
+						"sum":   1.5,
+						"count": int64(3),
+					},
+					time.Unix(0, 0),
+					telegraf.Histogram,
+				),
+			),
+			output: "# TYPE latency histogram\n" +
+				"latency_bucket{le=\"0.1\"} 1 0\n" +
+				"latency_bucket{le=\"0.5\"} 2 0\n" +
+				"latency_bucket{le=\"+Inf\"} 3 0\n" +
+				"latency_sum 1.5 0\n" +
+				"latency_count 3 0\n",
+		},
+		{
+			name: "summary",
+			input: MustMetric(
+				metric.New(
+					"latency",
+					map[string]string{},
+					map[string]interface{}{
+						"0.99":  0.3,
+						"sum":   1.5,
+						"count": int64(3),
+					},
+					time.Unix(0, 0),
+					telegraf.Summary,
+				),
+			),
+			output: "# TYPE latency summary\n" +
+				"latency{quantile=\"0.99\"} 0.3 0\n" +
+				"latency_sum 1.5 0\n" +
+				"latency_count 3 0\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.format)
+			require.NoError(t, err)
+			buf, err := s.Serialize(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.output, string(buf))
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	metrics := []telegraf.Metric{
+		MustMetric(
+			metric.New(
+				"cpu",
+				map[string]string{"cpu": "cpu0"},
+				map[string]interface{}{"value": 1.0},
+				time.Unix(0, 0),
+			),
+		),
+		MustMetric(
+			metric.New(
+				"mem",
+				map[string]string{},
+				map[string]interface{}{"value": 2.0},
+				time.Unix(0, 0),
+			),
+		),
+		MustMetric(
+			metric.New(
+				"cpu",
+				map[string]string{"cpu": "cpu1"},
+				map[string]interface{}{"value": 3.0},
+				time.Unix(0, 0),
+			),
+		),
+	}
+
+	s, err := NewSerializer("")
+	require.NoError(t, err)
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t,
+		"# TYPE cpu unknown\n"+
+			"cpu{cpu=\"cpu0\"} 1 0\n"+
+			"cpu{cpu=\"cpu1\"} 3 0\n"+
+			"# TYPE mem unknown\n"+
+			"mem 2 0\n"+
+			"# EOF\n", string(buf))
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := NewSerializer("wide")
+	require.Error(t, err)
+}
+
+// Test metrics shaped like the ones of the prometheus input, with the
+// cumulative buckets and the +Inf bucket as float fields.
+func TestSerializePrometheusInput(t *testing.T) {
+	metrics := []telegraf.Metric{
+		MustMetric(
+			metric.New(
+				"http_requests_total",
+				map[string]string{"method": "post"},
+				map[string]interface{}{"counter": 1027.0},
+				time.Unix(0, 0),
+				telegraf.Counter,
+			),
+		),
+		MustMetric(
+			metric.New(
+				"latency",
+				map[string]string{},
+				map[string]interface{}{
+					"0.5":   2.0,
+					"1":     3.0,
+					"+Inf":  4.0,
+					"sum":   2.5,
+					"count": 4.0,
+				},
+				time.Unix(0, 0),
+				telegraf.Histogram,
+			),
+		),
+	}
+
+	s, err := NewSerializer("")
+	require.NoError(t, err)
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t,
+		"# TYPE http_requests counter\n"+
+			"http_requests_total{method=\"post\"} 1027 0\n"+
+			"# TYPE latency histogram\n"+
+			"latency_bucket{le=\"0.5\"} 2 0\n"+
+			"latency_bucket{le=\"1\"} 3 0\n"+
+			"latency_bucket{le=\"+Inf\"} 4 0\n"+
+			"latency_sum 2.5 0\n"+
+			"latency_count 4 0\n"+
+			"# EOF\n", string(buf))
+}
@@ -6,10 +6,14 @@ import (
 
 	"github.com/influxdata/telegraf"
 
+	"github.com/influxdata/telegraf/plugins/serializers/carbon2"
+	"github.com/influxdata/telegraf/plugins/serializers/csv"
 	"github.com/influxdata/telegraf/plugins/serializers/graphite"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers/json"
+	"github.com/influxdata/telegraf/plugins/serializers/openmetrics"
 	"github.com/influxdata/telegraf/plugins/serializers/splunkmetric"
+	"github.com/influxdata/telegraf/plugins/serializers/template"
 )
 
 // SerializerOutput is an interface for output plugins that are able to
@@ -36,7 +40,8 @@ type Serializer interface {
 // Config is a struct that covers the data types needed for all serializer types,
 // and can be used to instantiate _any_ of the serializers.
 type Config struct {
-	// Dataformat can be one of: influx, graphite, or json
+	// Dataformat can be one of: influx, graphite, json, splunkmetric, template,
+	// csv, carbon2 or openmetrics
 	DataFormat string
 
 	// Support tags in graphite protocol
@@ -55,15 +60,43 @@ type Config struct {
 	// Prefix to add to all measurements, only supports Graphite
 	Prefix string
 
-	// Template for converting telegraf metrics into Graphite
-	// only supports Graphite
+	// Template for converting telegraf metrics into Graphite, or the Go
+	// text/template used to render each metric; graphite and template formats
+	// only
 	Template string
 
+	// Go text/template used to render a batch of metrics; template format only
+	TemplateBatch string
+
 	// Timestamp units to use for JSON formatted output
 	TimestampUnits time.Duration
 
 	// Include HEC routing fields for splunkmetric output
 	HecRouting bool
+
+	// Write a header row; csv format only
+	CSVHeader bool
+
+	// Delimiter between columns; csv format only
+	CSVDelimiter string
+
+	// Fixed list of columns to write; csv format only
+	CSVColumns []string
+
+	// Format of the timestamp column; csv format only
+	CSVTimestampFormat string
+
+	// Grouping of metrics into column layouts, "union" or "measurement"; csv
+	// format only
+	CSVLayout string
+
+	// Handling of field names, "field_separate" or "metric_includes_field";
+	// carbon2 format only
+	Carbon2Format string
+
+	// Handling of field names, "metric_includes_field" or "field_separate";
+	// openmetrics format only
+	OpenMetricsFormat string
 }
 
 // NewSerializer a Serializer interface based on the given config.
@@ -79,6 +112,14 @@ func NewSerializer(config *Config) (Serializer, error) {
 		serializer, err = NewJsonSerializer(config.TimestampUnits)
 	case "splunkmetric":
 		serializer, err = NewSplunkmetricSerializer(config.HecRouting)
+	case "template":
+		serializer, err = NewTemplateSerializer(config.Template, config.TemplateBatch)
+	case "csv":
+		serializer, err = NewCSVSerializer(config)
+	case "carbon2":
+		serializer, err = NewCarbon2Serializer(config.Carbon2Format)
+	case "openmetrics":
+		serializer, err = NewOpenMetricsSerializer(config.OpenMetricsFormat)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -93,6 +134,28 @@ func NewSplunkmetricSerializer(splunkmetric_hec_routing bool) (Serializer, error
 	return splunkmetric.NewSerializer(splunkmetric_hec_routing)
 }
 
+func NewTemplateSerializer(metricTemplate, batchTemplate string) (Serializer, error) {
+	return template.NewSerializer(metricTemplate, batchTemplate)
+}
+
+func NewCSVSerializer(config *Config) (Serializer, error) {
+	return csv.NewSerializer(
+		config.CSVHeader,
+		config.CSVDelimiter,
+		config.CSVColumns,
+		config.CSVTimestampFormat,
+		config.CSVLayout,
+	)
+}
+
+func NewCarbon2Serializer(format string) (Serializer, error) {
+	return carbon2.NewSerializer(format)
+}
+
+func NewOpenMetricsSerializer(format string) (Serializer, error) {
+	return openmetrics.NewSerializer(format)
+}
+
 func NewInfluxSerializerConfig(config *Config) (Serializer, error) {
 	var sort influx.FieldSortOrder
 	if config.InfluxSortFields {
@@ -0,0 +1,206 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+type serializer struct {
+	MetricTemplate *template.Template
+	BatchTemplate  *template.Template
+}
+
+// NewSerializer creates a serializer rendering each metric with metricTmpl
+// and, if batchTmpl is not empty, each batch of metrics with batchTmpl.
+func NewSerializer(metricTmpl, batchTmpl string) (*serializer, error) {
+	if metricTmpl == "" && batchTmpl == "" {
+		return nil, fmt.Errorf("template serializer requires a template")
+	}
+
+	s := &serializer{}
+
+	if metricTmpl != "" {
+		t, err := template.New("metric").Funcs(funcMap).Parse(metricTmpl)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse template: %s", err)
+		}
+		s.MetricTemplate = t
+	}
+
+	if batchTmpl != "" {
+		t, err := template.New("batch").Funcs(funcMap).Parse(batchTmpl)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse template_batch: %s", err)
+		}
+		s.BatchTemplate = t
+	}
+
+	return s, nil
+}
+
+func (s *serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	if s.MetricTemplate == nil {
+		return s.SerializeBatch([]telegraf.Metric{metric})
+	}
+
+	var buf bytes.Buffer
+	err := s.MetricTemplate.Execute(&buf, &Metric{metric})
+	if err != nil {
+		return nil, err
+	}
+
+	if buf.Len() > 0 && buf.Bytes()[buf.Len()-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	if s.BatchTemplate == nil {
+		var batch []byte
+		for _, m := range metrics {
+			buf, err := s.Serialize(m)
+			if err != nil {
+				return nil, err
+			}
+			batch = append(batch, buf...)
+		}
+		return batch, nil
+	}
+
+	data := make([]*Metric, 0, len(metrics))
+	for _, m := range metrics {
+		data = append(data, &Metric{m})
+	}
+
+	var buf bytes.Buffer
+	err := s.BatchTemplate.Execute(&buf, data)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Template is a template rendering a single metric to a string, with the
+// functions of the serializer templates.  It can be used by plugins to build
+// settings from the tags of a metric, such as URLs.
+type Template struct {
+	tmpl *template.Template
+}
+
+// NewTemplate parses the text of a template.
+func NewTemplate(name, text string) (*Template, error) {
+	t, err := template.New(name).Funcs(funcMap).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %s", name, err)
+	}
+	return &Template{tmpl: t}, nil
+}
+
+// Execute renders the metric.
+func (t *Template) Execute(metric telegraf.Metric) (string, error) {
+	var buf bytes.Buffer
+	err := t.tmpl.Execute(&buf, &Metric{metric})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Metric is the value passed to templates.  In addition to the methods of
+// telegraf.Metric it provides helpers that can be used from a template, where
+// functions with multiple return values are unavailable.
+type Metric struct {
+	telegraf.Metric
+}
+
+// Tag returns the value of the tag, or an empty string if it is not set.
+func (m *Metric) Tag(key string) string {
+	value, _ := m.GetTag(key)
+	return value
+}
+
+// Field returns the value of the field, or nil if it is not set.
+func (m *Metric) Field(key string) interface{} {
+	value, _ := m.GetField(key)
+	return value
+}
+
+// SortedTags returns the tags ordered by key.
+func (m *Metric) SortedTags() []*telegraf.Tag {
+	tags := append([]*telegraf.Tag(nil), m.TagList()...)
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+	return tags
+}
+
+// SortedFields returns the fields ordered by key.
+func (m *Metric) SortedFields() []*telegraf.Field {
+	fields := append([]*telegraf.Field(nil), m.FieldList()...)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields
+}
+
+var funcMap = template.FuncMap{
+	"formatTime": formatTime,
+	"unix":       func(t time.Time) int64 { return t.Unix() },
+	"unixMilli":  func(t time.Time) int64 { return t.UnixNano() / int64(time.Millisecond) },
+	"unixMicro":  func(t time.Time) int64 { return t.UnixNano() / int64(time.Microsecond) },
+	"unixNano":   func(t time.Time) int64 { return t.UnixNano() },
+	"escape":     escape,
+	"quote":      strconv.Quote,
+	"json":       toJSON,
+	"join":       func(sep string, s []string) string { return strings.Join(s, sep) },
+	"replace":    func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+}
+
+// formatTime formats the time using a Go reference time layout, or one of
+// the special layouts "unix", "unix_ms", "unix_us" and "unix_ns".
+func formatTime(layout string, t time.Time) string {
+	switch layout {
+	case "unix":
+		return strconv.FormatInt(t.Unix(), 10)
+	case "unix_ms":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	case "unix_us":
+		return strconv.FormatInt(t.UnixNano()/int64(time.Microsecond), 10)
+	case "unix_ns":
+		return strconv.FormatInt(t.UnixNano(), 10)
+	}
+	return t.UTC().Format(layout)
+}
+
+// escape prefixes each occurrence of any of the chars in value with a
+// backslash.
+func escape(chars string, value interface{}) string {
+	s := fmt.Sprint(value)
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+
+	var b bytes.Buffer
+	for _, r := range s {
+		if strings.ContainsRune(chars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func toJSON(v interface{}) (string, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
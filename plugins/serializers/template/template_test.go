@@ -0,0 +1,201 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func MustMetric(v telegraf.Metric, err error) telegraf.Metric {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		input    telegraf.Metric
+		output   string
+	}{
+		{
+			name:     "name and field",
+			template: `{{ .Name }} {{ .Field "value" }}`,
+			input: MustMetric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			output: "cpu 42\n",
+		},
+		{
+			name:     "iterate tags and fields",
+			template: `{{ .Name }}{{ range .SortedTags }},{{ .Key }}={{ .Value }}{{ end }}{{ range .SortedFields }} {{ .Key }}={{ .Value }}{{ end }}`,
+			input: MustMetric(
+				metric.New(
+					"cpu",
+					map[string]string{
+						"host": "localhost",
+						"cpu":  "cpu0",
+					},
+					map[string]interface{}{
+						"usage_user":   1,
+						"usage_idle":   2,
+						"usage_system": 3,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			output: "cpu,cpu=cpu0,host=localhost usage_idle=2 usage_system=3 usage_user=1\n",
+		},
+		{
+			name:     "missing tag",
+			template: `[{{ .Tag "host" }}]`,
+			input: MustMetric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			output: "[]\n",
+		},
+		{
+			name:     "time formatting",
+			template: `{{ .Time | formatTime "2006-01-02T15:04:05Z07:00" }} {{ .Time | formatTime "unix_ms" }} {{ .Time | unix }}`,
+			input: MustMetric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(1525478795, 123456789),
+				),
+			),
+			output: "2018-05-05T00:06:35Z 1525478795123 1525478795\n",
+		},
+		{
+			name:     "escaping",
+			template: `{{ .Tag "path" | escape " ," }} {{ .Tag "path" | quote }} {{ .Tags | json }}`,
+			input: MustMetric(
+				metric.New(
+					"disk",
+					map[string]string{
+						"path": `C:\some dir,x`,
+					},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			output: `C:\some\ dir\,x "C:\\some dir,x" {"path":"C:\\some dir,x"}` + "\n",
+		},
+		{
+			name:     "trailing newline is not doubled",
+			template: "{{ .Name }}\n",
+			input: MustMetric(
+				metric.New(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			),
+			output: "cpu\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.template, "")
+			require.NoError(t, err)
+			buf, err := s.Serialize(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.output, string(buf))
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	metrics := []telegraf.Metric{
+		MustMetric(
+			metric.New(
+				"cpu",
+				map[string]string{},
+				map[string]interface{}{
+					"value": 42.0,
+				},
+				time.Unix(0, 0),
+			),
+		),
+		MustMetric(
+			metric.New(
+				"mem",
+				map[string]string{},
+				map[string]interface{}{
+					"value": 43.0,
+				},
+				time.Unix(0, 0),
+			),
+		),
+	}
+
+	s, err := NewSerializer(`{{ .Name }}={{ .Field "value" }}`, "")
+	require.NoError(t, err)
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, "cpu=42\nmem=43\n", string(buf))
+
+	s, err = NewSerializer("", `[{{ range $i, $m := . }}{{ if $i }},{{ end }}"{{ $m.Name }}"{{ end }}]`)
+	require.NoError(t, err)
+	buf, err = s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, `["cpu","mem"]`, string(buf))
+}
+
+func TestInvalidTemplate(t *testing.T) {
+	_, err := NewSerializer("{{ .Name ", "")
+	require.Error(t, err)
+
+	_, err = NewSerializer("", "")
+	require.Error(t, err)
+}
+
+func TestTemplate(t *testing.T) {
+	m := MustMetric(
+		metric.New(
+			"cpu",
+			map[string]string{"host": "Server01"},
+			map[string]interface{}{
+				"value": 42.0,
+			},
+			time.Unix(0, 0),
+		),
+	)
+
+	tmpl, err := NewTemplate("url", `http://localhost/{{ .Tag "host" | lower }}/{{ .Name }}`)
+	require.NoError(t, err)
+	s, err := tmpl.Execute(m)
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost/server01/cpu", s)
+
+	_, err = NewTemplate("url", "{{ .Tag ")
+	require.Error(t, err)
+}